@@ -0,0 +1,99 @@
+// Command order-import creates orders from a CSV or NDJSON file, eg. for a B2B bulk upload or a
+// migration from another system, and prints the import report as JSON.
+//
+//	order-import -format csv -historical -tenant acme orders.csv
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"order/internal/bootstrap"
+	"order/internal/importer"
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/core/logger"
+	"order/pkg/core/tenant"
+	"order/pkg/http/utils"
+	"os"
+)
+
+func main() {
+	format := flag.String("format", importer.FormatCSV, "format of the file: csv or ndjson")
+	historical := flag.Bool("historical", false, "store the orders as already placed: no stock reservation, saga or payment request")
+	batchSize := flag.Int("batch", 100, "orders committed per transaction")
+	tenantID := flag.String("tenant", "", "tenant of the orders, the default tenant when empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <file|->\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logger.Init(utils.APPNAME)
+	logger.SetupLogger()
+
+	app, err := bootstrap.InitializeAppConfiguration()
+	if err != nil {
+		log.Fatalf("failed to initialize application: %v", err)
+	}
+	if *tenantID == "" {
+		*tenantID = app.AppConfig.TenantDefault
+	}
+
+	ctx := tenant.NewContext(context.Background(), *tenantID)
+	if err = app.TenantService.Authorize(ctx, *tenantID); err != nil {
+		log.Fatalf("tenant %q: %v", *tenantID, err)
+	}
+
+	core, err := bootstrap.NewOrderCore(ctx, app)
+	if err != nil {
+		log.Fatalf("failed to initialize order service: %v", err)
+	}
+
+	var input io.Reader = os.Stdin
+	if path := flag.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("failed to open %s: %v", path, err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	reader, err := importer.NewOrderReader(input, *format)
+	if err != nil {
+		log.Fatalf("failed to read %s: %v", flag.Arg(0), err)
+	}
+
+	report, importErr := core.OrderService.ImportOrders(ctx, reader, services.ImportOptions{
+		Historical: *historical,
+		BatchSize:  *batchSize,
+		Audit: models.StatusChange{
+			ActorType: models.ActorTypeSystem,
+			ActorID:   "order-import",
+			Source:    models.ChangeSourceImport,
+			SourceRef: flag.Arg(0),
+			Reason:    "order imported",
+		},
+	})
+
+	// the report of what was imported is printed even when the import stopped early
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if report != nil {
+		_ = encoder.Encode(report)
+	}
+	if importErr != nil {
+		log.Fatalf("import stopped: %v", importErr)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,15 +1,32 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
+	"gorm.io/gorm"
+	"order/internal/metrics"
+	"order/internal/models"
+	repositories "order/internal/repositories"
 	repo "order/internal/repositories/pg-gorm"
+	"order/internal/search"
+	"order/internal/services"
 	"order/pkg/core/configloader"
 	"order/pkg/core/db"
+	"order/pkg/core/filestore"
+	"time"
 )
 
 type AppSetup struct {
 	AppConfig       *configloader.Config
 	PGRepoInterface repo.PGInterface
+	// TenantService is shared by the HTTP and gRPC servers so both see tenant changes at once
+	TenantService *services.TenantService
+	// ExportStore holds the files of background exports
+	ExportStore *filestore.Local
+	// OrderSearcher answers the order search of the admin API
+	OrderSearcher search.OrderSearcher
+	// OrderCore is built by NewOrderCore once the app is configured
+	OrderCore *OrderCore
 }
 
 // InitializeApp initializes all application dependencies
@@ -22,10 +39,94 @@ func InitializeAppConfiguration() (*AppSetup, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	pgRepo := repo.NewPGRepo(dbBackend)
+	if err = checkSchema(context.Background(), dbBackend); err != nil {
+		return nil, fmt.Errorf("failed to check database schema: %w", err)
+	}
+
+	replicas, err := db.ReplicaInitialization(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database replicas: %w", err)
+	}
+
+	if err = registerPoolMetrics(dbBackend, replicas); err != nil {
+		return nil, err
+	}
+
+	timeouts, err := queryTimeouts(config)
+	if err != nil {
+		return nil, err
+	}
+
+	pgRepo := repo.NewPGRepoWithOptions(context.Background(), dbBackend, replicas, repo.Options{
+		ReplicaCheckInterval: time.Duration(config.PostgresReplicaCheckSeconds) * time.Second,
+		ReplicaMaxLag:        time.Duration(config.PostgresReplicaMaxLagSeconds) * time.Second,
+		QueryTimeouts:        timeouts,
+	})
+
+	tenantService := services.NewTenantService(repositories.NewTenantRepository(pgRepo), models.Tenant{
+		ID:                   config.TenantDefault,
+		BaseCurrency:         config.BaseCurrency,
+		InvoiceSellerName:    config.InvoiceSellerName,
+		InvoiceSellerTaxID:   config.InvoiceSellerTaxID,
+		InvoiceSellerAddress: config.InvoiceSellerAddress,
+		InvoiceSellerCountry: config.InvoiceSellerCountry,
+	}, time.Duration(config.TenantCacheTTLSeconds)*time.Second)
+
+	exportStore, err := filestore.NewLocal(config.ExportDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize export store: %w", err)
+	}
+
+	search.Register(search.ProviderPostgres, func() (search.OrderSearcher, error) {
+		return search.NewPostgresSearcher(pgRepo), nil
+	})
+	orderSearcher, err := search.New(config.SearchProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize order search: %w", err)
+	}
 
 	return &AppSetup{
 		PGRepoInterface: pgRepo,
 		AppConfig:       config,
+		TenantService:   tenantService,
+		ExportStore:     exportStore,
+		OrderSearcher:   orderSearcher,
 	}, nil
 }
+
+// queryTimeouts parses the repository timeouts of config
+func queryTimeouts(config *configloader.Config) (repo.QueryTimeouts, error) {
+	timeouts := repo.QueryTimeouts{
+		Default:    time.Duration(config.PostgresQueryTimeoutSeconds) * time.Second,
+		Operations: make(map[string]time.Duration, len(config.PostgresOperationTimeouts)),
+	}
+	if timeouts.Default < 0 {
+		return timeouts, fmt.Errorf("invalid POSTGRES_QUERY_TIMEOUT_SECONDS %d", config.PostgresQueryTimeoutSeconds)
+	}
+	for operation, value := range config.PostgresOperationTimeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return timeouts, fmt.Errorf("invalid POSTGRES_OPERATION_TIMEOUTS timeout %q of %s", value, operation)
+		}
+		timeouts.Operations[operation] = timeout
+	}
+	return timeouts, nil
+}
+
+// registerPoolMetrics exports the pool stats of the primary and of every replica
+func registerPoolMetrics(primary *gorm.DB, replicas []*gorm.DB) error {
+	pools := map[string]*gorm.DB{"primary": primary}
+	for i, replica := range replicas {
+		pools[fmt.Sprintf("replica-%d", i)] = replica
+	}
+	for name, pool := range pools {
+		sqlDB, err := pool.DB()
+		if err != nil {
+			return fmt.Errorf("failed to get %s database pool: %w", name, err)
+		}
+		if err = metrics.RegisterDBStats(name, sqlDB); err != nil {
+			return fmt.Errorf("failed to register %s database pool metrics: %w", name, err)
+		}
+	}
+	return nil
+}
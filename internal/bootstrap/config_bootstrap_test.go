@@ -0,0 +1,89 @@
+package bootstrap
+
+import (
+	"testing"
+	"time"
+
+	"order/pkg/core/configloader"
+)
+
+func TestQueryTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      configloader.Config
+		wantDefault time.Duration
+		want        map[string]time.Duration
+		wantErr     bool
+	}{
+		{
+			name:        "default only",
+			config:      configloader.Config{PostgresQueryTimeoutSeconds: 60},
+			wantDefault: time.Minute,
+			want:        map[string]time.Duration{},
+		},
+		{
+			name: "operation timeouts",
+			config: configloader.Config{PostgresQueryTimeoutSeconds: 60, PostgresOperationTimeouts: map[string]string{
+				"OrderStatsRepository.Rebuild": "5m",
+				"OrderRepository.GetByID":      "1500ms",
+				"ExportRepository.Stream":      "0s",
+			}},
+			wantDefault: time.Minute,
+			want: map[string]time.Duration{
+				"OrderStatsRepository.Rebuild": 5 * time.Minute,
+				"OrderRepository.GetByID":      1500 * time.Millisecond,
+				"ExportRepository.Stream":      0,
+			},
+		},
+		{
+			name:    "timeout without unit",
+			config:  configloader.Config{PostgresQueryTimeoutSeconds: 60, PostgresOperationTimeouts: map[string]string{"OrderRepository.GetByID": "30"}},
+			wantErr: true,
+		},
+		{
+			name:    "timeout that is no duration",
+			config:  configloader.Config{PostgresQueryTimeoutSeconds: 60, PostgresOperationTimeouts: map[string]string{"OrderRepository.GetByID": "soon"}},
+			wantErr: true,
+		},
+		{
+			name:    "empty timeout",
+			config:  configloader.Config{PostgresQueryTimeoutSeconds: 60, PostgresOperationTimeouts: map[string]string{"OrderRepository.GetByID": ""}},
+			wantErr: true,
+		},
+		{
+			name:    "negative operation timeout",
+			config:  configloader.Config{PostgresQueryTimeoutSeconds: 60, PostgresOperationTimeouts: map[string]string{"OrderRepository.GetByID": "-1s"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative default",
+			config:  configloader.Config{PostgresQueryTimeoutSeconds: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := queryTimeouts(&tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("queryTimeouts() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Default != tt.wantDefault {
+				t.Errorf("default = %v, want %v", got.Default, tt.wantDefault)
+			}
+			if len(got.Operations) != len(tt.want) {
+				t.Fatalf("operations = %v, want %v", got.Operations, tt.want)
+			}
+			for operation, timeout := range tt.want {
+				if got.Operations[operation] != timeout {
+					t.Errorf("%s timeout = %v, want %v", operation, got.Operations[operation], timeout)
+				}
+			}
+		})
+	}
+}
@@ -5,17 +5,24 @@ import (
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-	"order/internal/grpc/clients/payment"
+	"log"
+	"order/internal/events"
+	"order/internal/grpc/clients/inventory"
 	"order/internal/grpc/handlers"
 	"order/internal/grpc/server"
+	"order/internal/invoicing"
+	"order/internal/leader"
 	"order/internal/metrics"
 	repo "order/internal/repositories"
+	"order/internal/scheduler"
 	"order/internal/services"
 	"order/internal/workers"
 	"strconv"
 	"time"
 )
 
+// StartGRPC serves the order API over gRPC and the gateway and starts the workers; app.OrderCore
+// has to be built first, see NewOrderCore
 func StartGRPC(app *AppSetup) (*server.GRPCServer, func() error, error) {
 
 	// grpcPort using for grpc server to transport gRPC requests
@@ -33,53 +40,95 @@ func StartGRPC(app *AppSetup) (*server.GRPCServer, func() error, error) {
 	httpAddr := fmt.Sprintf(":%d", httpPort)
 
 	newPgRepo := app.PGRepoInterface
+	tenantService := app.TenantService
 	orderRepo := repo.NewOrderRepository(newPgRepo)
 	outboxRepo := repo.NewOutboxRepository(newPgRepo)
 
 	promotionRepo := repo.NewPromotionRepository(newPgRepo)
 
-	// create gRPC connection to payment service and build payment client
-	paymentAddr := app.AppConfig.PaymentServiceAddr
-	if paymentAddr == "" {
-		paymentAddr = "localhost:50052"
-	}
-
 	// start outbox worker properly (was previously discarded with `_ = ...`)
 	ctx := context.Background()
 
-	// Dial context with timeout
-	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	orderService := app.OrderCore.OrderService
+	invoiceService := app.OrderCore.InvoiceService
+	paymentClient := app.OrderCore.Payment
+	inventoryClient := app.OrderCore.Inventory
+	promotionService := services.NewPromotionService(promotionRepo, outboxRepo, orderRepo, newPgRepo)
 
-	// conn is connection to payment gRPC service
-	connection, err := grpc.DialContext(
-		dialCtx,
-		paymentAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	shipmentService := services.NewShipmentService(repo.NewShipmentRepository(newPgRepo), orderRepo, newPgRepo, orderService)
 
-		// outgoing from this order service to payment service will be measured here
-		grpc.WithUnaryInterceptor(metrics.UnaryClientInterceptor("order")),
-	)
+	kafkaApp, stopKafka, err := InitKafka(ctx, orderService, promotionService, shipmentService)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	paymentClient := paymentclient.NewPaymentGRPCClient(connection)
-	orderService := services.NewOrderService(orderRepo, newPgRepo, paymentClient, outboxRepo)
-	promotionService := services.NewPromotionService(promotionRepo, outboxRepo, orderRepo)
-
-	_, stopKafka, err := InitKafka(ctx, orderService, promotionService)
-	if err != nil {
-		return nil, nil, err
-	}
+	cartService := services.NewCartService(
+		repo.NewCartRepository(newPgRepo),
+		repo.NewCouponRepository(newPgRepo),
+		newPgRepo,
+		orderService,
+		time.Duration(app.AppConfig.CartTTLHours)*time.Hour,
+	)
 
 	handler := handlers.NewOrderHandler(orderService)
+	cartHandler := handlers.NewCartHandler(cartService)
+	shipmentHandler := handlers.NewShipmentHandler(shipmentService, app.AppConfig.ShipmentWebhookToken)
+	returnHandler := handlers.NewReturnHandler(services.NewReturnService(
+		repo.NewReturnRepository(newPgRepo),
+		orderRepo,
+		promotionRepo,
+		outboxRepo,
+		invoiceService,
+		newPgRepo,
+	))
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService, func(ctx context.Context) invoicing.Seller {
+		settings := tenantService.Settings(ctx)
+		return invoicing.Seller{
+			Name:    settings.InvoiceSellerName,
+			TaxID:   settings.InvoiceSellerTaxID,
+			Address: settings.InvoiceSellerAddress,
+			Country: settings.InvoiceSellerCountry,
+		}
+	})
+
+	grpcServer := server.NewGRPCServer(
+		handler,
+		cartHandler,
+		shipmentHandler,
+		returnHandler,
+		invoiceHandler,
+		server.TenantUnaryInterceptor(app.AppConfig.TenantDefault, tenantService.Authorize),
+		grpcAddr,
+		httpAddr,
+	)
 
-	grpcServer := server.NewGRPCServer(handler, grpcAddr, httpAddr)
-
-	worker := workers.NewOutboxWorkerInit(newPgRepo, paymentClient)
+	// events other than payment requests are published on the order events topic when configured
+	worker := workers.NewOutboxWorkerInit(newPgRepo, paymentClient, inventoryClient, kafkaApp.Producers[events.OrderEventsTopic.String()])
+	worker.Route(events.EventPromotionRewardRequested, kafkaApp.Producers[events.PromotionRewardTopic.String()])
 	go worker.Run(ctx)
 
+	promotionMetricsWorker := workers.NewPromotionMetricsWorker(promotionService)
+	go promotionMetricsWorker.Run(ctx)
+
+	reportService := services.NewReportService(repo.NewOrderStatsRepository(newPgRepo))
+	reportMetricsWorker := workers.NewReportMetricsWorker(reportService)
+	go reportMetricsWorker.Run(ctx)
+
+	if app.AppConfig.SchedulerEnabled {
+		jobScheduler := scheduler.NewScheduler(repo.NewScheduledJobRepository(newPgRepo))
+		for _, job := range scheduler.BuiltinJobs(app.AppConfig, tenantService, orderService, promotionService, cartService, reportService, outboxRepo) {
+			if err = jobScheduler.Register(job); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// only the elected instance runs the scheduler; job leases remain as a second guard
+		schedulerElector := leader.NewElector(newPgRepo, "scheduler", leader.Callbacks{
+			OnAcquire: jobScheduler.Run,
+		})
+		go schedulerElector.Run(ctx)
+	}
+
 	go func() {
 		if err := grpcServer.Run(ctx); err != nil {
 			panic(err)
@@ -88,3 +137,29 @@ func StartGRPC(app *AppSetup) (*server.GRPCServer, func() error, error) {
 
 	return grpcServer, stopKafka, nil
 }
+
+// newInventoryClient dials the inventory service. The in-memory inventory never runs out of
+// stock, so it is only used when asked for explicitly.
+func newInventoryClient(ctx context.Context, addr string, fake bool) (inventoryclient.InventoryClient, error) {
+	if fake {
+		log.Println("INVENTORY_FAKE set, using in-memory inventory")
+		return inventoryclient.NewFakeInventoryClient(nil), nil
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("INVENTORY_SERVICE_ADDR is not set; set INVENTORY_FAKE=true to use the in-memory inventory")
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	connection, err := grpc.DialContext(
+		dialCtx,
+		addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(metrics.UnaryClientInterceptor("order"), server.TenantUnaryClientInterceptor()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return inventoryclient.NewInventoryGRPCClient(connection), nil
+}
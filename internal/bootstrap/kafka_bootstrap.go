@@ -16,12 +16,18 @@ import (
 func InitKafka(
 	parent context.Context,
 	orderService services.OrderServiceInterface,
-	promotionService services.PromotionServiceInterface) (
+	promotionService services.PromotionServiceInterface,
+	shipmentService services.ShipmentServiceInterface) (
 	*kafka.App, func() error, error) {
 	cfg := configloader.GetConfig()
 
 	ctx, cancel := context.WithCancel(parent)
 
+	// every message is handled in the context of its tenant
+	tenantCtx := func(msg kafka.Message) context.Context {
+		return kafka.TenantContext(ctx, msg, cfg.TenantDefault)
+	}
+
 	app := &kafka.App{
 		Producers: make(map[string]*kafka.Producer),
 		Consumers: make(map[string]*kafka.Consumer),
@@ -39,22 +45,28 @@ func InitKafka(
 	var paymentTopic string
 	for _, topic := range cfg.KafkaTopics {
 		if paymentTopic != "" {
+			cancel()
 			err := errors.Error(errors.StatusInternalServerError, errors.StatusValidationError)
 			return nil, nil, err
-			break
 		}
 		switch topic {
 		case string(events.PaymentAuthorizationTopic):
 			c := kafka.NewConsumer(cfg.KafkaBrokers, topic, "payment_group")
 			app.Consumers[topic] = c
-			w := workers.NewPaymentEventWorker(orderService, app.Producers[string(events.PromotionRewardTopic)])
-			go c.Listen(ctx, func(data []byte) { w.Handle(ctx, data) })
+			w := workers.NewPaymentEventWorker(orderService)
+			go c.ListenMessage(ctx, func(msg kafka.Message) { w.Handle(tenantCtx(msg), msg) })
 
 		case string(events.PromotionRewardTopic):
 			c := kafka.NewConsumer(cfg.KafkaBrokers, topic, "promotion_group")
 			app.Consumers[topic] = c
 			w := workers.NewPromotionRewardWorker(promotionService)
-			go c.Listen(ctx, func(data []byte) { w.Handle(ctx, data) })
+			go c.ListenMessage(ctx, func(msg kafka.Message) { w.Handle(tenantCtx(msg), msg.Value) })
+
+		case string(events.ShipmentUpdatesTopic):
+			c := kafka.NewConsumer(cfg.KafkaBrokers, topic, "shipment_group")
+			app.Consumers[topic] = c
+			w := workers.NewShipmentEventWorker(shipmentService)
+			go c.ListenMessage(ctx, func(msg kafka.Message) { w.Handle(tenantCtx(msg), msg) })
 		}
 	}
 
@@ -0,0 +1,41 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"order/internal/migrations"
+	"order/pkg/core/configloader"
+	"order/pkg/core/db"
+)
+
+// Migrate runs the `migrate` subcommand of the binary against the primary database
+func Migrate(ctx context.Context, args []string, out io.Writer) error {
+	return migrations.Command(ctx, args, func() (*sql.DB, error) {
+		dbBackend, err := db.DatabaseInitialization(configloader.GetConfig())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize database: %w", err)
+		}
+		return dbBackend.DB()
+	}, out)
+}
+
+// checkSchema refuses to serve on a schema older than the migrations of this binary
+func checkSchema(ctx context.Context, dbBackend *gorm.DB) error {
+	sqlDB, err := dbBackend.DB()
+	if err != nil {
+		return err
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	err = migrator.Check(ctx)
+	if errors.Is(err, migrations.ErrSchemaBehind) {
+		return fmt.Errorf("%w; run `order migrate up` first", err)
+	}
+	return err
+}
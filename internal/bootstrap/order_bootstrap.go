@@ -0,0 +1,119 @@
+package bootstrap
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"order/internal/eventsourcing"
+	"order/internal/fx"
+	"order/internal/grpc/clients/inventory"
+	"order/internal/grpc/clients/payment"
+	"order/internal/grpc/server"
+	"order/internal/metrics"
+	repo "order/internal/repositories"
+	"order/internal/saga"
+	"order/internal/services"
+	"order/internal/tax"
+	"time"
+)
+
+// OrderCore is the order service with the clients and services it is built on. The gRPC server,
+// the admin HTTP routes and the import CLI share one.
+type OrderCore struct {
+	OrderService   *services.OrderService
+	InvoiceService *services.InvoiceService
+	Payment        paymentclient.PaymentClient
+	Inventory      inventoryclient.InventoryClient
+}
+
+// NewOrderCore dials the payment and inventory services and builds the order service with the
+// features enabled in the config
+func NewOrderCore(ctx context.Context, app *AppSetup) (*OrderCore, error) {
+	newPgRepo := app.PGRepoInterface
+	tenantService := app.TenantService
+	baseCurrency := func(ctx context.Context) string {
+		return tenantService.Settings(ctx).BaseCurrency
+	}
+	orderRepo := repo.NewOrderRepository(newPgRepo)
+	outboxRepo := repo.NewOutboxRepository(newPgRepo)
+	promotionRepo := repo.NewPromotionRepository(newPgRepo)
+
+	// create gRPC connection to payment service and build payment client
+	paymentAddr := app.AppConfig.PaymentServiceAddr
+	if paymentAddr == "" {
+		paymentAddr = "localhost:50052"
+	}
+
+	// Dial context with timeout
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// conn is connection to payment gRPC service
+	connection, err := grpc.DialContext(
+		dialCtx,
+		paymentAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+
+		grpc.WithChainUnaryInterceptor(
+			// outgoing from this order service to payment service will be measured here
+			metrics.UnaryClientInterceptor("order"),
+			server.TenantUnaryClientInterceptor(),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentClient := paymentclient.NewPaymentGRPCClient(connection)
+
+	inventoryClient, err := newInventoryClient(ctx, app.AppConfig.InventoryServiceAddr, app.AppConfig.InventoryFake)
+	if err != nil {
+		return nil, err
+	}
+
+	historyRepo := repo.NewOrderStatusHistoryRepository(newPgRepo)
+	orderService := services.NewOrderService(
+		orderRepo,
+		newPgRepo,
+		paymentClient,
+		inventoryClient,
+		outboxRepo,
+		historyRepo,
+		promotionRepo,
+		time.Duration(app.AppConfig.InventoryReservationTTLMinutes)*time.Minute,
+	)
+
+	invoiceService := services.NewInvoiceService(repo.NewInvoiceRepository(newPgRepo), orderRepo, baseCurrency)
+	orderService.EnableInvoicing(invoiceService)
+
+	if app.AppConfig.OrderEventSourcingEnabled {
+		orderEventRepo := repo.NewOrderEventRepository(newPgRepo)
+		eventStore := eventsourcing.NewStore(orderEventRepo, outboxRepo, app.AppConfig.OrderSnapshotEvery)
+		orderService.EnableEventSourcing(eventStore, eventsourcing.NewProjector(newPgRepo, eventStore, orderEventRepo))
+	}
+
+	if app.AppConfig.TaxProvider != "" {
+		tax.Register(tax.ProviderTable, func() (tax.TaxCalculator, error) {
+			return tax.NewTableCalculator(repo.NewTaxRateRepository(newPgRepo)), nil
+		})
+		calculator, err := tax.New(app.AppConfig.TaxProvider)
+		if err != nil {
+			return nil, err
+		}
+		orderService.EnableTax(calculator)
+	}
+
+	orderService.EnableMultiCurrency(fx.NewConverter(repo.NewFxRateRepository(newPgRepo), baseCurrency))
+
+	if app.AppConfig.CheckoutSagaEnabled {
+		orchestrator := saga.NewOrchestrator(newPgRepo, repo.NewSagaRepository(newPgRepo))
+		orderService.EnableCheckoutSaga(orchestrator, time.Duration(app.AppConfig.OrderPaymentTimeoutMinutes)*time.Minute)
+	}
+
+	return &OrderCore{
+		OrderService:   orderService,
+		InvoiceService: invoiceService,
+		Payment:        paymentClient,
+		Inventory:      inventoryClient,
+	}, nil
+}
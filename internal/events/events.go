@@ -11,11 +11,38 @@ const (
 	// Topic types
 	PaymentAuthorizationTopic TopicType = "payment_authorized"
 	PromotionRewardTopic      TopicType = "promotion_rewards"
+	OrderEventsTopic          TopicType = "order_events"
+	ShipmentUpdatesTopic      TopicType = "shipment_updates"
 
 	// Event types
 	EventPaymentRequired   EventType = "payment_required"
 	EventOrderCreated      EventType = "order_created"
 	EventPromotionRewarded EventType = "promotion_rewarded"
+
+	// Inventory reservation follow-ups delivered by the outbox worker
+	EventInventoryConfirm EventType = "inventory_confirm_requested"
+	EventInventoryRelease EventType = "inventory_release_requested"
+	EventInventoryRestock EventType = "inventory_restock_requested"
+
+	// Checkout saga follow-ups
+	EventPromotionRewardRequested EventType = "promotion_reward_requested"
+	EventPaymentVoidRequested     EventType = "payment_void_requested"
+
+	// Return follow-ups
+	EventPaymentRefundRequested EventType = "payment_refund_requested"
+	EventPromotionRewardRevoked EventType = "promotion.reward.revoked"
+
+	// Order event stream types
+	EventOrderItemAdded     EventType = "order_item_added"
+	EventOrderItemUpdated   EventType = "order_item_updated"
+	EventOrderItemRemoved   EventType = "order_item_removed"
+	EventOrderRepriced      EventType = "order_repriced"
+	EventPaymentAuthorized  EventType = "payment_authorized"
+	EventPaymentDeclined    EventType = "payment_declined"
+	EventOrderCancelled     EventType = "order_cancelled"
+	EventOrderExpired       EventType = "order_expired"
+	EventOrderCompleted     EventType = "order_completed"
+	EventOrderStatusChanged EventType = "order_status_changed"
 	// add more event types here...
 
 	// Aggregate types
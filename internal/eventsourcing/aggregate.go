@@ -0,0 +1,279 @@
+package eventsourcing
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/events"
+	"order/internal/models"
+)
+
+// OrderCreated opens an order stream
+type OrderCreated struct {
+	CustomerID        uuid.UUID  `json:"customer_id"`
+	Status            string     `json:"status"`
+	TotalAmount       float64    `json:"total_amount"`
+	DiscountAmount    float64    `json:"discount_amount,omitempty"`
+	TaxAmount         float64    `json:"tax_amount,omitempty"`
+	TaxCountry        string     `json:"tax_country,omitempty"`
+	TaxRegion         string     `json:"tax_region,omitempty"`
+	Currency          string     `json:"currency,omitempty"`
+	FxRate            float64    `json:"fx_rate,omitempty"`
+	PromotionConfigID *uuid.UUID `json:"promotion_config_id,omitempty"`
+
+	// Customer and the addresses are a snapshot taken when the order was placed
+	Customer models.CustomerSnapshot `json:"customer"`
+	Shipping models.Address          `json:"shipping_address"`
+	Billing  models.Address          `json:"billing_address"`
+
+	// PlacedAt is set on orders imported with the date they were placed in another system
+	PlacedAt *time.Time `json:"placed_at,omitempty"`
+}
+
+// ItemAdded adds a line item to the order
+type ItemAdded struct {
+	ItemID    uuid.UUID `json:"item_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+	TaxClass  string    `json:"tax_class,omitempty"`
+	TaxRate   float64   `json:"tax_rate,omitempty"`
+	TaxAmount float64   `json:"tax_amount,omitempty"`
+}
+
+// ItemUpdated changes the quantity of a line item
+type ItemUpdated struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Quantity int       `json:"quantity"`
+}
+
+// ItemRemoved drops a line item from the order
+type ItemRemoved struct {
+	ItemID uuid.UUID `json:"item_id"`
+}
+
+// Repriced records the totals, taxes and promotion recomputed after the items changed
+type Repriced struct {
+	TotalAmount       float64    `json:"total_amount"`
+	TaxAmount         float64    `json:"tax_amount,omitempty"`
+	ItemTaxes         []ItemTax  `json:"item_taxes,omitempty"`
+	PromotionConfigID *uuid.UUID `json:"promotion_config_id,omitempty"`
+}
+
+// ItemTax is the recomputed tax of one line item
+type ItemTax struct {
+	ItemID    uuid.UUID `json:"item_id"`
+	TaxRate   float64   `json:"tax_rate"`
+	TaxAmount float64   `json:"tax_amount"`
+}
+
+// StatusChanged is the payload of every status transition event
+type StatusChanged struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type OrderItemState struct {
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+	TaxClass  string    `json:"tax_class,omitempty"`
+	TaxRate   float64   `json:"tax_rate,omitempty"`
+	TaxAmount float64   `json:"tax_amount,omitempty"`
+}
+
+// OrderAggregate is the state of an order folded from its event stream
+type OrderAggregate struct {
+	ID                uuid.UUID               `json:"id"`
+	CustomerID        uuid.UUID               `json:"customer_id"`
+	Status            string                  `json:"status"`
+	TotalAmount       float64                 `json:"total_amount"`
+	DiscountAmount    float64                 `json:"discount_amount"`
+	TaxAmount         float64                 `json:"tax_amount"`
+	TaxCountry        string                  `json:"tax_country,omitempty"`
+	TaxRegion         string                  `json:"tax_region,omitempty"`
+	Currency          string                  `json:"currency,omitempty"`
+	FxRate            float64                 `json:"fx_rate"`
+	PromotionConfigID *uuid.UUID              `json:"promotion_config_id,omitempty"`
+	Customer          models.CustomerSnapshot `json:"customer"`
+	Shipping          models.Address          `json:"shipping_address"`
+	Billing           models.Address          `json:"billing_address"`
+	Items             []OrderItemState        `json:"items"`
+	Version           int                     `json:"version"`
+	CreatedAt         time.Time               `json:"created_at"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+}
+
+func NewOrderAggregate(id uuid.UUID) *OrderAggregate {
+	return &OrderAggregate{ID: id}
+}
+
+// StatusEventType maps a target order status onto the event recorded for the transition
+func StatusEventType(status models.OrderStatus) events.EventType {
+	switch status {
+	case models.OrderStatusAuthorized:
+		return events.EventPaymentAuthorized
+	case models.OrderStatusDeclined:
+		return events.EventPaymentDeclined
+	case models.OrderStatusCancelled:
+		return events.EventOrderCancelled
+	case models.OrderStatusExpired:
+		return events.EventOrderExpired
+	case models.OrderStatusCompleted:
+		return events.EventOrderCompleted
+	default:
+		return events.EventOrderStatusChanged
+	}
+}
+
+// Apply folds one event into the aggregate
+func (a *OrderAggregate) Apply(evt models.OrderEvent) error {
+	if evt.Version != a.Version+1 {
+		return fmt.Errorf("order %s: event version %d does not follow %d", a.ID, evt.Version, a.Version)
+	}
+
+	switch events.EventType(evt.EventType) {
+	case events.EventOrderCreated:
+		var p OrderCreated
+		if err := json.Unmarshal([]byte(evt.Payload), &p); err != nil {
+			return err
+		}
+		a.CustomerID = p.CustomerID
+		a.Status = p.Status
+		a.TotalAmount = p.TotalAmount
+		a.DiscountAmount = p.DiscountAmount
+		a.TaxAmount = p.TaxAmount
+		a.TaxCountry = p.TaxCountry
+		a.TaxRegion = p.TaxRegion
+		a.Currency = p.Currency
+		// streams recorded before currencies were snapshotted are in the base currency
+		a.FxRate = p.FxRate
+		if a.FxRate == 0 {
+			a.FxRate = 1
+		}
+		a.PromotionConfigID = p.PromotionConfigID
+		a.Customer = p.Customer
+		a.Shipping = p.Shipping
+		a.Billing = p.Billing
+		a.CreatedAt = evt.OccurredAt
+		if p.PlacedAt != nil {
+			a.CreatedAt = *p.PlacedAt
+		}
+
+	case events.EventOrderItemAdded:
+		var p ItemAdded
+		if err := json.Unmarshal([]byte(evt.Payload), &p); err != nil {
+			return err
+		}
+		a.Items = append(a.Items, OrderItemState{
+			ID:        p.ItemID,
+			ProductID: p.ProductID,
+			Quantity:  p.Quantity,
+			UnitPrice: p.UnitPrice,
+			TaxClass:  p.TaxClass,
+			TaxRate:   p.TaxRate,
+			TaxAmount: p.TaxAmount,
+		})
+
+	case events.EventOrderItemUpdated:
+		var p ItemUpdated
+		if err := json.Unmarshal([]byte(evt.Payload), &p); err != nil {
+			return err
+		}
+		for i := range a.Items {
+			if a.Items[i].ID == p.ItemID {
+				a.Items[i].Quantity = p.Quantity
+			}
+		}
+
+	case events.EventOrderItemRemoved:
+		var p ItemRemoved
+		if err := json.Unmarshal([]byte(evt.Payload), &p); err != nil {
+			return err
+		}
+		items := a.Items[:0]
+		for _, item := range a.Items {
+			if item.ID != p.ItemID {
+				items = append(items, item)
+			}
+		}
+		a.Items = items
+
+	case events.EventOrderRepriced:
+		var p Repriced
+		if err := json.Unmarshal([]byte(evt.Payload), &p); err != nil {
+			return err
+		}
+		a.TotalAmount = p.TotalAmount
+		a.TaxAmount = p.TaxAmount
+		a.PromotionConfigID = p.PromotionConfigID
+		for _, t := range p.ItemTaxes {
+			for i := range a.Items {
+				if a.Items[i].ID == t.ItemID {
+					a.Items[i].TaxRate = t.TaxRate
+					a.Items[i].TaxAmount = t.TaxAmount
+				}
+			}
+		}
+
+	case events.EventPaymentAuthorized,
+		events.EventPaymentDeclined,
+		events.EventOrderCancelled,
+		events.EventOrderExpired,
+		events.EventOrderCompleted,
+		events.EventOrderStatusChanged:
+		var p StatusChanged
+		if err := json.Unmarshal([]byte(evt.Payload), &p); err != nil {
+			return err
+		}
+		a.Status = p.To
+
+	default:
+		return fmt.Errorf("order %s: unknown event type %q", a.ID, evt.EventType)
+	}
+
+	a.Version = evt.Version
+	a.UpdatedAt = evt.OccurredAt
+	return nil
+}
+
+// FromOrder rebuilds the genesis events of an order that was stored before event sourcing was enabled
+func FromOrder(order *models.Order) []Change {
+	changes := []Change{{
+		Type: events.EventOrderCreated,
+		Payload: OrderCreated{
+			CustomerID:        order.CustomerID,
+			Status:            order.Status,
+			TotalAmount:       order.TotalAmount,
+			DiscountAmount:    order.DiscountAmount,
+			TaxAmount:         order.TaxAmount,
+			TaxCountry:        order.TaxCountry,
+			TaxRegion:         order.TaxRegion,
+			Currency:          order.Currency,
+			FxRate:            order.FxRate,
+			PromotionConfigID: order.PromotionConfigID,
+			Customer:          order.Customer,
+			Shipping:          order.ShippingAddress,
+			Billing:           order.BillingAddress,
+		},
+	}}
+	for _, item := range order.OrderItems {
+		changes = append(changes, Change{
+			Type: events.EventOrderItemAdded,
+			Payload: ItemAdded{
+				ItemID:    item.ID,
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				UnitPrice: item.UnitPrice,
+				TaxClass:  item.TaxClass,
+				TaxRate:   item.TaxRate,
+				TaxAmount: item.TaxAmount,
+			},
+		})
+	}
+	return changes
+}
@@ -0,0 +1,266 @@
+package eventsourcing
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/events"
+	"order/internal/models"
+)
+
+var testOccurredAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// stream turns changes into the events of one order, numbered from version 1
+func stream(t *testing.T, id uuid.UUID, changes ...Change) []models.OrderEvent {
+	t.Helper()
+	evts := make([]models.OrderEvent, 0, len(changes))
+	for i, change := range changes {
+		payload, err := json.Marshal(change.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		evts = append(evts, models.OrderEvent{
+			AggregateType: events.AggregateOrder.String(),
+			AggregateID:   id,
+			Version:       i + 1,
+			EventType:     change.Type.String(),
+			Payload:       string(payload),
+			OccurredAt:    testOccurredAt.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return evts
+}
+
+func TestOrderAggregateApply(t *testing.T) {
+	var (
+		orderID    = uuid.New()
+		customerID = uuid.New()
+		promoID    = uuid.New()
+		first      = uuid.New()
+		second     = uuid.New()
+		productA   = uuid.New()
+		productB   = uuid.New()
+		placedAt   = time.Date(2023, 11, 3, 9, 0, 0, 0, time.UTC)
+	)
+	created := Change{Type: events.EventOrderCreated, Payload: OrderCreated{
+		CustomerID:  customerID,
+		Status:      string(models.OrderStatusPending),
+		TotalAmount: 30,
+		Currency:    "EUR",
+	}}
+	addFirst := Change{Type: events.EventOrderItemAdded, Payload: ItemAdded{ItemID: first, ProductID: productA, Quantity: 2, UnitPrice: 10, TaxClass: "standard"}}
+	addSecond := Change{Type: events.EventOrderItemAdded, Payload: ItemAdded{ItemID: second, ProductID: productB, Quantity: 1, UnitPrice: 10}}
+
+	tests := []struct {
+		name    string
+		changes []Change
+		want    OrderAggregate
+	}{
+		{
+			name:    "created",
+			changes: []Change{created},
+			want: OrderAggregate{
+				ID: orderID, CustomerID: customerID, Status: "PENDING", TotalAmount: 30, Currency: "EUR",
+				FxRate: 1, Version: 1, CreatedAt: testOccurredAt, UpdatedAt: testOccurredAt,
+			},
+		},
+		{
+			name: "created with its placing time and rate",
+			changes: []Change{{Type: events.EventOrderCreated, Payload: OrderCreated{
+				CustomerID: customerID, Status: "COMPLETED", TotalAmount: 30, Currency: "USD", FxRate: 0.9, PlacedAt: &placedAt,
+			}}},
+			want: OrderAggregate{
+				ID: orderID, CustomerID: customerID, Status: "COMPLETED", TotalAmount: 30, Currency: "USD",
+				FxRate: 0.9, Version: 1, CreatedAt: placedAt, UpdatedAt: testOccurredAt,
+			},
+		},
+		{
+			name: "items added, updated, removed, repriced and paid",
+			changes: []Change{
+				created,
+				addFirst,
+				addSecond,
+				{Type: events.EventOrderItemUpdated, Payload: ItemUpdated{ItemID: first, Quantity: 3}},
+				{Type: events.EventOrderItemRemoved, Payload: ItemRemoved{ItemID: second}},
+				{Type: events.EventOrderRepriced, Payload: Repriced{
+					TotalAmount:       36,
+					TaxAmount:         6,
+					ItemTaxes:         []ItemTax{{ItemID: first, TaxRate: 0.2, TaxAmount: 6}},
+					PromotionConfigID: &promoID,
+				}},
+				{Type: events.EventPaymentAuthorized, Payload: StatusChanged{From: "PENDING", To: "AUTHORIZED"}},
+			},
+			want: OrderAggregate{
+				ID: orderID, CustomerID: customerID, Status: "AUTHORIZED", TotalAmount: 36, TaxAmount: 6,
+				Currency: "EUR", FxRate: 1, PromotionConfigID: &promoID,
+				Items: []OrderItemState{
+					{ID: first, ProductID: productA, Quantity: 3, UnitPrice: 10, TaxClass: "standard", TaxRate: 0.2, TaxAmount: 6},
+				},
+				Version: 7, CreatedAt: testOccurredAt, UpdatedAt: testOccurredAt.Add(6 * time.Minute),
+			},
+		},
+		{
+			name: "every status event moves the status",
+			changes: []Change{
+				created,
+				{Type: events.EventOrderStatusChanged, Payload: StatusChanged{From: "PENDING", To: "AUTHORIZED"}},
+				{Type: events.EventOrderCompleted, Payload: StatusChanged{From: "AUTHORIZED", To: "COMPLETED"}},
+			},
+			want: OrderAggregate{
+				ID: orderID, CustomerID: customerID, Status: "COMPLETED", TotalAmount: 30, Currency: "EUR",
+				FxRate: 1, Version: 3, CreatedAt: testOccurredAt, UpdatedAt: testOccurredAt.Add(2 * time.Minute),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := NewOrderAggregate(orderID)
+			for _, evt := range stream(t, orderID, tt.changes...) {
+				if err := agg.Apply(evt); err != nil {
+					t.Fatalf("Apply(%s): %v", evt.EventType, err)
+				}
+			}
+			if !reflect.DeepEqual(*agg, tt.want) {
+				t.Errorf("aggregate = %+v\nwant %+v", *agg, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderAggregateApplyRejects(t *testing.T) {
+	orderID := uuid.New()
+	created := Change{Type: events.EventOrderCreated, Payload: OrderCreated{Status: "PENDING"}}
+
+	tests := []struct {
+		name    string
+		evts    func() []models.OrderEvent
+		wantErr string
+	}{
+		{
+			name: "version gap",
+			evts: func() []models.OrderEvent {
+				evts := stream(t, orderID, created, Change{Type: events.EventOrderItemAdded, Payload: ItemAdded{ItemID: uuid.New()}})
+				evts[1].Version = 3
+				return evts
+			},
+			wantErr: "event version 3 does not follow 1",
+		},
+		{
+			name: "replayed version",
+			evts: func() []models.OrderEvent {
+				evts := stream(t, orderID, created)
+				return append(evts, evts[0])
+			},
+			wantErr: "event version 1 does not follow 1",
+		},
+		{
+			name: "unknown event type",
+			evts: func() []models.OrderEvent {
+				return stream(t, orderID, created, Change{Type: "order_teleported", Payload: struct{}{}})
+			},
+			wantErr: `unknown event type "order_teleported"`,
+		},
+		{
+			name: "malformed payload",
+			evts: func() []models.OrderEvent {
+				evts := stream(t, orderID, created)
+				evts[0].Payload = "{"
+				return evts
+			},
+			wantErr: "unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := NewOrderAggregate(orderID)
+			var err error
+			evts := tt.evts()
+			for _, evt := range evts {
+				if err = agg.Apply(evt); err != nil {
+					break
+				}
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %q", err, tt.wantErr)
+			}
+			if agg.Version >= len(evts) {
+				t.Errorf("version moved to %d past the rejected event", agg.Version)
+			}
+		})
+	}
+}
+
+func TestFromOrder(t *testing.T) {
+	promoID := uuid.New()
+	order := &models.Order{
+		CustomerID:        uuid.New(),
+		Status:            string(models.OrderStatusAuthorized),
+		TotalAmount:       42,
+		DiscountAmount:    3,
+		TaxAmount:         7,
+		TaxCountry:        "DE",
+		Currency:          "USD",
+		FxRate:            0.92,
+		PromotionConfigID: &promoID,
+		Customer:          models.CustomerSnapshot{Email: "ada@example.com"},
+		ShippingAddress:   models.Address{Country: "DE", City: "Berlin"},
+	}
+	order.ID = uuid.New()
+	for _, quantity := range []int{1, 2} {
+		item := models.OrderItem{OrderID: order.ID, ProductID: uuid.New(), Quantity: quantity, UnitPrice: 12, TaxClass: "reduced", TaxRate: 0.07}
+		item.ID = uuid.New()
+		order.OrderItems = append(order.OrderItems, item)
+	}
+
+	changes := FromOrder(order)
+	if len(changes) != 1+len(order.OrderItems) {
+		t.Fatalf("%d genesis events, want %d", len(changes), 1+len(order.OrderItems))
+	}
+	if changes[0].Type != events.EventOrderCreated {
+		t.Errorf("first genesis event is %s, want %s", changes[0].Type, events.EventOrderCreated)
+	}
+
+	agg := NewOrderAggregate(order.ID)
+	for _, evt := range stream(t, order.ID, changes...) {
+		if err := agg.Apply(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if agg.CustomerID != order.CustomerID || agg.Status != order.Status || agg.TotalAmount != order.TotalAmount ||
+		agg.DiscountAmount != order.DiscountAmount || agg.TaxAmount != order.TaxAmount || agg.TaxCountry != order.TaxCountry ||
+		agg.Currency != order.Currency || agg.FxRate != order.FxRate || *agg.PromotionConfigID != promoID {
+		t.Errorf("aggregate %+v does not match the order", *agg)
+	}
+	if agg.Customer != order.Customer || agg.Shipping != order.ShippingAddress {
+		t.Errorf("snapshot %+v / %+v does not match the order", agg.Customer, agg.Shipping)
+	}
+	if len(agg.Items) != len(order.OrderItems) {
+		t.Fatalf("%d items, want %d", len(agg.Items), len(order.OrderItems))
+	}
+	for i, item := range order.OrderItems {
+		want := OrderItemState{ID: item.ID, ProductID: item.ProductID, Quantity: item.Quantity, UnitPrice: item.UnitPrice, TaxClass: item.TaxClass, TaxRate: item.TaxRate}
+		if agg.Items[i] != want {
+			t.Errorf("item %d = %+v, want %+v", i, agg.Items[i], want)
+		}
+	}
+}
+
+func TestStatusEventType(t *testing.T) {
+	for status, want := range map[models.OrderStatus]events.EventType{
+		models.OrderStatusAuthorized: events.EventPaymentAuthorized,
+		models.OrderStatusDeclined:   events.EventPaymentDeclined,
+		models.OrderStatusCancelled:  events.EventOrderCancelled,
+		models.OrderStatusExpired:    events.EventOrderExpired,
+		models.OrderStatusCompleted:  events.EventOrderCompleted,
+		models.OrderStatusPending:    events.EventOrderStatusChanged,
+	} {
+		if got := StatusEventType(status); got != want {
+			t.Errorf("StatusEventType(%s) = %s, want %s", status, got, want)
+		}
+	}
+}
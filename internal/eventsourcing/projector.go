@@ -0,0 +1,156 @@
+package eventsourcing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/internal/tax"
+	"order/pkg/core/logger"
+)
+
+const rebuildBatchSize = 100
+
+// Projector maintains the orders and order_items tables as a projection of the event streams
+type Projector struct {
+	pg        pgGorm.PGInterface
+	store     *Store
+	eventRepo repo.OrderEventRepoInterface
+}
+
+func NewProjector(pg pgGorm.PGInterface, store *Store, eventRepo repo.OrderEventRepoInterface) *Projector {
+	return &Projector{pg: pg, store: store, eventRepo: eventRepo}
+}
+
+// Project writes the state of agg into the orders and order_items rows.
+// Columns not derived from events (e.g. reward_given) are left untouched, and the customer
+// snapshot and currency are only written when the row is created.
+func (p *Projector) Project(ctx context.Context, tx *gorm.DB, agg *OrderAggregate) error {
+	order := &models.Order{
+		CustomerID:        agg.CustomerID,
+		TotalAmount:       agg.TotalAmount,
+		DiscountAmount:    agg.DiscountAmount,
+		TaxAmount:         agg.TaxAmount,
+		TaxCountry:        agg.TaxCountry,
+		TaxRegion:         agg.TaxRegion,
+		Currency:          agg.Currency,
+		FxRate:            agg.FxRate,
+		Customer:          agg.Customer,
+		ShippingAddress:   agg.Shipping,
+		BillingAddress:    agg.Billing,
+		Status:            agg.Status,
+		PromotionConfigID: agg.PromotionConfigID,
+	}
+	order.ID = agg.ID
+	order.CreatedAt = agg.CreatedAt
+	order.UpdatedAt = agg.UpdatedAt
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"customer_id", "total_amount", "discount_amount", "tax_amount", "tax_country", "tax_region",
+			"status", "promotion_config_id", "updated_at",
+		}),
+	}).Create(order).Error; err != nil {
+		return err
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(agg.Items))
+	for _, item := range agg.Items {
+		itemIDs = append(itemIDs, item.ID)
+
+		row := &models.OrderItem{
+			OrderID:   agg.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			TaxClass:  item.TaxClass,
+			TaxRate:   item.TaxRate,
+			TaxAmount: item.TaxAmount,
+		}
+		if row.TaxClass == "" {
+			row.TaxClass = tax.ClassStandard
+		}
+		row.ID = item.ID
+		row.CreatedAt = agg.CreatedAt
+		row.UpdatedAt = agg.UpdatedAt
+
+		if err := tx.WithContext(ctx).Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"product_id", "quantity", "unit_price", "tax_class", "tax_rate", "tax_amount", "updated_at"}),
+		}).Create(row).Error; err != nil {
+			return err
+		}
+	}
+
+	// drop items that are no longer part of the aggregate
+	stale := tx.WithContext(ctx).Where("order_id = ?", agg.ID)
+	if len(itemIDs) > 0 {
+		stale = stale.Where("id NOT IN ?", itemIDs)
+	}
+	return stale.Delete(&models.OrderItem{}).Error
+}
+
+// Rebuild replays every stream and rewrites its projection, one transaction per order.
+// With ignoreSnapshots the streams are folded from their first event and snapshots are rewritten.
+func (p *Projector) Rebuild(ctx context.Context, ignoreSnapshots bool) (int64, error) {
+	log := logger.WithTag("Projector|Rebuild")
+
+	var (
+		rebuilt int64
+		after   uuid.UUID
+	)
+	for {
+		ids, err := p.eventRepo.ListAggregateIDs(ctx, after, rebuildBatchSize)
+		if err != nil {
+			return rebuilt, err
+		}
+		if len(ids) == 0 {
+			return rebuilt, nil
+		}
+
+		for _, id := range ids {
+			if err = p.rebuildOne(ctx, id, ignoreSnapshots); err != nil {
+				logger.LogError(log, err, "failed to rebuild projection of order "+id.String())
+				continue
+			}
+			rebuilt++
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+func (p *Projector) rebuildOne(ctx context.Context, id uuid.UUID, ignoreSnapshots bool) error {
+	db, cancel := p.pg.DBWithTimeout(ctx, "Projector.rebuildOne")
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var (
+			agg *OrderAggregate
+			err error
+		)
+		if ignoreSnapshots {
+			agg, err = p.store.LoadFromScratch(ctx, tx, id)
+		} else {
+			agg, err = p.store.Load(ctx, tx, id)
+		}
+		if err != nil {
+			if errors.Is(err, ErrStreamNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if ignoreSnapshots && agg.Version >= p.store.snapshotEvery {
+			if err = p.store.Snapshot(ctx, tx, agg); err != nil {
+				return err
+			}
+		}
+		return p.Project(ctx, tx, agg)
+	})
+}
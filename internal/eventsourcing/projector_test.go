@@ -0,0 +1,65 @@
+package eventsourcing
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+)
+
+func TestProjectorRebuild(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	eventRepo := repo.NewOrderEventRepository(pg)
+	store := NewStore(eventRepo, repo.NewOutboxRepository(pg), 2)
+	projector := NewProjector(pg, store, eventRepo)
+
+	agg := NewOrderAggregate(uuid.New())
+	kept, dropped := uuid.New(), uuid.New()
+	err := pg.GetRepo().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := store.Save(ctx, tx, agg,
+			Change{Type: events.EventOrderCreated, Payload: OrderCreated{CustomerID: uuid.New(), Status: "PENDING", TotalAmount: 20, Currency: "EUR"}},
+			Change{Type: events.EventOrderItemAdded, Payload: ItemAdded{ItemID: kept, ProductID: uuid.New(), Quantity: 1, UnitPrice: 10}},
+			Change{Type: events.EventOrderItemAdded, Payload: ItemAdded{ItemID: dropped, ProductID: uuid.New(), Quantity: 1, UnitPrice: 10}},
+		); err != nil {
+			return err
+		}
+		if err := projector.Project(ctx, tx, agg); err != nil {
+			return err
+		}
+		_, err := store.Save(ctx, tx, agg,
+			Change{Type: events.EventOrderItemRemoved, Payload: ItemRemoved{ItemID: dropped}},
+			Change{Type: events.EventOrderRepriced, Payload: Repriced{TotalAmount: 10}},
+			Change{Type: events.EventPaymentAuthorized, Payload: StatusChanged{From: "PENDING", To: "AUTHORIZED"}},
+		)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ignoreSnapshots := range []bool{false, true} {
+		rebuilt, err := projector.Rebuild(ctx, ignoreSnapshots)
+		if err != nil {
+			t.Fatalf("Rebuild(%v): %v", ignoreSnapshots, err)
+		}
+		if rebuilt != 1 {
+			t.Errorf("Rebuild(%v) rebuilt %d orders, want 1", ignoreSnapshots, rebuilt)
+		}
+
+		var order models.Order
+		if err = pg.GetRepo().WithContext(ctx).Preload("OrderItems").First(&order, "id = ?", agg.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if order.Status != "AUTHORIZED" || order.TotalAmount != 10 {
+			t.Errorf("projected order = %s %v, want AUTHORIZED 10", order.Status, order.TotalAmount)
+		}
+		if len(order.OrderItems) != 1 || order.OrderItems[0].ID != kept {
+			t.Errorf("projected items = %+v, want only %s", order.OrderItems, kept)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package eventsourcing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/models"
+	repo "order/internal/repositories"
+)
+
+var (
+	ErrStreamNotFound      = errors.New("order event stream not found")
+	ErrConcurrencyConflict = errors.New("order event stream was modified concurrently")
+)
+
+// Change is an event to append, before it gets a version and an id
+type Change struct {
+	Type    events.EventType
+	Payload interface{}
+}
+
+// Envelope is the message published through the outbox for every stored event
+type Envelope struct {
+	EventID       uuid.UUID       `json:"event_id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id"`
+	Version       int             `json:"version"`
+	EventType     string          `json:"event_type"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Store appends to and loads order event streams. Every appended event is also written
+// to the outbox in the same transaction so the stream can be published as is.
+type Store struct {
+	eventRepo     repo.OrderEventRepoInterface
+	outboxRepo    repo.OutboxRepoInterface
+	snapshotEvery int
+}
+
+func NewStore(eventRepo repo.OrderEventRepoInterface, outboxRepo repo.OutboxRepoInterface, snapshotEvery int) *Store {
+	if snapshotEvery <= 0 {
+		snapshotEvery = 50
+	}
+	return &Store{
+		eventRepo:     eventRepo,
+		outboxRepo:    outboxRepo,
+		snapshotEvery: snapshotEvery,
+	}
+}
+
+// Save appends changes to the stream of agg, expecting the stream to be at agg.Version,
+// folds them into agg and snapshots the stream when enough events accumulated.
+func (s *Store) Save(ctx context.Context, tx *gorm.DB, agg *OrderAggregate, changes ...Change) ([]*models.OrderEvent, error) {
+	current, err := s.eventRepo.LastVersion(ctx, tx, agg.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current != agg.Version {
+		return nil, ErrConcurrencyConflict
+	}
+
+	now := time.Now()
+	evts := make([]*models.OrderEvent, 0, len(changes))
+	outboxes := make([]*models.Outbox, 0, len(changes))
+	for i, change := range changes {
+		payload, err := json.Marshal(change.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		evt := &models.OrderEvent{
+			AggregateType: events.AggregateOrder.String(),
+			AggregateID:   agg.ID,
+			Version:       agg.Version + i + 1,
+			EventType:     change.Type.String(),
+			Payload:       string(payload),
+			OccurredAt:    now,
+		}
+		evt.ID = uuid.New()
+		evts = append(evts, evt)
+
+		envelope, err := json.Marshal(Envelope{
+			EventID:       evt.ID,
+			AggregateType: evt.AggregateType,
+			AggregateID:   evt.AggregateID,
+			Version:       evt.Version,
+			EventType:     evt.EventType,
+			OccurredAt:    evt.OccurredAt,
+			Payload:       payload,
+		})
+		if err != nil {
+			return nil, err
+		}
+		outboxes = append(outboxes, &models.Outbox{
+			EventID:       evt.ID,
+			EventType:     evt.EventType,
+			AggregateType: evt.AggregateType,
+			AggregateID:   evt.AggregateID,
+			Payload:       string(envelope),
+			Status:        models.OutboxStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	if err = s.eventRepo.Append(ctx, tx, evts); err != nil {
+		return nil, err
+	}
+	for _, outbox := range outboxes {
+		if err = s.outboxRepo.CreateOutbox(ctx, tx, outbox); err != nil {
+			return nil, err
+		}
+	}
+
+	snapshotVersion := agg.Version - agg.Version%s.snapshotEvery
+	for _, evt := range evts {
+		if err = agg.Apply(*evt); err != nil {
+			return nil, err
+		}
+	}
+	if agg.Version-snapshotVersion >= s.snapshotEvery {
+		if err = s.Snapshot(ctx, tx, agg); err != nil {
+			return nil, err
+		}
+	}
+
+	return evts, nil
+}
+
+// Load folds the stream of an order starting from its latest snapshot.
+func (s *Store) Load(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (*OrderAggregate, error) {
+	agg := NewOrderAggregate(aggregateID)
+
+	snapshot, err := s.eventRepo.GetSnapshot(ctx, tx, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		if err = json.Unmarshal([]byte(snapshot.State), agg); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.replay(ctx, tx, agg)
+}
+
+// LoadFromScratch folds the whole stream of an order, ignoring snapshots.
+func (s *Store) LoadFromScratch(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (*OrderAggregate, error) {
+	return s.replay(ctx, tx, NewOrderAggregate(aggregateID))
+}
+
+func (s *Store) replay(ctx context.Context, tx *gorm.DB, agg *OrderAggregate) (*OrderAggregate, error) {
+	evts, err := s.eventRepo.ListAfter(ctx, tx, agg.ID, agg.Version)
+	if err != nil {
+		return nil, err
+	}
+	for _, evt := range evts {
+		if err = agg.Apply(evt); err != nil {
+			return nil, err
+		}
+	}
+	if agg.Version == 0 {
+		return nil, ErrStreamNotFound
+	}
+	return agg, nil
+}
+
+// Snapshot stores the current state of agg
+func (s *Store) Snapshot(ctx context.Context, tx *gorm.DB, agg *OrderAggregate) error {
+	state, err := json.Marshal(agg)
+	if err != nil {
+		return err
+	}
+	return s.eventRepo.SaveSnapshot(ctx, tx, &models.OrderSnapshot{
+		AggregateID: agg.ID,
+		Version:     agg.Version,
+		State:       string(state),
+	})
+}
@@ -0,0 +1,217 @@
+package eventsourcing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/models"
+	repo "order/internal/repositories"
+)
+
+// memoryEvents keeps the streams and snapshots of every order in memory
+type memoryEvents struct {
+	repo.OrderEventRepoInterface
+	streams   map[uuid.UUID][]models.OrderEvent
+	snapshots map[uuid.UUID]models.OrderSnapshot
+	saved     []int
+}
+
+func newMemoryEvents() *memoryEvents {
+	return &memoryEvents{streams: map[uuid.UUID][]models.OrderEvent{}, snapshots: map[uuid.UUID]models.OrderSnapshot{}}
+}
+
+func (m *memoryEvents) Append(ctx context.Context, tx *gorm.DB, evts []*models.OrderEvent) error {
+	for _, evt := range evts {
+		m.streams[evt.AggregateID] = append(m.streams[evt.AggregateID], *evt)
+	}
+	return nil
+}
+
+func (m *memoryEvents) LastVersion(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (int, error) {
+	return len(m.streams[aggregateID]), nil
+}
+
+func (m *memoryEvents) ListAfter(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, version int) ([]models.OrderEvent, error) {
+	var evts []models.OrderEvent
+	for _, evt := range m.streams[aggregateID] {
+		if evt.Version > version {
+			evts = append(evts, evt)
+		}
+	}
+	return evts, nil
+}
+
+func (m *memoryEvents) GetSnapshot(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (*models.OrderSnapshot, error) {
+	snapshot, ok := m.snapshots[aggregateID]
+	if !ok {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
+
+func (m *memoryEvents) SaveSnapshot(ctx context.Context, tx *gorm.DB, snapshot *models.OrderSnapshot) error {
+	m.snapshots[snapshot.AggregateID] = *snapshot
+	m.saved = append(m.saved, snapshot.Version)
+	return nil
+}
+
+// memoryOutbox records the outbox rows written along with the events
+type memoryOutbox struct {
+	repo.OutboxRepoInterface
+	rows []*models.Outbox
+}
+
+func (m *memoryOutbox) CreateOutbox(ctx context.Context, tx *gorm.DB, outbox *models.Outbox) error {
+	m.rows = append(m.rows, outbox)
+	return nil
+}
+
+func itemAdded() Change {
+	return Change{Type: events.EventOrderItemAdded, Payload: ItemAdded{ItemID: uuid.New(), ProductID: uuid.New(), Quantity: 1, UnitPrice: 5}}
+}
+
+func TestStoreSaveSnapshotsEverySnapshotEvery(t *testing.T) {
+	tests := []struct {
+		name          string
+		batches       []int
+		wantSnapshots []int
+	}{
+		{name: "one event at a time", batches: []int{1, 1, 1, 1, 1, 1, 1}, wantSnapshots: []int{3, 6}},
+		{name: "batch reaching the cadence", batches: []int{3}, wantSnapshots: []int{3}},
+		{name: "batch crossing the cadence", batches: []int{2, 2}, wantSnapshots: []int{4}},
+		{name: "batch spanning two cadences", batches: []int{1, 6}, wantSnapshots: []int{7}},
+		{name: "short of the cadence", batches: []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventRepo, outbox := newMemoryEvents(), &memoryOutbox{}
+			store := NewStore(eventRepo, outbox, 3)
+			agg := NewOrderAggregate(uuid.New())
+
+			for i, size := range tt.batches {
+				var changes []Change
+				if i == 0 {
+					changes = append(changes, Change{Type: events.EventOrderCreated, Payload: OrderCreated{Status: "PENDING"}})
+				}
+				for len(changes) < size {
+					changes = append(changes, itemAdded())
+				}
+				if _, err := store.Save(context.Background(), nil, agg, changes...); err != nil {
+					t.Fatalf("Save batch %d: %v", i, err)
+				}
+			}
+
+			if len(eventRepo.saved) != len(tt.wantSnapshots) {
+				t.Fatalf("snapshots at %v, want %v", eventRepo.saved, tt.wantSnapshots)
+			}
+			for i := range tt.wantSnapshots {
+				if eventRepo.saved[i] != tt.wantSnapshots[i] {
+					t.Fatalf("snapshots at %v, want %v", eventRepo.saved, tt.wantSnapshots)
+				}
+			}
+			if len(outbox.rows) != agg.Version {
+				t.Errorf("%d outbox rows for %d events", len(outbox.rows), agg.Version)
+			}
+		})
+	}
+}
+
+func TestStoreSaveWritesEnvelopes(t *testing.T) {
+	eventRepo, outbox := newMemoryEvents(), &memoryOutbox{}
+	store := NewStore(eventRepo, outbox, 50)
+	agg := NewOrderAggregate(uuid.New())
+
+	evts, err := store.Save(context.Background(), nil, agg,
+		Change{Type: events.EventOrderCreated, Payload: OrderCreated{Status: "PENDING", TotalAmount: 5}}, itemAdded())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.Version != 2 || agg.TotalAmount != 5 || len(agg.Items) != 1 {
+		t.Errorf("aggregate not folded: %+v", *agg)
+	}
+	for i, evt := range evts {
+		var envelope Envelope
+		if err = json.Unmarshal([]byte(outbox.rows[i].Payload), &envelope); err != nil {
+			t.Fatal(err)
+		}
+		if envelope.EventID != evt.ID || envelope.AggregateID != agg.ID || envelope.Version != i+1 || envelope.EventType != evt.EventType {
+			t.Errorf("envelope %d = %+v does not describe event %+v", i, envelope, *evt)
+		}
+		if string(envelope.Payload) != evt.Payload {
+			t.Errorf("envelope %d payload = %s, want %s", i, envelope.Payload, evt.Payload)
+		}
+	}
+}
+
+func TestStoreSaveRejectsStaleAggregate(t *testing.T) {
+	eventRepo := newMemoryEvents()
+	store := NewStore(eventRepo, &memoryOutbox{}, 50)
+	id := uuid.New()
+
+	fresh := NewOrderAggregate(id)
+	if _, err := store.Save(context.Background(), nil, fresh, Change{Type: events.EventOrderCreated, Payload: OrderCreated{Status: "PENDING"}}); err != nil {
+		t.Fatal(err)
+	}
+	stale := NewOrderAggregate(id)
+	if _, err := store.Save(context.Background(), nil, stale, itemAdded()); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Errorf("Save() error = %v, want %v", err, ErrConcurrencyConflict)
+	}
+	if len(eventRepo.streams[id]) != 1 {
+		t.Errorf("stale save appended to the stream: %d events", len(eventRepo.streams[id]))
+	}
+}
+
+func TestStoreLoad(t *testing.T) {
+	eventRepo := newMemoryEvents()
+	store := NewStore(eventRepo, &memoryOutbox{}, 3)
+	agg := NewOrderAggregate(uuid.New())
+
+	if _, err := store.Save(context.Background(), nil, agg,
+		Change{Type: events.EventOrderCreated, Payload: OrderCreated{Status: "PENDING", TotalAmount: 10}},
+		itemAdded(), itemAdded()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Save(context.Background(), nil, agg,
+		Change{Type: events.EventPaymentAuthorized, Payload: StatusChanged{From: "PENDING", To: "AUTHORIZED"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the snapshot at version 3 says something the events do not, which tells the two loads apart
+	snapshot := eventRepo.snapshots[agg.ID]
+	var state OrderAggregate
+	if err := json.Unmarshal([]byte(snapshot.State), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != 3 || len(state.Items) != 2 {
+		t.Fatalf("snapshot state = %+v, want version 3 with 2 items", state)
+	}
+	state.TotalAmount = 99
+	raw, _ := json.Marshal(state)
+	snapshot.State = string(raw)
+	eventRepo.snapshots[agg.ID] = snapshot
+
+	loaded, err := store.Load(context.Background(), nil, agg.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Version != 4 || loaded.Status != "AUTHORIZED" || loaded.TotalAmount != 99 || len(loaded.Items) != 2 {
+		t.Errorf("Load() = %+v, want the snapshot with the authorization replayed onto it", *loaded)
+	}
+
+	scratch, err := store.LoadFromScratch(context.Background(), nil, agg.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scratch.Version != 4 || scratch.Status != "AUTHORIZED" || scratch.TotalAmount != 10 || len(scratch.Items) != 2 {
+		t.Errorf("LoadFromScratch() = %+v, want the stream folded from its first event", *scratch)
+	}
+
+	if _, err = store.Load(context.Background(), nil, uuid.New()); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("Load() of an unknown order error = %v, want %v", err, ErrStreamNotFound)
+	}
+}
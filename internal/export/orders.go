@@ -0,0 +1,272 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"order/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+
+	// ItemsNested writes one record per order holding its items, ItemsFlattened one record per item
+	ItemsNested    = "nested"
+	ItemsFlattened = "flattened"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown export format")
+	ErrUnknownLayout = errors.New("unknown items layout")
+)
+
+// OrderWriter writes orders in an export format. Close flushes what is buffered; it does not
+// close the underlying writer.
+type OrderWriter interface {
+	Write(order *models.Order) error
+	Close() error
+}
+
+// NewOrderWriter returns a writer of format with the items laid out as items; an empty format
+// writes CSV and an empty layout nests the items
+func NewOrderWriter(w io.Writer, format, items string) (OrderWriter, error) {
+	switch items {
+	case "", ItemsNested, ItemsFlattened:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownLayout, items)
+	}
+	flatten := items == ItemsFlattened
+
+	switch strings.ToLower(format) {
+	case "", FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w), flatten: flatten}, nil
+	case FormatNDJSON, "jsonl":
+		return &ndjsonWriter{w: bufio.NewWriter(w), flatten: flatten}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// ContentType returns the media type and file extension of format
+func ContentType(format string) (string, string) {
+	if strings.ToLower(format) == FormatNDJSON || strings.ToLower(format) == "jsonl" {
+		return "application/x-ndjson", "ndjson"
+	}
+	return "text/csv; charset=utf-8", "csv"
+}
+
+// orderRecord is the exported view of an order
+type orderRecord struct {
+	OrderID           uuid.UUID    `json:"order_id"`
+	CreatedAt         time.Time    `json:"created_at"`
+	Status            string       `json:"status"`
+	CustomerID        uuid.UUID    `json:"customer_id"`
+	CustomerName      string       `json:"customer_name"`
+	CustomerEmail     string       `json:"customer_email"`
+	Currency          string       `json:"currency"`
+	FxRate            float64      `json:"fx_rate"`
+	TotalAmount       float64      `json:"total_amount"`
+	TaxAmount         float64      `json:"tax_amount"`
+	DiscountAmount    float64      `json:"discount_amount"`
+	RefundedAmount    float64      `json:"refunded_amount"`
+	PromotionConfigID *uuid.UUID   `json:"promotion_config_id"`
+	Items             []itemRecord `json:"items,omitempty"`
+}
+
+type itemRecord struct {
+	ItemID    uuid.UUID `json:"item_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+	TaxClass  string    `json:"tax_class"`
+	TaxRate   float64   `json:"tax_rate"`
+	TaxAmount float64   `json:"item_tax_amount"`
+}
+
+// flatRecord is one item of an order with the order fields repeated
+type flatRecord struct {
+	orderRecord
+	itemRecord
+}
+
+func newOrderRecord(order *models.Order) orderRecord {
+	return orderRecord{
+		OrderID:           order.ID,
+		CreatedAt:         order.CreatedAt,
+		Status:            order.Status,
+		CustomerID:        order.CustomerID,
+		CustomerName:      order.Customer.Name,
+		CustomerEmail:     order.Customer.Email,
+		Currency:          order.Currency,
+		FxRate:            order.FxRate,
+		TotalAmount:       order.TotalAmount,
+		TaxAmount:         order.TaxAmount,
+		DiscountAmount:    order.DiscountAmount,
+		RefundedAmount:    order.RefundedAmount,
+		PromotionConfigID: order.PromotionConfigID,
+	}
+}
+
+func newItemRecord(item *models.OrderItem) itemRecord {
+	return itemRecord{
+		ItemID:    item.ID,
+		ProductID: item.ProductID,
+		Quantity:  item.Quantity,
+		UnitPrice: item.UnitPrice,
+		TaxClass:  item.TaxClass,
+		TaxRate:   item.TaxRate,
+		TaxAmount: item.TaxAmount,
+	}
+}
+
+var (
+	orderColumns = []string{
+		"order_id", "created_at", "status", "customer_id", "customer_name", "customer_email", "currency",
+		"fx_rate", "total_amount", "tax_amount", "discount_amount", "refunded_amount", "promotion_config_id",
+	}
+	itemColumns = []string{
+		"item_id", "product_id", "quantity", "unit_price", "tax_class", "tax_rate", "item_tax_amount",
+	}
+)
+
+func (r *orderRecord) columns() []string {
+	promotion := ""
+	if r.PromotionConfigID != nil {
+		promotion = r.PromotionConfigID.String()
+	}
+	return []string{
+		r.OrderID.String(), r.CreatedAt.UTC().Format(time.RFC3339), r.Status, r.CustomerID.String(),
+		safeCell(r.CustomerName), safeCell(r.CustomerEmail), r.Currency, formatFloat(r.FxRate), formatFloat(r.TotalAmount),
+		formatFloat(r.TaxAmount), formatFloat(r.DiscountAmount), formatFloat(r.RefundedAmount), promotion,
+	}
+}
+
+func (r *itemRecord) columns() []string {
+	return []string{
+		r.ItemID.String(), r.ProductID.String(), strconv.Itoa(r.Quantity), formatFloat(r.UnitPrice),
+		r.TaxClass, formatFloat(r.TaxRate), formatFloat(r.TaxAmount),
+	}
+}
+
+// safeCell keeps text entered by customers from being read as a formula by spreadsheets
+func safeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// csvWriter writes a header and then one row per order, with the items as a JSON column, or one
+// row per item. Orders without items still get a row when flattened.
+type csvWriter struct {
+	w           *csv.Writer
+	flatten     bool
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(order *models.Order) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	record := newOrderRecord(order)
+	if !c.flatten {
+		items := make([]itemRecord, 0, len(order.OrderItems))
+		for i := range order.OrderItems {
+			items = append(items, newItemRecord(&order.OrderItems[i]))
+		}
+		raw, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+		return c.w.Write(append(record.columns(), string(raw)))
+	}
+
+	if len(order.OrderItems) == 0 {
+		return c.w.Write(append(record.columns(), make([]string, len(itemColumns))...))
+	}
+	for i := range order.OrderItems {
+		item := newItemRecord(&order.OrderItems[i])
+		if err := c.w.Write(append(record.columns(), item.columns()...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+	header := append([]string{}, orderColumns...)
+	if c.flatten {
+		header = append(header, itemColumns...)
+	} else {
+		header = append(header, "items")
+	}
+	return c.w.Write(header)
+}
+
+// Close writes the header of an empty export and flushes
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonWriter writes one JSON object per line, an order with its items or an item with its order fields
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	flatten bool
+}
+
+func (n *ndjsonWriter) Write(order *models.Order) error {
+	record := newOrderRecord(order)
+	if !n.flatten {
+		record.Items = make([]itemRecord, 0, len(order.OrderItems))
+		for i := range order.OrderItems {
+			record.Items = append(record.Items, newItemRecord(&order.OrderItems[i]))
+		}
+		return n.writeLine(record)
+	}
+
+	if len(order.OrderItems) == 0 {
+		return n.writeLine(record)
+	}
+	for i := range order.OrderItems {
+		if err := n.writeLine(flatRecord{orderRecord: record, itemRecord: newItemRecord(&order.OrderItems[i])}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonWriter) writeLine(v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err = n.w.Write(raw); err != nil {
+		return err
+	}
+	return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"order/internal/models"
+)
+
+func testOrder() *models.Order {
+	order := &models.Order{
+		CustomerID:  uuid.New(),
+		Status:      "AUTHORIZED",
+		Currency:    "EUR",
+		TotalAmount: 30,
+		Customer:    models.CustomerSnapshot{Name: "=HYPERLINK(\"x\")", Email: "ann@example.com"},
+		OrderItems: []models.OrderItem{
+			{ProductID: uuid.New(), Quantity: 2, UnitPrice: 10},
+			{ProductID: uuid.New(), Quantity: 1, UnitPrice: 10},
+		},
+	}
+	order.ID = uuid.New()
+	return order
+}
+
+func TestNewOrderWriterRejectsUnknownFormatsAndLayouts(t *testing.T) {
+	if _, err := NewOrderWriter(nil, "xlsx", ""); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("format xlsx err = %v, want %v", err, ErrUnknownFormat)
+	}
+	if _, err := NewOrderWriter(nil, FormatCSV, "grouped"); !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("layout grouped err = %v, want %v", err, ErrUnknownLayout)
+	}
+}
+
+func TestCSVWriterNestsOrFlattensTheItems(t *testing.T) {
+	for _, tc := range []struct {
+		items      string
+		rows       int
+		lastColumn string
+	}{
+		{items: ItemsNested, rows: 1, lastColumn: "items"},
+		{items: ItemsFlattened, rows: 2, lastColumn: "item_tax_amount"},
+	} {
+		t.Run(tc.items, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewOrderWriter(&buf, FormatCSV, tc.items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = w.Write(testOrder()); err != nil {
+				t.Fatal(err)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tc.rows+1 {
+				t.Fatalf("%d records, want a header and %d rows", len(records), tc.rows)
+			}
+			header := records[0]
+			if header[len(header)-1] != tc.lastColumn {
+				t.Errorf("last column = %q, want %q", header[len(header)-1], tc.lastColumn)
+			}
+			// the customer name would run as a formula in a spreadsheet
+			if name := records[1][4]; !strings.HasPrefix(name, "'=") {
+				t.Errorf("customer name = %q, want it escaped", name)
+			}
+		})
+	}
+}
+
+func TestCSVWriterWritesTheHeaderOfAnEmptyExport(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewOrderWriter(&buf, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(append(orderColumns, "items"), ",") + "\n"; buf.String() != want {
+		t.Errorf("empty export = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNDJSONWriterWritesALinePerOrderOrItem(t *testing.T) {
+	for _, tc := range []struct {
+		items string
+		lines int
+	}{
+		{items: ItemsNested, lines: 1},
+		{items: ItemsFlattened, lines: 2},
+	} {
+		t.Run(tc.items, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewOrderWriter(&buf, FormatNDJSON, tc.items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			order := testOrder()
+			if err = w.Write(order); err != nil {
+				t.Fatal(err)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != tc.lines {
+				t.Fatalf("%d lines, want %d", len(lines), tc.lines)
+			}
+			var record struct {
+				OrderID  uuid.UUID         `json:"order_id"`
+				Items    []json.RawMessage `json:"items"`
+				Quantity int               `json:"quantity"`
+			}
+			if err = json.Unmarshal([]byte(lines[0]), &record); err != nil {
+				t.Fatal(err)
+			}
+			if record.OrderID != order.ID {
+				t.Errorf("order_id = %s, want %s", record.OrderID, order.ID)
+			}
+			if tc.items == ItemsNested && len(record.Items) != 2 {
+				t.Errorf("%d nested items, want 2", len(record.Items))
+			}
+			if tc.items == ItemsFlattened && record.Quantity != 2 {
+				t.Errorf("quantity of the first item = %d, want 2", record.Quantity)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	repo "order/internal/repositories"
+	"strings"
+	"time"
+)
+
+var ErrUnsupportedCurrency = errors.New("no exchange rate for currency")
+
+// Converter resolves the rate of a currency into the base currency from the fx_rates table
+type Converter struct {
+	rateRepo repo.FxRateRepoInterface
+	base     func(ctx context.Context) string
+}
+
+// NewConverter builds a converter; base returns the base currency of the tenant of the context
+func NewConverter(rateRepo repo.FxRateRepoInterface, base func(ctx context.Context) string) *Converter {
+	return &Converter{rateRepo: rateRepo, base: base}
+}
+
+// Base is the currency amounts are normalized to
+func (c *Converter) Base(ctx context.Context) string {
+	return strings.ToUpper(c.base(ctx))
+}
+
+// Rate returns the value of one unit of currency in the base currency at the given time
+func (c *Converter) Rate(ctx context.Context, currency string, at time.Time) (float64, error) {
+	currency = strings.ToUpper(currency)
+	if currency == c.Base(ctx) {
+		return 1, nil
+	}
+
+	rate, err := c.rateRepo.FindRate(ctx, currency, at)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rate.Rate, nil
+}
@@ -0,0 +1,106 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+)
+
+// fakeRates serves the latest rate of a currency effective at the asked time
+type fakeRates struct {
+	repo.FxRateRepoInterface
+	rates   []models.FxRate
+	err     error
+	lookups int
+}
+
+func (f *fakeRates) FindRate(ctx context.Context, currency string, at time.Time) (*models.FxRate, error) {
+	f.lookups++
+	if f.err != nil {
+		return nil, f.err
+	}
+	var found *models.FxRate
+	for i, rate := range f.rates {
+		if rate.Currency == currency && !rate.EffectiveAt.After(at) && (found == nil || rate.EffectiveAt.After(found.EffectiveAt)) {
+			found = &f.rates[i]
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return found, nil
+}
+
+func TestConverterRate(t *testing.T) {
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rates := []models.FxRate{
+		{Currency: "EUR", Rate: 1.08, EffectiveAt: january},
+		{Currency: "EUR", Rate: 1.1, EffectiveAt: march},
+		{Currency: "JPY", Rate: 0.0067, EffectiveAt: january},
+	}
+
+	tests := []struct {
+		name        string
+		currency    string
+		at          time.Time
+		want        float64
+		wantErr     error
+		wantLookups int
+	}{
+		{name: "base currency", currency: "USD", at: march, want: 1},
+		{name: "base currency in lower case", currency: "usd", at: march, want: 1},
+		{name: "latest rate", currency: "EUR", at: march.AddDate(0, 1, 0), want: 1.1, wantLookups: 1},
+		{name: "rate in effect at the time", currency: "eur", at: march.Add(-time.Second), want: 1.08, wantLookups: 1},
+		{name: "small rate is kept as is", currency: "JPY", at: march, want: 0.0067, wantLookups: 1},
+		{name: "before the first rate", currency: "EUR", at: january.Add(-time.Second), wantErr: ErrUnsupportedCurrency, wantLookups: 1},
+		{name: "currency without rates", currency: "GBP", at: march, wantErr: ErrUnsupportedCurrency, wantLookups: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rateRepo := &fakeRates{rates: rates}
+			converter := NewConverter(rateRepo, func(context.Context) string { return "usd" })
+
+			got, err := converter.Rate(context.Background(), tt.currency, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Rate() = %v, want %v", got, tt.want)
+			}
+			if rateRepo.lookups != tt.wantLookups {
+				t.Errorf("%d rate lookups, want %d", rateRepo.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestConverterRateFailsOnLookupError(t *testing.T) {
+	broken := errors.New("connection reset")
+	converter := NewConverter(&fakeRates{err: broken}, func(context.Context) string { return "USD" })
+
+	_, err := converter.Rate(context.Background(), "EUR", time.Now())
+	if !errors.Is(err, broken) || errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Rate() error = %v, want %v", err, broken)
+	}
+}
+
+func TestConverterBaseFollowsTheTenant(t *testing.T) {
+	type baseKey struct{}
+	converter := NewConverter(&fakeRates{}, func(ctx context.Context) string {
+		base, _ := ctx.Value(baseKey{}).(string)
+		return base
+	})
+
+	if got := converter.Base(context.WithValue(context.Background(), baseKey{}, "eur")); got != "EUR" {
+		t.Errorf("Base() = %q, want EUR", got)
+	}
+	if got := converter.Base(context.WithValue(context.Background(), baseKey{}, "JPY")); got != "JPY" {
+		t.Errorf("Base() = %q, want JPY", got)
+	}
+}
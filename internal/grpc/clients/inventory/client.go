@@ -0,0 +1,13 @@
+package inventoryclient
+
+import (
+	"context"
+	pbInventory "order/pkg/proto/inventorypb"
+)
+
+type InventoryClient interface {
+	Reserve(ctx context.Context, req *pbInventory.ReserveRequest) (*pbInventory.ReserveResponse, error)
+	Confirm(ctx context.Context, req *pbInventory.ConfirmRequest) (*pbInventory.ConfirmResponse, error)
+	Release(ctx context.Context, req *pbInventory.ReleaseRequest) (*pbInventory.ReleaseResponse, error)
+	Restock(ctx context.Context, req *pbInventory.RestockRequest) (*pbInventory.RestockResponse, error)
+}
@@ -0,0 +1,154 @@
+package inventoryclient
+
+import (
+	"context"
+	pbInventory "order/pkg/proto/inventorypb"
+	"sync"
+	"time"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusReleased  = "RELEASED"
+	StatusRestocked = "RESTOCKED"
+)
+
+type fakeReservation struct {
+	lines     map[string]int32
+	expiresAt time.Time
+	confirmed bool
+}
+
+// FakeInventoryClient is an in-memory InventoryClient for tests and local runs without an
+// inventory service. Products missing from the stock map have unlimited stock.
+type FakeInventoryClient struct {
+	mu           sync.Mutex
+	stock        map[string]int32
+	reservations map[string]*fakeReservation
+	restocked    map[string]bool
+	nowFunc      func() time.Time
+}
+
+func NewFakeInventoryClient(stock map[string]int32) *FakeInventoryClient {
+	if stock == nil {
+		stock = map[string]int32{}
+	}
+	return &FakeInventoryClient{
+		stock:        stock,
+		reservations: map[string]*fakeReservation{},
+		restocked:    map[string]bool{},
+		nowFunc:      time.Now,
+	}
+}
+
+// SetStock sets the on-hand quantity of a product
+func (c *FakeInventoryClient) SetStock(productID string, quantity int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stock[productID] = quantity
+}
+
+func (c *FakeInventoryClient) Reserve(_ context.Context, req *pbInventory.ReserveRequest) (*pbInventory.ReserveResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expire()
+
+	// a new request replaces the previous reservation, so its quantities are available again
+	previous := c.reservations[req.GetReservationId()]
+	delete(c.reservations, req.GetReservationId())
+
+	resp := &pbInventory.ReserveResponse{ReservationId: req.GetReservationId(), Reserved: true}
+	requested := map[string]int32{}
+	for _, line := range req.GetLines() {
+		requested[line.GetProductId()] += line.GetQuantity()
+	}
+	for _, line := range req.GetLines() {
+		result := &pbInventory.LineResult{ProductId: line.GetProductId(), Requested: line.GetQuantity(), Reserved: true}
+		if onHand, tracked := c.stock[line.GetProductId()]; tracked {
+			available := onHand - c.reserved(line.GetProductId())
+			result.Available = available
+			if requested[line.GetProductId()] > available {
+				result.Reserved = false
+				result.Reason = "insufficient stock"
+				resp.Reserved = false
+			}
+		}
+		resp.Lines = append(resp.Lines, result)
+	}
+
+	if !resp.Reserved {
+		if previous != nil {
+			c.reservations[req.GetReservationId()] = previous
+		}
+		return resp, nil
+	}
+
+	c.reservations[req.GetReservationId()] = &fakeReservation{
+		lines:     requested,
+		expiresAt: c.nowFunc().Add(time.Duration(req.GetTtlSeconds()) * time.Second),
+	}
+	return resp, nil
+}
+
+func (c *FakeInventoryClient) Confirm(_ context.Context, req *pbInventory.ConfirmRequest) (*pbInventory.ConfirmResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.reservations[req.GetReservationId()]; ok && !r.confirmed {
+		// confirmed stock leaves the warehouse
+		for productID, quantity := range r.lines {
+			if onHand, tracked := c.stock[productID]; tracked {
+				c.stock[productID] = onHand - quantity
+			}
+		}
+		r.confirmed = true
+	}
+	return &pbInventory.ConfirmResponse{Status: StatusConfirmed}, nil
+}
+
+func (c *FakeInventoryClient) Release(_ context.Context, req *pbInventory.ReleaseRequest) (*pbInventory.ReleaseResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.reservations[req.GetReservationId()]; ok && !r.confirmed {
+		delete(c.reservations, req.GetReservationId())
+	}
+	return &pbInventory.ReleaseResponse{Status: StatusReleased}, nil
+}
+
+func (c *FakeInventoryClient) Restock(_ context.Context, req *pbInventory.RestockRequest) (*pbInventory.RestockResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// a redelivered event must not restock twice
+	if !c.restocked[req.GetEventId()] {
+		for _, line := range req.GetLines() {
+			if onHand, tracked := c.stock[line.GetProductId()]; tracked {
+				c.stock[line.GetProductId()] = onHand + line.GetQuantity()
+			}
+		}
+		c.restocked[req.GetEventId()] = true
+	}
+	return &pbInventory.RestockResponse{Status: StatusRestocked}, nil
+}
+
+// reserved sums the unconfirmed reservations of a product
+func (c *FakeInventoryClient) reserved(productID string) int32 {
+	var total int32
+	for _, r := range c.reservations {
+		if !r.confirmed {
+			total += r.lines[productID]
+		}
+	}
+	return total
+}
+
+func (c *FakeInventoryClient) expire() {
+	now := c.nowFunc()
+	for id, r := range c.reservations {
+		if !r.confirmed && now.After(r.expiresAt) {
+			delete(c.reservations, id)
+		}
+	}
+}
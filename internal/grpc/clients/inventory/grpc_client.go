@@ -0,0 +1,41 @@
+package inventoryclient
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	pbInventory "order/pkg/proto/inventorypb"
+)
+
+type InventoryGRPCClient struct {
+	client pbInventory.InventoryServiceClient
+}
+
+func NewInventoryGRPCClient(conn *grpc.ClientConn) *InventoryGRPCClient {
+	return &InventoryGRPCClient{client: pbInventory.NewInventoryServiceClient(conn)}
+}
+
+func (c *InventoryGRPCClient) Reserve(ctx context.Context, req *pbInventory.ReserveRequest) (*pbInventory.ReserveResponse, error) {
+	return c.client.Reserve(withTrace(ctx), req)
+}
+
+func (c *InventoryGRPCClient) Confirm(ctx context.Context, req *pbInventory.ConfirmRequest) (*pbInventory.ConfirmResponse, error) {
+	return c.client.Confirm(withTrace(ctx), req)
+}
+
+func (c *InventoryGRPCClient) Release(ctx context.Context, req *pbInventory.ReleaseRequest) (*pbInventory.ReleaseResponse, error) {
+	return c.client.Release(withTrace(ctx), req)
+}
+
+func (c *InventoryGRPCClient) Restock(ctx context.Context, req *pbInventory.RestockRequest) (*pbInventory.RestockResponse, error) {
+	return c.client.Restock(withTrace(ctx), req)
+}
+
+// withTrace injects the trace headers into the outgoing metadata
+func withTrace(ctx context.Context) context.Context {
+	headers := map[string]string{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	return metadata.NewOutgoingContext(ctx, metadata.New(headers))
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"order/internal/fx"
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/core/jwt"
+	pbOrder "order/pkg/proto"
+	"strings"
+)
+
+type CartHandler struct {
+	pbOrder.UnimplementedCartServiceServer
+	service services.CartServiceInterface
+}
+
+func NewCartHandler(s services.CartServiceInterface) *CartHandler {
+	return &CartHandler{service: s}
+}
+
+func (h *CartHandler) CreateCart(ctx context.Context, req *pbOrder.CreateCartRequest) (*pbOrder.Cart, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.CreateCart",
+		trace.WithAttributes(attribute.String("grpc.method", "CreateCart")))
+	defer span.End()
+
+	// a customer cart is opened for the caller only; without a token the cart is a guest cart
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetCustomerId() != "" {
+		if caller == nil {
+			return nil, status.Error(codes.Unauthenticated, "customer carts need a customer token")
+		}
+		if req.GetCustomerId() != caller.String() {
+			return nil, status.Error(codes.PermissionDenied, "customer id does not match the token")
+		}
+	}
+
+	if req.GetCurrency() != "" && len(req.GetCurrency()) != 3 {
+		return nil, status.Error(codes.InvalidArgument, "currency must be an ISO 4217 code")
+	}
+
+	cart, err := h.service.CreateCart(ctx, caller, req.GetCurrency())
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return h.toCart(ctx, cart)
+}
+
+func (h *CartHandler) GetCart(ctx context.Context, req *pbOrder.GetCartRequest) (*pbOrder.Cart, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.GetCart",
+		trace.WithAttributes(attribute.String("grpc.method", "GetCart")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := h.service.GetCart(ctx, cartID, caller)
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return h.toCart(ctx, cart)
+}
+
+func (h *CartHandler) AddCartItem(ctx context.Context, req *pbOrder.AddCartItemRequest) (*pbOrder.Cart, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.AddCartItem",
+		trace.WithAttributes(attribute.String("grpc.method", "AddCartItem")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+	if req.GetItem() == nil {
+		return nil, status.Error(codes.InvalidArgument, "item is required")
+	}
+	productID, err := uuid.Parse(req.GetItem().GetProductId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product id: %v", err)
+	}
+	if req.GetItem().GetQuantity() <= 0 || req.GetItem().GetPrice() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity and price must be positive")
+	}
+
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := h.service.AddItem(ctx, cartID, caller, models.CreateOrderItemRequest{
+		ProductID:   productID,
+		Quantity:    int(req.GetItem().GetQuantity()),
+		UniquePrice: req.GetItem().GetPrice(),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return h.toCart(ctx, cart)
+}
+
+func (h *CartHandler) RemoveCartItem(ctx context.Context, req *pbOrder.RemoveCartItemRequest) (*pbOrder.Cart, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.RemoveCartItem",
+		trace.WithAttributes(attribute.String("grpc.method", "RemoveCartItem")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+	productID, err := uuid.Parse(req.GetProductId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product id: %v", err)
+	}
+
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := h.service.RemoveItem(ctx, cartID, caller, productID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return h.toCart(ctx, cart)
+}
+
+func (h *CartHandler) ApplyCoupon(ctx context.Context, req *pbOrder.ApplyCouponRequest) (*pbOrder.Cart, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.ApplyCoupon",
+		trace.WithAttributes(attribute.String("grpc.method", "ApplyCoupon")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := h.service.ApplyCoupon(ctx, cartID, caller, req.GetCode())
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return h.toCart(ctx, cart)
+}
+
+func (h *CartHandler) SetCartShipping(ctx context.Context, req *pbOrder.SetCartShippingRequest) (*pbOrder.Cart, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.SetCartShipping",
+		trace.WithAttributes(attribute.String("grpc.method", "SetCartShipping")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := h.service.SetShipping(ctx, cartID, caller, toAddress(req.GetShippingAddress()), req.GetCountry(), req.GetRegion())
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return h.toCart(ctx, cart)
+}
+
+func (h *CartHandler) PreviewCart(ctx context.Context, req *pbOrder.PreviewCartRequest) (*pbOrder.CartPricing, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.PreviewCart",
+		trace.WithAttributes(attribute.String("grpc.method", "PreviewCart")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := h.service.GetCart(ctx, cartID, caller)
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	pricing, err := h.service.Preview(ctx, cart)
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return toCartPricing(pricing), nil
+}
+
+func (h *CartHandler) CheckoutCart(ctx context.Context, req *pbOrder.CheckoutCartRequest) (*pbOrder.CheckoutCartResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.CheckoutCart",
+		trace.WithAttributes(attribute.String("grpc.method", "CheckoutCart")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.service.Checkout(ctx, cartID, caller, models.CartCheckout{
+		Customer: models.CustomerSnapshot{
+			Name:  req.GetCustomer().GetName(),
+			Email: req.GetCustomer().GetEmail(),
+			Phone: req.GetCustomer().GetPhone(),
+		},
+		Billing: toAddress(req.GetBillingAddress()),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+
+	return &pbOrder.CheckoutCartResponse{
+		CartId:      cartID.String(),
+		OrderId:     resp.Data.OrderID.String(),
+		TotalAmount: resp.Data.TotalAmount,
+		Status:      resp.Data.Status,
+	}, nil
+}
+
+func (h *CartHandler) MergeCart(ctx context.Context, req *pbOrder.MergeCartRequest) (*pbOrder.Cart, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "CartHandler.MergeCart",
+		trace.WithAttributes(attribute.String("grpc.method", "MergeCart")))
+	defer span.End()
+
+	cartID, err := uuid.Parse(req.GetCartId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart id: %v", err)
+	}
+	// the guest cart is merged into the cart of the caller, never of another customer
+	caller, err := callerCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller == nil {
+		return nil, status.Error(codes.Unauthenticated, "merging a cart needs a customer token")
+	}
+	if req.GetCustomerId() != "" && req.GetCustomerId() != caller.String() {
+		return nil, status.Error(codes.PermissionDenied, "customer id does not match the token")
+	}
+
+	cart, err := h.service.MergeGuestCart(ctx, cartID, *caller)
+	if err != nil {
+		span.RecordError(err)
+		return nil, cartError(err)
+	}
+	return h.toCart(ctx, cart)
+}
+
+// cartError maps cart failures onto grpc status codes
+func cartError(err error) error {
+	if stockErr := stockStatus(err); stockErr != nil {
+		return stockErr
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "cart not found")
+	case errors.Is(err, services.ErrCartItemNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrCartNotOwned):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, services.ErrCouponInvalid),
+		errors.Is(err, services.ErrInvalidAddress),
+		errors.Is(err, services.ErrInvalidContact),
+		errors.Is(err, fx.ErrUnsupportedCurrency):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrCartNotActive),
+		errors.Is(err, services.ErrCartEmpty),
+		errors.Is(err, services.ErrCartNoCustomer),
+		errors.Is(err, services.ErrCartNotGuest),
+		errors.Is(err, services.ErrCartCurrency),
+		errors.Is(err, services.ErrCouponNotApplicable),
+		errors.Is(err, services.ErrCouponExhausted):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "cart operation failed: %v", err)
+	}
+}
+
+// callerCustomer returns the customer of the bearer token of the call, or nil for guests who send none
+func callerCustomer(ctx context.Context) (*uuid.UUID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header")
+	}
+	customerID, err := jwt.CustomerFromToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid customer token")
+	}
+	return &customerID, nil
+}
+
+func (h *CartHandler) toCart(ctx context.Context, cart *models.Cart) (*pbOrder.Cart, error) {
+	pricing, err := h.service.Preview(ctx, cart)
+	if err != nil {
+		return nil, cartError(err)
+	}
+
+	resp := &pbOrder.Cart{
+		CartId:     cart.ID.String(),
+		Status:     string(cart.Status),
+		CouponCode: cart.CouponCode,
+		Currency:   cart.Currency,
+		Pricing:    toCartPricing(pricing),
+		ExpiresAt:  timestamppb.New(cart.ExpiresAt),
+		Country:    cart.TaxCountry,
+		Region:     cart.TaxRegion,
+	}
+	if cart.CustomerID != nil {
+		resp.CustomerId = cart.CustomerID.String()
+	}
+	if !cart.ShippingAddress.IsZero() {
+		resp.ShippingAddress = fromAddress(cart.ShippingAddress)
+	}
+	for _, item := range cart.Items {
+		resp.Items = append(resp.Items, &pbOrder.CartItem{
+			ProductId: item.ProductID.String(),
+			Quantity:  int32(item.Quantity),
+			Price:     item.UnitPrice,
+		})
+	}
+	return resp, nil
+}
+
+func toCartPricing(pricing *models.CartPricing) *pbOrder.CartPricing {
+	return &pbOrder.CartPricing{
+		Subtotal:   pricing.Subtotal,
+		Discount:   pricing.Discount,
+		Total:      pricing.Total,
+		CouponCode: pricing.CouponCode,
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"order/internal/invoicing"
+	"order/internal/models"
+	"order/internal/services"
+	pbOrder "order/pkg/proto"
+)
+
+// ContentDispositionHeader names the downloaded file of invoice documents
+const ContentDispositionHeader = "content-disposition"
+
+type InvoiceHandler struct {
+	pbOrder.UnimplementedInvoiceServiceServer
+	service services.InvoiceServiceInterface
+	seller  func(ctx context.Context) invoicing.Seller
+}
+
+// NewInvoiceHandler builds the invoice handler; seller returns the seller printed on the documents
+// of the tenant of the context
+func NewInvoiceHandler(s services.InvoiceServiceInterface, seller func(ctx context.Context) invoicing.Seller) *InvoiceHandler {
+	return &InvoiceHandler{service: s, seller: seller}
+}
+
+func (h *InvoiceHandler) GetOrderInvoice(ctx context.Context, req *pbOrder.GetOrderInvoiceRequest) (*httpbody.HttpBody, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "InvoiceHandler.GetOrderInvoice",
+		trace.WithAttributes(attribute.String("grpc.method", "GetOrderInvoice"),
+			attribute.String("format", req.GetFormat())))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+
+	invoice, err := h.service.GetOrderInvoice(ctx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, invoiceError(err)
+	}
+	return h.render(ctx, req.GetFormat(), invoice)
+}
+
+func (h *InvoiceHandler) GetInvoice(ctx context.Context, req *pbOrder.GetInvoiceRequest) (*httpbody.HttpBody, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "InvoiceHandler.GetInvoice",
+		trace.WithAttributes(attribute.String("grpc.method", "GetInvoice"),
+			attribute.String("format", req.GetFormat())))
+	defer span.End()
+
+	invoiceID, err := uuid.Parse(req.GetInvoiceId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid invoice id: %v", err)
+	}
+
+	invoice, err := h.service.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, invoiceError(err)
+	}
+	return h.render(ctx, req.GetFormat(), invoice)
+}
+
+func (h *InvoiceHandler) ListOrderInvoices(ctx context.Context, req *pbOrder.ListOrderInvoicesRequest) (*pbOrder.ListOrderInvoicesResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "InvoiceHandler.ListOrderInvoices",
+		trace.WithAttributes(attribute.String("grpc.method", "ListOrderInvoices")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+
+	invoices, err := h.service.ListOrderInvoices(ctx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, invoiceError(err)
+	}
+
+	resp := &pbOrder.ListOrderInvoicesResponse{}
+	for i := range invoices {
+		resp.Invoices = append(resp.Invoices, toInvoice(&invoices[i]))
+	}
+	return resp, nil
+}
+
+// render renders invoice and names the file for gateway downloads
+func (h *InvoiceHandler) render(ctx context.Context, format string, invoice *models.Invoice) (*httpbody.HttpBody, error) {
+	doc, err := invoicing.Render(format, invoice, h.seller(ctx))
+	if err != nil {
+		return nil, invoiceError(err)
+	}
+
+	disposition := fmt.Sprintf("attachment; filename=%q", doc.FileName)
+	if err = grpc.SetHeader(ctx, metadata.Pairs(ContentDispositionHeader, disposition)); err != nil {
+		return nil, status.Errorf(codes.Internal, "set header: %v", err)
+	}
+	return &httpbody.HttpBody{ContentType: doc.ContentType, Data: doc.Data}, nil
+}
+
+// invoiceError maps invoice failures onto grpc status codes
+func invoiceError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "invoice not found")
+	case errors.Is(err, invoicing.ErrUnknownFormat):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "invoice operation failed: %v", err)
+	}
+}
+
+func toInvoice(invoice *models.Invoice) *pbOrder.Invoice {
+	return &pbOrder.Invoice{
+		InvoiceId:      invoice.ID.String(),
+		OrderId:        invoice.OrderID.String(),
+		Kind:           string(invoice.Kind),
+		Number:         invoice.Number,
+		IssuedAt:       timestamppb.New(invoice.IssuedAt),
+		Currency:       invoice.Currency,
+		Subtotal:       invoice.Subtotal,
+		DiscountAmount: invoice.DiscountAmount,
+		TaxAmount:      invoice.TaxAmount,
+		TotalAmount:    invoice.TotalAmount,
+		CreditedNumber: invoice.CreditedNumber,
+	}
+}
@@ -2,15 +2,23 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"order/internal/fx"
 	"order/internal/models"
 	"order/internal/services"
 	pbOrder "order/pkg/proto"
+	"strings"
 )
 
 type OrderHandler struct {
@@ -48,7 +56,11 @@ func (h *OrderHandler) CreateOrder(ctx context.Context, req *pbOrder.CreateOrder
 	for _, v := range req.OrderItems {
 		orderItems.UniquePrice = v.Price
 		orderItems.ProductID, err = uuid.Parse(v.ProductId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product id: %v", err)
+		}
 		orderItems.Quantity = int(v.Quantity)
+		orderItems.TaxClass = v.TaxClass
 		listOrderItems = append(listOrderItems, orderItems)
 	}
 
@@ -57,11 +69,35 @@ func (h *OrderHandler) CreateOrder(ctx context.Context, req *pbOrder.CreateOrder
 		TotalAmount: req.TotalAmount,
 		Status:      req.Status,
 		OrderItems:  listOrderItems,
+		Country:     strings.ToUpper(req.GetCountry()),
+		Region:      req.GetRegion(),
+		Currency:    req.GetCurrency(),
+		Customer: models.CustomerSnapshot{
+			Name:  req.GetCustomer().GetName(),
+			Email: req.GetCustomer().GetEmail(),
+			Phone: req.GetCustomer().GetPhone(),
+		},
+		Shipping: toAddress(req.GetShippingAddress()),
+		Billing:  toAddress(req.GetBillingAddress()),
+		Audit: models.StatusChange{
+			ActorType: models.ActorTypeUser,
+			ActorID:   customerID.String(),
+			Source:    models.ChangeSourceGRPC,
+			SourceRef: grpcMethod(ctx),
+			Reason:    "order created",
+		},
 	}
 
 	createOrderResp, err := h.service.CreateOrder(ctx, servicesRequest)
 	if err != nil {
 		span.RecordError(err)
+		if stockErr := stockStatus(err); stockErr != nil {
+			return nil, stockErr
+		}
+		if errors.Is(err, services.ErrInvalidAddress) || errors.Is(err, services.ErrInvalidContact) ||
+			errors.Is(err, fx.ErrUnsupportedCurrency) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "create order failed: %v", err)
 	}
 
@@ -70,7 +106,240 @@ func (h *OrderHandler) CreateOrder(ctx context.Context, req *pbOrder.CreateOrder
 		CustomerId:  createOrderResp.Data.CustomerID.String(),
 		TotalAmount: createOrderResp.Data.TotalAmount,
 		Status:      createOrderResp.Data.Status,
+		TaxAmount:   createOrderResp.Data.TaxAmount,
+		Currency:    createOrderResp.Data.Currency,
+		FxRate:      createOrderResp.Data.FxRate,
+	}
+	for _, line := range createOrderResp.Data.TaxLines {
+		grpcResponse.TaxLines = append(grpcResponse.TaxLines, &pbOrder.TaxLine{
+			ProductId: line.ProductID.String(),
+			TaxClass:  line.TaxClass,
+			Rate:      line.Rate,
+			Amount:    line.Amount,
+		})
 	}
 
 	return grpcResponse, nil
 }
+
+func (h *OrderHandler) GetOrderHistory(ctx context.Context, req *pbOrder.GetOrderHistoryRequest) (*pbOrder.GetOrderHistoryResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "OrderHandler.GetOrderHistory",
+		trace.WithAttributes(attribute.String("grpc.method", "GetOrderHistory")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+
+	history, err := h.service.GetOrderHistory(ctx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "order not found")
+		}
+		return nil, status.Errorf(codes.Internal, "get order history failed: %v", err)
+	}
+
+	resp := &pbOrder.GetOrderHistoryResponse{OrderId: orderID.String()}
+	for _, h := range history {
+		resp.History = append(resp.History, &pbOrder.OrderStatusChange{
+			Id:         h.ID.String(),
+			FromStatus: h.FromStatus,
+			ToStatus:   h.ToStatus,
+			ActorType:  string(h.ActorType),
+			ActorId:    h.ActorID,
+			Source:     string(h.Source),
+			SourceRef:  h.SourceRef,
+			Reason:     h.Reason,
+			ChangedAt:  timestamppb.New(h.ChangedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *OrderHandler) AddOrderItem(ctx context.Context, req *pbOrder.AddOrderItemRequest) (*pbOrder.ModifyOrderResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "OrderHandler.AddOrderItem",
+		trace.WithAttributes(attribute.String("grpc.method", "AddOrderItem")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+	if req.GetItem() == nil {
+		return nil, status.Error(codes.InvalidArgument, "item is required")
+	}
+	productID, err := uuid.Parse(req.GetItem().GetProductId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product id: %v", err)
+	}
+	if req.GetItem().GetQuantity() <= 0 || req.GetItem().GetPrice() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity and price must be positive")
+	}
+
+	order, err := h.service.AddOrderItem(ctx, orderID, models.CreateOrderItemRequest{
+		ProductID:   productID,
+		Quantity:    int(req.GetItem().GetQuantity()),
+		UniquePrice: req.GetItem().GetPrice(),
+		TaxClass:    req.GetItem().GetTaxClass(),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, modifyOrderError(err)
+	}
+	return toModifyOrderResponse(order), nil
+}
+
+func (h *OrderHandler) UpdateOrderItem(ctx context.Context, req *pbOrder.UpdateOrderItemRequest) (*pbOrder.ModifyOrderResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "OrderHandler.UpdateOrderItem",
+		trace.WithAttributes(attribute.String("grpc.method", "UpdateOrderItem")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+	itemID, err := uuid.Parse(req.GetItemId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid item id: %v", err)
+	}
+	if req.GetQuantity() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must be positive; remove the item instead")
+	}
+
+	order, err := h.service.UpdateOrderItem(ctx, orderID, itemID, int(req.GetQuantity()))
+	if err != nil {
+		span.RecordError(err)
+		return nil, modifyOrderError(err)
+	}
+	return toModifyOrderResponse(order), nil
+}
+
+func (h *OrderHandler) RemoveOrderItem(ctx context.Context, req *pbOrder.RemoveOrderItemRequest) (*pbOrder.ModifyOrderResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "OrderHandler.RemoveOrderItem",
+		trace.WithAttributes(attribute.String("grpc.method", "RemoveOrderItem")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+	itemID, err := uuid.Parse(req.GetItemId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid item id: %v", err)
+	}
+
+	order, err := h.service.RemoveOrderItem(ctx, orderID, itemID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, modifyOrderError(err)
+	}
+	return toModifyOrderResponse(order), nil
+}
+
+// modifyOrderError maps order modification failures onto grpc status codes
+func modifyOrderError(err error) error {
+	if stockErr := stockStatus(err); stockErr != nil {
+		return stockErr
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "order not found")
+	case errors.Is(err, services.ErrOrderItemNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrOrderNotModifiable), errors.Is(err, services.ErrOrderLastItem):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "modify order failed: %v", err)
+	}
+}
+
+func toAddress(address *pbOrder.Address) models.Address {
+	return models.Address{
+		Name:       address.GetName(),
+		Line1:      address.GetLine1(),
+		Line2:      address.GetLine2(),
+		City:       address.GetCity(),
+		Region:     address.GetRegion(),
+		PostalCode: address.GetPostalCode(),
+		Country:    address.GetCountry(),
+	}
+}
+
+func fromAddress(address models.Address) *pbOrder.Address {
+	return &pbOrder.Address{
+		Name:       address.Name,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+func toModifyOrderResponse(order *models.Order) *pbOrder.ModifyOrderResponse {
+	resp := &pbOrder.ModifyOrderResponse{
+		OrderId:     order.ID.String(),
+		CustomerId:  order.CustomerID.String(),
+		TotalAmount: order.TotalAmount,
+		Status:      order.Status,
+		TaxAmount:   order.TaxAmount,
+		Currency:    order.Currency,
+	}
+	if order.PromotionConfigID != nil {
+		resp.PromotionConfigId = order.PromotionConfigID.String()
+	}
+	for _, item := range order.OrderItems {
+		resp.Items = append(resp.Items, &pbOrder.OrderLine{
+			Id:        item.ID.String(),
+			ProductId: item.ProductID.String(),
+			Quantity:  int32(item.Quantity),
+			Price:     item.UnitPrice,
+			TaxClass:  item.TaxClass,
+			TaxRate:   item.TaxRate,
+			TaxAmount: item.TaxAmount,
+		})
+	}
+	return resp
+}
+
+// stockStatus turns a failed reservation into FailedPrecondition with one violation per line item.
+// It returns nil for any other error.
+func stockStatus(err error) error {
+	var stockErr *services.StockError
+	if !errors.As(err, &stockErr) {
+		return nil
+	}
+
+	failure := &errdetails.PreconditionFailure{}
+	for _, s := range stockErr.Shortages {
+		failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+			Type:        "STOCK",
+			Subject:     s.ProductID.String(),
+			Description: fmt.Sprintf("%s: requested %d, available %d", s.Reason, s.Requested, s.Available),
+		})
+	}
+
+	st := status.New(codes.FailedPrecondition, stockErr.Error())
+	if detailed, dErr := st.WithDetails(failure); dErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// grpcMethod returns the full method name of the current RPC, used as audit source reference
+func grpcMethod(ctx context.Context) string {
+	method, _ := grpc.Method(ctx)
+	return method
+}
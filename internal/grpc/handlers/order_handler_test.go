@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"order/internal/grpc/clients/inventory"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+	"order/internal/services"
+	pbOrder "order/pkg/proto"
+)
+
+func TestCreateOrderRejectsMalformedProductID(t *testing.T) {
+	// the request is rejected before the service is reached
+	h := NewOrderHandler(nil)
+
+	_, err := h.CreateOrder(context.Background(), &pbOrder.CreateOrderRequest{
+		CustomerId:  uuid.NewString(),
+		TotalAmount: 20,
+		OrderItems: []*pbOrder.OrderItem{
+			{ProductId: uuid.NewString(), Quantity: 1, Price: 10},
+			{ProductId: "not-a-product", Quantity: 1, Price: 10},
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateOrder() error = %v, want %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestGetOrderHistoryOfUnknownOrderIsNotFound(t *testing.T) {
+	pg := pgtest.Open(t)
+	oS := services.NewOrderService(repo.NewOrderRepository(pg), pg, nil, inventoryclient.NewFakeInventoryClient(nil),
+		repo.NewOutboxRepository(pg), repo.NewOrderStatusHistoryRepository(pg), repo.NewPromotionRepository(pg), time.Hour)
+
+	_, err := NewOrderHandler(oS).GetOrderHistory(pgtest.Context(), &pbOrder.GetOrderHistoryRequest{OrderId: uuid.NewString()})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetOrderHistory() error = %v, want %v", err, codes.NotFound)
+	}
+}
+
+func TestGetOrderHistoryRejectsMalformedOrderID(t *testing.T) {
+	_, err := NewOrderHandler(nil).GetOrderHistory(context.Background(), &pbOrder.GetOrderHistoryRequest{OrderId: "not-an-order"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetOrderHistory() error = %v, want %v", err, codes.InvalidArgument)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"order/internal/models"
+	"order/internal/services"
+	pbOrder "order/pkg/proto"
+)
+
+type ReturnHandler struct {
+	pbOrder.UnimplementedReturnServiceServer
+	service services.ReturnServiceInterface
+}
+
+func NewReturnHandler(s services.ReturnServiceInterface) *ReturnHandler {
+	return &ReturnHandler{service: s}
+}
+
+func (h *ReturnHandler) CreateReturn(ctx context.Context, req *pbOrder.CreateReturnRequest) (*pbOrder.ReturnRequest, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ReturnHandler.CreateReturn",
+		trace.WithAttributes(attribute.String("grpc.method", "CreateReturn")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+	itemID, err := uuid.Parse(req.GetOrderItemId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order item id: %v", err)
+	}
+
+	ret, err := h.service.CreateReturn(ctx, orderID, models.CreateReturnRequest{
+		OrderItemID: itemID,
+		Quantity:    int(req.GetQuantity()),
+		Reason:      req.GetReason(),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, returnError(err)
+	}
+	return toReturnRequest(ret), nil
+}
+
+func (h *ReturnHandler) ListReturns(ctx context.Context, req *pbOrder.ListReturnsRequest) (*pbOrder.ListReturnsResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ReturnHandler.ListReturns",
+		trace.WithAttributes(attribute.String("grpc.method", "ListReturns")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+
+	rets, err := h.service.ListReturns(ctx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, returnError(err)
+	}
+
+	resp := &pbOrder.ListReturnsResponse{}
+	for i := range rets {
+		resp.Returns = append(resp.Returns, toReturnRequest(&rets[i]))
+	}
+	return resp, nil
+}
+
+func (h *ReturnHandler) GetReturn(ctx context.Context, req *pbOrder.GetReturnRequest) (*pbOrder.ReturnRequest, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ReturnHandler.GetReturn",
+		trace.WithAttributes(attribute.String("grpc.method", "GetReturn")))
+	defer span.End()
+
+	returnID, err := uuid.Parse(req.GetReturnId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid return id: %v", err)
+	}
+
+	ret, err := h.service.GetReturn(ctx, returnID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, returnError(err)
+	}
+	return toReturnRequest(ret), nil
+}
+
+func (h *ReturnHandler) ReviewReturn(ctx context.Context, req *pbOrder.ReviewReturnRequest) (*pbOrder.ReturnRequest, error) {
+	return h.transition(ctx, "ReviewReturn", req.GetReturnId(), func(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error) {
+		return h.service.ReviewReturn(ctx, id, req.GetApproved(), req.GetNote())
+	})
+}
+
+func (h *ReturnHandler) ReceiveReturn(ctx context.Context, req *pbOrder.ReceiveReturnRequest) (*pbOrder.ReturnRequest, error) {
+	return h.transition(ctx, "ReceiveReturn", req.GetReturnId(), h.service.ReceiveReturn)
+}
+
+func (h *ReturnHandler) InspectReturn(ctx context.Context, req *pbOrder.InspectReturnRequest) (*pbOrder.ReturnRequest, error) {
+	return h.transition(ctx, "InspectReturn", req.GetReturnId(), func(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error) {
+		return h.service.InspectReturn(ctx, id, req.GetPassed(), req.GetNote())
+	})
+}
+
+func (h *ReturnHandler) CompleteReturn(ctx context.Context, req *pbOrder.CompleteReturnRequest) (*pbOrder.ReturnRequest, error) {
+	return h.transition(ctx, "CompleteReturn", req.GetReturnId(), h.service.CompleteReturn)
+}
+
+func (h *ReturnHandler) transition(
+	ctx context.Context,
+	method, rawID string,
+	apply func(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error),
+) (*pbOrder.ReturnRequest, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ReturnHandler."+method,
+		trace.WithAttributes(attribute.String("grpc.method", method)))
+	defer span.End()
+
+	returnID, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid return id: %v", err)
+	}
+
+	ret, err := apply(ctx, returnID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, returnError(err)
+	}
+	return toReturnRequest(ret), nil
+}
+
+// returnError maps return failures onto grpc status codes
+func returnError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "order or return not found")
+	case errors.Is(err, services.ErrOrderItemNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrReturnQuantity),
+		errors.Is(err, services.ErrReturnReasonRequired):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrOrderNotReturnable),
+		errors.Is(err, services.ErrReturnTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "return operation failed: %v", err)
+	}
+}
+
+func toReturnRequest(ret *models.ReturnRequest) *pbOrder.ReturnRequest {
+	return &pbOrder.ReturnRequest{
+		ReturnId:       ret.ID.String(),
+		OrderId:        ret.OrderID.String(),
+		OrderItemId:    ret.OrderItemID.String(),
+		Quantity:       int32(ret.Quantity),
+		Reason:         ret.Reason,
+		Status:         string(ret.Status),
+		ReviewNote:     ret.ReviewNote,
+		InspectionNote: ret.InspectionNote,
+		RefundAmount:   ret.RefundAmount,
+		CreatedAt:      timestamppb.New(ret.CreatedAt),
+		CompletedAt:    optionalTimestamp(ret.CompletedAt),
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"order/internal/models"
+	"order/internal/services"
+	pbOrder "order/pkg/proto"
+	"strings"
+	"time"
+)
+
+// WebhookTokenHeader carries the shared secret of shipment webhooks
+const WebhookTokenHeader = "x-webhook-token"
+
+type ShipmentHandler struct {
+	pbOrder.UnimplementedShipmentServiceServer
+	service      services.ShipmentServiceInterface
+	webhookToken string
+}
+
+// NewShipmentHandler builds the shipment handler. Webhook calls are rejected while webhookToken is empty.
+func NewShipmentHandler(s services.ShipmentServiceInterface, webhookToken string) *ShipmentHandler {
+	return &ShipmentHandler{service: s, webhookToken: webhookToken}
+}
+
+func (h *ShipmentHandler) CreateShipment(ctx context.Context, req *pbOrder.CreateShipmentRequest) (*pbOrder.Shipment, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ShipmentHandler.CreateShipment",
+		trace.WithAttributes(attribute.String("grpc.method", "CreateShipment")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+
+	createReq := models.CreateShipmentRequest{
+		Carrier:        req.GetCarrier(),
+		TrackingNumber: req.GetTrackingNumber(),
+	}
+	for _, line := range req.GetLines() {
+		itemID, err := uuid.Parse(line.GetOrderItemId())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid order item id: %v", err)
+		}
+		createReq.Items = append(createReq.Items, models.CreateShipmentItemRequest{
+			OrderItemID: itemID,
+			Quantity:    int(line.GetQuantity()),
+		})
+	}
+
+	shipment, err := h.service.CreateShipment(ctx, orderID, createReq)
+	if err != nil {
+		span.RecordError(err)
+		return nil, shipmentError(err)
+	}
+	return toShipment(shipment), nil
+}
+
+func (h *ShipmentHandler) ListShipments(ctx context.Context, req *pbOrder.ListShipmentsRequest) (*pbOrder.ListShipmentsResponse, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ShipmentHandler.ListShipments",
+		trace.WithAttributes(attribute.String("grpc.method", "ListShipments")))
+	defer span.End()
+
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order id: %v", err)
+	}
+
+	shipments, err := h.service.ListShipments(ctx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, shipmentError(err)
+	}
+
+	resp := &pbOrder.ListShipmentsResponse{}
+	for i := range shipments {
+		resp.Shipments = append(resp.Shipments, toShipment(&shipments[i]))
+	}
+	return resp, nil
+}
+
+func (h *ShipmentHandler) UpdateShipmentStatus(ctx context.Context, req *pbOrder.UpdateShipmentStatusRequest) (*pbOrder.Shipment, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ShipmentHandler.UpdateShipmentStatus",
+		trace.WithAttributes(attribute.String("grpc.method", "UpdateShipmentStatus")))
+	defer span.End()
+
+	shipmentID, err := uuid.Parse(req.GetShipmentId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid shipment id: %v", err)
+	}
+
+	shipment, err := h.service.UpdateShipmentStatus(ctx, models.ShipmentUpdate{
+		ShipmentID:     &shipmentID,
+		Carrier:        req.GetCarrier(),
+		TrackingNumber: req.GetTrackingNumber(),
+		Status:         models.ShipmentStatus(strings.ToUpper(req.GetStatus())),
+		Source:         models.ChangeSourceGRPC,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, shipmentError(err)
+	}
+	return toShipment(shipment), nil
+}
+
+func (h *ShipmentHandler) ShipmentWebhook(ctx context.Context, req *pbOrder.ShipmentWebhookRequest) (*pbOrder.Shipment, error) {
+
+	tracer := otel.Tracer("order/handler")
+	ctx, span := tracer.Start(ctx, "ShipmentHandler.ShipmentWebhook",
+		trace.WithAttributes(attribute.String("grpc.method", "ShipmentWebhook"),
+			attribute.String("carrier", req.GetCarrier())))
+	defer span.End()
+
+	if !h.authorizedWebhook(ctx) {
+		return nil, status.Error(codes.Unauthenticated, "invalid webhook token")
+	}
+
+	update := models.ShipmentUpdate{
+		Carrier:        req.GetCarrier(),
+		TrackingNumber: req.GetTrackingNumber(),
+		Status:         models.ShipmentStatus(strings.ToUpper(req.GetStatus())),
+		Source:         models.ChangeSourceHTTP,
+		SourceRef:      "webhook/" + req.GetCarrier() + "/" + req.GetTrackingNumber(),
+	}
+	if req.GetOccurredAt() != nil {
+		occurredAt := req.GetOccurredAt().AsTime()
+		update.OccurredAt = &occurredAt
+	}
+
+	shipment, err := h.service.UpdateShipmentStatus(ctx, update)
+	if err != nil {
+		span.RecordError(err)
+		return nil, shipmentError(err)
+	}
+	return toShipment(shipment), nil
+}
+
+func (h *ShipmentHandler) authorizedWebhook(ctx context.Context) bool {
+	if h.webhookToken == "" {
+		return false
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get(WebhookTokenHeader)
+	return len(tokens) == 1 && subtle.ConstantTimeCompare([]byte(tokens[0]), []byte(h.webhookToken)) == 1
+}
+
+// shipmentError maps shipment failures onto grpc status codes
+func shipmentError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "order or shipment not found")
+	case errors.Is(err, services.ErrShipmentEmpty),
+		errors.Is(err, services.ErrShipmentItemInvalid),
+		errors.Is(err, services.ErrShipmentQuantity),
+		errors.Is(err, services.ErrShipmentStatusInvalid),
+		errors.Is(err, services.ErrShipmentTrackingNeeded):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrOrderNotShippable),
+		errors.Is(err, services.ErrShipmentTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "shipment operation failed: %v", err)
+	}
+}
+
+func toShipment(shipment *models.Shipment) *pbOrder.Shipment {
+	resp := &pbOrder.Shipment{
+		ShipmentId:     shipment.ID.String(),
+		OrderId:        shipment.OrderID.String(),
+		Carrier:        shipment.Carrier,
+		TrackingNumber: shipment.TrackingNumber,
+		Status:         string(shipment.Status),
+		ShippedAt:      optionalTimestamp(shipment.ShippedAt),
+		DeliveredAt:    optionalTimestamp(shipment.DeliveredAt),
+		ReturnedAt:     optionalTimestamp(shipment.ReturnedAt),
+	}
+	for _, item := range shipment.Items {
+		resp.Lines = append(resp.Lines, &pbOrder.ShipmentLine{
+			OrderItemId: item.OrderItemID.String(),
+			Quantity:    int32(item.Quantity),
+		})
+	}
+	return resp
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -9,8 +9,11 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"order/internal/grpc/handlers"
 	"order/internal/metrics"
+	"order/pkg/core/tenant"
 	pb "order/pkg/proto"
+	"strings"
 )
 
 type GRPCServer struct {
@@ -21,12 +24,28 @@ type GRPCServer struct {
 	lis        net.Listener
 }
 
-func NewGRPCServer(handler pb.OrderServiceServer, grpcAddr, httpAddr string) *GRPCServer {
+func NewGRPCServer(
+	handler pb.OrderServiceServer,
+	cartHandler pb.CartServiceServer,
+	shipmentHandler pb.ShipmentServiceServer,
+	returnHandler pb.ReturnServiceServer,
+	invoiceHandler pb.InvoiceServiceServer,
+	tenantInterceptor grpc.UnaryServerInterceptor,
+	grpcAddr, httpAddr string,
+) *GRPCServer {
 	s := grpc.NewServer(
-		// incoming from clients to server will be measured here
-		grpc.UnaryInterceptor(metrics.UnaryServerInterceptor("order")),
+		grpc.ChainUnaryInterceptor(
+			// incoming from clients to server will be measured here
+			metrics.UnaryServerInterceptor("order"),
+			// every call is scoped to a tenant
+			tenantInterceptor,
+		),
 	)
 	pb.RegisterOrderServiceServer(s, handler)
+	pb.RegisterCartServiceServer(s, cartHandler)
+	pb.RegisterShipmentServiceServer(s, shipmentHandler)
+	pb.RegisterReturnServiceServer(s, returnHandler)
+	pb.RegisterInvoiceServiceServer(s, invoiceHandler)
 	return &GRPCServer{
 		server:   s,
 		grpcAddr: grpcAddr,
@@ -51,12 +70,31 @@ func (s *GRPCServer) Run(ctx context.Context) error {
 	}()
 
 	// setup grpc-gateway
-	gwMux := runtime.NewServeMux()
+	gwMux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+	)
 	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 	if err := pb.RegisterOrderServiceHandlerFromEndpoint(ctx, gwMux, s.grpcAddr, dialOpts); err != nil {
 		s.server.GracefulStop()
 		return err
 	}
+	if err := pb.RegisterCartServiceHandlerFromEndpoint(ctx, gwMux, s.grpcAddr, dialOpts); err != nil {
+		s.server.GracefulStop()
+		return err
+	}
+	if err := pb.RegisterShipmentServiceHandlerFromEndpoint(ctx, gwMux, s.grpcAddr, dialOpts); err != nil {
+		s.server.GracefulStop()
+		return err
+	}
+	if err := pb.RegisterReturnServiceHandlerFromEndpoint(ctx, gwMux, s.grpcAddr, dialOpts); err != nil {
+		s.server.GracefulStop()
+		return err
+	}
+	if err := pb.RegisterInvoiceServiceHandlerFromEndpoint(ctx, gwMux, s.grpcAddr, dialOpts); err != nil {
+		s.server.GracefulStop()
+		return err
+	}
 
 	// create top-level HTTP mux and mount /metrics and the gateway
 	httpMux := http.NewServeMux()
@@ -90,6 +128,25 @@ func (s *GRPCServer) Run(ctx context.Context) error {
 	}
 }
 
+// incomingHeaderMatcher forwards the webhook token and the tenant next to the gateway's default headers
+func incomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, handlers.WebhookTokenHeader) {
+		return handlers.WebhookTokenHeader, true
+	}
+	if strings.EqualFold(key, tenant.Header) {
+		return tenant.Header, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher passes the file name of invoice downloads through as a plain header
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, handlers.ContentDispositionHeader) {
+		return "Content-Disposition", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
 // Stop triggers an immediate graceful shutdown.
 func (s *GRPCServer) Stop() {
 	if s.httpServer != nil {
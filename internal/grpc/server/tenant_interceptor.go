@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"order/pkg/core/jwt"
+	"order/pkg/core/tenant"
+	"strings"
+)
+
+// TenantAuthorizer rejects tenants that are unknown or not active
+type TenantAuthorizer func(ctx context.Context, id string) error
+
+// TenantUnaryInterceptor scopes every call to the tenant named by the tenant_id claim of the bearer
+// token or by the x-tenant-id metadata, falling back to fallback when neither is sent. The
+// metadata is only trusted from platform tokens or when it matches the claim.
+func TenantUnaryInterceptor(fallback string, authorize TenantAuthorizer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		var caller tenant.Caller
+		if auth := firstValue(md, "authorization"); auth != "" {
+			token, ok := strings.CutPrefix(auth, "Bearer ")
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "invalid authorization header")
+			}
+			var err error
+			if caller, err = jwt.CallerFromToken(token); err != nil {
+				return nil, status.Error(codes.Unauthenticated, "invalid token")
+			}
+		}
+
+		id, err := tenant.Resolve(caller, firstValue(md, tenant.Header), fallback)
+		switch {
+		case errors.Is(err, tenant.ErrMissingTenant):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case err != nil:
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if err = authorize(ctx, id); err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "tenant %s: %v", id, err)
+		}
+
+		return handler(tenant.NewContext(ctx, id), req)
+	}
+}
+
+// TenantUnaryClientInterceptor sends the tenant of ctx along with outgoing calls to other services
+func TenantUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if id, ok := tenant.FromContext(ctx); ok {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			if len(md.Get(tenant.Header)) == 0 {
+				ctx = metadata.AppendToOutgoingContext(ctx, tenant.Header, id)
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
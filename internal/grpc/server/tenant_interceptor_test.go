@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"order/pkg/core/configloader"
+	"order/pkg/core/tenant"
+)
+
+func TestTenantUnaryInterceptor(t *testing.T) {
+	t.Setenv("JWT_ACCESS_SECURE", "test-secret")
+	secret := []byte(configloader.GetConfig().JWTAccessSecure)
+
+	token := func(claims jwt.MapClaims) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + signed
+	}
+	active := func(_ context.Context, id string) error {
+		if id == "suspended" {
+			return errors.New("tenant suspended")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		auth       string
+		header     string
+		fallback   string
+		wantTenant string
+		wantCode   codes.Code
+	}{
+		{name: "tenant claim", auth: token(jwt.MapClaims{"tenant_id": "acme"}), wantTenant: "acme"},
+		{name: "tenant claim matching metadata", auth: token(jwt.MapClaims{"tenant_id": "acme"}), header: "acme", wantTenant: "acme"},
+		{name: "tenant claim and other metadata", auth: token(jwt.MapClaims{"tenant_id": "acme"}), header: "globex", wantCode: codes.PermissionDenied},
+		{name: "admin without claim picks metadata", auth: token(jwt.MapClaims{"role": "admin"}), header: "globex", wantCode: codes.PermissionDenied},
+		{name: "platform token picks metadata", auth: token(jwt.MapClaims{"role": tenant.PlatformRole}), header: "globex", wantTenant: "globex"},
+		{name: "platform token picks inactive tenant", auth: token(jwt.MapClaims{"role": tenant.PlatformRole}), header: "suspended", wantCode: codes.PermissionDenied},
+		{name: "no token picks metadata", header: "globex", fallback: tenant.Default, wantCode: codes.PermissionDenied},
+		{name: "no token", fallback: tenant.Default, wantTenant: tenant.Default},
+		{name: "no token and no fallback", wantCode: codes.Unauthenticated},
+		{name: "not a bearer token", auth: "Basic abc", wantCode: codes.Unauthenticated},
+		{name: "forged token", auth: "Bearer not-a-token", header: "globex", wantCode: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tt.auth != "" {
+				md.Set("authorization", tt.auth)
+			}
+			if tt.header != "" {
+				md.Set(tenant.Header, tt.header)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			var got string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				got, _ = tenant.FromContext(ctx)
+				return nil, nil
+			}
+			_, err := TenantUnaryInterceptor(tt.fallback, active)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/order.OrderService/GetOrder"}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if got != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestTenantUnaryClientInterceptor(t *testing.T) {
+	var sent []string
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		sent = md.Get(tenant.Header)
+		return nil
+	}
+
+	ctx := tenant.NewContext(context.Background(), "acme")
+	if err := TenantUnaryClientInterceptor()(ctx, "/inventory/Reserve", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0] != "acme" {
+		t.Errorf("sent tenant %v, want [acme]", sent)
+	}
+
+	if err := TenantUnaryClientInterceptor()(context.Background(), "/inventory/Reserve", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("sent tenant %v without a tenant in context", sent)
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	stdErrors "errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	repo "order/internal/repositories"
+	"order/pkg/core/logger"
+	"order/pkg/http/paging"
+	"order/pkg/http/utils/errors"
+)
+
+type CustomerOrderHandler struct {
+	orderRepo repo.OrderRepoInterface
+}
+
+func NewCustomerOrderHandler(orderRepo repo.OrderRepoInterface) *CustomerOrderHandler {
+	return &CustomerOrderHandler{orderRepo: orderRepo}
+}
+
+// ListCustomerOrders lists the orders of the authenticated customer newest first, one cursor page at a time
+func (h *CustomerOrderHandler) ListCustomerOrders(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "CustomerOrderHandler|ListCustomerOrders")
+
+	customerID, ok := ctx.MustGet("customer_id").(uuid.UUID)
+	if !ok {
+		_ = ctx.Error(errors.Error("fail to authenticate", errors.StatusValidationError))
+		return
+	}
+
+	pager, err := paging.NewCursorPagerWithGinCtx(ctx)
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	orders, err := h.orderRepo.ListByCustomer(ctx.Request.Context(), customerID, pager)
+	if err != nil {
+		if stdErrors.Is(err, paging.ErrInvalidCursor) || stdErrors.Is(err, paging.ErrInvalidFilter) {
+			_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+			return
+		}
+		logger.LogError(log, err, "failed to list customer orders")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paging.NewBodyCursor(ctx.Request.Context(), orders, pager))
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"net/http"
+	"order/internal/export"
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/core/logger"
+	"order/pkg/http/paging"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+	"time"
+)
+
+type ExportHandler struct {
+	exportService services.ExportServiceInterface
+}
+
+func NewExportHandler(exportService services.ExportServiceInterface) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// ExportOrders streams the orders matching the filter parameters as CSV or NDJSON
+func (h *ExportHandler) ExportOrders(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "ExportHandler|ExportOrders")
+
+	req, filters, ok := bindExportRequest(ctx)
+	if !ok {
+		return
+	}
+	if err := paging.ValidateFilters(filters, models.Order{}.GetFilterableFields()); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	contentType, ext := export.ContentType(req.Format)
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="orders-%s.%s"`, time.Now().UTC().Format("20060102-150405"), ext))
+
+	w, err := export.NewOrderWriter(ctx.Writer, req.Format, req.Items)
+	if err == nil {
+		_, err = h.exportService.StreamOrders(ctx.Request.Context(), w, filters)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		logger.LogError(log, err, "failed to export orders")
+		if ctx.Writer.Written() {
+			// the status is sent; dropping the connection before the last chunk tells the client the file is incomplete
+			if conn, _, hijackErr := ctx.Writer.Hijack(); hijackErr == nil {
+				_ = conn.Close()
+			}
+			ctx.Abort()
+			return
+		}
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.Writer.Header().Del("Content-Type")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+	}
+}
+
+// StartOrderExport writes the orders matching the filter parameters to a file in the background
+func (h *ExportHandler) StartOrderExport(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "ExportHandler|StartOrderExport")
+
+	req, filters, ok := bindExportRequest(ctx)
+	if !ok {
+		return
+	}
+
+	job, err := h.exportService.StartOrderExport(ctx.Request.Context(), req, filters)
+	if err != nil {
+		if stdErrors.Is(err, paging.ErrInvalidFilter) || stdErrors.Is(err, export.ErrUnknownFormat) || stdErrors.Is(err, export.ErrUnknownLayout) {
+			_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+			return
+		}
+		logger.LogError(log, err, "failed to start order export")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.ExportJobResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: *job,
+	})
+}
+
+// GetExport returns the state of a background export, with its download link once it succeeded
+func (h *ExportHandler) GetExport(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "ExportHandler|GetExport")
+
+	jobID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	job, err := h.exportService.GetExport(ctx.Request.Context(), jobID)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			_ = ctx.Error(errors.Error(errors.StatusNotFound, errors.StatusNotFound))
+			return
+		}
+		logger.LogError(log, err, "failed to get export")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+	if job.Status == models.ExportStatusSucceeded {
+		job.DownloadURL = fmt.Sprintf("/v1/internal/exports/%s/download", job.ID)
+	}
+
+	ctx.JSON(http.StatusOK, models.ExportJobResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: *job,
+	})
+}
+
+// DownloadExport sends the file of a finished background export
+func (h *ExportHandler) DownloadExport(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "ExportHandler|DownloadExport")
+
+	jobID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	job, file, err := h.exportService.OpenExport(ctx.Request.Context(), jobID)
+	switch {
+	case stdErrors.Is(err, gorm.ErrRecordNotFound):
+		_ = ctx.Error(errors.Error(errors.StatusNotFound, errors.StatusNotFound))
+		return
+	case stdErrors.Is(err, services.ErrExportNotReady), stdErrors.Is(err, services.ErrExportExpired):
+		_ = ctx.Error(errors.Error(err.Error(), errors.StatusConflict))
+		return
+	case err != nil:
+		logger.LogError(log, err, "failed to open export")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+	defer file.Close()
+
+	size := int64(-1)
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	contentType, ext := export.ContentType(job.Format)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="orders-%s.%s"`, job.CreatedAt.UTC().Format("20060102-150405"), ext))
+	ctx.DataFromReader(http.StatusOK, size, contentType, file, nil)
+}
+
+func bindExportRequest(ctx *gin.Context) (models.ExportOrdersRequest, []paging.Condition, bool) {
+	var req models.ExportOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return req, nil, false
+	}
+	filters, err := paging.ParseFilters(ctx.Request.URL.Query())
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return req, nil, false
+	}
+	return req, filters, true
+}
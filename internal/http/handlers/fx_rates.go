@@ -0,0 +1,68 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+	"time"
+)
+
+type FxRateHandler struct {
+	rateRepo repo.FxRateRepoInterface
+}
+
+func NewFxRateHandler(rateRepo repo.FxRateRepoInterface) *FxRateHandler {
+	return &FxRateHandler{rateRepo: rateRepo}
+}
+
+// ListFxRates lists the rate history of every currency
+func (f *FxRateHandler) ListFxRates(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "FxRateHandler|ListFxRates")
+
+	rates, err := f.rateRepo.List(ctx.Request.Context())
+	if err != nil {
+		logger.LogError(log, err, "failed to list fx rates")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ListFxRatesResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: rates,
+	})
+}
+
+// UpsertFxRate records a rate effective from the given time, or from now when omitted.
+// Orders keep the rate they were placed with.
+func (f *FxRateHandler) UpsertFxRate(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "FxRateHandler|UpsertFxRate")
+
+	var req models.UpsertFxRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	rate := &models.FxRate{
+		Currency:    req.Currency,
+		Rate:        req.Rate,
+		EffectiveAt: time.Now().UTC(),
+	}
+	if req.EffectiveAt != nil {
+		rate.EffectiveAt = req.EffectiveAt.UTC()
+	}
+	if err := f.rateRepo.Upsert(ctx.Request.Context(), rate); err != nil {
+		logger.LogError(log, err, "failed to upsert fx rate")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.FxRateResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: rate,
+	})
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	stdErrors "errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"order/internal/importer"
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+	"strings"
+)
+
+// maxImportBytes bounds the size of an uploaded import file
+const maxImportBytes = 64 << 20
+
+type ImportHandler struct {
+	orderService services.OrderServiceInterface
+}
+
+func NewImportHandler(orderService services.OrderServiceInterface) *ImportHandler {
+	return &ImportHandler{orderService: orderService}
+}
+
+// ImportOrders creates the orders of a CSV or NDJSON file, sent as the request body or as the
+// "file" field of a multipart form, and reports the rows that were rejected
+func (h *ImportHandler) ImportOrders(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "ImportHandler|ImportOrders")
+
+	var req models.ImportOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+	body := io.Reader(ctx.Request.Body)
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			_ = ctx.Error(errors.Error("missing import file", errors.StatusBadRequest))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			logger.LogError(log, err, "failed to open import file")
+			_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	reader, err := importer.NewOrderReader(body, req.Format)
+	if err != nil {
+		_ = ctx.Error(errors.Error(err.Error(), errors.StatusBadRequest))
+		return
+	}
+
+	report, err := h.orderService.ImportOrders(ctx.Request.Context(), reader, services.ImportOptions{
+		Historical: req.Historical,
+		Audit: models.StatusChange{
+			ActorType: models.ActorTypeUser,
+			ActorID:   ctx.GetString("role"),
+			Source:    models.ChangeSourceHTTP,
+			SourceRef: ctx.FullPath(),
+			Reason:    "order imported",
+		},
+	})
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stdErrors.As(err, &tooLarge) {
+			_ = ctx.Error(errors.Error("import file too large", errors.StatusBadRequest))
+			return
+		}
+		logger.LogError(log, err, "failed to import orders")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ImportOrdersResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: *report,
+	})
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order/internal/models"
+	"order/internal/search"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+)
+
+type SearchHandler struct {
+	searcher search.OrderSearcher
+}
+
+func NewSearchHandler(searcher search.OrderSearcher) *SearchHandler {
+	return &SearchHandler{searcher: searcher}
+}
+
+// SearchOrders finds orders by partial order ID, customer email, product ID, snapshot words and
+// date, with status and date facets and the matches of each hit highlighted
+func (h *SearchHandler) SearchOrders(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "SearchHandler|SearchOrders")
+
+	var req models.OrderSearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		_ = ctx.Error(errors.Error("from must be before to", errors.StatusBadRequest))
+		return
+	}
+
+	result, err := h.searcher.Search(ctx.Request.Context(), req)
+	if err != nil {
+		logger.LogError(log, err, "failed to search orders")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.OrderSearchResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: *result,
+	})
+}
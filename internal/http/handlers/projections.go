@@ -0,0 +1,43 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order/internal/eventsourcing"
+	"order/internal/models"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+)
+
+type ProjectionHandler struct {
+	projector *eventsourcing.Projector
+}
+
+func NewProjectionHandler(projector *eventsourcing.Projector) *ProjectionHandler {
+	return &ProjectionHandler{projector: projector}
+}
+
+// RebuildOrders replays every order stream into the orders and order_items tables
+func (p *ProjectionHandler) RebuildOrders(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "ProjectionHandler|RebuildOrders")
+
+	var req models.RebuildProjectionsRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+			return
+		}
+	}
+
+	rebuilt, err := p.projector.Rebuild(ctx.Request.Context(), req.IgnoreSnapshots)
+	if err != nil {
+		logger.LogError(log, err, "failed to rebuild order projections")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	resp := models.RebuildProjectionsResponse{Meta: utils.NewMetaData(ctx.Request.Context())}
+	resp.Data.Rebuilt = rebuilt
+	ctx.JSON(http.StatusOK, resp)
+}
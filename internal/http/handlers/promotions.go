@@ -1,16 +1,26 @@
 package http
 
 import (
+	stdErrors "errors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"net/http"
+	"order/internal/models"
 	repo "order/internal/repositories/pg-gorm"
+	"order/internal/services"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
 )
 
 type PromotionHandler struct {
-	newRepo repo.PGInterface
+	newRepo          repo.PGInterface
+	promotionService services.PromotionServiceInterface
 }
 
-func NewPromotionHandler(newRepo repo.PGInterface) *PromotionHandler {
-	return &PromotionHandler{newRepo: newRepo}
+func NewPromotionHandler(newRepo repo.PGInterface, promotionService services.PromotionServiceInterface) *PromotionHandler {
+	return &PromotionHandler{newRepo: newRepo, promotionService: promotionService}
 }
 
 // Add methods for PromotionHandler as needed
@@ -25,3 +35,90 @@ func (p *PromotionHandler) GetPromotion(ctx *gin.Context) {
 func (p *PromotionHandler) UpdatePromotion(ctx *gin.Context) {
 	// Implementation for updating a promotion
 }
+
+func (p *PromotionHandler) GetPromotionAnalytics(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "PromotionHandler|GetPromotionAnalytics")
+
+	promoID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	analytics, err := p.promotionService.GetPromotionAnalytics(ctx.Request.Context(), promoID)
+	if err != nil {
+		logger.LogError(log, err, "failed to get promotion analytics")
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			_ = ctx.Error(errors.Error(errors.StatusNotFound, errors.StatusNotFound))
+			return
+		}
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.PromotionAnalyticsResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: *analytics,
+	})
+}
+
+func (p *PromotionHandler) GetRewardTimeline(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "PromotionHandler|GetRewardTimeline")
+
+	promoID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	var req models.PromotionRewardTimelineRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	buckets, err := p.promotionService.GetRewardTimeline(ctx.Request.Context(), promoID, req)
+	if err != nil {
+		logger.LogError(log, err, "failed to get promotion reward timeline")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.PromotionRewardTimelineResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: buckets,
+	})
+}
+
+// UpsertThreshold sets the minimum order value of the promotion for one order currency
+func (p *PromotionHandler) UpsertThreshold(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "PromotionHandler|UpsertThreshold")
+
+	promoID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	var req models.UpsertPromotionThresholdRequest
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	threshold, err := p.promotionService.UpsertThreshold(ctx.Request.Context(), promoID, req)
+	if err != nil {
+		logger.LogError(log, err, "failed to upsert promotion threshold")
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			_ = ctx.Error(errors.Error(errors.StatusNotFound, errors.StatusNotFound))
+			return
+		}
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.PromotionThresholdResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: threshold,
+	})
+}
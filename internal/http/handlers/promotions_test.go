@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/http/middlewares"
+	"order/pkg/http/utils/errors"
+)
+
+type fakePromotionService struct {
+	services.PromotionServiceInterface
+	err error
+}
+
+func (f fakePromotionService) GetPromotionAnalytics(ctx context.Context, promoID uuid.UUID) (*models.PromotionAnalytics, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.PromotionAnalytics{PromotionConfigID: promoID}, nil
+}
+
+func TestGetPromotionAnalyticsStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		id   string
+		err  error
+		want int
+	}{
+		{name: "found", id: uuid.NewString(), want: http.StatusOK},
+		{name: "malformed id", id: "not-a-uuid", want: http.StatusBadRequest},
+		{name: "unknown promotion", id: uuid.NewString(), err: gorm.ErrRecordNotFound, want: http.StatusNotFound},
+		{name: "database failure", id: uuid.NewString(), err: stdErrors.New("connection reset"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPromotionHandler(nil, fakePromotionService{err: tt.err})
+			router := gin.New()
+			router.Use(middlewares.RequestIDMiddleware(), errors.NewHandlerError)
+			router.GET("/promotions/:id/analytics", handler.GetPromotionAnalytics)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/promotions/"+tt.id+"/analytics", nil))
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	stdErrors "errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+)
+
+type ReportHandler struct {
+	reportService services.ReportServiceInterface
+}
+
+func NewReportHandler(reportService services.ReportServiceInterface) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// GetOrderReport returns order counts, revenue, average order value and status shares per hour
+// or day, optionally grouped by currency and status
+func (h *ReportHandler) GetOrderReport(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "ReportHandler|GetOrderReport")
+
+	var req models.OrderReportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	report, err := h.reportService.OrderReport(ctx.Request.Context(), req)
+	if err != nil {
+		if stdErrors.Is(err, services.ErrInvalidReport) {
+			_ = ctx.Error(errors.Error(err.Error(), errors.StatusBadRequest))
+			return
+		}
+		logger.LogError(log, err, "failed to build order report")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.OrderReportResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: *report,
+	})
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+)
+
+const defaultSagasLimit = 50
+
+type SagaHandler struct {
+	sagaRepo repo.SagaRepoInterface
+}
+
+func NewSagaHandler(sagaRepo repo.SagaRepoInterface) *SagaHandler {
+	return &SagaHandler{sagaRepo: sagaRepo}
+}
+
+// ListSagas lists the most recently updated sagas, optionally filtered by status
+func (s *SagaHandler) ListSagas(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "SagaHandler|ListSagas")
+
+	var req models.ListSagasRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSagasLimit
+	}
+
+	sagas, err := s.sagaRepo.List(ctx.Request.Context(), req.Status, req.Limit)
+	if err != nil {
+		logger.LogError(log, err, "failed to list sagas")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ListSagasResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: sagas,
+	})
+}
+
+// GetOrderSagas returns the sagas of an order with their step log
+func (s *SagaHandler) GetOrderSagas(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "SagaHandler|GetOrderSagas")
+
+	orderID, err := uuid.Parse(ctx.Param("order_id"))
+	if err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	sagas, err := s.sagaRepo.GetByOrderID(ctx.Request.Context(), orderID)
+	if err != nil {
+		logger.LogError(log, err, "failed to get order sagas")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+	if len(sagas) == 0 {
+		_ = ctx.Error(errors.Error(errors.StatusNotFound, errors.StatusNotFound))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ListSagasResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: sagas,
+	})
+}
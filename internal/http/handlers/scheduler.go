@@ -0,0 +1,62 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+)
+
+const defaultJobRunsLimit = 50
+
+type SchedulerHandler struct {
+	jobRepo repo.ScheduledJobRepoInterface
+}
+
+func NewSchedulerHandler(jobRepo repo.ScheduledJobRepoInterface) *SchedulerHandler {
+	return &SchedulerHandler{jobRepo: jobRepo}
+}
+
+func (s *SchedulerHandler) ListJobs(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "SchedulerHandler|ListJobs")
+
+	jobs, err := s.jobRepo.ListJobs(ctx.Request.Context())
+	if err != nil {
+		logger.LogError(log, err, "failed to list scheduled jobs")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ListScheduledJobsResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: jobs,
+	})
+}
+
+func (s *SchedulerHandler) ListRuns(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "SchedulerHandler|ListRuns")
+
+	var req models.ListJobRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultJobRunsLimit
+	}
+
+	runs, err := s.jobRepo.ListRuns(ctx.Request.Context(), ctx.Param("name"), req.Limit)
+	if err != nil {
+		logger.LogError(log, err, "failed to list job runs")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ListJobRunsResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: runs,
+	})
+}
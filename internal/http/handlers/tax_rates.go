@@ -0,0 +1,64 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+)
+
+type TaxRateHandler struct {
+	rateRepo repo.TaxRateRepoInterface
+}
+
+func NewTaxRateHandler(rateRepo repo.TaxRateRepoInterface) *TaxRateHandler {
+	return &TaxRateHandler{rateRepo: rateRepo}
+}
+
+// ListTaxRates lists every configured tax rate by country, region and class
+func (t *TaxRateHandler) ListTaxRates(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "TaxRateHandler|ListTaxRates")
+
+	rates, err := t.rateRepo.List(ctx.Request.Context())
+	if err != nil {
+		logger.LogError(log, err, "failed to list tax rates")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ListTaxRatesResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: rates,
+	})
+}
+
+// UpsertTaxRate creates a rate or replaces the rate of the same country, region and class
+func (t *TaxRateHandler) UpsertTaxRate(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "TaxRateHandler|UpsertTaxRate")
+
+	var req models.UpsertTaxRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	rate := &models.TaxRate{
+		Country:  req.Country,
+		Region:   req.Region,
+		TaxClass: req.TaxClass,
+		Rate:     req.Rate,
+	}
+	if err := t.rateRepo.Upsert(ctx.Request.Context(), rate); err != nil {
+		logger.LogError(log, err, "failed to upsert tax rate")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TaxRateResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: rate,
+	})
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"order/pkg/http/utils/errors"
+)
+
+type TenantHandler struct {
+	tenantService services.TenantServiceInterface
+}
+
+func NewTenantHandler(tenantService services.TenantServiceInterface) *TenantHandler {
+	return &TenantHandler{tenantService: tenantService}
+}
+
+// ListTenants lists every tenant with the settings it overrides
+func (t *TenantHandler) ListTenants(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "TenantHandler|ListTenants")
+
+	tenants, err := t.tenantService.ListTenants(ctx.Request.Context())
+	if err != nil {
+		logger.LogError(log, err, "failed to list tenants")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ListTenantsResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: tenants,
+	})
+}
+
+// UpsertTenant creates a tenant or replaces its name, state and settings
+func (t *TenantHandler) UpsertTenant(ctx *gin.Context) {
+	log := logger.WithCtx(ctx, "TenantHandler|UpsertTenant")
+
+	id := ctx.Param("id")
+	var req models.UpsertTenantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || id == "" || len(id) > 64 {
+		_ = ctx.Error(errors.Error(errors.StatusBadRequest, errors.StatusBadRequest))
+		return
+	}
+
+	tenant, err := t.tenantService.UpsertTenant(ctx.Request.Context(), id, req)
+	if err != nil {
+		logger.LogError(log, err, "failed to upsert tenant")
+		_ = ctx.Error(errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TenantResponse{
+		Meta: utils.NewMetaData(ctx.Request.Context()),
+		Data: tenant,
+	})
+}
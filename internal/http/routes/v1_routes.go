@@ -20,6 +20,10 @@ func NewHTTPServerSetup(router *gin.Engine, configCors cors.Config, app *bootstr
 
 	server.ApplicationV1Router(
 		app.PGRepoInterface,
+		app.TenantService,
+		app.ExportStore,
+		app.OrderCore.OrderService,
+		app.OrderSearcher,
 		router,
 	)
 
@@ -4,12 +4,24 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"order/internal/eventsourcing"
 	handlers2 "order/internal/http/handlers"
+	repo "order/internal/repositories"
 	pgGorm "order/internal/repositories/pg-gorm"
+	"order/internal/search"
+	"order/internal/services"
+	"order/pkg/core/configloader"
+	"order/pkg/core/filestore"
+	"order/pkg/http/middlewares"
+	"time"
 )
 
 func ApplicationV1Router(
 	newPgRepo pgGorm.PGInterface,
+	tenantService *services.TenantService,
+	exportStore *filestore.Local,
+	orderService services.OrderServiceInterface,
+	orderSearcher search.OrderSearcher,
 	router *gin.Engine,
 ) {
 	routerV1 := router.Group("/v1")
@@ -17,19 +29,58 @@ func ApplicationV1Router(
 		// Swagger
 		routerV1.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-		// Migrations
-		MigrateRoutes(routerV1, handlers2.NewMigrationHandler(newPgRepo))
+		// Tenants are managed across tenants
+		TenantRoutes(routerV1, handlers2.NewTenantHandler(tenantService))
+	}
+
+	// everything else is scoped to the tenant of the request
+	routerV1 = router.Group("/v1", middlewares.TenantMiddleware(configloader.GetConfig().TenantDefault, tenantService.Authorize))
+	{
 
 		//orderRepo := repo.NewOrderRepository(newPgRepo)
 		//orderService := services.NewOrderService(orderRepo, newPgRepo)
 		//OrderRoutes(routerV1, handlers2.NewOrderHandler(newPgRepo, orderService))
-	}
-}
 
-func MigrateRoutes(router *gin.RouterGroup, handler *handlers2.MigrationHandler) {
-	routerAuth := router.Group("/internal")
-	{
-		routerAuth.POST("/migrate", handler.Migrate)
+		orderRepo := repo.NewOrderRepository(newPgRepo)
+		CustomerRoutes(routerV1, handlers2.NewCustomerOrderHandler(orderRepo))
+
+		// Order exports
+		exportService := services.NewExportService(orderRepo, repo.NewExportJobRepository(newPgRepo), exportStore,
+			time.Duration(configloader.GetConfig().ExportRetentionHours)*time.Hour)
+		ExportRoutes(routerV1, handlers2.NewExportHandler(exportService))
+
+		// Bulk order imports
+		ImportRoutes(routerV1, handlers2.NewImportHandler(orderService))
+
+		// Order search
+		SearchRoutes(routerV1, handlers2.NewSearchHandler(orderSearcher))
+
+		// Order reports
+		ReportRoutes(routerV1, handlers2.NewReportHandler(services.NewReportService(repo.NewOrderStatsRepository(newPgRepo))))
+
+		outboxRepo := repo.NewOutboxRepository(newPgRepo)
+		promotionRepo := repo.NewPromotionRepository(newPgRepo)
+		promotionService := services.NewPromotionService(promotionRepo, outboxRepo, orderRepo, newPgRepo)
+		PromotionRoutes(routerV1, handlers2.NewPromotionHandler(newPgRepo, promotionService))
+
+		// Scheduler run history
+		SchedulerRoutes(routerV1, handlers2.NewSchedulerHandler(repo.NewScheduledJobRepository(newPgRepo)))
+
+		// Checkout sagas
+		SagaRoutes(routerV1, handlers2.NewSagaHandler(repo.NewSagaRepository(newPgRepo)))
+
+		// Tax rates
+		TaxRateRoutes(routerV1, handlers2.NewTaxRateHandler(repo.NewTaxRateRepository(newPgRepo)))
+
+		// Exchange rates
+		FxRateRoutes(routerV1, handlers2.NewFxRateHandler(repo.NewFxRateRepository(newPgRepo)))
+
+		// Order projections only exist for event-sourced orders
+		if configloader.GetConfig().OrderEventSourcingEnabled {
+			orderEventRepo := repo.NewOrderEventRepository(newPgRepo)
+			eventStore := eventsourcing.NewStore(orderEventRepo, outboxRepo, configloader.GetConfig().OrderSnapshotEvery)
+			ProjectionRoutes(routerV1, handlers2.NewProjectionHandler(eventsourcing.NewProjector(newPgRepo, eventStore, orderEventRepo)))
+		}
 	}
 }
 
@@ -39,3 +90,97 @@ func OrderRoutes(router *gin.RouterGroup, handler *handlers2.OrderHandler) {
 		routerOrder.POST("/", handler.CreateOrder)
 	}
 }
+
+func CustomerRoutes(router *gin.RouterGroup, handler *handlers2.CustomerOrderHandler) {
+	routerCustomer := router.Group("/customers/me", middlewares.CustomerAuthMiddleware())
+	{
+		routerCustomer.GET("/orders", handler.ListCustomerOrders)
+	}
+}
+
+func ExportRoutes(router *gin.RouterGroup, handler *handlers2.ExportHandler) {
+	routerExports := router.Group("/internal", middlewares.AuthMiddleware())
+	{
+		routerExports.GET("/orders/export", handler.ExportOrders)
+		routerExports.POST("/orders/exports", handler.StartOrderExport)
+		routerExports.GET("/exports/:id", handler.GetExport)
+		routerExports.GET("/exports/:id/download", handler.DownloadExport)
+	}
+}
+
+func ImportRoutes(router *gin.RouterGroup, handler *handlers2.ImportHandler) {
+	routerImports := router.Group("/internal", middlewares.AuthMiddleware())
+	{
+		routerImports.POST("/orders/import", handler.ImportOrders)
+	}
+}
+
+func SearchRoutes(router *gin.RouterGroup, handler *handlers2.SearchHandler) {
+	routerSearch := router.Group("/internal/orders", middlewares.AuthMiddleware())
+	{
+		routerSearch.GET("/search", handler.SearchOrders)
+	}
+}
+
+func ReportRoutes(router *gin.RouterGroup, handler *handlers2.ReportHandler) {
+	routerReports := router.Group("/internal/reports", middlewares.AuthMiddleware())
+	{
+		routerReports.GET("/orders", handler.GetOrderReport)
+	}
+}
+
+func PromotionRoutes(router *gin.RouterGroup, handler *handlers2.PromotionHandler) {
+	routerPromotion := router.Group("/promotions", middlewares.AuthMiddleware())
+	{
+		routerPromotion.GET("/:id/analytics", handler.GetPromotionAnalytics)
+		routerPromotion.GET("/:id/analytics/rewards", handler.GetRewardTimeline)
+		routerPromotion.PUT("/:id/thresholds", handler.UpsertThreshold)
+	}
+}
+
+func TenantRoutes(router *gin.RouterGroup, handler *handlers2.TenantHandler) {
+	routerTenants := router.Group("/internal/tenants", middlewares.PlatformAdminMiddleware())
+	{
+		routerTenants.GET("", handler.ListTenants)
+		routerTenants.PUT("/:id", handler.UpsertTenant)
+	}
+}
+
+func SchedulerRoutes(router *gin.RouterGroup, handler *handlers2.SchedulerHandler) {
+	routerJobs := router.Group("/internal/jobs", middlewares.AuthMiddleware())
+	{
+		routerJobs.GET("", handler.ListJobs)
+		routerJobs.GET("/:name/runs", handler.ListRuns)
+	}
+}
+
+func ProjectionRoutes(router *gin.RouterGroup, handler *handlers2.ProjectionHandler) {
+	routerProjections := router.Group("/internal/projections", middlewares.AuthMiddleware())
+	{
+		routerProjections.POST("/orders/rebuild", handler.RebuildOrders)
+	}
+}
+
+func SagaRoutes(router *gin.RouterGroup, handler *handlers2.SagaHandler) {
+	routerSagas := router.Group("/internal/sagas", middlewares.AuthMiddleware())
+	{
+		routerSagas.GET("", handler.ListSagas)
+		routerSagas.GET("/orders/:order_id", handler.GetOrderSagas)
+	}
+}
+
+func TaxRateRoutes(router *gin.RouterGroup, handler *handlers2.TaxRateHandler) {
+	routerTax := router.Group("/internal/tax-rates", middlewares.AuthMiddleware())
+	{
+		routerTax.GET("", handler.ListTaxRates)
+		routerTax.PUT("", handler.UpsertTaxRate)
+	}
+}
+
+func FxRateRoutes(router *gin.RouterGroup, handler *handlers2.FxRateHandler) {
+	routerFx := router.Group("/internal/fx-rates", middlewares.AuthMiddleware())
+	{
+		routerFx.GET("", handler.ListFxRates)
+		routerFx.PUT("", handler.UpsertFxRate)
+	}
+}
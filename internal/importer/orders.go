@@ -0,0 +1,302 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"io"
+	"order/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+
+	// maxLineBytes bounds one NDJSON line, an order with all its items
+	maxLineBytes = 4 << 20
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown import format")
+	ErrBadHeader     = errors.New("bad CSV header")
+)
+
+// Record is one order read from an import file. Err is set when the order cannot be read or
+// breaks the rules of CreateOrderRequest; reading goes on with the next order.
+type Record struct {
+	// Row is the line of the order in the file, the first of its lines for CSV
+	Row      int
+	Ref      string
+	Request  models.CreateOrderRequest
+	PlacedAt *time.Time
+	Err      error
+}
+
+// OrderReader reads the orders of an import file one by one and returns io.EOF after the last
+type OrderReader interface {
+	Next() (*Record, error)
+}
+
+// NewOrderReader returns a reader of format; an empty format reads CSV
+func NewOrderReader(r io.Reader, format string) (OrderReader, error) {
+	switch strings.ToLower(format) {
+	case "", FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON, "jsonl":
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+		return &ndjsonReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// Validate applies the binding rules of CreateOrderRequest and of each of its items
+func Validate(req *models.CreateOrderRequest) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return err
+	}
+	if len(req.OrderItems) == 0 {
+		return errors.New("order has no items")
+	}
+	for i := range req.OrderItems {
+		if err := binding.Validator.ValidateStruct(&req.OrderItems[i]); err != nil {
+			return fmt.Errorf("item %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ndjsonReader reads one order per line: a CreateOrderRequest with an optional ref and placed_at
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+type ndjsonRecord struct {
+	models.CreateOrderRequest
+	Ref      string     `json:"ref"`
+	PlacedAt *time.Time `json:"placed_at"`
+}
+
+func (n *ndjsonReader) Next() (*Record, error) {
+	for n.scanner.Scan() {
+		n.line++
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var raw ndjsonRecord
+		record := &Record{Row: n.line}
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			record.Err = err
+			return record, nil
+		}
+		record.Ref = raw.Ref
+		record.Request = raw.CreateOrderRequest
+		record.PlacedAt = raw.PlacedAt
+		record.Err = Validate(&record.Request)
+		return record, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// csvReader reads one item per row. Consecutive rows with the same ref are the items of one
+// order, whose fields are taken from its first row; rows without a ref are orders of their own.
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	pending *csvRow
+}
+
+// csvRow is a row with the line it starts on, or the error reading it
+type csvRow struct {
+	fields []string
+	line   int
+	err    error
+}
+
+var (
+	// csvRequired are the columns every import file needs
+	csvRequired = []string{"customer_id", "status", "total_amount", "product_id", "quantity", "price"}
+	csvOptional = []string{
+		"ref", "placed_at", "discount_amount", "currency", "country", "region",
+		"customer_name", "customer_email", "customer_phone", "tax_class",
+		"shipping_name", "shipping_line1", "shipping_line2", "shipping_city", "shipping_region", "shipping_postal_code", "shipping_country",
+		"billing_name", "billing_line1", "billing_line2", "billing_city", "billing_region", "billing_postal_code", "billing_country",
+	}
+)
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadHeader, err)
+	}
+	known := map[string]bool{}
+	for _, name := range append(append([]string{}, csvRequired...), csvOptional...) {
+		known[name] = true
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known[name] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrBadHeader, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range csvRequired {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrBadHeader, name)
+		}
+	}
+	return &csvReader{r: reader, columns: columns}, nil
+}
+
+func (c *csvReader) Next() (*Record, error) {
+	first := c.pending
+	c.pending = nil
+	if first == nil {
+		var err error
+		if first, err = c.read(); err != nil {
+			return nil, err
+		}
+	}
+	if first.err != nil {
+		return &Record{Row: first.line, Err: first.err}, nil
+	}
+
+	rows := [][]string{first.fields}
+	ref := c.get(first.fields, "ref")
+	for ref != "" {
+		next, err := c.read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if next.err != nil || c.get(next.fields, "ref") != ref {
+			c.pending = next
+			break
+		}
+		rows = append(rows, next.fields)
+	}
+
+	record := &Record{Row: first.line, Ref: ref}
+	record.Request, record.PlacedAt, record.Err = c.order(rows)
+	if record.Err == nil {
+		record.Err = Validate(&record.Request)
+	}
+	return record, nil
+}
+
+// read returns the next row. A malformed row is returned with its error so the rows after it
+// are still read; other errors end the file.
+func (c *csvReader) read() (*csvRow, error) {
+	fields, err := c.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &csvRow{line: parseErr.StartLine, err: err}, nil
+		}
+		return nil, err
+	}
+	line, _ := c.r.FieldPos(0)
+	return &csvRow{fields: fields, line: line}, nil
+}
+
+func (c *csvReader) get(row []string, column string) string {
+	i, ok := c.columns[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// order builds the request from the rows of one order
+func (c *csvReader) order(rows [][]string) (models.CreateOrderRequest, *time.Time, error) {
+	first := rows[0]
+	req := models.CreateOrderRequest{
+		Status:   c.get(first, "status"),
+		Currency: c.get(first, "currency"),
+		Country:  c.get(first, "country"),
+		Region:   c.get(first, "region"),
+		Customer: models.CustomerSnapshot{
+			Name:  c.get(first, "customer_name"),
+			Email: c.get(first, "customer_email"),
+			Phone: c.get(first, "customer_phone"),
+		},
+		Shipping: c.address(first, "shipping_"),
+		Billing:  c.address(first, "billing_"),
+	}
+
+	var err error
+	if req.CustomerID, err = uuid.Parse(c.get(first, "customer_id")); err != nil {
+		return req, nil, fmt.Errorf("customer_id: %w", err)
+	}
+	if req.TotalAmount, err = parseFloat(c.get(first, "total_amount")); err != nil {
+		return req, nil, fmt.Errorf("total_amount: %w", err)
+	}
+	if req.Discount, err = parseFloat(c.get(first, "discount_amount")); err != nil {
+		return req, nil, fmt.Errorf("discount_amount: %w", err)
+	}
+
+	var placedAt *time.Time
+	if raw := c.get(first, "placed_at"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return req, nil, fmt.Errorf("placed_at: %w", err)
+		}
+		placedAt = &t
+	}
+
+	for i, row := range rows {
+		item := models.CreateOrderItemRequest{TaxClass: c.get(row, "tax_class")}
+		if item.ProductID, err = uuid.Parse(c.get(row, "product_id")); err != nil {
+			return req, nil, fmt.Errorf("item %d: product_id: %w", i+1, err)
+		}
+		if item.Quantity, err = strconv.Atoi(c.get(row, "quantity")); err != nil {
+			return req, nil, fmt.Errorf("item %d: quantity: %w", i+1, err)
+		}
+		if item.UniquePrice, err = parseFloat(c.get(row, "price")); err != nil {
+			return req, nil, fmt.Errorf("item %d: price: %w", i+1, err)
+		}
+		req.OrderItems = append(req.OrderItems, item)
+	}
+	return req, placedAt, nil
+}
+
+func (c *csvReader) address(row []string, prefix string) models.Address {
+	return models.Address{
+		Name:       c.get(row, prefix+"name"),
+		Line1:      c.get(row, prefix+"line1"),
+		Line2:      c.get(row, prefix+"line2"),
+		City:       c.get(row, prefix+"city"),
+		Region:     c.get(row, prefix+"region"),
+		PostalCode: c.get(row, prefix+"postal_code"),
+		Country:    c.get(row, prefix+"country"),
+	}
+}
+
+// parseFloat reads an amount; an empty cell is zero
+func parseFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
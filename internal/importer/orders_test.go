@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// readAll returns the records of an import file
+func readAll(t *testing.T, format, file string) []*Record {
+	t.Helper()
+	reader, err := NewOrderReader(strings.NewReader(file), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []*Record
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestNewOrderReaderRejectsUnknownFormatsAndHeaders(t *testing.T) {
+	if _, err := NewOrderReader(strings.NewReader(""), "xlsx"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("format xlsx err = %v, want %v", err, ErrUnknownFormat)
+	}
+	for name, header := range map[string]string{
+		"unknown column": "customer_id,status,total_amount,product_id,quantity,price,colour\n",
+		"missing column": "customer_id,status,total_amount,product_id,quantity\n",
+		"empty file":     "",
+	} {
+		if _, err := NewOrderReader(strings.NewReader(header), FormatCSV); !errors.Is(err, ErrBadHeader) {
+			t.Errorf("%s err = %v, want %v", name, err, ErrBadHeader)
+		}
+	}
+}
+
+func TestCSVReaderGroupsTheRowsOfAnOrderByRef(t *testing.T) {
+	customer, product := uuid.New(), uuid.New()
+	file := "ref,customer_id,status,total_amount,product_id,quantity,price,placed_at\n" +
+		"A-1," + customer.String() + ",completed,30," + product.String() + ",2,10,2024-05-01T10:00:00Z\n" +
+		"A-1,,,," + uuid.New().String() + ",1,10,\n" +
+		"," + customer.String() + ",pending,10," + product.String() + ",1,10,\n" +
+		"B-1,not-a-uuid,pending,10," + product.String() + ",1,10,\n" +
+		"C-1," + customer.String() + ",pending,10," + product.String() + ",0,10,\n"
+
+	records := readAll(t, FormatCSV, file)
+	if len(records) != 4 {
+		t.Fatalf("%d records, want 4", len(records))
+	}
+
+	first := records[0]
+	if first.Err != nil || first.Row != 2 || first.Ref != "A-1" || len(first.Request.OrderItems) != 2 {
+		t.Errorf("first record = row %d ref %q with %d items, %v; want row 2 ref A-1 with 2 items",
+			first.Row, first.Ref, len(first.Request.OrderItems), first.Err)
+	}
+	if first.PlacedAt == nil || first.PlacedAt.Year() != 2024 || first.Request.CustomerID != customer {
+		t.Errorf("first record placed at %v by %s, want 2024 by %s", first.PlacedAt, first.Request.CustomerID, customer)
+	}
+	// a row without a ref is an order of its own
+	if second := records[1]; second.Err != nil || second.Row != 4 || len(second.Request.OrderItems) != 1 {
+		t.Errorf("second record = row %d with %d items, %v; want row 4 with 1 item", second.Row, len(second.Request.OrderItems), second.Err)
+	}
+	if bad := records[2]; bad.Err == nil || !strings.Contains(bad.Err.Error(), "customer_id") || bad.Ref != "B-1" {
+		t.Errorf("record with a bad customer = %q, %v; want a customer_id error", bad.Ref, bad.Err)
+	}
+	if invalid := records[3]; invalid.Err == nil || invalid.Ref != "C-1" {
+		t.Errorf("record with quantity 0 = %q, %v; want a validation error", invalid.Ref, invalid.Err)
+	}
+}
+
+func TestNDJSONReaderReportsBadLinesAndReadsOn(t *testing.T) {
+	order := `{"ref":"A-1","customer_id":"` + uuid.New().String() + `","status":"pending","total_amount":10,` +
+		`"order_items":[{"product_id":"` + uuid.New().String() + `","quantity":1,"price":10}]}`
+	file := order + "\n\n{not json\n" + `{"ref":"B-1","status":"pending","total_amount":10,"order_items":[]}` + "\n"
+
+	records := readAll(t, FormatNDJSON, file)
+	if len(records) != 3 {
+		t.Fatalf("%d records, want 3", len(records))
+	}
+	if records[0].Err != nil || records[0].Ref != "A-1" || records[0].Row != 1 {
+		t.Errorf("first record = row %d ref %q, %v; want row 1 ref A-1", records[0].Row, records[0].Ref, records[0].Err)
+	}
+	// blank lines are skipped but still counted
+	if records[1].Err == nil || records[1].Row != 3 {
+		t.Errorf("malformed line = row %d, %v; want row 3 with an error", records[1].Row, records[1].Err)
+	}
+	if records[2].Err == nil || records[2].Ref != "B-1" {
+		t.Errorf("order without customer and items = %q, %v; want a validation error", records[2].Ref, records[2].Err)
+	}
+}
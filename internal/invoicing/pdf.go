@@ -0,0 +1,192 @@
+package invoicing
+
+import (
+	"bytes"
+	"fmt"
+	"order/internal/models"
+	"strings"
+)
+
+// A4 in points; documents are set in Courier so columns line up without font metrics
+const (
+	pdfPageWidth   = 595
+	pdfPageHeight  = 842
+	pdfMargin      = 50
+	pdfFontSize    = 8
+	pdfTitleSize   = 16
+	pdfLineSpacing = 4
+)
+
+type pdfLine struct {
+	text string
+	size int
+	bold bool
+}
+
+// renderPDF writes a plain single-font PDF 1.4 document, breaking pages as needed
+func renderPDF(invoice *models.Invoice, seller Seller) []byte {
+	pages := paginate(invoiceText(invoice, seller))
+
+	// objects 1-4 are the catalog, the page tree and the fonts; each page adds a page and a content object
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	objects = append(objects,
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		content := pageContent(page)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+				"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func paginate(lines []pdfLine) [][]pdfLine {
+	var (
+		pages [][]pdfLine
+		page  []pdfLine
+		y     = pdfPageHeight - pdfMargin
+	)
+	for _, line := range lines {
+		y -= line.size + pdfLineSpacing
+		if y < pdfMargin && len(page) > 0 {
+			pages = append(pages, page)
+			page = nil
+			y = pdfPageHeight - pdfMargin - line.size - pdfLineSpacing
+		}
+		page = append(page, line)
+	}
+	return append(pages, page)
+}
+
+func pageContent(lines []pdfLine) string {
+	var b strings.Builder
+	y := pdfPageHeight - pdfMargin
+	for _, line := range lines {
+		y -= line.size + pdfLineSpacing
+		if line.text == "" {
+			continue
+		}
+		font := "F1"
+		if line.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, line.size, pdfMargin, y, pdfEscape(line.text))
+	}
+	return b.String()
+}
+
+// pdfEscape escapes a string literal; Latin-1 characters are written as WinAnsi octal codes
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func invoiceText(invoice *models.Invoice, seller Seller) []pdfLine {
+	var lines []pdfLine
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, pdfLine{text: fmt.Sprintf(format, args...), size: pdfFontSize})
+	}
+	heading := func(text string) {
+		lines = append(lines, pdfLine{text: text, size: pdfFontSize, bold: true})
+	}
+	money := func(v float64) string {
+		return fmt.Sprintf("%.2f", v)
+	}
+
+	lines = append(lines, pdfLine{text: title(invoice), size: pdfTitleSize, bold: true}, pdfLine{size: pdfFontSize})
+	add("Number:     %s", invoice.Number)
+	add("Issued:     %s", invoice.IssuedAt.Format("2006-01-02"))
+	add("Order:      %s", invoice.OrderID)
+	if invoice.CreditedNumber != "" {
+		add("Credits:    %s", invoice.CreditedNumber)
+	}
+	add("")
+
+	heading("From")
+	add("%s", seller.Name)
+	if seller.Address != "" {
+		add("%s", seller.Address)
+	}
+	if seller.Country != "" {
+		add("%s", seller.Country)
+	}
+	if seller.TaxID != "" {
+		add("Tax ID: %s", seller.TaxID)
+	}
+	add("")
+
+	heading("Bill to")
+	add("%s", partyName(invoice))
+	address := invoice.BillingAddress
+	for _, text := range []string{address.Line1, address.Line2, strings.TrimSpace(address.PostalCode + " " + address.City), address.Region, address.Country} {
+		if text != "" {
+			add("%s", text)
+		}
+	}
+	if invoice.Customer.Email != "" {
+		add("%s", invoice.Customer.Email)
+	}
+	if invoice.Customer.Phone != "" {
+		add("%s", invoice.Customer.Phone)
+	}
+	add("")
+
+	heading(fmt.Sprintf("%-36s %5s %10s %10s %6s %9s %10s", "Item", "Qty", "Unit", "Net", "Tax %", "Tax", "Total"))
+	for _, line := range invoice.Lines {
+		add("%-36s %5d %10s %10s %6s %9s %10s",
+			line.ProductID, line.Quantity, money(line.UnitPrice), money(line.NetAmount),
+			percent(line.TaxRate), money(line.TaxAmount), money(line.TotalAmount))
+	}
+	add("")
+
+	total := func(label string, v float64) {
+		add("%71s %18s", label, money(v))
+	}
+	total("Subtotal", invoice.Subtotal)
+	if invoice.DiscountAmount > 0 {
+		total("Discount", -invoice.DiscountAmount)
+	}
+	total("Net", invoice.TotalAmount-invoice.TaxAmount)
+	total("Tax", invoice.TaxAmount)
+	heading(fmt.Sprintf("%71s %18s", "Total "+invoice.Currency, money(invoice.TotalAmount)))
+	return lines
+}
@@ -0,0 +1,73 @@
+package invoicing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"order/internal/models"
+	"strings"
+)
+
+const (
+	FormatPDF  = "pdf"
+	FormatJSON = "json"
+	FormatUBL  = "ubl"
+)
+
+var ErrUnknownFormat = errors.New("unknown invoice format")
+
+// Seller is the issuer printed on every document
+type Seller struct {
+	Name    string `json:"name"`
+	TaxID   string `json:"tax_id,omitempty"`
+	Address string `json:"address,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// Document is a rendered invoice or credit note
+type Document struct {
+	Data        []byte
+	ContentType string
+	FileName    string
+}
+
+// Render renders invoice in format; an empty format renders the PDF
+func Render(format string, invoice *models.Invoice, seller Seller) (*Document, error) {
+	switch strings.ToLower(format) {
+	case "", FormatPDF:
+		return &Document{Data: renderPDF(invoice, seller), ContentType: "application/pdf", FileName: invoice.Number + ".pdf"}, nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(struct {
+			Seller Seller `json:"seller"`
+			*models.Invoice
+		}{Seller: seller, Invoice: invoice}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &Document{Data: data, ContentType: "application/json", FileName: invoice.Number + ".json"}, nil
+	case FormatUBL, "xml":
+		data, err := renderUBL(invoice, seller)
+		if err != nil {
+			return nil, err
+		}
+		return &Document{Data: data, ContentType: "application/xml", FileName: invoice.Number + ".xml"}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// lineNetTotal is the sum of the net amounts of the lines
+func lineNetTotal(invoice *models.Invoice) float64 {
+	var total float64
+	for _, line := range invoice.Lines {
+		total += line.NetAmount
+	}
+	return total
+}
+
+func title(invoice *models.Invoice) string {
+	if invoice.Kind == models.InvoiceKindCreditNote {
+		return "CREDIT NOTE"
+	}
+	return "INVOICE"
+}
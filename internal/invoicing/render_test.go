@@ -0,0 +1,295 @@
+package invoicing
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/models"
+)
+
+var testSeller = Seller{Name: "Acme GmbH", TaxID: "DE123456789", Address: "Hauptstr. 1, 10115 Berlin", Country: "DE"}
+
+// testInvoice has a discounted standard line, a reduced line and an untaxed line
+func testInvoice(kind models.InvoiceKind) *models.Invoice {
+	invoice := &models.Invoice{
+		OrderID:        uuid.New(),
+		Kind:           kind,
+		Number:         kind.NumberPrefix() + "-2024-000042",
+		IssuedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		CustomerID:     uuid.New(),
+		Customer:       models.CustomerSnapshot{Name: "Ada Lovelace", Email: "ada@example.com"},
+		BillingAddress: models.Address{Name: "Ada Lovelace", Line1: "1 Main St", City: "München", PostalCode: "80331", Country: "DE"},
+		Currency:       "EUR",
+		Subtotal:       60,
+		DiscountAmount: 10,
+		TaxAmount:      8.3,
+		TotalAmount:    58.3,
+		Lines: []models.InvoiceLine{
+			{ProductID: uuid.New(), Quantity: 2, UnitPrice: 10, NetAmount: 16.67, TaxRate: 0.19, TaxAmount: 3.17, TotalAmount: 19.84},
+			{ProductID: uuid.New(), Quantity: 1, UnitPrice: 30, NetAmount: 25, TaxRate: 0.07, TaxAmount: 1.75, TotalAmount: 26.75},
+			{ProductID: uuid.New(), Quantity: 1, UnitPrice: 10, NetAmount: 8.33, TaxRate: 0.19, TaxAmount: 1.58, TotalAmount: 9.91},
+		},
+	}
+	if kind == models.InvoiceKindCreditNote {
+		invoice.CreditedNumber = "INV-2024-000041"
+	}
+	return invoice
+}
+
+// parsedUBL reads back the elements of an Invoice or CreditNote checked by the tests
+type parsedUBL struct {
+	XMLName            xml.Name
+	ID                 string `xml:"ID"`
+	InvoiceTypeCode    string `xml:"InvoiceTypeCode"`
+	CreditNoteTypeCode string `xml:"CreditNoteTypeCode"`
+	Currency           string `xml:"DocumentCurrencyCode"`
+	BillingReference   string `xml:"BillingReference>InvoiceDocumentReference>ID"`
+	SupplierTaxID      string `xml:"AccountingSupplierParty>Party>PartyTaxScheme>CompanyID"`
+	CustomerCity       string `xml:"AccountingCustomerParty>Party>PostalAddress>CityName"`
+	TaxTotal           struct {
+		TaxAmount parsedAmount `xml:"TaxAmount"`
+		Subtotals []struct {
+			TaxableAmount parsedAmount `xml:"TaxableAmount"`
+			TaxAmount     parsedAmount `xml:"TaxAmount"`
+			Percent       string       `xml:"TaxCategory>Percent"`
+		} `xml:"TaxSubtotal"`
+	} `xml:"TaxTotal"`
+	MonetaryTotal struct {
+		LineExtensionAmount  parsedAmount `xml:"LineExtensionAmount"`
+		TaxExclusiveAmount   parsedAmount `xml:"TaxExclusiveAmount"`
+		TaxInclusiveAmount   parsedAmount `xml:"TaxInclusiveAmount"`
+		AllowanceTotalAmount parsedAmount `xml:"AllowanceTotalAmount"`
+		PayableAmount        parsedAmount `xml:"PayableAmount"`
+	} `xml:"LegalMonetaryTotal"`
+	InvoiceLines    []parsedUBLLine `xml:"InvoiceLine"`
+	CreditNoteLines []parsedUBLLine `xml:"CreditNoteLine"`
+}
+
+type parsedUBLLine struct {
+	ID                  int          `xml:"ID"`
+	InvoicedQuantity    string       `xml:"InvoicedQuantity"`
+	CreditedQuantity    string       `xml:"CreditedQuantity"`
+	LineExtensionAmount parsedAmount `xml:"LineExtensionAmount"`
+	Percent             string       `xml:"Item>ClassifiedTaxCategory>Percent"`
+}
+
+type parsedAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+func renderParsedUBL(t *testing.T, invoice *models.Invoice) (parsedUBL, []byte) {
+	t.Helper()
+	doc, err := Render(FormatUBL, invoice, testSeller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ContentType != "application/xml" || doc.FileName != invoice.Number+".xml" {
+		t.Errorf("document %s %s, want application/xml %s.xml", doc.ContentType, doc.FileName, invoice.Number)
+	}
+	var parsed parsedUBL
+	if err = xml.Unmarshal(doc.Data, &parsed); err != nil {
+		t.Fatalf("UBL is not well-formed: %v\n%s", err, doc.Data)
+	}
+	return parsed, doc.Data
+}
+
+func TestRenderUBLInvoice(t *testing.T) {
+	invoice := testInvoice(models.InvoiceKindInvoice)
+	ubl, data := renderParsedUBL(t, invoice)
+
+	if ubl.XMLName.Local != "Invoice" || ubl.XMLName.Space != "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2" {
+		t.Errorf("root = %v, want the UBL Invoice", ubl.XMLName)
+	}
+	if ubl.ID != invoice.Number || ubl.InvoiceTypeCode != ublInvoiceTypeCode || ubl.CreditNoteTypeCode != "" {
+		t.Errorf("id %s, type codes %q / %q, want %s with invoice type code %s", ubl.ID, ubl.InvoiceTypeCode, ubl.CreditNoteTypeCode, invoice.Number, ublInvoiceTypeCode)
+	}
+	if ubl.BillingReference != "" {
+		t.Errorf("invoice references %q", ubl.BillingReference)
+	}
+	if ubl.Currency != "EUR" || ubl.SupplierTaxID != testSeller.TaxID || ubl.CustomerCity != "München" {
+		t.Errorf("currency %s, supplier tax id %s, customer city %s", ubl.Currency, ubl.SupplierTaxID, ubl.CustomerCity)
+	}
+
+	totals := ubl.MonetaryTotal
+	for name, got := range map[string]parsedAmount{
+		"LineExtensionAmount":  totals.LineExtensionAmount,
+		"TaxExclusiveAmount":   totals.TaxExclusiveAmount,
+		"TaxInclusiveAmount":   totals.TaxInclusiveAmount,
+		"AllowanceTotalAmount": totals.AllowanceTotalAmount,
+		"PayableAmount":        totals.PayableAmount,
+		"TaxAmount":            ubl.TaxTotal.TaxAmount,
+	} {
+		want := map[string]string{
+			"LineExtensionAmount":  "50.00",
+			"TaxExclusiveAmount":   "50.00",
+			"TaxInclusiveAmount":   "58.30",
+			"AllowanceTotalAmount": "10.00",
+			"PayableAmount":        "58.30",
+			"TaxAmount":            "8.30",
+		}[name]
+		if got.Value != want || got.Currency != "EUR" {
+			t.Errorf("%s = %s %s, want EUR %s", name, got.Currency, got.Value, want)
+		}
+	}
+
+	// one subtotal per rate, lowest rate first
+	wantSubtotals := [][3]string{{"25.00", "1.75", "7.00"}, {"25.00", "4.75", "19.00"}}
+	if len(ubl.TaxTotal.Subtotals) != len(wantSubtotals) {
+		t.Fatalf("%d tax subtotals, want %d", len(ubl.TaxTotal.Subtotals), len(wantSubtotals))
+	}
+	for i, want := range wantSubtotals {
+		got := ubl.TaxTotal.Subtotals[i]
+		if got.TaxableAmount.Value != want[0] || got.TaxAmount.Value != want[1] || got.Percent != want[2] {
+			t.Errorf("tax subtotal %d = %s / %s at %s%%, want %s / %s at %s%%",
+				i, got.TaxableAmount.Value, got.TaxAmount.Value, got.Percent, want[0], want[1], want[2])
+		}
+	}
+
+	if len(ubl.InvoiceLines) != 3 || len(ubl.CreditNoteLines) != 0 {
+		t.Fatalf("%d invoice lines, %d credit note lines, want 3 and 0", len(ubl.InvoiceLines), len(ubl.CreditNoteLines))
+	}
+	first := ubl.InvoiceLines[0]
+	if first.ID != 1 || first.InvoicedQuantity != "2" || first.CreditedQuantity != "" || first.LineExtensionAmount.Value != "16.67" || first.Percent != "19.00" {
+		t.Errorf("first line = %+v", first)
+	}
+	if !bytes.HasPrefix(data, []byte(xml.Header)) {
+		t.Error("UBL does not start with the XML declaration")
+	}
+}
+
+func TestRenderUBLCreditNote(t *testing.T) {
+	invoice := testInvoice(models.InvoiceKindCreditNote)
+	ubl, data := renderParsedUBL(t, invoice)
+
+	if ubl.XMLName.Local != "CreditNote" || ubl.XMLName.Space != "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2" {
+		t.Errorf("root = %v, want the UBL CreditNote", ubl.XMLName)
+	}
+	if ubl.CreditNoteTypeCode != ublCreditNoteTypeCode || ubl.InvoiceTypeCode != "" {
+		t.Errorf("type codes %q / %q, want credit note type code %s only", ubl.InvoiceTypeCode, ubl.CreditNoteTypeCode, ublCreditNoteTypeCode)
+	}
+	if ubl.BillingReference != invoice.CreditedNumber {
+		t.Errorf("billing reference = %q, want the credited invoice %s", ubl.BillingReference, invoice.CreditedNumber)
+	}
+	if len(ubl.CreditNoteLines) != 3 || len(ubl.InvoiceLines) != 0 {
+		t.Fatalf("%d credit note lines, %d invoice lines, want 3 and 0", len(ubl.CreditNoteLines), len(ubl.InvoiceLines))
+	}
+	if line := ubl.CreditNoteLines[0]; line.CreditedQuantity != "2" || line.InvoicedQuantity != "" {
+		t.Errorf("first line quantities = %q credited / %q invoiced, want 2 credited", line.CreditedQuantity, line.InvoicedQuantity)
+	}
+
+	// credited amounts are positive; the document kind says they are credited
+	if ubl.MonetaryTotal.PayableAmount.Value != "58.30" || ubl.TaxTotal.TaxAmount.Value != "8.30" {
+		t.Errorf("payable %s, tax %s, want 58.30 and 8.30", ubl.MonetaryTotal.PayableAmount.Value, ubl.TaxTotal.TaxAmount.Value)
+	}
+	if amounts := regexp.MustCompile(`currencyID="EUR">-`).FindAll(data, -1); len(amounts) > 0 {
+		t.Errorf("credit note has %d negative amounts", len(amounts))
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	invoice := testInvoice(models.InvoiceKindInvoice)
+	doc, err := Render(FormatJSON, invoice, testSeller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ContentType != "application/json" || doc.FileName != invoice.Number+".json" {
+		t.Errorf("document %s %s", doc.ContentType, doc.FileName)
+	}
+
+	var got struct {
+		Seller      Seller               `json:"seller"`
+		Number      string               `json:"number"`
+		Kind        models.InvoiceKind   `json:"kind"`
+		TotalAmount float64              `json:"total_amount"`
+		Lines       []models.InvoiceLine `json:"lines"`
+	}
+	if err = json.Unmarshal(doc.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Seller != testSeller || got.Number != invoice.Number || got.Kind != models.InvoiceKindInvoice ||
+		got.TotalAmount != invoice.TotalAmount || len(got.Lines) != len(invoice.Lines) {
+		t.Errorf("JSON document = %+v", got)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      models.InvoiceKind
+		lines     int
+		wantTitle string
+		wantPages int
+	}{
+		{name: "invoice", kind: models.InvoiceKindInvoice, lines: 3, wantTitle: "INVOICE", wantPages: 1},
+		{name: "credit note", kind: models.InvoiceKindCreditNote, lines: 3, wantTitle: "CREDIT NOTE", wantPages: 1},
+		{name: "invoice over several pages", kind: models.InvoiceKindInvoice, lines: 150, wantTitle: "INVOICE", wantPages: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := testInvoice(tt.kind)
+			for len(invoice.Lines) < tt.lines {
+				invoice.Lines = append(invoice.Lines, invoice.Lines[0])
+			}
+
+			doc, err := Render("", invoice, testSeller)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.ContentType != "application/pdf" || doc.FileName != invoice.Number+".pdf" {
+				t.Errorf("document %s %s", doc.ContentType, doc.FileName)
+			}
+			data := doc.Data
+			if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+				t.Fatal("not a complete PDF document")
+			}
+			for _, text := range []string{"(" + tt.wantTitle + ")", "Number:     " + invoice.Number, "M\\374nchen", "Total EUR"} {
+				if !bytes.Contains(data, []byte(text)) {
+					t.Errorf("PDF does not show %q", text)
+				}
+			}
+			if tt.kind == models.InvoiceKindCreditNote && !bytes.Contains(data, []byte("Credits:    "+invoice.CreditedNumber)) {
+				t.Error("credit note does not name the credited invoice")
+			}
+			if pages := bytes.Count(data, []byte("/Type /Page /Parent")); pages != tt.wantPages {
+				t.Errorf("%d pages, want %d", pages, tt.wantPages)
+			}
+			checkXref(t, data)
+		})
+	}
+}
+
+// checkXref verifies that every cross-reference entry points at the object it numbers
+func checkXref(t *testing.T, data []byte) {
+	t.Helper()
+	start := bytes.LastIndex(data, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(data[start+len("startxref\n"):]))[0])
+	if err != nil || !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at the cross-reference table")
+	}
+	entries := strings.Split(string(data[xref:]), "\n")[3:]
+	for i, entry := range entries {
+		if !strings.HasSuffix(entry, " n ") {
+			break
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[offset:offset+10])
+		}
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := Render("docx", testInvoice(models.InvoiceKindInvoice), testSeller); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Render(docx) error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
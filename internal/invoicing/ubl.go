@@ -0,0 +1,246 @@
+package invoicing
+
+import (
+	"encoding/xml"
+	"fmt"
+	"order/internal/models"
+	"sort"
+)
+
+// UBL 2.1 type codes of commercial invoices and credit notes (UNTDID 1001)
+const (
+	ublInvoiceTypeCode    = "380"
+	ublCreditNoteTypeCode = "381"
+)
+
+type ublAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    int    `xml:",chardata"`
+}
+
+type ublTaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublTaxCategory struct {
+	Percent   string       `xml:"cbc:Percent"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublCountry struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+type ublAddress struct {
+	StreetName           string      `xml:"cbc:StreetName,omitempty"`
+	AdditionalStreetName string      `xml:"cbc:AdditionalStreetName,omitempty"`
+	CityName             string      `xml:"cbc:CityName,omitempty"`
+	PostalZone           string      `xml:"cbc:PostalZone,omitempty"`
+	CountrySubentity     string      `xml:"cbc:CountrySubentity,omitempty"`
+	Country              *ublCountry `xml:"cac:Country,omitempty"`
+}
+
+type ublPartyTaxScheme struct {
+	CompanyID string       `xml:"cbc:CompanyID"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublContact struct {
+	Name           string `xml:"cbc:Name,omitempty"`
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type ublParty struct {
+	Name           string             `xml:"cac:PartyName>cbc:Name"`
+	PostalAddress  ublAddress         `xml:"cac:PostalAddress"`
+	PartyTaxScheme *ublPartyTaxScheme `xml:"cac:PartyTaxScheme,omitempty"`
+	Contact        *ublContact        `xml:"cac:Contact,omitempty"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount    ublAmount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount  ublAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount   ublAmount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount   ublAmount  `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount *ublAmount `xml:"cbc:AllowanceTotalAmount,omitempty"`
+	PayableAmount        ublAmount  `xml:"cbc:PayableAmount"`
+}
+
+type ublItem struct {
+	Name                  string         `xml:"cbc:Name"`
+	SellersItemID         string         `xml:"cac:SellersItemIdentification>cbc:ID"`
+	ClassifiedTaxCategory ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type ublLine struct {
+	ID                  int          `xml:"cbc:ID"`
+	InvoicedQuantity    *ublQuantity `xml:"cbc:InvoicedQuantity,omitempty"`
+	CreditedQuantity    *ublQuantity `xml:"cbc:CreditedQuantity,omitempty"`
+	LineExtensionAmount ublAmount    `xml:"cbc:LineExtensionAmount"`
+	Item                ublItem      `xml:"cac:Item"`
+	PriceAmount         ublAmount    `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+// ublDocument is a UBL 2.1 Invoice or CreditNote; only the elements of its kind are set
+type ublDocument struct {
+	XMLName              xml.Name
+	Xmlns                string           `xml:"xmlns,attr"`
+	XmlnsCac             string           `xml:"xmlns:cac,attr"`
+	XmlnsCbc             string           `xml:"xmlns:cbc,attr"`
+	UBLVersionID         string           `xml:"cbc:UBLVersionID"`
+	ID                   string           `xml:"cbc:ID"`
+	IssueDate            string           `xml:"cbc:IssueDate"`
+	InvoiceTypeCode      string           `xml:"cbc:InvoiceTypeCode,omitempty"`
+	CreditNoteTypeCode   string           `xml:"cbc:CreditNoteTypeCode,omitempty"`
+	DocumentCurrencyCode string           `xml:"cbc:DocumentCurrencyCode"`
+	OrderReference       string           `xml:"cac:OrderReference>cbc:ID"`
+	BillingReference     string           `xml:"cac:BillingReference>cac:InvoiceDocumentReference>cbc:ID,omitempty"`
+	Supplier             ublParty         `xml:"cac:AccountingSupplierParty>cac:Party"`
+	Customer             ublParty         `xml:"cac:AccountingCustomerParty>cac:Party"`
+	TaxTotal             ublTaxTotal      `xml:"cac:TaxTotal"`
+	MonetaryTotal        ublMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines         []ublLine        `xml:"cac:InvoiceLine"`
+	CreditNoteLines      []ublLine        `xml:"cac:CreditNoteLine"`
+}
+
+func renderUBL(invoice *models.Invoice, seller Seller) ([]byte, error) {
+	amount := func(v float64) ublAmount {
+		return ublAmount{Currency: invoice.Currency, Value: fmt.Sprintf("%.2f", v)}
+	}
+	vat := ublTaxScheme{ID: "VAT"}
+
+	doc := ublDocument{
+		XMLName:              xml.Name{Local: "Invoice"},
+		Xmlns:                "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+		XmlnsCac:             "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+		XmlnsCbc:             "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+		UBLVersionID:         "2.1",
+		ID:                   invoice.Number,
+		IssueDate:            invoice.IssuedAt.Format("2006-01-02"),
+		InvoiceTypeCode:      ublInvoiceTypeCode,
+		DocumentCurrencyCode: invoice.Currency,
+		OrderReference:       invoice.OrderID.String(),
+		Supplier: ublParty{
+			Name:          seller.Name,
+			PostalAddress: ublAddress{StreetName: seller.Address},
+		},
+		Customer: ublParty{
+			Name: partyName(invoice),
+			PostalAddress: ublAddress{
+				StreetName:           invoice.BillingAddress.Line1,
+				AdditionalStreetName: invoice.BillingAddress.Line2,
+				CityName:             invoice.BillingAddress.City,
+				PostalZone:           invoice.BillingAddress.PostalCode,
+				CountrySubentity:     invoice.BillingAddress.Region,
+			},
+		},
+		TaxTotal: ublTaxTotal{TaxAmount: amount(invoice.TaxAmount)},
+		MonetaryTotal: ublMonetaryTotal{
+			LineExtensionAmount: amount(lineNetTotal(invoice)),
+			TaxExclusiveAmount:  amount(invoice.TotalAmount - invoice.TaxAmount),
+			TaxInclusiveAmount:  amount(invoice.TotalAmount),
+			PayableAmount:       amount(invoice.TotalAmount),
+		},
+	}
+	if invoice.Kind == models.InvoiceKindCreditNote {
+		doc.XMLName.Local = "CreditNote"
+		doc.Xmlns = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+		doc.InvoiceTypeCode = ""
+		doc.CreditNoteTypeCode = ublCreditNoteTypeCode
+		doc.BillingReference = invoice.CreditedNumber
+	}
+	if seller.Country != "" {
+		doc.Supplier.PostalAddress.Country = &ublCountry{IdentificationCode: seller.Country}
+	}
+	if seller.TaxID != "" {
+		doc.Supplier.PartyTaxScheme = &ublPartyTaxScheme{CompanyID: seller.TaxID, TaxScheme: vat}
+	}
+	if invoice.BillingAddress.Country != "" {
+		doc.Customer.PostalAddress.Country = &ublCountry{IdentificationCode: invoice.BillingAddress.Country}
+	}
+	if !invoice.Customer.IsZero() {
+		doc.Customer.Contact = &ublContact{
+			Name:           invoice.Customer.Name,
+			Telephone:      invoice.Customer.Phone,
+			ElectronicMail: invoice.Customer.Email,
+		}
+	}
+	if invoice.DiscountAmount > 0 {
+		discount := amount(invoice.DiscountAmount)
+		doc.MonetaryTotal.AllowanceTotalAmount = &discount
+	}
+
+	// one tax subtotal per rate
+	taxable := map[float64][2]float64{}
+	for i, line := range invoice.Lines {
+		sums := taxable[line.TaxRate]
+		taxable[line.TaxRate] = [2]float64{sums[0] + line.NetAmount, sums[1] + line.TaxAmount}
+
+		ublLine := ublLine{
+			ID:                  i + 1,
+			LineExtensionAmount: amount(line.NetAmount),
+			Item: ublItem{
+				Name:                  "Product " + line.ProductID.String(),
+				SellersItemID:         line.ProductID.String(),
+				ClassifiedTaxCategory: ublTaxCategory{Percent: percent(line.TaxRate), TaxScheme: vat},
+			},
+			PriceAmount: amount(line.UnitPrice),
+		}
+		quantity := &ublQuantity{UnitCode: "C62", Value: line.Quantity}
+		if invoice.Kind == models.InvoiceKindCreditNote {
+			ublLine.CreditedQuantity = quantity
+			doc.CreditNoteLines = append(doc.CreditNoteLines, ublLine)
+		} else {
+			ublLine.InvoicedQuantity = quantity
+			doc.InvoiceLines = append(doc.InvoiceLines, ublLine)
+		}
+	}
+	rates := make([]float64, 0, len(taxable))
+	for rate := range taxable {
+		rates = append(rates, rate)
+	}
+	sort.Float64s(rates)
+	for _, rate := range rates {
+		doc.TaxTotal.TaxSubtotals = append(doc.TaxTotal.TaxSubtotals, ublTaxSubtotal{
+			TaxableAmount: amount(taxable[rate][0]),
+			TaxAmount:     amount(taxable[rate][1]),
+			TaxCategory:   ublTaxCategory{Percent: percent(rate), TaxScheme: vat},
+		})
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func partyName(invoice *models.Invoice) string {
+	if invoice.BillingAddress.Name != "" {
+		return invoice.BillingAddress.Name
+	}
+	if invoice.Customer.Name != "" {
+		return invoice.Customer.Name
+	}
+	return invoice.CustomerID.String()
+}
+
+func percent(rate float64) string {
+	return fmt.Sprintf("%.2f", rate*100)
+}
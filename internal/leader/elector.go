@@ -0,0 +1,175 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"order/internal/metrics"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/logger"
+)
+
+// Callbacks are invoked on leadership changes. OnAcquire runs in its own goroutine with a
+// context that is cancelled as soon as leadership is lost, and has to return once it is:
+// OnLose runs, and the lock is released, only after OnAcquire returned, so its work never
+// overlaps with that of the next leadership.
+type Callbacks struct {
+	OnAcquire func(ctx context.Context)
+	OnLose    func()
+}
+
+// Elector campaigns for leadership of a named election using a Postgres session-level
+// advisory lock. The lock lives as long as the dedicated connection holding it, which is
+// kept alive by a periodic ping; a failed ping means the lock may be gone and
+// leadership is given up.
+type Elector struct {
+	pg                pgGorm.PGInterface
+	name              string
+	key               int64
+	instanceID        string
+	callbacks         Callbacks
+	retryInterval     time.Duration
+	keepAliveInterval time.Duration
+	isLeader          atomic.Bool
+}
+
+func NewElector(pg pgGorm.PGInterface, name string, callbacks Callbacks) *Elector {
+	hostname, _ := os.Hostname()
+	return &Elector{
+		pg:                pg,
+		name:              name,
+		key:               lockKey(name),
+		instanceID:        fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		callbacks:         callbacks,
+		retryInterval:     5 * time.Second,
+		keepAliveInterval: 5 * time.Second,
+	}
+}
+
+// lockKey maps an election name onto the bigint key space of pg advisory locks
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("leader:" + name))
+	return int64(h.Sum64())
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *Elector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Run campaigns until ctx is cancelled, re-campaigning whenever leadership is lost.
+func (e *Elector) Run(ctx context.Context) {
+	log := logger.WithTag("Elector|Run")
+	metrics.SetLeader(e.name, e.instanceID, false)
+
+	for {
+		if err := e.campaign(ctx); err != nil && ctx.Err() == nil {
+			logger.LogError(log, err, "leader election "+e.name+" failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.retryInterval):
+		}
+	}
+}
+
+// campaign tries to take the lock once and, on success, holds it until the connection
+// breaks or ctx is cancelled. The lock is released before the connection goes back to the pool.
+func (e *Elector) campaign(ctx context.Context) error {
+	sqlDB, err := e.pg.GetRepo().DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil {
+		// the lock may have been taken before the result got lost
+		discard(conn)
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+
+	defer e.release(conn)
+	e.lead(ctx, conn)
+	return nil
+}
+
+// release unlocks the election so another instance can take over without waiting for the
+// session to end. A connection that cannot confirm the unlock is discarded, since its session
+// would keep the lock while sitting in the pool.
+func (e *Elector) release(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var unlocked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", e.key).Scan(&unlocked); err == nil && unlocked {
+		return
+	}
+	discard(conn)
+}
+
+// discard closes the session of conn instead of returning it to the pool
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+}
+
+func (e *Elector) lead(ctx context.Context, conn *sql.Conn) {
+	log := logger.WithTag("Elector|lead")
+	log.Infof("instance %s acquired leadership of %s", e.instanceID, e.name)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	e.isLeader.Store(true)
+	metrics.SetLeader(e.name, e.instanceID, true)
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		if e.callbacks.OnAcquire != nil {
+			e.callbacks.OnAcquire(leaderCtx)
+		}
+	}()
+
+	defer func() {
+		cancel()
+		<-acquired
+		e.isLeader.Store(false)
+		metrics.SetLeader(e.name, e.instanceID, false)
+		if e.callbacks.OnLose != nil {
+			e.callbacks.OnLose()
+		}
+		log.Infof("instance %s lost leadership of %s", e.instanceID, e.name)
+	}()
+
+	ticker := time.NewTicker(e.keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, e.keepAliveInterval)
+			_, err := conn.ExecContext(pingCtx, "SELECT 1")
+			pingCancel()
+			if err != nil {
+				logger.LogError(log, err, "leader session keepalive failed for "+e.name)
+				return
+			}
+		}
+	}
+}
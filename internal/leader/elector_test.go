@@ -0,0 +1,410 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"order/internal/pgtest"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+// fakeLocks is the advisory lock table of a fake database, shared by its sessions. Locks are
+// held per session and end with it, as in Postgres.
+type fakeLocks struct {
+	mu         sync.Mutex
+	holders    map[int64]*fakeSession
+	failPing   atomic.Bool
+	failUnlock atomic.Bool
+	closed     atomic.Int32
+}
+
+func (l *fakeLocks) Connect(context.Context) (driver.Conn, error) {
+	return &fakeSession{locks: l}, nil
+}
+
+func (l *fakeLocks) Driver() driver.Driver { return nil }
+
+func (l *fakeLocks) holder(key int64) *fakeSession {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.holders[key]
+}
+
+type fakeSession struct {
+	locks *fakeLocks
+}
+
+func (s *fakeSession) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	key := args[0].Value.(int64)
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+
+	switch query {
+	case "SELECT pg_try_advisory_lock($1)":
+		if holder, ok := s.locks.holders[key]; ok && holder != s {
+			return &boolRows{value: false}, nil
+		}
+		s.locks.holders[key] = s
+		return &boolRows{value: true}, nil
+	case "SELECT pg_advisory_unlock($1)":
+		if s.locks.failUnlock.Load() {
+			return nil, errors.New("connection reset")
+		}
+		if s.locks.holders[key] != s {
+			return &boolRows{value: false}, nil
+		}
+		delete(s.locks.holders, key)
+		return &boolRows{value: true}, nil
+	}
+	return nil, errors.New("unexpected query " + query)
+}
+
+func (s *fakeSession) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if s.locks.failPing.Load() {
+		return nil, errors.New("connection reset")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *fakeSession) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *fakeSession) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+// Close ends the session and with it the locks it holds
+func (s *fakeSession) Close() error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	for key, holder := range s.locks.holders {
+		if holder == s {
+			delete(s.locks.holders, key)
+		}
+	}
+	s.locks.closed.Add(1)
+	return nil
+}
+
+type boolRows struct {
+	value bool
+	read  bool
+}
+
+func (r *boolRows) Columns() []string { return []string{"result"} }
+func (r *boolRows) Close() error      { return nil }
+
+func (r *boolRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = r.value
+	return nil
+}
+
+type fakePG struct {
+	pgGorm.PGInterface
+	db *gorm.DB
+}
+
+func (f fakePG) GetRepo() *gorm.DB {
+	return f.db
+}
+
+func newFakePG(t *testing.T, locks *fakeLocks) pgGorm.PGInterface {
+	t.Helper()
+	sqlDB := sql.OpenDB(locks)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}),
+		&gorm.Config{DisableAutomaticPing: true, Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fakePG{db: db}
+}
+
+// leaderGauge reads the leadership metric of instance in election
+func leaderGauge(t *testing.T, election, instance string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "leader_election_is_leader" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["election"] == election && labels["instance"] == instance {
+				return m.GetGauge().GetValue()
+			}
+		}
+	}
+	return -1
+}
+
+// leadership records the callbacks an elector makes
+type leadership struct {
+	mu         sync.Mutex
+	changes    []string
+	acquireCtx context.Context
+	acquired   chan struct{}
+	lost       chan struct{}
+}
+
+func (l *leadership) onAcquire(ctx context.Context) {
+	l.mu.Lock()
+	l.changes = append(l.changes, "acquire")
+	l.acquireCtx = ctx
+	l.mu.Unlock()
+	select {
+	case l.acquired <- struct{}{}:
+	default:
+	}
+}
+
+// onLose also records whether the work started on acquire was already stopped
+func (l *leadership) onLose() {
+	l.mu.Lock()
+	change := "lose while acquired context is live"
+	if l.acquireCtx.Err() != nil {
+		change = "lose"
+	}
+	l.changes = append(l.changes, change)
+	l.mu.Unlock()
+	select {
+	case l.lost <- struct{}{}:
+	default:
+	}
+}
+
+func (l *leadership) history() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.changes...)
+}
+
+// testElector campaigns quickly and records its leadership changes
+func testElector(pg pgGorm.PGInterface, name, instanceID string) (*Elector, *leadership) {
+	l := &leadership{acquired: make(chan struct{}, 16), lost: make(chan struct{}, 16)}
+	e := NewElector(pg, name, Callbacks{OnAcquire: l.onAcquire, OnLose: l.onLose})
+	e.instanceID = instanceID
+	e.retryInterval = 10 * time.Millisecond
+	e.keepAliveInterval = 10 * time.Millisecond
+	return e, l
+}
+
+// run campaigns until the test ends and returns the function stopping the campaign
+func run(t *testing.T, e *Elector) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+	stop = func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+func waitFor(t *testing.T, signal <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-signal:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting until %s", what)
+	}
+}
+
+func assertHistory(t *testing.T, l *leadership, want ...string) {
+	t.Helper()
+	got := l.history()
+	if len(got) != len(want) {
+		t.Fatalf("leadership changes %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("leadership changes %v, want %v", got, want)
+		}
+	}
+}
+
+func TestElectorLosesLeadershipWhenKeepaliveFails(t *testing.T) {
+	locks := &fakeLocks{holders: map[int64]*fakeSession{}}
+	e, l := testElector(newFakePG(t, locks), "keepalive", "instance-a")
+	stop := run(t, e)
+
+	waitFor(t, l.acquired, "leadership is acquired")
+	if !e.IsLeader() || leaderGauge(t, "keepalive", "instance-a") != 1 {
+		t.Fatal("acquired leadership is not reported")
+	}
+	if locks.holder(e.key) == nil {
+		t.Fatal("leader does not hold the advisory lock")
+	}
+
+	locks.failPing.Store(true)
+	waitFor(t, l.lost, "leadership is lost")
+	if e.IsLeader() || leaderGauge(t, "keepalive", "instance-a") != 0 {
+		t.Error("lost leadership is still reported")
+	}
+
+	// the lock is given back and taken again once the database answers again
+	locks.failPing.Store(false)
+	waitFor(t, l.acquired, "leadership is acquired again")
+
+	stop()
+	assertHistory(t, l, "acquire", "lose", "acquire", "lose")
+	if e.IsLeader() || locks.holder(e.key) != nil {
+		t.Error("leadership kept after Run returned")
+	}
+}
+
+func TestElectorWaitsForOnAcquireToReturn(t *testing.T) {
+	locks := &fakeLocks{holders: map[int64]*fakeSession{}}
+	l := &leadership{acquired: make(chan struct{}, 16), lost: make(chan struct{}, 16)}
+	var running, overlaps atomic.Int32
+	var e *Elector
+	e = NewElector(newFakePG(t, locks), "slow", Callbacks{
+		// winds down for a while after the loss, like a scheduler waiting for its running jobs
+		OnAcquire: func(ctx context.Context) {
+			if running.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			l.onAcquire(ctx)
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			if locks.holder(e.key) == nil {
+				t.Error("lock released while OnAcquire still runs")
+			}
+			running.Add(-1)
+		},
+		OnLose: func() {
+			if running.Load() != 0 {
+				overlaps.Add(1)
+			}
+			l.onLose()
+		},
+	})
+	e.retryInterval = 10 * time.Millisecond
+	e.keepAliveInterval = 10 * time.Millisecond
+	stop := run(t, e)
+
+	waitFor(t, l.acquired, "leadership is acquired")
+	// lose leadership and take it again at once, while the first OnAcquire winds down
+	locks.failPing.Store(true)
+	time.Sleep(15 * time.Millisecond)
+	locks.failPing.Store(false)
+	waitFor(t, l.lost, "leadership is lost")
+	waitFor(t, l.acquired, "leadership is acquired again")
+
+	stop()
+	if overlaps.Load() != 0 {
+		t.Errorf("%d callbacks ran while an OnAcquire had not returned", overlaps.Load())
+	}
+	assertHistory(t, l, "acquire", "lose", "acquire", "lose")
+}
+
+func TestElectorDiscardsSessionThatCannotUnlock(t *testing.T) {
+	locks := &fakeLocks{holders: map[int64]*fakeSession{}}
+	e, l := testElector(newFakePG(t, locks), "unlock", "instance-a")
+	stop := run(t, e)
+	waitFor(t, l.acquired, "leadership is acquired")
+
+	locks.failUnlock.Store(true)
+	stop()
+
+	if locks.closed.Load() == 0 {
+		t.Error("session that could not unlock went back to the pool")
+	}
+	if locks.holder(e.key) != nil {
+		t.Error("advisory lock still held after shutdown")
+	}
+}
+
+func TestElectorReturnsUnlockedSessionToThePool(t *testing.T) {
+	locks := &fakeLocks{holders: map[int64]*fakeSession{}}
+	e, l := testElector(newFakePG(t, locks), "release", "instance-a")
+	stop := run(t, e)
+	waitFor(t, l.acquired, "leadership is acquired")
+
+	stop()
+	if locks.closed.Load() != 0 {
+		t.Error("session closed although its lock was released")
+	}
+	if locks.holder(e.key) != nil {
+		t.Error("advisory lock still held after shutdown")
+	}
+}
+
+func TestElectorWaitsForTheHolderOfTheLock(t *testing.T) {
+	locks := &fakeLocks{holders: map[int64]*fakeSession{}}
+	e, l := testElector(newFakePG(t, locks), "busy", "instance-b")
+	other := &fakeSession{locks: locks}
+	locks.holders[e.key] = other
+	run(t, e)
+
+	time.Sleep(50 * time.Millisecond)
+	if e.IsLeader() || len(l.history()) != 0 {
+		t.Fatal("elector leads while another session holds the lock")
+	}
+
+	_ = other.Close()
+	waitFor(t, l.acquired, "the freed lock is acquired")
+}
+
+func TestLockKey(t *testing.T) {
+	if lockKey("scheduler") != lockKey("scheduler") {
+		t.Error("lock key of an election is not stable")
+	}
+	if lockKey("scheduler") == lockKey("outbox") {
+		t.Error("different elections share a lock key")
+	}
+}
+
+func TestElectorsTakeOverOnPostgres(t *testing.T) {
+	pg := pgtest.Open(t)
+
+	first, firstLeadership := testElector(pg, "takeover-"+uuid.NewString(), "instance-a")
+	second, secondLeadership := testElector(pg, first.name, "instance-b")
+
+	stopFirst := run(t, first)
+	waitFor(t, firstLeadership.acquired, "the first elector leads")
+	run(t, second)
+
+	// the second elector keeps campaigning without success while the first leads
+	time.Sleep(100 * time.Millisecond)
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("leaders: first %v, second %v, want only the first", first.IsLeader(), second.IsLeader())
+	}
+
+	stopFirst()
+	waitFor(t, secondLeadership.acquired, "the second elector takes over")
+	if first.IsLeader() || !second.IsLeader() {
+		t.Fatalf("leaders: first %v, second %v, want only the second", first.IsLeader(), second.IsLeader())
+	}
+	if leaderGauge(t, first.name, "instance-a") != 0 || leaderGauge(t, first.name, "instance-b") != 1 {
+		t.Error("leadership metric does not follow the takeover")
+	}
+	assertHistory(t, firstLeadership, "acquire", "lose")
+}
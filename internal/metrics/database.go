@@ -0,0 +1,14 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterDBStats exports the pool stats of db as the go_sql_* metrics labeled db_name=name,
+// eg. open and idle connections, waits and connections closed by the pool limits
+func RegisterDBStats(name string, db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
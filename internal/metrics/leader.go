@@ -0,0 +1,24 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var leaderElectionIsLeader = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "leader_election_is_leader",
+		Help: "Whether this instance currently holds the leadership of an election (1) or not (0)",
+	},
+	[]string{"election", "instance"},
+)
+
+func init() {
+	prometheus.MustRegister(leaderElectionIsLeader)
+}
+
+// SetLeader records the current leadership state of this instance for an election.
+func SetLeader(election, instance string, isLeader bool) {
+	value := float64(0)
+	if isLeader {
+		value = 1
+	}
+	leaderElectionIsLeader.WithLabelValues(election, instance).Set(value)
+}
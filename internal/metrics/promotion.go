@@ -0,0 +1,117 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	promotionRewardsIssued = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_rewards_issued",
+			Help: "Number of rewards issued per promotion",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+	promotionDistinctCustomers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_distinct_customers",
+			Help: "Number of distinct customers rewarded per promotion",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+	promotionQualifyingRevenue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_qualifying_revenue",
+			Help: "Revenue of orders that qualified for a promotion reward",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+	promotionAOVUplift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_aov_uplift",
+			Help: "Average order value of qualifying orders minus non-qualifying orders",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+	promotionSpend = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_spend",
+			Help: "Monetary value of rewards issued per promotion",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+	promotionRemainingRewards = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_remaining_rewards",
+			Help: "Rewards left before the promotion reward cap is reached (-1 when uncapped)",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+	promotionRemainingCustomerSlots = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_remaining_customer_slots",
+			Help: "Customers left before the promotion customer cap is reached (-1 when uncapped)",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+	promotionRemainingBudget = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "promotion_remaining_budget",
+			Help: "Budget left before the promotion is deactivated (-1 when uncapped)",
+		},
+		[]string{"tenant", "promotion_id", "promotion"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		promotionRewardsIssued,
+		promotionDistinctCustomers,
+		promotionQualifyingRevenue,
+		promotionAOVUplift,
+		promotionSpend,
+		promotionRemainingRewards,
+		promotionRemainingCustomerSlots,
+		promotionRemainingBudget,
+	)
+}
+
+// PromotionStats is the set of figures exported per promotion
+type PromotionStats struct {
+	Tenant                 string
+	ID                     string
+	Name                   string
+	RewardsIssued          int64
+	DistinctCustomers      int64
+	QualifyingRevenue      float64
+	AOVUplift              float64
+	Spend                  float64
+	RemainingRewards       *int64
+	RemainingCustomerSlots *int64
+	RemainingBudget        *float64
+}
+
+// ObservePromotion updates the promotion gauges with the latest analytics figures.
+func ObservePromotion(s PromotionStats) {
+	promotionRewardsIssued.WithLabelValues(s.Tenant, s.ID, s.Name).Set(float64(s.RewardsIssued))
+	promotionDistinctCustomers.WithLabelValues(s.Tenant, s.ID, s.Name).Set(float64(s.DistinctCustomers))
+	promotionQualifyingRevenue.WithLabelValues(s.Tenant, s.ID, s.Name).Set(s.QualifyingRevenue)
+	promotionAOVUplift.WithLabelValues(s.Tenant, s.ID, s.Name).Set(s.AOVUplift)
+	promotionSpend.WithLabelValues(s.Tenant, s.ID, s.Name).Set(s.Spend)
+
+	remainingRewards := float64(-1)
+	if s.RemainingRewards != nil {
+		remainingRewards = float64(*s.RemainingRewards)
+	}
+	promotionRemainingRewards.WithLabelValues(s.Tenant, s.ID, s.Name).Set(remainingRewards)
+
+	remainingSlots := float64(-1)
+	if s.RemainingCustomerSlots != nil {
+		remainingSlots = float64(*s.RemainingCustomerSlots)
+	}
+	promotionRemainingCustomerSlots.WithLabelValues(s.Tenant, s.ID, s.Name).Set(remainingSlots)
+
+	remainingBudget := float64(-1)
+	if s.RemainingBudget != nil {
+		remainingBudget = *s.RemainingBudget
+	}
+	promotionRemainingBudget.WithLabelValues(s.Tenant, s.ID, s.Name).Set(remainingBudget)
+}
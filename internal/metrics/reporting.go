@@ -0,0 +1,66 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	orderReportOrdersToday = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "order_report_orders_today",
+			Help: "Orders created today (UTC) per tenant, currency and status, as of the last stats refresh",
+		},
+		[]string{"tenant", "currency", "status"},
+	)
+	orderReportRevenueToday = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "order_report_revenue_today",
+			Help: "Revenue net of refunds of the paid orders created today (UTC), in the order currency",
+		},
+		[]string{"tenant", "currency"},
+	)
+	orderReportAOVToday = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "order_report_aov_today",
+			Help: "Average order value of the paid orders created today (UTC), in the order currency",
+		},
+		[]string{"tenant", "currency"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		orderReportOrdersToday,
+		orderReportRevenueToday,
+		orderReportAOVToday,
+	)
+}
+
+// OrderReportStats is the set of headline figures exported per tenant and currency
+type OrderReportStats struct {
+	Tenant   string
+	Currency string
+	// Orders counts the orders by status
+	Orders     map[string]int64
+	PaidOrders int64
+	Revenue    float64
+}
+
+// ObserveOrderReport replaces the headline gauges with stats, dropping tenants and currencies
+// that no longer have orders today
+func ObserveOrderReport(stats []OrderReportStats) {
+	orderReportOrdersToday.Reset()
+	orderReportRevenueToday.Reset()
+	orderReportAOVToday.Reset()
+
+	for _, s := range stats {
+		for status, count := range s.Orders {
+			orderReportOrdersToday.WithLabelValues(s.Tenant, s.Currency, status).Set(float64(count))
+		}
+		orderReportRevenueToday.WithLabelValues(s.Tenant, s.Currency).Set(s.Revenue)
+
+		aov := float64(0)
+		if s.PaidOrders > 0 {
+			aov = s.Revenue / float64(s.PaidOrders)
+		}
+		orderReportAOVToday.WithLabelValues(s.Tenant, s.Currency).Set(aov)
+	}
+}
@@ -0,0 +1,112 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const Usage = `usage: order migrate <command>
+
+  up                     apply the pending migrations
+  down [n]               roll back the last n applied migrations (default 1), short of the baseline
+  status                 list the migrations and whether they are applied
+  create [-dir d] <name> write an empty up and down file for a new migration`
+
+var ErrUsage = errors.New(Usage)
+
+// Command runs the migrate subcommand of args. open is only called by the commands that need
+// the database.
+func Command(ctx context.Context, args []string, open func() (*sql.DB, error), out io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	if args[0] == "create" {
+		flags := flag.NewFlagSet("create", flag.ContinueOnError)
+		flags.SetOutput(out)
+		dir := flags.String("dir", Dir, "directory of the migration files")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 1 {
+			return ErrUsage
+		}
+		paths, err := Create(*dir, flags.Arg(0), time.Now())
+		for _, path := range paths {
+			_, _ = fmt.Fprintln(out, "created", path)
+		}
+		return err
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+	case "down":
+		if len(args) > 2 {
+			return ErrUsage
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return ErrUsage
+			}
+			steps = n
+		}
+	default:
+		return ErrUsage
+	}
+
+	db, err := open()
+	if err != nil {
+		return err
+	}
+	migrator, err := New(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up(ctx)
+		_, _ = fmt.Fprintf(out, "applied %d migration(s)\n", count)
+		return err
+	case "down":
+		count, err := migrator.Down(ctx, steps)
+		_, _ = fmt.Fprintf(out, "rolled back %d migration(s)\n", count)
+		return err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatus(out, statuses)
+	}
+}
+
+func printStatus(out io.Writer, statuses []Status) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", "-"
+		switch {
+		case status.Missing:
+			state = "missing"
+		case status.Modified:
+			state = "modified"
+		case status.Applied:
+			state = "applied"
+		}
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	errOpen := errors.New("database unreachable")
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		args []string
+		// opens tells whether the arguments are accepted up to the point the database is needed
+		opens   bool
+		wantErr error
+		wantOut string
+	}{
+		{name: "no command", args: nil, wantErr: ErrUsage},
+		{name: "unknown command", args: []string{"redo"}, wantErr: ErrUsage},
+		{name: "up with an argument", args: []string{"up", "1"}, wantErr: ErrUsage},
+		{name: "status with an argument", args: []string{"status", "all"}, wantErr: ErrUsage},
+		{name: "down with a word", args: []string{"down", "all"}, wantErr: ErrUsage},
+		{name: "down zero", args: []string{"down", "0"}, wantErr: ErrUsage},
+		{name: "down negative", args: []string{"down", "-2"}, wantErr: ErrUsage},
+		{name: "down with two arguments", args: []string{"down", "1", "2"}, wantErr: ErrUsage},
+		{name: "create without a name", args: []string{"create"}, wantErr: ErrUsage},
+		{name: "create with two names", args: []string{"create", "a", "b"}, wantErr: ErrUsage},
+		{name: "create with an unknown flag", args: []string{"create", "-force", "a"}, wantErr: ErrUsage},
+		{name: "create with an invalid name", args: []string{"create", "-dir", dir, "!!"}, wantErr: ErrInvalidName},
+		{name: "create", args: []string{"create", "-dir", dir, "add_index"}, wantOut: "created " + filepath.Join(dir, "2")},
+		{name: "up", args: []string{"up"}, opens: true, wantErr: errOpen},
+		{name: "down", args: []string{"down"}, opens: true, wantErr: errOpen},
+		{name: "down n", args: []string{"down", "3"}, opens: true, wantErr: errOpen},
+		{name: "status", args: []string{"status"}, opens: true, wantErr: errOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened := false
+			open := func() (*sql.DB, error) {
+				opened = true
+				return nil, errOpen
+			}
+			var out bytes.Buffer
+
+			err := Command(context.Background(), tt.args, open, &out)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if opened != tt.opens {
+				t.Errorf("opened the database: %v, want %v", opened, tt.opens)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Errorf("create wrote %d files, want an up and a down file: %v", len(entries), err)
+	}
+}
@@ -0,0 +1,11 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Locked runs fn holding the migration lock, for the tests of package migrations_test
+func (m *Migrator) Locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.locked(ctx, fn)
+}
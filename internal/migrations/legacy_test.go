@@ -0,0 +1,210 @@
+package migrations_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/migrations"
+	"order/internal/pgtest"
+)
+
+// The models as of the last gormigrate release, 20230523172948, whose AutoMigrate built the schema
+// the baseline has to upgrade
+
+type legacyBase struct {
+	ID        uuid.UUID `gorm:"primary_key;type:uuid;default:uuid_generate_v4()"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	DeletedAt *gorm.DeletedAt
+}
+
+type legacyOrder struct {
+	legacyBase
+	CustomerID        uuid.UUID              `gorm:"type:uuid;not null;index"`
+	TotalAmount       float64                `gorm:"type:decimal(10,2);not null"`
+	Status            string                 `gorm:"type:varchar(20);not null;index"`
+	RewardGiven       bool                   `gorm:"type:boolean;not null;default:false"`
+	OrderItems        []legacyOrderItem      `gorm:"foreignKey:OrderID"`
+	PromotionConfigID *uuid.UUID             `gorm:"type:uuid;index"`
+	PromotionConfig   *legacyPromotionConfig `gorm:"foreignKey:PromotionConfigID;references:ID"`
+}
+
+func (legacyOrder) TableName() string { return "orders" }
+
+type legacyOrderItem struct {
+	legacyBase
+	OrderID   uuid.UUID   `gorm:"type:uuid;not null;index"`
+	ProductID uuid.UUID   `gorm:"type:uuid;not null;index"`
+	Quantity  int         `gorm:"type:int;not null"`
+	UnitPrice float64     `gorm:"type:decimal(10,2);not null"`
+	Order     legacyOrder `gorm:"foreignKey:OrderID;references:ID"`
+}
+
+func (legacyOrderItem) TableName() string { return "order_items" }
+
+type legacyPromotionConfig struct {
+	legacyBase
+	Name            string                  `gorm:"type:varchar(100);not null;unique"`
+	CustomerLimit   int                     `gorm:"type:int;not null;default:1"`
+	RewardLimit     int                     `gorm:"type:int;not null;default:1"`
+	MinOrderValue   float64                 `gorm:"type:decimal(10,2);not null;default:0.00"`
+	IsActive        bool                    `gorm:"type:boolean;not null;default:true"`
+	StartTime       time.Time               `gorm:"type:timestamp;not null"`
+	EndTime         time.Time               `gorm:"type:timestamp;not null"`
+	PromotionReward []legacyPromotionReward `gorm:"foreignKey:PromotionConfigID"`
+	Order           []legacyOrder           `gorm:"foreignKey:PromotionConfigID;references:ID"`
+}
+
+func (legacyPromotionConfig) TableName() string { return "promotion_configs" }
+
+type legacyPromotionReward struct {
+	legacyBase
+	PromotionConfigID uuid.UUID              `gorm:"type:uuid;not null;index"`
+	PromotionConfig   *legacyPromotionConfig `gorm:"foreignKey:PromotionConfigID;references:ID"`
+	OrderID           uuid.UUID              `gorm:"type:uuid;not null;index"`
+	CustomerID        uuid.UUID              `gorm:"type:uuid;not null;index"`
+	ReceivedAt        time.Time              `gorm:"type:timestamp;not null"`
+}
+
+func (legacyPromotionReward) TableName() string { return "promotion_rewards" }
+
+type legacyOutbox struct {
+	legacyBase
+	EventID       uuid.UUID `gorm:"type:uuid;uniqueIndex;not null"`
+	EventType     string    `gorm:"type:varchar(100);not null"`
+	Payload       string    `gorm:"type:jsonb;not null"`
+	AggregateType string    `gorm:"size:100;not null"`
+	AggregateID   uuid.UUID `gorm:"type:uuid;index"`
+	Status        string    `gorm:"size:20;not null;index"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"index"`
+	ProcessedAt   *time.Time
+}
+
+func (legacyOutbox) TableName() string { return "outbox" }
+
+// openLegacy returns a schema as the gormigrate migrations left it after the given IDs ran
+func openLegacy(t *testing.T, ids ...string) *sql.DB {
+	t.Helper()
+	db := pgtest.OpenEmpty(t)
+	if err := db.Exec(`CREATE TABLE migrations (id varchar(255) PRIMARY KEY)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if err := db.Exec(`INSERT INTO migrations (id) VALUES (?)`, id).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AutoMigrate(&legacyOrder{}, &legacyOrderItem{}, &legacyPromotionReward{}, &legacyPromotionConfig{}, &legacyOutbox{}); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+// shape lists the columns with their types and the indexes of the current schema
+func shape(t *testing.T, db *sql.DB) map[string]bool {
+	t.Helper()
+	rows, err := db.Query(`
+		SELECT table_name || '.' || column_name || ' ' || data_type || coalesce('(' || character_maximum_length || ')', '') ||
+			coalesce('(' || numeric_precision || ',' || numeric_scale || ')', '') || ' nullable=' || is_nullable
+		FROM information_schema.columns WHERE table_schema = current_schema() AND table_name NOT IN ('migrations', 'schema_migrations')
+		UNION ALL
+		SELECT 'index ' || indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename NOT IN ('migrations', 'schema_migrations')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	items := map[string]bool{}
+	for rows.Next() {
+		var item string
+		if err = rows.Scan(&item); err != nil {
+			t.Fatal(err)
+		}
+		items[item] = true
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+func TestUpUpgradesTheLastLegacyRelease(t *testing.T) {
+	ctx := context.Background()
+	legacy := openLegacy(t, "20220523172948", "20230523172948")
+
+	promoID, orderID := uuid.New(), uuid.New()
+	for _, statement := range []string{
+		fmt.Sprintf(`INSERT INTO promotion_configs (id, name, start_time, end_time) VALUES ('%s', 'spring', now(), now())`, promoID),
+		fmt.Sprintf(`INSERT INTO orders (id, customer_id, total_amount, status, promotion_config_id) VALUES ('%s', '%s', 30, 'PENDING', '%s')`,
+			orderID, uuid.New(), promoID),
+		fmt.Sprintf(`INSERT INTO order_items (order_id, product_id, quantity, unit_price) VALUES ('%s', '%s', 3, 10)`, orderID, uuid.New()),
+	} {
+		if _, err := legacy.ExecContext(ctx, statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := migrations.New(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := m.Up(ctx); err != nil || count != 1 {
+		t.Fatalf("Up = %d, %v; want the baseline applied", count, err)
+	}
+	if count, err := m.Up(ctx); err != nil || count != 0 {
+		t.Fatalf("second Up = %d, %v; want nothing to apply", count, err)
+	}
+	if err = m.Check(ctx); err != nil {
+		t.Fatalf("Check after the upgrade: %v", err)
+	}
+
+	// the upgraded schema is the one a new database gets
+	fresh, err := pgtest.Open(t).GetRepo().DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgraded, want := shape(t, legacy), shape(t, fresh)
+	for item := range want {
+		if !upgraded[item] {
+			t.Errorf("upgraded schema lacks %s", item)
+		}
+	}
+	for item := range upgraded {
+		if !want[item] {
+			t.Errorf("upgraded schema has %s, which a new one does not", item)
+		}
+	}
+
+	// the rows of the legacy release belong to the default tenant
+	var tenantID, currency string
+	if err = legacy.QueryRowContext(ctx, `SELECT tenant_id, currency FROM orders WHERE id = $1`, orderID).Scan(&tenantID, &currency); err != nil {
+		t.Fatal(err)
+	}
+	if tenantID != "default" || currency != "" {
+		t.Errorf("legacy order has tenant %q and currency %q, want default and none", tenantID, currency)
+	}
+	if _, err = legacy.ExecContext(ctx, `INSERT INTO promotion_configs (tenant_id, name, start_time, end_time) VALUES ('acme', 'spring', now(), now())`); err != nil {
+		t.Errorf("promotion name of the default tenant taken in another tenant: %v", err)
+	}
+}
+
+func TestUpRefusesLegacyShortOfTheLastRelease(t *testing.T) {
+	m, err := migrations.New(openLegacy(t, "20220523172948"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(context.Background()); !errors.Is(err, migrations.ErrLegacySchema) {
+		t.Errorf("err = %v, want %v", err, migrations.ErrLegacySchema)
+	}
+}
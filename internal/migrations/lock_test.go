@@ -0,0 +1,130 @@
+package migrations_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"order/internal/migrations"
+	"order/internal/pgtest"
+)
+
+func openMigrated(t *testing.T) *sql.DB {
+	t.Helper()
+	sqlDB, err := pgtest.Open(t).GetRepo().DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+func TestUpWaitsForTheMigrationLock(t *testing.T) {
+	sqlDB := openMigrated(t)
+	ctx := context.Background()
+	holder, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	held := make(chan error, 1)
+	go func() {
+		held <- holder.Locked(ctx, func(*sql.Conn) error {
+			close(entered)
+			<-release
+			return nil
+		})
+	}()
+	<-entered
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := runner.Up(ctx)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		t.Fatalf("Up finished while another runner held the lock: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	close(release)
+	for _, result := range []chan error{held, done} {
+		select {
+		case err = <-result:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("runner still waiting after the lock was released")
+		}
+	}
+}
+
+func TestMigratorRefusesDriftedSchema(t *testing.T) {
+	const (
+		changeApplied = `UPDATE schema_migrations SET checksum = repeat('0', 64)`
+		applyUnknown  = `INSERT INTO schema_migrations (version, name, checksum) VALUES (99991231000000, 'from_the_future', repeat('0', 64))`
+		forgetApplied = `DELETE FROM schema_migrations`
+	)
+	up := func(ctx context.Context, m *migrations.Migrator) error { _, err := m.Up(ctx); return err }
+	down := func(ctx context.Context, m *migrations.Migrator) error { _, err := m.Down(ctx, 1); return err }
+	check := func(ctx context.Context, m *migrations.Migrator) error { return m.Check(ctx) }
+
+	tests := []struct {
+		name    string
+		drift   string
+		run     func(ctx context.Context, m *migrations.Migrator) error
+		wantErr error
+	}{
+		{name: "up over a changed migration", drift: changeApplied, run: up, wantErr: migrations.ErrChecksumMismatch},
+		{name: "down of a changed migration", drift: changeApplied, run: down, wantErr: migrations.ErrChecksumMismatch},
+		{name: "check of a changed migration", drift: changeApplied, run: check, wantErr: migrations.ErrChecksumMismatch},
+		{name: "down of a migration unknown to the release", drift: applyUnknown, run: down, wantErr: migrations.ErrUnknownVersion},
+		{name: "check of a database ahead of the release", drift: applyUnknown, run: check},
+		{name: "check of a database behind the release", drift: forgetApplied, run: check, wantErr: migrations.ErrSchemaBehind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sqlDB := openMigrated(t)
+			if _, err := sqlDB.ExecContext(ctx, tt.drift); err != nil {
+				t.Fatal(err)
+			}
+			m, err := migrations.New(sqlDB)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = tt.run(ctx, m); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownRefusesTheBaseline(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := openMigrated(t)
+	m, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := m.Down(ctx, 1)
+	if count != 0 || !errors.Is(err, migrations.ErrIrreversible) {
+		t.Fatalf("Down = %d, %v; want %v", count, err, migrations.ErrIrreversible)
+	}
+	if err = m.Check(ctx); err != nil {
+		t.Errorf("schema after the refused rollback: %v", err)
+	}
+	var orders int
+	if err = sqlDB.QueryRowContext(ctx, `SELECT count(*) FROM pg_tables WHERE schemaname = current_schema() AND tablename = 'orders'`).Scan(&orders); err != nil || orders != 1 {
+		t.Errorf("orders table count = %d, %v; want it kept", orders, err)
+	}
+}
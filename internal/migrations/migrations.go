@@ -0,0 +1,455 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"order/pkg/core/logger"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dir is where the migration files live in the source tree, for Create
+const Dir = "internal/migrations/sql"
+
+const (
+	// lockKey is the advisory lock held while migrations run, so that two instances started at
+	// once do not apply the same migration twice
+	lockKey = 7260437312950016
+
+	// legacyTable and legacyLastID are the table of the gormigrate migrations that ran through
+	// the HTTP endpoint, and the last of them released, whose schema the baseline upgrades
+	legacyTable  = "migrations"
+	legacyLastID = "20230523172948"
+
+	// baselineVersion is the migration that creates, or adopts from legacyTable, every table.
+	// Rolling it back would drop the data of upgraded databases, so Down refuses it.
+	baselineVersion = 20261020020000
+
+	createTableSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		checksum char(64) NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now(),
+		duration_ms bigint NOT NULL DEFAULT 0
+	)`
+)
+
+var (
+	ErrSchemaBehind     = errors.New("database schema is behind")
+	ErrChecksumMismatch = errors.New("applied migration was changed")
+	ErrUnknownVersion   = errors.New("applied migration is unknown to this release")
+	ErrLegacySchema     = errors.New("database was migrated by an older release that did not reach the baseline")
+	ErrInvalidName      = errors.New("invalid migration name")
+	ErrIrreversible     = errors.New("migration cannot be rolled back")
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+var (
+	fileName    = regexp.MustCompile(`^(\d{14})_([a-z0-9_]+)\.(up|down)\.sql$`)
+	nameInvalid = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// Migration is a versioned pair of SQL files, <version>_<name>.up.sql and .down.sql. Each file
+// runs in a transaction of its own together with the bookkeeping of schema_migrations.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status is a migration as known to the files and to the database
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	// Modified is set when the applied up file differs from the one of this release
+	Modified bool
+	// Missing is set when the database applied a migration this release does not have
+	Missing bool
+}
+
+type applied struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies the migrations embedded in the binary
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a Migrator of the embedded migrations over db, which has to be the primary
+func New(db *sql.DB) (*Migrator, error) {
+	sub, err := fs.Sub(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads the migrations of fsys in version order. Every up file needs its down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies the pending migrations in version order and returns how many ran
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	log := logger.WithTag("Migrator|Up")
+
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		if err := m.checkLegacy(ctx, conn); err != nil {
+			return err
+		}
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(done); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			started := time.Now()
+			err := m.run(ctx, conn, migration.Up, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum, duration_ms)
+					VALUES ($1, $2, $3, $4)`, migration.Version, migration.Name, migration.Checksum, time.Since(started).Milliseconds())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Infof("applied %d_%s in %s", migration.Version, migration.Name, time.Since(started).Round(time.Millisecond))
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down rolls back the last steps applied migrations and returns how many were rolled back.
+// Nothing is rolled back when one of them cannot be, see rollbacks.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	log := logger.WithTag("Migrator|Down")
+
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		migrations, err := m.rollbacks(done, steps)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			started := time.Now()
+			err = m.run(ctx, conn, migration.Down, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Infof("rolled back %d_%s in %s", migration.Version, migration.Name, time.Since(started).Round(time.Millisecond))
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// rollbacks returns the last steps applied migrations, newest first. It refuses migrations this
+// release does not know or that changed since they ran, and the baseline.
+func (m *Migrator) rollbacks(done map[int64]applied, steps int) ([]Migration, error) {
+	versions := make([]int64, 0, len(done))
+	for version := range done {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var migrations []Migration
+	for _, version := range versions {
+		if len(migrations) == steps {
+			break
+		}
+		migration, ok := m.find(version)
+		if !ok {
+			return nil, fmt.Errorf("%w: %d_%s", ErrUnknownVersion, version, done[version].name)
+		}
+		if done[version].checksum != migration.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, version, migration.Name)
+		}
+		if version == baselineVersion {
+			return nil, fmt.Errorf("%w: %d_%s holds the tables of every release, restore a backup instead",
+				ErrIrreversible, version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// Status lists the migrations of this release and those only the database knows, by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	done, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := done[migration.Version]; ok {
+			appliedAt := row.appliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = row.checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range done {
+		if _, ok := m.find(version); !ok {
+			appliedAt := row.appliedAt
+			statuses = append(statuses, Status{Version: version, Name: row.name, Applied: true, AppliedAt: &appliedAt, Missing: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Check fails with ErrSchemaBehind when migrations of this release are pending, and with
+// ErrChecksumMismatch when an applied one was changed since. A database ahead of the release,
+// eg. during a rollback of the binary, passes.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if status.Modified {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, status.Version, status.Name)
+		}
+		if !status.Applied {
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d pending migration(s) %s", ErrSchemaBehind, len(pending), strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Create writes an empty up and down file for a new migration to dir, versioned by now
+func Create(dir, name string, now time.Time) ([]string, error) {
+	name = strings.Trim(strings.ToLower(nameInvalid.ReplaceAllString(name, "_")), "_")
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	version := now.UTC().Format("20060102150405")
+	var paths []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s %s\n\n", direction, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// locked runs fn on a connection holding the migration lock, waiting for other runners to finish
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// migrations may outlast POSTGRES_STATEMENT_TIMEOUT_MS, and so may the wait for the lock
+	if _, err = conn.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		return err
+	}
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer func() {
+		// the lock and the timeout go with the session should these fail
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey)
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `RESET statement_timeout`)
+	}()
+
+	if _, err = conn.ExecContext(ctx, createTableSQL); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// run executes script and then record in one transaction
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if err = record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkLegacy refuses databases migrated through the former HTTP endpoint that stopped short of
+// its last release. From there on the baseline, which only adds what is missing, upgrades them.
+func (m *Migrator) checkLegacy(ctx context.Context, conn *sql.Conn) error {
+	var versions, legacy int
+	if err := conn.QueryRowContext(ctx, `SELECT count(*) FROM schema_migrations`).Scan(&versions); err != nil {
+		return err
+	}
+	if versions > 0 {
+		return nil
+	}
+	if err := conn.QueryRowContext(ctx, `SELECT count(*) FROM pg_tables WHERE schemaname = current_schema() AND tablename = $1`,
+		legacyTable).Scan(&legacy); err != nil || legacy == 0 {
+		return err
+	}
+
+	var reached bool
+	if err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+legacyTable+` WHERE id = $1)`,
+		legacyLastID).Scan(&reached); err != nil {
+		return err
+	}
+	if !reached {
+		return fmt.Errorf("%w: upgrade through the release before the versioned migrations first", ErrLegacySchema)
+	}
+	logger.WithTag("Migrator|checkLegacy").Infof("upgrading a database migrated by %s up to %s", legacyTable, legacyLastID)
+	return nil
+}
+
+// applied reads schema_migrations, which is empty until the first Up
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]applied, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil || !exists {
+		return map[int64]applied{}, err
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := map[int64]applied{}
+	for rows.Next() {
+		var (
+			version int64
+			row     applied
+		)
+		if err = rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = row
+	}
+	return done, rows.Err()
+}
+
+// verify refuses to run on top of applied migrations that were changed after they ran
+func (m *Migrator) verify(done map[int64]applied) error {
+	for _, migration := range m.migrations {
+		if row, ok := done[migration.Version]; ok && row.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,276 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoad(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	tests := []struct {
+		name         string
+		fsys         fstest.MapFS
+		wantVersions []int64
+		wantErr      string
+	}{
+		{name: "empty", fsys: fstest.MapFS{}},
+		{
+			name: "pairs in version order",
+			fsys: fstest.MapFS{
+				"20260102000000_add_index.up.sql":   file("CREATE INDEX i ON t (c);"),
+				"20260102000000_add_index.down.sql": file("DROP INDEX i;"),
+				"20260101000000_baseline.up.sql":    file("CREATE TABLE t (c int);"),
+				"20260101000000_baseline.down.sql":  file("DROP TABLE t;"),
+			},
+			wantVersions: []int64{20260101000000, 20260102000000},
+		},
+		{
+			name: "other files are ignored",
+			fsys: fstest.MapFS{
+				"20260101000000_baseline.up.sql":   file("CREATE TABLE t (c int);"),
+				"20260101000000_baseline.down.sql": file("DROP TABLE t;"),
+				"README.md":                        file("how to migrate"),
+				"2026_short_version.up.sql":        file("SELECT 1;"),
+				"20260103000000_Upper.up.sql":      file("SELECT 1;"),
+				"20260104000000_sideways.sql":      file("SELECT 1;"),
+				"20260105000000_dir.up.sql/x":      file("SELECT 1;"),
+			},
+			wantVersions: []int64{20260101000000},
+		},
+		{
+			name:    "up without down",
+			fsys:    fstest.MapFS{"20260101000000_baseline.up.sql": file("CREATE TABLE t (c int);")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name:    "down without up",
+			fsys:    fstest.MapFS{"20260101000000_baseline.down.sql": file("DROP TABLE t;")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "empty up file",
+			fsys: fstest.MapFS{
+				"20260101000000_baseline.up.sql":   file(""),
+				"20260101000000_baseline.down.sql": file("DROP TABLE t;"),
+			},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "one version with two names",
+			fsys: fstest.MapFS{
+				"20260101000000_baseline.up.sql":  file("CREATE TABLE t (c int);"),
+				"20260101000000_initial.down.sql": file("DROP TABLE t;"),
+			},
+			wantErr: "is named both",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.fsys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			versions := make([]int64, 0, len(migrations))
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+				if m.Checksum != checksum(m.Up) {
+					t.Errorf("checksum of %d = %s, want the sha256 of its up file", m.Version, m.Checksum)
+				}
+			}
+			if !slices.Equal(versions, tt.wantVersions) {
+				t.Errorf("versions = %v, want %v", versions, tt.wantVersions)
+			}
+		})
+	}
+}
+
+func TestLoadChecksumCoversOnlyTheUpFile(t *testing.T) {
+	load := func(up, down string) Migration {
+		t.Helper()
+		migrations, err := Load(fstest.MapFS{
+			"20260101000000_baseline.up.sql":   {Data: []byte(up)},
+			"20260101000000_baseline.down.sql": {Data: []byte(down)},
+		})
+		if err != nil || len(migrations) != 1 {
+			t.Fatalf("Load: %v, %d migrations", err, len(migrations))
+		}
+		return migrations[0]
+	}
+
+	base := load("CREATE TABLE t (c int);", "DROP TABLE t;")
+	if load("CREATE TABLE t (c int);", "DROP TABLE IF EXISTS t;").Checksum != base.Checksum {
+		t.Error("changing the down file changed the checksum")
+	}
+	if load("CREATE TABLE t (c bigint);", "DROP TABLE t;").Checksum == base.Checksum {
+		t.Error("changing the up file kept the checksum")
+	}
+}
+
+func TestLoadFailsOnUnreadableFS(t *testing.T) {
+	if _, err := Load(os.DirFS(filepath.Join(t.TempDir(), "missing"))); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("err = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.migrations) == 0 || m.migrations[0].Name != "baseline" || m.migrations[0].Version != baselineVersion {
+		t.Fatalf("embedded migrations = %+v, want the baseline first", m.migrations)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	m := &Migrator{migrations: []Migration{
+		{Version: 1, Name: "baseline", Checksum: checksum("a")},
+		{Version: 2, Name: "add_index", Checksum: checksum("b")},
+	}}
+
+	tests := []struct {
+		name    string
+		done    map[int64]applied
+		wantErr error
+	}{
+		{name: "nothing applied", done: map[int64]applied{}},
+		{name: "applied as released", done: map[int64]applied{1: {checksum: checksum("a")}, 2: {checksum: checksum("b")}}},
+		{name: "applied ahead of the release", done: map[int64]applied{1: {checksum: checksum("a")}, 3: {checksum: checksum("c")}}},
+		{name: "applied file changed since", done: map[int64]applied{1: {checksum: checksum("a")}, 2: {checksum: checksum("b2")}}, wantErr: ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.verify(tt.done); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRollbacks(t *testing.T) {
+	m := &Migrator{migrations: []Migration{
+		{Version: baselineVersion, Name: "baseline", Checksum: checksum("a")},
+		{Version: baselineVersion + 1, Name: "add_index", Checksum: checksum("b")},
+		{Version: baselineVersion + 2, Name: "add_column", Checksum: checksum("c")},
+	}}
+	all := map[int64]applied{
+		baselineVersion:     {checksum: checksum("a")},
+		baselineVersion + 1: {checksum: checksum("b")},
+		baselineVersion + 2: {checksum: checksum("c")},
+	}
+
+	tests := []struct {
+		name         string
+		done         map[int64]applied
+		steps        int
+		wantVersions []int64
+		wantErr      error
+	}{
+		{name: "newest first", done: all, steps: 2, wantVersions: []int64{baselineVersion + 2, baselineVersion + 1}},
+		{name: "nothing applied", done: map[int64]applied{}, steps: 1},
+		{name: "down to the baseline", done: all, steps: 3, wantErr: ErrIrreversible},
+		{name: "the baseline alone", done: map[int64]applied{baselineVersion: {checksum: checksum("a")}}, steps: 1, wantErr: ErrIrreversible},
+		{name: "unknown to the release", done: map[int64]applied{baselineVersion + 9: {name: "from_the_future"}}, steps: 1, wantErr: ErrUnknownVersion},
+		{
+			name:    "changed since it ran",
+			done:    map[int64]applied{baselineVersion: {checksum: checksum("a")}, baselineVersion + 1: {checksum: checksum("b2")}},
+			steps:   1,
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := m.rollbacks(tt.done, tt.steps)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && migrations != nil {
+				t.Errorf("refused rollback still returned %d migrations", len(migrations))
+			}
+			versions := make([]int64, 0, len(migrations))
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+			if !slices.Equal(versions, tt.wantVersions) {
+				t.Errorf("versions = %v, want %v", versions, tt.wantVersions)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name     string
+		input    string
+		wantName string
+		wantErr  error
+	}{
+		{name: "plain", input: "add_index", wantName: "add_index"},
+		{name: "spaces and case", input: "  Add Orders Index ", wantName: "add_orders_index"},
+		{name: "punctuation", input: "drop-legacy.table!", wantName: "drop_legacy_table"},
+		{name: "empty", input: "", wantErr: ErrInvalidName},
+		{name: "only punctuation", input: "--!", wantErr: ErrInvalidName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "sql")
+			paths, err := Create(dir, tt.input, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := []string{
+				filepath.Join(dir, "20260304040607_"+tt.wantName+".up.sql"),
+				filepath.Join(dir, "20260304040607_"+tt.wantName+".down.sql"),
+			}
+			if !slices.Equal(paths, want) {
+				t.Fatalf("paths = %v, want %v", paths, want)
+			}
+			// the created pair loads as one migration
+			loaded, err := Load(os.DirFS(dir))
+			if err != nil || len(loaded) != 1 || loaded[0].Name != tt.wantName {
+				t.Errorf("Load of the created files = %+v, %v", loaded, err)
+			}
+		})
+	}
+}
+
+func TestCreateFailsOnUnwritableDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Create(filepath.Join(file, "sql"), "add_index", time.Now()); err == nil {
+		t.Error("want an error creating migrations below a file")
+	}
+}
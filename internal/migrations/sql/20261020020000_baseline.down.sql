@@ -0,0 +1,3 @@
+-- The baseline is not rolled back: it adopts the tables of databases migrated by the legacy
+-- releases, so dropping them would lose their data. Migrator.Down refuses it before this runs.
+SELECT 1;
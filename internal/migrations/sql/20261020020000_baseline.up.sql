@@ -0,0 +1,614 @@
+-- Baseline of the schema. It also upgrades databases migrated by the last gormigrate release, up
+-- to 20230523172948, so every statement has to be a no-op on what that release created: the
+-- tables and indexes are created if missing, and the columns added since are added if missing.
+-- See Migrator.checkLegacy.
+
+CREATE SCHEMA IF NOT EXISTS public;
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS "tenants" (
+    "id" varchar(64),
+    "name" varchar(100) NOT NULL,
+    "is_active" boolean NOT NULL,
+    "base_currency" varchar(3) NOT NULL DEFAULT '',
+    "invoice_seller_name" varchar(200) NOT NULL DEFAULT '',
+    "invoice_seller_tax_id" varchar(50) NOT NULL DEFAULT '',
+    "invoice_seller_address" varchar(500) NOT NULL DEFAULT '',
+    "invoice_seller_country" varchar(2) NOT NULL DEFAULT '',
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "promotion_configs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "name" varchar(100) NOT NULL,
+    "customer_limit" bigint NOT NULL DEFAULT 1,
+    "reward_limit" bigint NOT NULL DEFAULT 1,
+    "min_order_value" decimal(10,2) NOT NULL DEFAULT 0,
+    "reward_value" decimal(10,2) NOT NULL DEFAULT 0,
+    "budget" decimal(12,2) NOT NULL DEFAULT 0,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "start_time" timestamp NOT NULL,
+    "end_time" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+ALTER TABLE "promotion_configs" ADD COLUMN IF NOT EXISTS "tenant_id" varchar(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "promotion_configs" ADD COLUMN IF NOT EXISTS "reward_value" decimal(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE "promotion_configs" ADD COLUMN IF NOT EXISTS "budget" decimal(12,2) NOT NULL DEFAULT 0;
+-- promotion names were unique across what is now every tenant
+ALTER TABLE "promotion_configs" DROP CONSTRAINT IF EXISTS "uni_promotion_configs_name";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_promotion_configs_tenant_name" ON "promotion_configs" ("tenant_id","name");
+
+CREATE TABLE IF NOT EXISTS "promotion_thresholds" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "promotion_config_id" uuid NOT NULL,
+    "currency" varchar(3) NOT NULL,
+    "min_order_value" decimal(10,2) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_promotion_configs_thresholds" FOREIGN KEY ("promotion_config_id") REFERENCES "promotion_configs"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_promotion_thresholds_currency" ON "promotion_thresholds" ("promotion_config_id","currency");
+CREATE INDEX IF NOT EXISTS "idx_promotion_thresholds_tenant_id" ON "promotion_thresholds" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "customer_id" uuid NOT NULL,
+    "total_amount" decimal(10,2) NOT NULL,
+    "discount_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "refunded_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "currency" varchar(3) NOT NULL DEFAULT '',
+    "fx_rate" decimal(18,8) NOT NULL DEFAULT 1,
+    "tax_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "tax_country" varchar(2) NOT NULL DEFAULT '',
+    "tax_region" varchar(50) NOT NULL DEFAULT '',
+    "customer_name" varchar(200) NOT NULL DEFAULT '',
+    "customer_email" varchar(254) NOT NULL DEFAULT '',
+    "customer_phone" varchar(30) NOT NULL DEFAULT '',
+    "shipping_name" varchar(200) NOT NULL DEFAULT '',
+    "shipping_line1" varchar(200) NOT NULL DEFAULT '',
+    "shipping_line2" varchar(200) NOT NULL DEFAULT '',
+    "shipping_city" varchar(100) NOT NULL DEFAULT '',
+    "shipping_region" varchar(100) NOT NULL DEFAULT '',
+    "shipping_postal_code" varchar(20) NOT NULL DEFAULT '',
+    "shipping_country" varchar(2) NOT NULL DEFAULT '',
+    "billing_name" varchar(200) NOT NULL DEFAULT '',
+    "billing_line1" varchar(200) NOT NULL DEFAULT '',
+    "billing_line2" varchar(200) NOT NULL DEFAULT '',
+    "billing_city" varchar(100) NOT NULL DEFAULT '',
+    "billing_region" varchar(100) NOT NULL DEFAULT '',
+    "billing_postal_code" varchar(20) NOT NULL DEFAULT '',
+    "billing_country" varchar(2) NOT NULL DEFAULT '',
+    "status" varchar(20) NOT NULL,
+    "reward_given" boolean NOT NULL DEFAULT false,
+    "promotion_config_id" uuid,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_promotion_configs_order" FOREIGN KEY ("promotion_config_id") REFERENCES "promotion_configs"("id")
+);
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "tenant_id" varchar(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "discount_amount" decimal(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "refunded_amount" decimal(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "fx_rate" decimal(18,8) NOT NULL DEFAULT 1;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "tax_amount" decimal(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "tax_country" varchar(2) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "tax_region" varchar(50) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "customer_name" varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "customer_email" varchar(254) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "customer_phone" varchar(30) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "shipping_name" varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "shipping_line1" varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "shipping_line2" varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "shipping_city" varchar(100) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "shipping_region" varchar(100) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "shipping_postal_code" varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "shipping_country" varchar(2) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "billing_name" varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "billing_line1" varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "billing_line2" varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "billing_city" varchar(100) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "billing_region" varchar(100) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "billing_postal_code" varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "billing_country" varchar(2) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS "idx_orders_promotion_config_id" ON "orders" ("promotion_config_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_status" ON "orders" ("status");
+CREATE INDEX IF NOT EXISTS "idx_orders_customer_id" ON "orders" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_tenant_id" ON "orders" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "order_items" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "order_id" uuid NOT NULL,
+    "product_id" uuid NOT NULL,
+    "quantity" bigint NOT NULL,
+    "unit_price" decimal(10,2) NOT NULL,
+    "tax_class" varchar(30) NOT NULL DEFAULT 'standard',
+    "tax_rate" decimal(7,5) NOT NULL DEFAULT 0,
+    "tax_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_order_items" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+ALTER TABLE "order_items" ADD COLUMN IF NOT EXISTS "tenant_id" varchar(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "order_items" ADD COLUMN IF NOT EXISTS "tax_class" varchar(30) NOT NULL DEFAULT 'standard';
+ALTER TABLE "order_items" ADD COLUMN IF NOT EXISTS "tax_rate" decimal(7,5) NOT NULL DEFAULT 0;
+ALTER TABLE "order_items" ADD COLUMN IF NOT EXISTS "tax_amount" decimal(10,2) NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_order_items_product_id" ON "order_items" ("product_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_order_id" ON "order_items" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_tenant_id" ON "order_items" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "order_status_history" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "order_id" uuid NOT NULL,
+    "from_status" varchar(20),
+    "to_status" varchar(20) NOT NULL,
+    "actor_type" varchar(20) NOT NULL,
+    "actor_id" varchar(100),
+    "source" varchar(20) NOT NULL,
+    "source_ref" varchar(255),
+    "reason" text,
+    "changed_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_status_history_changed_at" ON "order_status_history" ("changed_at");
+CREATE INDEX IF NOT EXISTS "idx_order_status_history_order_id" ON "order_status_history" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_order_status_history_tenant_id" ON "order_status_history" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "order_events" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "aggregate_type" varchar(100) NOT NULL,
+    "aggregate_id" uuid NOT NULL,
+    "version" bigint NOT NULL,
+    "event_type" varchar(100) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "occurred_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_events_occurred_at" ON "order_events" ("occurred_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_events_aggregate_version" ON "order_events" ("aggregate_id","version");
+CREATE INDEX IF NOT EXISTS "idx_order_events_tenant_id" ON "order_events" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "order_snapshots" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "aggregate_id" uuid NOT NULL,
+    "version" bigint NOT NULL,
+    "state" jsonb NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_snapshots_aggregate_id" ON "order_snapshots" ("aggregate_id");
+CREATE INDEX IF NOT EXISTS "idx_order_snapshots_tenant_id" ON "order_snapshots" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "promotion_rewards" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "promotion_config_id" uuid NOT NULL,
+    "order_id" uuid NOT NULL,
+    "customer_id" uuid NOT NULL,
+    "amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "received_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_promotion_configs_promotion_reward" FOREIGN KEY ("promotion_config_id") REFERENCES "promotion_configs"("id")
+);
+ALTER TABLE "promotion_rewards" ADD COLUMN IF NOT EXISTS "tenant_id" varchar(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "promotion_rewards" ADD COLUMN IF NOT EXISTS "amount" decimal(10,2) NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_promotion_rewards_customer_id" ON "promotion_rewards" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_promotion_rewards_order_id" ON "promotion_rewards" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_promotion_rewards_promotion_config_id" ON "promotion_rewards" ("promotion_config_id");
+CREATE INDEX IF NOT EXISTS "idx_promotion_rewards_tenant_id" ON "promotion_rewards" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "outbox" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "event_id" uuid NOT NULL,
+    "event_type" varchar(100) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "aggregate_type" varchar(100) NOT NULL,
+    "aggregate_id" uuid,
+    "status" varchar(20) NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "processed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+ALTER TABLE "outbox" ADD COLUMN IF NOT EXISTS "tenant_id" varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS "idx_outbox_next_attempt_at" ON "outbox" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_status" ON "outbox" ("status");
+CREATE INDEX IF NOT EXISTS "idx_outbox_aggregate_id" ON "outbox" ("aggregate_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_outbox_event_id" ON "outbox" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_outbox_tenant_id" ON "outbox" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "scheduled_jobs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "name" varchar(100) NOT NULL,
+    "schedule" varchar(100) NOT NULL,
+    "enabled" boolean NOT NULL DEFAULT true,
+    "next_run_at" timestamptz NOT NULL,
+    "last_run_at" timestamptz,
+    "last_status" varchar(20),
+    "locked_by" varchar(255),
+    "locked_until" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_scheduled_jobs_next_run_at" ON "scheduled_jobs" ("next_run_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_scheduled_jobs_name" ON "scheduled_jobs" ("name");
+
+CREATE TABLE IF NOT EXISTS "job_runs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "job_name" varchar(100) NOT NULL,
+    "instance_id" varchar(255) NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "scheduled_at" timestamptz NOT NULL,
+    "started_at" timestamptz NOT NULL,
+    "finished_at" timestamptz,
+    "duration_ms" bigint NOT NULL DEFAULT 0,
+    "affected" bigint NOT NULL DEFAULT 0,
+    "error" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_job_runs_status" ON "job_runs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_job_runs_job_name" ON "job_runs" ("job_name");
+
+CREATE TABLE IF NOT EXISTS "sagas" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "type" varchar(50) NOT NULL,
+    "order_id" uuid NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "current_step" varchar(50),
+    "step_index" bigint NOT NULL DEFAULT 0,
+    "deadline" timestamptz,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "last_error" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_sagas_deadline" ON "sagas" ("deadline");
+CREATE INDEX IF NOT EXISTS "idx_sagas_status" ON "sagas" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_sagas_type_order" ON "sagas" ("type","order_id");
+CREATE INDEX IF NOT EXISTS "idx_sagas_tenant_id" ON "sagas" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "saga_steps" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "saga_id" uuid NOT NULL,
+    "name" varchar(50) NOT NULL,
+    "phase" varchar(20) NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "error" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sagas_steps" FOREIGN KEY ("saga_id") REFERENCES "sagas"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_saga_steps_saga_id" ON "saga_steps" ("saga_id");
+CREATE INDEX IF NOT EXISTS "idx_saga_steps_tenant_id" ON "saga_steps" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "carts" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "customer_id" uuid,
+    "status" varchar(20) NOT NULL,
+    "coupon_code" varchar(50),
+    "currency" varchar(3) NOT NULL DEFAULT '',
+    "tax_country" varchar(2) NOT NULL DEFAULT '',
+    "tax_region" varchar(50) NOT NULL DEFAULT '',
+    "shipping_name" varchar(200) NOT NULL DEFAULT '',
+    "shipping_line1" varchar(200) NOT NULL DEFAULT '',
+    "shipping_line2" varchar(200) NOT NULL DEFAULT '',
+    "shipping_city" varchar(100) NOT NULL DEFAULT '',
+    "shipping_region" varchar(100) NOT NULL DEFAULT '',
+    "shipping_postal_code" varchar(20) NOT NULL DEFAULT '',
+    "shipping_country" varchar(2) NOT NULL DEFAULT '',
+    "order_id" uuid,
+    "expires_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_carts_expires_at" ON "carts" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_carts_status" ON "carts" ("status");
+CREATE INDEX IF NOT EXISTS "idx_carts_customer_id" ON "carts" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_carts_tenant_id" ON "carts" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "cart_items" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "cart_id" uuid NOT NULL,
+    "product_id" uuid NOT NULL,
+    "quantity" bigint NOT NULL,
+    "unit_price" decimal(10,2) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_carts_items" FOREIGN KEY ("cart_id") REFERENCES "carts"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_cart_items_cart_product" ON "cart_items" ("cart_id","product_id");
+CREATE INDEX IF NOT EXISTS "idx_cart_items_tenant_id" ON "cart_items" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "coupons" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "code" varchar(50) NOT NULL,
+    "discount_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "discount_percent" decimal(5,2) NOT NULL DEFAULT 0,
+    "min_order_value" decimal(10,2) NOT NULL DEFAULT 0,
+    "usage_limit" bigint NOT NULL DEFAULT 0,
+    "used_count" bigint NOT NULL DEFAULT 0,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "start_time" timestamp NOT NULL,
+    "end_time" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_coupons_tenant_code" ON "coupons" ("tenant_id","code");
+
+CREATE TABLE IF NOT EXISTS "shipments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "order_id" uuid NOT NULL,
+    "carrier" varchar(50) NOT NULL,
+    "tracking_number" varchar(100) NOT NULL DEFAULT '',
+    "status" varchar(20) NOT NULL,
+    "shipped_at" timestamptz,
+    "delivered_at" timestamptz,
+    "returned_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_shipments_status" ON "shipments" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_shipments_tracking" ON "shipments" ("carrier","tracking_number") WHERE tracking_number <> '';
+CREATE INDEX IF NOT EXISTS "idx_shipments_order_id" ON "shipments" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_shipments_tenant_id" ON "shipments" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "shipment_items" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "shipment_id" uuid NOT NULL,
+    "order_item_id" uuid NOT NULL,
+    "quantity" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_shipments_items" FOREIGN KEY ("shipment_id") REFERENCES "shipments"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_shipment_items_order_item_id" ON "shipment_items" ("order_item_id");
+CREATE INDEX IF NOT EXISTS "idx_shipment_items_shipment_id" ON "shipment_items" ("shipment_id");
+CREATE INDEX IF NOT EXISTS "idx_shipment_items_tenant_id" ON "shipment_items" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "return_requests" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "order_id" uuid NOT NULL,
+    "order_item_id" uuid NOT NULL,
+    "customer_id" uuid NOT NULL,
+    "quantity" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "review_note" text,
+    "inspection_note" text,
+    "refund_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "reviewed_at" timestamptz,
+    "received_at" timestamptz,
+    "inspected_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_return_requests_status" ON "return_requests" ("status");
+CREATE INDEX IF NOT EXISTS "idx_return_requests_customer_id" ON "return_requests" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_return_requests_order_item_id" ON "return_requests" ("order_item_id");
+CREATE INDEX IF NOT EXISTS "idx_return_requests_order_id" ON "return_requests" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_return_requests_tenant_id" ON "return_requests" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "invoice_sequences" (
+    "tenant_id" varchar(64) DEFAULT 'default',
+    "kind" varchar(20),
+    "year" bigint,
+    "last_number" bigint NOT NULL,
+    PRIMARY KEY ("tenant_id","kind","year")
+);
+
+CREATE TABLE IF NOT EXISTS "invoices" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "order_id" uuid NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "year" bigint NOT NULL,
+    "sequence" bigint NOT NULL,
+    "number" varchar(30) NOT NULL,
+    "issued_at" timestamptz NOT NULL,
+    "credited_invoice_id" uuid,
+    "credited_number" varchar(30) NOT NULL DEFAULT '',
+    "return_request_id" uuid,
+    "customer_id" uuid NOT NULL,
+    "customer_name" varchar(200) NOT NULL DEFAULT '',
+    "customer_email" varchar(254) NOT NULL DEFAULT '',
+    "customer_phone" varchar(30) NOT NULL DEFAULT '',
+    "billing_name" varchar(200) NOT NULL DEFAULT '',
+    "billing_line1" varchar(200) NOT NULL DEFAULT '',
+    "billing_line2" varchar(200) NOT NULL DEFAULT '',
+    "billing_city" varchar(100) NOT NULL DEFAULT '',
+    "billing_region" varchar(100) NOT NULL DEFAULT '',
+    "billing_postal_code" varchar(20) NOT NULL DEFAULT '',
+    "billing_country" varchar(2) NOT NULL DEFAULT '',
+    "currency" varchar(3) NOT NULL,
+    "subtotal" decimal(10,2) NOT NULL,
+    "discount_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "tax_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "total_amount" decimal(10,2) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invoices_customer_id" ON "invoices" ("customer_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_return_request_id" ON "invoices" ("return_request_id");
+CREATE INDEX IF NOT EXISTS "idx_invoices_credited_invoice_id" ON "invoices" ("credited_invoice_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_order" ON "invoices" ("order_id") WHERE kind = 'INVOICE';
+CREATE INDEX IF NOT EXISTS "idx_invoices_order_id" ON "invoices" ("order_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_tenant_number" ON "invoices" ("tenant_id","number");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_tenant_sequence" ON "invoices" ("tenant_id","kind","year","sequence");
+
+CREATE TABLE IF NOT EXISTS "invoice_lines" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "invoice_id" uuid NOT NULL,
+    "order_item_id" uuid NOT NULL,
+    "product_id" uuid NOT NULL,
+    "quantity" bigint NOT NULL,
+    "unit_price" decimal(10,2) NOT NULL,
+    "net_amount" decimal(10,2) NOT NULL,
+    "tax_class" varchar(30) NOT NULL DEFAULT 'standard',
+    "tax_rate" decimal(7,5) NOT NULL DEFAULT 0,
+    "tax_amount" decimal(10,2) NOT NULL DEFAULT 0,
+    "total_amount" decimal(10,2) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_invoices_lines" FOREIGN KEY ("invoice_id") REFERENCES "invoices"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invoice_lines_invoice_id" ON "invoice_lines" ("invoice_id");
+CREATE INDEX IF NOT EXISTS "idx_invoice_lines_tenant_id" ON "invoice_lines" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "tax_rates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "country" varchar(2) NOT NULL,
+    "region" varchar(50) NOT NULL DEFAULT '',
+    "tax_class" varchar(30) NOT NULL,
+    "rate" decimal(7,5) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tax_rates_tenant_lookup" ON "tax_rates" ("tenant_id","country","region","tax_class");
+
+CREATE TABLE IF NOT EXISTS "fx_rates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "currency" varchar(3) NOT NULL,
+    "rate" decimal(18,8) NOT NULL,
+    "effective_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_fx_rates_tenant_currency_effective" ON "fx_rates" ("tenant_id","currency","effective_at");
+
+CREATE TABLE IF NOT EXISTS "export_jobs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "kind" varchar(30) NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "items" varchar(10) NOT NULL DEFAULT '',
+    "filters" text NOT NULL DEFAULT '[]',
+    "status" varchar(20) NOT NULL,
+    "file_name" varchar(255) NOT NULL DEFAULT '',
+    "row_count" bigint NOT NULL DEFAULT 0,
+    "error" text,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_export_jobs_expires_at" ON "export_jobs" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_export_jobs_status" ON "export_jobs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_export_jobs_tenant_id" ON "export_jobs" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "order_stats" (
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "granularity" varchar(5) NOT NULL,
+    "bucket_start" timestamptz NOT NULL,
+    "currency" varchar(3) NOT NULL DEFAULT '',
+    "status" varchar(20) NOT NULL,
+    "order_count" bigint NOT NULL DEFAULT 0,
+    "revenue" decimal(14,2) NOT NULL DEFAULT 0,
+    "revenue_base" decimal(14,2) NOT NULL DEFAULT 0,
+    "refreshed_at" timestamptz NOT NULL);
+CREATE INDEX IF NOT EXISTS "idx_order_stats_tenant_id" ON "order_stats" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "order_stats_watermarks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    "tenant_id" varchar(64) NOT NULL DEFAULT 'default',
+    "refreshed_until" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_stats_watermarks_tenant_id" ON "order_stats_watermarks" ("tenant_id");
+
+-- keyset index behind the cursor paginated order history of a customer
+CREATE INDEX IF NOT EXISTS idx_orders_customer_history ON orders (tenant_id, customer_id, created_at DESC, id DESC);
+
+-- trigram indexes for partial order ID, email and product ID search, and the full-text document
+-- of the customer and address snapshots
+CREATE INDEX IF NOT EXISTS idx_orders_search_id ON orders USING gin ((id::text) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_orders_search_email ON orders USING gin (customer_email gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_order_items_search_product ON order_items USING gin ((product_id::text) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_orders_search_document ON orders USING gin (to_tsvector('simple', customer_name || ' ' || customer_email || ' ' || customer_phone || ' ' ||
+	shipping_name || ' ' || shipping_city || ' ' || shipping_postal_code || ' ' || billing_name || ' ' || billing_city));
+
+-- hourly and daily order stats
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_stats_bucket ON order_stats (tenant_id, granularity, bucket_start, currency, status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_stats_watermarks_tenant ON order_stats_watermarks (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_updated ON orders (tenant_id, updated_at);
+
+INSERT INTO tenants (id, name, is_active) VALUES ('default', 'Default', true) ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,31 @@
+package models
+
+// Address is a postal address copied onto an order when it is placed.
+// Country is an ISO 3166-1 alpha-2 code.
+type Address struct {
+	Name       string `json:"name" gorm:"type:varchar(200);not null;default:''"`
+	Line1      string `json:"line1" gorm:"type:varchar(200);not null;default:''"`
+	Line2      string `json:"line2,omitempty" gorm:"type:varchar(200);not null;default:''"`
+	City       string `json:"city" gorm:"type:varchar(100);not null;default:''"`
+	Region     string `json:"region,omitempty" gorm:"type:varchar(100);not null;default:''"`
+	PostalCode string `json:"postal_code,omitempty" gorm:"type:varchar(20);not null;default:''"`
+	Country    string `json:"country" gorm:"type:varchar(2);not null;default:''"`
+}
+
+// IsZero reports whether no field of the address is set
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// CustomerSnapshot is the customer's name and contact details at the time the order was placed.
+// It is never updated afterwards, so invoices and fulfillment show what the customer entered.
+type CustomerSnapshot struct {
+	Name  string `json:"name" gorm:"type:varchar(200);not null;default:''"`
+	Email string `json:"email" gorm:"type:varchar(254);not null;default:''"`
+	Phone string `json:"phone,omitempty" gorm:"type:varchar(30);not null;default:''"`
+}
+
+// IsZero reports whether no contact detail is set
+func (c CustomerSnapshot) IsZero() bool {
+	return c == CustomerSnapshot{}
+}
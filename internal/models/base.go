@@ -12,3 +12,9 @@ type BaseModel struct {
 	UpdatedAt time.Time       `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DeletedAt *gorm.DeletedAt `json:"deleted_at,omitempty" `
 }
+
+// TenantModel scopes a row to the tenant (storefront) it belongs to. The column is filled and
+// filtered from the statement context by the tenant scope of pg-gorm.
+type TenantModel struct {
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';index" json:"-"`
+}
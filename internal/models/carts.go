@@ -0,0 +1,76 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type CartStatus string
+
+const (
+	CartStatusActive    CartStatus = "ACTIVE"
+	CartStatusConverted CartStatus = "CONVERTED"
+	CartStatusMerged    CartStatus = "MERGED"
+	CartStatusExpired   CartStatus = "EXPIRED"
+)
+
+// Cart is a server-side shopping cart. Guest carts have no CustomerID until they are merged.
+// The currency, shipping address and tax location are carried over to the order at checkout.
+type Cart struct {
+	BaseModel
+	TenantModel
+	CustomerID      *uuid.UUID `json:"customer_id" gorm:"type:uuid;index"`
+	Status          CartStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	CouponCode      string     `json:"coupon_code" gorm:"type:varchar(50)"`
+	Currency        string     `json:"currency" gorm:"type:varchar(3);not null;default:''"`
+	TaxCountry      string     `json:"tax_country,omitempty" gorm:"type:varchar(2);not null;default:''"`
+	TaxRegion       string     `json:"tax_region,omitempty" gorm:"type:varchar(50);not null;default:''"`
+	ShippingAddress Address    `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_"`
+	OrderID         *uuid.UUID `json:"order_id" gorm:"type:uuid"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null;index"`
+	Items           []CartItem `json:"items" gorm:"foreignKey:CartID"`
+}
+
+func (Cart) TableName() string {
+	return "carts"
+}
+
+func (c *Cart) IsActive() bool {
+	return c.Status == CartStatusActive
+}
+
+// Subtotal returns the cart value before discounts
+func (c *Cart) Subtotal() float64 {
+	var total float64
+	for _, item := range c.Items {
+		total += float64(item.Quantity) * item.UnitPrice
+	}
+	return total
+}
+
+type CartItem struct {
+	BaseModel
+	TenantModel
+	CartID    uuid.UUID `json:"cart_id" gorm:"type:uuid;not null;uniqueIndex:idx_cart_items_cart_product"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_cart_items_cart_product"`
+	Quantity  int       `json:"quantity" gorm:"type:int;not null"`
+	UnitPrice float64   `json:"unit_price" gorm:"type:decimal(10,2);not null"`
+}
+
+func (CartItem) TableName() string {
+	return "cart_items"
+}
+
+// CartCheckout is what the customer enters when checking out a cart
+type CartCheckout struct {
+	Customer CustomerSnapshot
+	Billing  Address
+}
+
+// CartPricing is the price preview of a cart
+type CartPricing struct {
+	Subtotal   float64 `json:"subtotal"`
+	Discount   float64 `json:"discount"`
+	Total      float64 `json:"total"`
+	CouponCode string  `json:"coupon_code,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Coupon is a discount code customers can apply to their cart
+type Coupon struct {
+	BaseModel
+	TenantID        string    `json:"-" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_coupons_tenant_code"`
+	Code            string    `json:"code" gorm:"type:varchar(50);not null;uniqueIndex:idx_coupons_tenant_code"`
+	DiscountAmount  float64   `json:"discount_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	DiscountPercent float64   `json:"discount_percent" gorm:"type:decimal(5,2);not null;default:0.00"`
+	MinOrderValue   float64   `json:"min_order_value" gorm:"type:decimal(10,2);not null;default:0.00"`
+	UsageLimit      int       `json:"usage_limit" gorm:"type:int;not null;default:0"` // 0 means unlimited
+	UsedCount       int       `json:"used_count" gorm:"type:int;not null;default:0"`
+	IsActive        bool      `json:"is_active" gorm:"type:boolean;not null;default:true"`
+	StartTime       time.Time `json:"start_time" gorm:"type:timestamp;not null"`
+	EndTime         time.Time `json:"end_time" gorm:"type:timestamp;not null"`
+}
+
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// Discount returns the discount granted on subtotal, never more than the subtotal itself
+func (c *Coupon) Discount(subtotal float64) float64 {
+	if subtotal < c.MinOrderValue {
+		return 0
+	}
+	discount := c.DiscountAmount + subtotal*c.DiscountPercent/100
+	if discount > subtotal {
+		return subtotal
+	}
+	return discount
+}
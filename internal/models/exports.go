@@ -0,0 +1,53 @@
+package models
+
+import (
+	"order/pkg/http/utils"
+	"time"
+)
+
+type ExportStatus string
+
+const (
+	ExportStatusPending   ExportStatus = "PENDING"
+	ExportStatusRunning   ExportStatus = "RUNNING"
+	ExportStatusSucceeded ExportStatus = "SUCCEEDED"
+	ExportStatusFailed    ExportStatus = "FAILED"
+	ExportStatusExpired   ExportStatus = "EXPIRED"
+)
+
+const ExportKindOrders = "orders"
+
+// ExportJob is an export written in the background to the file store. The file can be
+// downloaded until ExpiresAt.
+type ExportJob struct {
+	BaseModel
+	TenantModel
+	Kind        string       `json:"kind" gorm:"type:varchar(30);not null"`
+	Format      string       `json:"format" gorm:"type:varchar(10);not null"`
+	Items       string       `json:"items" gorm:"type:varchar(10);not null;default:''"`
+	Filters     string       `json:"filters" gorm:"type:text;not null;default:'[]'"`
+	Status      ExportStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	FileName    string       `json:"-" gorm:"type:varchar(255);not null;default:''"`
+	RowCount    int64        `json:"rows" gorm:"not null;default:0"`
+	Error       string       `json:"error,omitempty" gorm:"type:text"`
+	StartedAt   *time.Time   `json:"started_at"`
+	FinishedAt  *time.Time   `json:"finished_at"`
+	ExpiresAt   *time.Time   `json:"expires_at" gorm:"index"`
+	DownloadURL string       `json:"download_url,omitempty" gorm:"-"`
+}
+
+func (ExportJob) TableName() string {
+	return "export_jobs"
+}
+
+// ExportOrdersRequest selects the format of an order export; the orders are selected by the
+// filter[field][operator] parameters of order listings
+type ExportOrdersRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson jsonl"`
+	Items  string `form:"items" binding:"omitempty,oneof=nested flattened"`
+}
+
+type ExportJobResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data ExportJob       `json:"data"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"order/pkg/http/utils"
+	"time"
+)
+
+// FxRate is the value of one unit of Currency in the base currency from EffectiveAt on
+type FxRate struct {
+	BaseModel
+	TenantID    string    `json:"-" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_fx_rates_tenant_currency_effective"`
+	Currency    string    `json:"currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_fx_rates_tenant_currency_effective"`
+	Rate        float64   `json:"rate" gorm:"type:decimal(18,8);not null"`
+	EffectiveAt time.Time `json:"effective_at" gorm:"not null;uniqueIndex:idx_fx_rates_tenant_currency_effective"`
+}
+
+func (FxRate) TableName() string {
+	return "fx_rates"
+}
+
+type UpsertFxRateRequest struct {
+	Currency    string     `json:"currency" binding:"required,len=3"`
+	Rate        float64    `json:"rate" binding:"required,gt=0"`
+	EffectiveAt *time.Time `json:"effective_at"`
+}
+
+type ListFxRatesResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data []FxRate        `json:"data"`
+}
+
+type FxRateResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data *FxRate         `json:"data"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "github.com/google/uuid"
+
+// StockShortage describes a line item the inventory service could not reserve
+type StockShortage struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Requested int       `json:"requested"`
+	Available int       `json:"available"`
+	Reason    string    `json:"reason"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type InvoiceKind string
+
+const (
+	InvoiceKindInvoice    InvoiceKind = "INVOICE"
+	InvoiceKindCreditNote InvoiceKind = "CREDIT_NOTE"
+)
+
+// NumberPrefix is the prefix of the document numbers of kind k
+func (k InvoiceKind) NumberPrefix() string {
+	if k == InvoiceKindCreditNote {
+		return "CN"
+	}
+	return "INV"
+}
+
+// InvoicedOrderStatuses are the order statuses that issue the invoice of an order
+var InvoicedOrderStatuses = []OrderStatus{OrderStatusAuthorized, OrderStatusCompleted}
+
+// Invoice is an invoice or a credit note. It copies everything it shows from the order when it is
+// issued and is never changed afterwards. Numbers run gap-free per tenant, kind and calendar year.
+// Amounts of credit notes are the amounts credited, so they are positive as well.
+type Invoice struct {
+	BaseModel
+	TenantID          string           `json:"-" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_invoices_tenant_sequence;uniqueIndex:idx_invoices_tenant_number"`
+	OrderID           uuid.UUID        `json:"order_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_invoices_order,where:kind = 'INVOICE'"`
+	Kind              InvoiceKind      `json:"kind" gorm:"type:varchar(20);not null;uniqueIndex:idx_invoices_tenant_sequence"`
+	Year              int              `json:"year" gorm:"type:int;not null;uniqueIndex:idx_invoices_tenant_sequence"`
+	Sequence          int              `json:"sequence" gorm:"type:int;not null;uniqueIndex:idx_invoices_tenant_sequence"`
+	Number            string           `json:"number" gorm:"type:varchar(30);not null;uniqueIndex:idx_invoices_tenant_number"`
+	IssuedAt          time.Time        `json:"issued_at" gorm:"not null"`
+	CreditedInvoiceID *uuid.UUID       `json:"credited_invoice_id,omitempty" gorm:"type:uuid;index"`
+	CreditedNumber    string           `json:"credited_number,omitempty" gorm:"type:varchar(30);not null;default:''"`
+	ReturnRequestID   *uuid.UUID       `json:"return_request_id,omitempty" gorm:"type:uuid;uniqueIndex"`
+	CustomerID        uuid.UUID        `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Customer          CustomerSnapshot `json:"customer" gorm:"embedded;embeddedPrefix:customer_"`
+	BillingAddress    Address          `json:"billing_address" gorm:"embedded;embeddedPrefix:billing_"`
+	Currency          string           `json:"currency" gorm:"type:varchar(3);not null"`
+	Subtotal          float64          `json:"subtotal" gorm:"type:decimal(10,2);not null"`
+	DiscountAmount    float64          `json:"discount_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	TaxAmount         float64          `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	TotalAmount       float64          `json:"total_amount" gorm:"type:decimal(10,2);not null"`
+	Lines             []InvoiceLine    `json:"lines" gorm:"foreignKey:InvoiceID"`
+}
+
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceLine is one invoiced order item. NetAmount is the line value after its share of the discount.
+type InvoiceLine struct {
+	BaseModel
+	TenantModel
+	InvoiceID   uuid.UUID `json:"invoice_id" gorm:"type:uuid;not null;index"`
+	OrderItemID uuid.UUID `json:"order_item_id" gorm:"type:uuid;not null"`
+	ProductID   uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Quantity    int       `json:"quantity" gorm:"type:int;not null"`
+	UnitPrice   float64   `json:"unit_price" gorm:"type:decimal(10,2);not null"`
+	NetAmount   float64   `json:"net_amount" gorm:"type:decimal(10,2);not null"`
+	TaxClass    string    `json:"tax_class" gorm:"type:varchar(30);not null;default:'standard'"`
+	TaxRate     float64   `json:"tax_rate" gorm:"type:decimal(7,5);not null;default:0"`
+	TaxAmount   float64   `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	TotalAmount float64   `json:"total_amount" gorm:"type:decimal(10,2);not null"`
+}
+
+func (InvoiceLine) TableName() string {
+	return "invoice_lines"
+}
+
+// InvoiceSequence holds the last number issued per tenant, document kind and year.
+// The row stays locked until the issuing transaction ends, so numbers are never skipped.
+type InvoiceSequence struct {
+	TenantID   string      `gorm:"type:varchar(64);primaryKey;default:'default'"`
+	Kind       InvoiceKind `gorm:"type:varchar(20);primaryKey"`
+	Year       int         `gorm:"type:int;primaryKey;autoIncrement:false"`
+	LastNumber int         `gorm:"type:int;not null"`
+}
+
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"order/pkg/http/utils"
+	"time"
+)
+
+// OrderEvent is one entry of an order's append-only event stream
+type OrderEvent struct {
+	BaseModel
+	TenantModel
+	AggregateType string    `json:"aggregate_type" gorm:"size:100;not null"`
+	AggregateID   uuid.UUID `json:"aggregate_id" gorm:"type:uuid;not null;uniqueIndex:idx_order_events_aggregate_version"`
+	Version       int       `json:"version" gorm:"not null;uniqueIndex:idx_order_events_aggregate_version"`
+	EventType     string    `json:"event_type" gorm:"type:varchar(100);not null"`
+	Payload       string    `json:"payload" gorm:"type:jsonb;not null"`
+	OccurredAt    time.Time `json:"occurred_at" gorm:"not null;index"`
+}
+
+func (OrderEvent) TableName() string {
+	return "order_events"
+}
+
+// OrderSnapshot stores the folded state of a stream up to Version so long streams load quickly
+type OrderSnapshot struct {
+	BaseModel
+	TenantModel
+	AggregateID uuid.UUID `json:"aggregate_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Version     int       `json:"version" gorm:"not null"`
+	State       string    `json:"state" gorm:"type:jsonb;not null"`
+}
+
+func (OrderSnapshot) TableName() string {
+	return "order_snapshots"
+}
+
+type RebuildProjectionsRequest struct {
+	// IgnoreSnapshots replays every stream from its first event and rewrites the snapshots
+	IgnoreSnapshots bool `json:"ignore_snapshots"`
+}
+
+type RebuildProjectionsResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data struct {
+		Rebuilt int64 `json:"rebuilt"`
+	} `json:"data"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"order/pkg/http/utils"
+)
+
+// ImportOrdersRequest selects the format of an import file. Historical imports store the orders
+// as they were placed elsewhere: no stock is reserved and no payment is requested.
+type ImportOrdersRequest struct {
+	Format     string `form:"format" binding:"omitempty,oneof=csv ndjson jsonl"`
+	Historical bool   `form:"historical"`
+}
+
+// ImportReport is the outcome of an import, with the orders created and the rows rejected
+type ImportReport struct {
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Orders   []ImportedOrder  `json:"orders"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+type ImportedOrder struct {
+	Row     int       `json:"row"`
+	Ref     string    `json:"ref,omitempty"`
+	OrderID uuid.UUID `json:"order_id"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Ref   string `json:"ref,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportOrdersResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data ImportReport    `json:"data"`
+}
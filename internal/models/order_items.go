@@ -4,10 +4,14 @@ import "github.com/google/uuid"
 
 type OrderItem struct {
 	BaseModel
+	TenantModel
 	OrderID   uuid.UUID `json:"order_id" gorm:"type:uuid;not null;index"`
 	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
 	Quantity  int       `json:"quantity" gorm:"type:int;not null"`
 	UnitPrice float64   `json:"unit_price" gorm:"type:decimal(10,2);not null"`
+	TaxClass  string    `json:"tax_class" gorm:"type:varchar(30);not null;default:'standard'"`
+	TaxRate   float64   `json:"tax_rate" gorm:"type:decimal(7,5);not null;default:0"`
+	TaxAmount float64   `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
 	Order     Order     `json:"order" gorm:"foreignKey:OrderID;references:ID"`
 }
 
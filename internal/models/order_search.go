@@ -0,0 +1,57 @@
+package models
+
+import (
+	"order/pkg/http/utils"
+	"time"
+)
+
+// OrderSearchRequest is a search of orders by support agents. Q matches a partial order ID,
+// customer email or product ID, or words of the customer and address snapshots; the other
+// fields narrow the result.
+type OrderSearchRequest struct {
+	Q             string     `form:"q" binding:"omitempty,max=200"`
+	OrderID       string     `form:"order_id" binding:"omitempty,max=36"`
+	CustomerEmail string     `form:"customer_email" binding:"omitempty,max=254"`
+	ProductID     string     `form:"product_id" binding:"omitempty,uuid"`
+	Status        []string   `form:"status"`
+	From          *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To            *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Interval is the bucket width of the date histogram
+	Interval string `form:"interval" binding:"omitempty,oneof=day week month"`
+	Page     int    `form:"page" binding:"omitempty,gte=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,gte=1,lte=100"`
+}
+
+// OrderSearchHit is a matching order with the matched parts of its fields wrapped in <em>, by field name
+type OrderSearchHit struct {
+	Order      Order               `json:"order"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+type StatusFacet struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+type DateBucket struct {
+	Start time.Time `json:"start"`
+	Count int64     `json:"count"`
+}
+
+// OrderSearchFacets count all the matching orders, not only the returned page
+type OrderSearchFacets struct {
+	Status    []StatusFacet `json:"status"`
+	CreatedAt []DateBucket  `json:"created_at"`
+}
+
+type OrderSearchResult struct {
+	Total  int64             `json:"total"`
+	Hits   []OrderSearchHit  `json:"hits"`
+	Facets OrderSearchFacets `json:"facets"`
+}
+
+type OrderSearchResponse struct {
+	Meta *utils.MetaData   `json:"meta"`
+	Data OrderSearchResult `json:"data"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"order/pkg/http/utils"
+	"time"
+)
+
+type StatsGranularity string
+
+const (
+	StatsGranularityHour StatsGranularity = "hour"
+	StatsGranularityDay  StatsGranularity = "day"
+)
+
+// RevenueOrderStatuses are the statuses of orders whose payment went through; revenue and
+// average order value only count them
+var RevenueOrderStatuses = []string{string(OrderStatusAuthorized), string(OrderStatusCompleted)}
+
+// OrderStat aggregates the orders of a tenant created within one UTC hour or day, by currency and
+// status. Rows are rebuilt by the refresh_order_stats job from the orders changed since the
+// last refresh. Amounts are net of refunds; the base amounts are converted at the order FX rate.
+type OrderStat struct {
+	TenantModel
+	Granularity StatsGranularity `json:"granularity" gorm:"type:varchar(5);not null"`
+	BucketStart time.Time        `json:"bucket_start" gorm:"not null"`
+	Currency    string           `json:"currency" gorm:"type:varchar(3);not null;default:''"`
+	Status      string           `json:"status" gorm:"type:varchar(20);not null"`
+	OrderCount  int64            `json:"order_count" gorm:"not null;default:0"`
+	Revenue     float64          `json:"revenue" gorm:"type:decimal(14,2);not null;default:0"`
+	RevenueBase float64          `json:"revenue_base" gorm:"type:decimal(14,2);not null;default:0"`
+	RefreshedAt time.Time        `json:"refreshed_at" gorm:"not null"`
+}
+
+func (OrderStat) TableName() string {
+	return "order_stats"
+}
+
+// OrderStatsWatermark is the time up to which the order stats of a tenant are current
+type OrderStatsWatermark struct {
+	BaseModel
+	TenantModel
+	RefreshedUntil time.Time `json:"refreshed_until" gorm:"not null"`
+}
+
+func (OrderStatsWatermark) TableName() string {
+	return "order_stats_watermarks"
+}
+
+// OrderStatsBucket sums the stats of a bucket, or of one currency / status of it when grouped
+type OrderStatsBucket struct {
+	BucketStart time.Time
+	Currency    string
+	Status      string
+	Orders      int64
+	PaidOrders  int64
+	Revenue     float64
+	RevenueBase float64
+}
+
+// OrderReportRequest selects the buckets of a report. GroupBy takes currency and status,
+// comma separated; without currency, revenue is only given in the base currency.
+type OrderReportRequest struct {
+	From        *time.Time       `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          *time.Time       `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Granularity StatsGranularity `form:"granularity" binding:"omitempty,oneof=hour day"`
+	GroupBy     string           `form:"group_by"`
+}
+
+// OrderReportRow holds the figures of one bucket, or of one currency / status of it when grouped
+type OrderReportRow struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Currency    string    `json:"currency,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Orders      int64     `json:"orders"`
+	PaidOrders  int64     `json:"paid_orders"`
+	// Revenue is in Currency, set when grouped by currency
+	Revenue           *float64 `json:"revenue,omitempty"`
+	RevenueBase       float64  `json:"revenue_base"`
+	AverageOrderValue float64  `json:"average_order_value_base"`
+	// StatusShare is the part of the orders of the bucket (and currency) in Status, set when grouped by status
+	StatusShare *float64 `json:"status_share,omitempty"`
+}
+
+type OrderReport struct {
+	Granularity StatsGranularity `json:"granularity"`
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	GroupBy     []string         `json:"group_by"`
+	// RefreshedUntil is how current the figures are
+	RefreshedUntil *time.Time       `json:"refreshed_until"`
+	Rows           []OrderReportRow `json:"rows"`
+}
+
+type OrderReportResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data OrderReport     `json:"data"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ActorType string
+
+const (
+	ActorTypeUser   ActorType = "user"
+	ActorTypeSystem ActorType = "system"
+	ActorTypeWorker ActorType = "worker"
+)
+
+type ChangeSource string
+
+const (
+	ChangeSourceHTTP      ChangeSource = "http"
+	ChangeSourceGRPC      ChangeSource = "grpc"
+	ChangeSourceKafka     ChangeSource = "kafka"
+	ChangeSourceScheduler ChangeSource = "scheduler"
+	ChangeSourceImport    ChangeSource = "import"
+)
+
+// OrderStatusHistory is the audit trail of every orders.status change
+type OrderStatusHistory struct {
+	BaseModel
+	TenantModel
+	OrderID    uuid.UUID    `json:"order_id" gorm:"type:uuid;not null;index"`
+	FromStatus string       `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   string       `json:"to_status" gorm:"type:varchar(20);not null"`
+	ActorType  ActorType    `json:"actor_type" gorm:"type:varchar(20);not null"`
+	ActorID    string       `json:"actor_id" gorm:"type:varchar(100)"`
+	Source     ChangeSource `json:"source" gorm:"type:varchar(20);not null"`
+	SourceRef  string       `json:"source_ref" gorm:"type:varchar(255)"` // rpc method, route, or kafka topic/partition@offset
+	Reason     string       `json:"reason" gorm:"type:text"`
+	ChangedAt  time.Time    `json:"changed_at" gorm:"not null;index"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// StatusChange describes who changed an order status, from where and why
+type StatusChange struct {
+	ActorType ActorType
+	ActorID   string
+	Source    ChangeSource
+	SourceRef string
+	Reason    string
+}
+
+// NewHistory builds the audit row for a transition of an order
+func (c StatusChange) NewHistory(orderID uuid.UUID, from, to string) *OrderStatusHistory {
+	return &OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorType:  c.ActorType,
+		ActorID:    c.ActorID,
+		Source:     c.Source,
+		SourceRef:  c.SourceRef,
+		Reason:     c.Reason,
+		ChangedAt:  time.Now(),
+	}
+}
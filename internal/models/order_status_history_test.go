@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestStatusChangeNewHistory(t *testing.T) {
+	change := StatusChange{
+		ActorType: ActorTypeWorker,
+		ActorID:   "payment_event_worker",
+		Source:    ChangeSourceKafka,
+		SourceRef: "payments/3@42",
+		Reason:    "payment pay-1 authorized",
+	}
+	orderID := uuid.New()
+	before := time.Now()
+
+	history := change.NewHistory(orderID, "PENDING", "AUTHORIZED")
+	want := OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: "PENDING",
+		ToStatus:   "AUTHORIZED",
+		ActorType:  ActorTypeWorker,
+		ActorID:    "payment_event_worker",
+		Source:     ChangeSourceKafka,
+		SourceRef:  "payments/3@42",
+		Reason:     "payment pay-1 authorized",
+		ChangedAt:  history.ChangedAt,
+	}
+	if *history != want {
+		t.Errorf("NewHistory() = %+v, want %+v", *history, want)
+	}
+	if history.ChangedAt.Before(before) || history.ChangedAt.After(time.Now()) {
+		t.Errorf("changed at %v, want now", history.ChangedAt)
+	}
+}
@@ -2,13 +2,59 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"math"
+	"order/pkg/http/paging"
 	"order/pkg/http/utils"
+	"slices"
+	"strings"
+	"time"
 )
 
+type OrderStatus string
+
+const (
+	OrderStatusPending    OrderStatus = "PENDING"
+	OrderStatusAuthorized OrderStatus = "AUTHORIZED"
+	OrderStatusDeclined   OrderStatus = "DECLINED"
+	OrderStatusCompleted  OrderStatus = "COMPLETED"
+	OrderStatusCancelled  OrderStatus = "CANCELLED"
+	OrderStatusExpired    OrderStatus = "EXPIRED"
+)
+
+// PendingOrderStatuses lists the spellings a pending order can be stored with,
+// since CreateOrderRequest accepts the lowercase form from HTTP clients.
+var PendingOrderStatuses = []string{
+	string(OrderStatusPending),
+	strings.ToLower(string(OrderStatusPending)),
+}
+
+// orderStatusTransitions lists the statuses an order may move to from each status. Declined,
+// completed, cancelled and expired orders are final.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusAuthorized, OrderStatusDeclined, OrderStatusCancelled, OrderStatusExpired},
+	OrderStatusAuthorized: {OrderStatusCompleted, OrderStatusCancelled},
+}
+
+// CanTransitionOrder reports whether an order stored with status from may move to status to
+func CanTransitionOrder(from string, to OrderStatus) bool {
+	return slices.Contains(orderStatusTransitions[OrderStatus(strings.ToUpper(from))], to)
+}
+
 type Order struct {
 	BaseModel
+	TenantModel
 	CustomerID        uuid.UUID        `json:"customer_id" gorm:"type:uuid;not null;index"`
 	TotalAmount       float64          `json:"total_amount" gorm:"type:decimal(10,2);not null"`
+	DiscountAmount    float64          `json:"discount_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	RefundedAmount    float64          `json:"refunded_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	Currency          string           `json:"currency" gorm:"type:varchar(3);not null;default:''"`
+	FxRate            float64          `json:"fx_rate" gorm:"type:decimal(18,8);not null;default:1"`
+	TaxAmount         float64          `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	TaxCountry        string           `json:"tax_country,omitempty" gorm:"type:varchar(2);not null;default:''"`
+	TaxRegion         string           `json:"tax_region,omitempty" gorm:"type:varchar(50);not null;default:''"`
+	Customer          CustomerSnapshot `json:"customer" gorm:"embedded;embeddedPrefix:customer_"`
+	ShippingAddress   Address          `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_"`
+	BillingAddress    Address          `json:"billing_address" gorm:"embedded;embeddedPrefix:billing_"`
 	Status            string           `json:"status" gorm:"type:varchar(20);not null;index"`
 	RewardGiven       bool             `json:"reward_given" gorm:"type:boolean;not null;default:false"`
 	OrderItems        []OrderItem      `json:"order_items" gorm:"foreignKey:OrderID"`
@@ -20,17 +66,58 @@ func (Order) TableName() string {
 	return "orders"
 }
 
+// GetFilterableFields lists the columns list endpoints may filter orders by
+func (Order) GetFilterableFields() map[string]paging.FieldType {
+	return map[string]paging.FieldType{
+		"status":              paging.FieldString,
+		"currency":            paging.FieldString,
+		"total_amount":        paging.FieldNumber,
+		"tax_amount":          paging.FieldNumber,
+		"discount_amount":     paging.FieldNumber,
+		"refunded_amount":     paging.FieldNumber,
+		"reward_given":        paging.FieldBool,
+		"promotion_config_id": paging.FieldUUID,
+		"created_at":          paging.FieldTime,
+		"updated_at":          paging.FieldTime,
+	}
+}
+
+// IsPendingPayment reports whether the order is still waiting for payment
+func (o *Order) IsPendingPayment() bool {
+	return strings.EqualFold(o.Status, string(OrderStatusPending))
+}
+
 type CreateOrderRequest struct {
 	CustomerID  uuid.UUID                `json:"customer_id" binding:"required,uuid"`
 	TotalAmount float64                  `json:"total_amount" binding:"required,gt=0"`
+	Discount    float64                  `json:"discount_amount" binding:"gte=0"`
 	Status      string                   `json:"status" binding:"required,oneof=pending completed cancelled"`
 	OrderItems  []CreateOrderItemRequest `json:"order_items" binding:"required"`
+	Country     string                   `json:"country" binding:"omitempty,len=2"`
+	Region      string                   `json:"region"`
+	Currency    string                   `json:"currency" binding:"omitempty,len=3"`
+	Customer    CustomerSnapshot         `json:"customer"`
+	Shipping    Address                  `json:"shipping_address"`
+	Billing     Address                  `json:"billing_address"`
+	Audit       StatusChange             `json:"-"`
+
+	// TaxAmount and FxRate are computed when the order is priced
+	TaxAmount float64 `json:"-"`
+	FxRate    float64 `json:"-"`
+
+	// PlacedAt backdates orders imported from another system
+	PlacedAt *time.Time `json:"-"`
 }
 
 type CreateOrderItemRequest struct {
 	ProductID   uuid.UUID `json:"product_id" binding:"required,uuid"`
 	Quantity    int       `json:"quantity" binding:"required,gt=0"`
 	UniquePrice float64   `json:"price" binding:"required,gt=0"`
+	TaxClass    string    `json:"tax_class"`
+
+	// TaxRate and TaxAmount are computed when the order is priced
+	TaxRate   float64 `json:"-"`
+	TaxAmount float64 `json:"-"`
 }
 
 type CreateOrderResponse struct {
@@ -42,5 +129,76 @@ type CreateOrderResponseData struct {
 	OrderID     uuid.UUID `json:"order_id"`
 	CustomerID  uuid.UUID `json:"customer_id"`
 	TotalAmount float64   `json:"total_amount"`
+	TaxAmount   float64   `json:"tax_amount"`
+	TaxLines    []TaxLine `json:"tax_lines,omitempty"`
+	Currency    string    `json:"currency"`
+	FxRate      float64   `json:"fx_rate"`
 	Status      string    `json:"status"`
 }
+
+// TaxLine is the tax charged on one line item
+type TaxLine struct {
+	ProductID uuid.UUID `json:"product_id"`
+	TaxClass  string    `json:"tax_class"`
+	Rate      float64   `json:"rate"`
+	Amount    float64   `json:"amount"`
+}
+
+// Subtotal is the value of the line items before discount and tax
+func (o *Order) Subtotal() float64 {
+	var subtotal float64
+	for _, item := range o.OrderItems {
+		subtotal += float64(item.Quantity) * item.UnitPrice
+	}
+	return subtotal
+}
+
+// SumItems returns the order total derived from its line items: their value less the discount, plus their tax
+func (o *Order) SumItems() float64 {
+	var tax float64
+	for _, item := range o.OrderItems {
+		tax += item.TaxAmount
+	}
+	return o.NetOfDiscount() + tax
+}
+
+// NetOfDiscount is the value of the line items less the discount, before tax
+func (o *Order) NetOfDiscount() float64 {
+	subtotal := o.Subtotal()
+	if subtotal < o.DiscountAmount {
+		return 0
+	}
+	return subtotal - o.DiscountAmount
+}
+
+// NetAmount is what the customer still pays for the order after refunds
+func (o *Order) NetAmount() float64 {
+	return o.TotalAmount - o.RefundedAmount
+}
+
+// QualifyingAmount is the value promotions are matched on: the goods less the discount, before
+// tax, reduced in proportion to what was refunded
+func (o *Order) QualifyingAmount() float64 {
+	amount := o.NetOfDiscount()
+	if o.RefundedAmount > 0 && o.TotalAmount > 0 {
+		amount *= math.Max(o.NetAmount(), 0) / o.TotalAmount
+	}
+	return amount
+}
+
+// RefundFor returns the refund for quantity units of item. The discount of the order is spread
+// over its items in proportion to their value, the tax of the item is refunded pro rata, and the
+// refund never exceeds the net amount.
+func (o *Order) RefundFor(item OrderItem, quantity int) float64 {
+	subtotal := o.Subtotal()
+	if subtotal <= 0 {
+		return 0
+	}
+
+	refund := float64(quantity) * item.UnitPrice * (o.TotalAmount - o.TaxAmount) / subtotal
+	if item.Quantity > 0 {
+		refund += item.TaxAmount * float64(quantity) / float64(item.Quantity)
+	}
+	refund = math.Round(refund*100) / 100
+	return math.Min(refund, o.NetAmount())
+}
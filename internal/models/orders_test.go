@@ -0,0 +1,78 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOrderQualifyingAmount(t *testing.T) {
+	items := []OrderItem{{Quantity: 2, UnitPrice: 10}, {Quantity: 1, UnitPrice: 30}}
+
+	tests := []struct {
+		name  string
+		order Order
+		want  float64
+	}{
+		{
+			name:  "goods before tax",
+			order: Order{OrderItems: items, TaxAmount: 10, TotalAmount: 60},
+			want:  50,
+		},
+		{
+			name:  "less the discount",
+			order: Order{OrderItems: items, DiscountAmount: 5, TaxAmount: 9, TotalAmount: 54},
+			want:  45,
+		},
+		{
+			name:  "discount above the goods",
+			order: Order{OrderItems: items, DiscountAmount: 80, TotalAmount: 0},
+			want:  0,
+		},
+		{
+			name:  "half refunded",
+			order: Order{OrderItems: items, TaxAmount: 10, TotalAmount: 60, RefundedAmount: 30},
+			want:  25,
+		},
+		{
+			name:  "fully refunded",
+			order: Order{OrderItems: items, TaxAmount: 10, TotalAmount: 60, RefundedAmount: 60},
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.order.QualifyingAmount(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("QualifyingAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from string
+		to   OrderStatus
+		want bool
+	}{
+		{"PENDING", OrderStatusAuthorized, true},
+		{"pending", OrderStatusAuthorized, true},
+		{"PENDING", OrderStatusExpired, true},
+		{"PENDING", OrderStatusCompleted, false},
+		{"AUTHORIZED", OrderStatusCancelled, true},
+		{"AUTHORIZED", OrderStatusCompleted, true},
+		{"AUTHORIZED", OrderStatusAuthorized, false},
+		{"AUTHORIZED", OrderStatusExpired, false},
+		{"EXPIRED", OrderStatusAuthorized, false},
+		{"DECLINED", OrderStatusAuthorized, false},
+		{"COMPLETED", OrderStatusCancelled, false},
+		{"CANCELLED", OrderStatusPending, false},
+		{"", OrderStatusAuthorized, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+string(tt.to), func(t *testing.T) {
+			if got := CanTransitionOrder(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransitionOrder(%q, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
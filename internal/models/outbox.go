@@ -17,10 +17,13 @@ const (
 	OutboxStatusRetry   OutboxStatus = "RETRY"
 	OutboxStatusDone    OutboxStatus = "DONE"
 	OutboxStatusFailed  OutboxStatus = "FAILED"
+	// OutboxStatusSuperseded marks a row replaced by a newer event before it was delivered
+	OutboxStatusSuperseded OutboxStatus = "SUPERSEDED"
 )
 
 type Outbox struct {
 	BaseModel
+	TenantModel
 	EventID       uuid.UUID    `gorm:"type:uuid;uniqueIndex;not null"`
 	EventType     string       `gorm:"type:varchar(100);not null"`
 	Payload       string       `gorm:"type:jsonb;not null"`
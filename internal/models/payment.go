@@ -9,3 +9,19 @@ type PaymentAuthorizedEvent struct {
 	Amount         float64              `json:"amount"`
 	Status         events.PaymentStatus `json:"status"`
 }
+
+// PaymentVoidEvent asks the payment service to void the payment of an order whose checkout was rolled
+// back, or only the payment PaymentID when it authorized a superseded payment request
+type PaymentVoidEvent struct {
+	OrderID   string `json:"order_id"`
+	PaymentID string `json:"payment_id,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// PaymentRefundEvent asks the payment service to refund part of the payment of an order
+type PaymentRefundEvent struct {
+	OrderID  string  `json:"order_id"`
+	ReturnID string  `json:"return_id"`
+	Amount   float64 `json:"amount"`
+	Reason   string  `json:"reason"`
+}
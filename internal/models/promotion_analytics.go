@@ -0,0 +1,78 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"order/pkg/http/utils"
+	"time"
+)
+
+type PromotionRewardInterval string
+
+const (
+	PromotionRewardIntervalHour PromotionRewardInterval = "hour"
+	PromotionRewardIntervalDay  PromotionRewardInterval = "day"
+	PromotionRewardIntervalWeek PromotionRewardInterval = "week"
+)
+
+// PromotionAnalytics aggregates promotion_rewards joined with orders for a single PromotionConfig
+type PromotionAnalytics struct {
+	PromotionConfigID      uuid.UUID `json:"promotion_config_id"`
+	Name                   string    `json:"name"`
+	IsActive               bool      `json:"is_active"`
+	RewardsIssued          int64     `json:"rewards_issued"`
+	DistinctCustomers      int64     `json:"distinct_customers"`
+	QualifyingOrders       int64     `json:"qualifying_orders"`
+	QualifyingRevenue      float64   `json:"qualifying_revenue"`
+	QualifyingAOV          float64   `json:"qualifying_aov"`
+	NonQualifyingOrders    int64     `json:"non_qualifying_orders"`
+	NonQualifyingAOV       float64   `json:"non_qualifying_aov"`
+	AOVUplift              float64   `json:"aov_uplift"`
+	AOVUpliftPercent       float64   `json:"aov_uplift_percent"`
+	Spend                  float64   `json:"spend"`
+	Budget                 float64   `json:"budget"`
+	RemainingBudget        *float64  `json:"remaining_budget,omitempty"`
+	RemainingRewards       *int64    `json:"remaining_rewards,omitempty"`
+	RemainingCustomerSlots *int64    `json:"remaining_customer_slots,omitempty"`
+	StartTime              time.Time `json:"start_time"`
+	EndTime                time.Time `json:"end_time"`
+}
+
+// PromotionQualifyingStats is the raw result of the rewards/orders join
+type PromotionQualifyingStats struct {
+	RewardsIssued     int64   `gorm:"column:rewards_issued"`
+	DistinctCustomers int64   `gorm:"column:distinct_customers"`
+	QualifyingOrders  int64   `gorm:"column:qualifying_orders"`
+	QualifyingRevenue float64 `gorm:"column:qualifying_revenue"`
+	QualifyingAOV     float64 `gorm:"column:qualifying_aov"`
+	Spend             float64 `gorm:"column:spend"`
+}
+
+// PromotionNonQualifyingStats describes paid orders in the promotion window that did not receive a reward
+type PromotionNonQualifyingStats struct {
+	Orders int64   `gorm:"column:orders"`
+	AOV    float64 `gorm:"column:aov"`
+}
+
+type PromotionRewardBucket struct {
+	Bucket            time.Time `json:"bucket" gorm:"column:bucket"`
+	RewardsIssued     int64     `json:"rewards_issued" gorm:"column:rewards_issued"`
+	DistinctCustomers int64     `json:"distinct_customers" gorm:"column:distinct_customers"`
+	QualifyingRevenue float64   `json:"qualifying_revenue" gorm:"column:qualifying_revenue"`
+	Spend             float64   `json:"spend" gorm:"column:spend"`
+}
+
+type PromotionRewardTimelineRequest struct {
+	Interval PromotionRewardInterval `form:"interval" binding:"omitempty,oneof=hour day week"`
+	From     *time.Time              `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time              `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type PromotionAnalyticsResponse struct {
+	Meta *utils.MetaData    `json:"meta"`
+	Data PromotionAnalytics `json:"data"`
+}
+
+type PromotionRewardTimelineResponse struct {
+	Meta *utils.MetaData         `json:"meta"`
+	Data []PromotionRewardBucket `json:"data"`
+}
@@ -1,20 +1,72 @@
 package models
 
-import "time"
+import (
+	"github.com/google/uuid"
+	"order/pkg/http/utils"
+	"strings"
+	"time"
+)
 
 type PromotionConfig struct {
 	BaseModel
-	Name            string            `json:"name" gorm:"type:varchar(100);not null;unique"`
-	CustomerLimit   int               `json:"customer_limit" gorm:"type:int;not null;default:1"`
-	RewardLimit     int               `json:"reward_limit" gorm:"type:int;not null;default:1"`
-	MinOrderValue   float64           `json:"min_order_value" gorm:"type:decimal(10,2);not null;default:0.00"`
-	IsActive        bool              `json:"is_active" gorm:"type:boolean;not null;default:true"`
-	StartTime       time.Time         `json:"start_time" gorm:"type:timestamp;not null"`
-	EndTime         time.Time         `json:"end_time" gorm:"type:timestamp;not null"`
-	PromotionReward []PromotionReward `json:"promotion_rewards" gorm:"foreignKey:PromotionConfigID"`
-	Order           []Order           `json:"orders" gorm:"foreignKey:PromotionConfigID;references:ID"`
+	TenantID        string               `json:"-" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_promotion_configs_tenant_name"`
+	Name            string               `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_promotion_configs_tenant_name"`
+	CustomerLimit   int                  `json:"customer_limit" gorm:"type:int;not null;default:1"`
+	RewardLimit     int                  `json:"reward_limit" gorm:"type:int;not null;default:1"`
+	MinOrderValue   float64              `json:"min_order_value" gorm:"type:decimal(10,2);not null;default:0.00"`
+	RewardValue     float64              `json:"reward_value" gorm:"type:decimal(10,2);not null;default:0.00"`
+	Budget          float64              `json:"budget" gorm:"type:decimal(12,2);not null;default:0.00"` // 0 means unlimited
+	IsActive        bool                 `json:"is_active" gorm:"type:boolean;not null;default:true"`
+	StartTime       time.Time            `json:"start_time" gorm:"type:timestamp;not null"`
+	EndTime         time.Time            `json:"end_time" gorm:"type:timestamp;not null"`
+	Thresholds      []PromotionThreshold `json:"thresholds,omitempty" gorm:"foreignKey:PromotionConfigID"`
+	PromotionReward []PromotionReward    `json:"promotion_rewards" gorm:"foreignKey:PromotionConfigID"`
+	Order           []Order              `json:"orders" gorm:"foreignKey:PromotionConfigID;references:ID"`
 }
 
 func (PromotionConfig) TableName() string {
 	return "promotion_configs"
 }
+
+// PromotionThreshold overrides the minimum order value of a promotion for orders in Currency
+type PromotionThreshold struct {
+	BaseModel
+	TenantModel
+	PromotionConfigID uuid.UUID `json:"promotion_config_id" gorm:"type:uuid;not null;uniqueIndex:idx_promotion_thresholds_currency"`
+	Currency          string    `json:"currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_promotion_thresholds_currency"`
+	MinOrderValue     float64   `json:"min_order_value" gorm:"type:decimal(10,2);not null"`
+}
+
+func (PromotionThreshold) TableName() string {
+	return "promotion_thresholds"
+}
+
+type UpsertPromotionThresholdRequest struct {
+	Currency      string  `json:"currency" binding:"required,len=3"`
+	MinOrderValue float64 `json:"min_order_value" binding:"gte=0"`
+}
+
+type PromotionThresholdResponse struct {
+	Meta *utils.MetaData     `json:"meta"`
+	Data *PromotionThreshold `json:"data"`
+}
+
+// Qualifies reports whether an order amount in currency reaches the minimum order value.
+// A threshold defined for the currency wins; otherwise the amount is converted with fxRate and
+// compared to MinOrderValue, which is in the base currency.
+func (p *PromotionConfig) Qualifies(amount float64, currency string, fxRate float64) bool {
+	for _, threshold := range p.Thresholds {
+		if strings.EqualFold(threshold.Currency, currency) {
+			return amount >= threshold.MinOrderValue
+		}
+	}
+	if fxRate <= 0 {
+		fxRate = 1
+	}
+	return amount*fxRate >= p.MinOrderValue
+}
+
+// HasBudget reports whether the promotion is capped by a monetary budget
+func (p *PromotionConfig) HasBudget() bool {
+	return p.Budget > 0
+}
@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestPromotionConfigQualifies(t *testing.T) {
+	promo := &PromotionConfig{MinOrderValue: 100, Thresholds: []PromotionThreshold{{Currency: "JPY", MinOrderValue: 15000}}}
+
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		fxRate   float64
+		want     bool
+	}{
+		{name: "base currency at the minimum", amount: 100, currency: "USD", fxRate: 1, want: true},
+		{name: "base currency below the minimum", amount: 99.99, currency: "USD", fxRate: 1},
+		{name: "converted above the minimum", amount: 95, currency: "EUR", fxRate: 1.1, want: true},
+		{name: "converted below the minimum", amount: 90, currency: "EUR", fxRate: 1.1},
+		{name: "threshold of the currency wins over conversion", amount: 15000, currency: "jpy", fxRate: 0.0067, want: true},
+		{name: "below the threshold of the currency", amount: 14999, currency: "JPY", fxRate: 1},
+		{name: "missing rate counts as the base currency", amount: 100, currency: "EUR", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promo.Qualifies(tt.amount, tt.currency, tt.fxRate); got != tt.want {
+				t.Errorf("Qualifies(%v %s at %v) = %v, want %v", tt.amount, tt.currency, tt.fxRate, got, tt.want)
+			}
+		})
+	}
+}
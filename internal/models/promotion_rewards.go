@@ -7,10 +7,12 @@ import (
 
 type PromotionReward struct {
 	BaseModel
+	TenantModel
 	PromotionConfigID uuid.UUID        `json:"promotion_config_id" gorm:"type:uuid;not null;index"`
 	PromotionConfig   *PromotionConfig `json:"promotion_config" gorm:"foreignKey:PromotionConfigID;references:ID"`
 	OrderID           uuid.UUID        `json:"order_id" gorm:"type:uuid;not null;index"`
 	CustomerID        uuid.UUID        `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Amount            float64          `json:"amount" gorm:"type:decimal(10,2);not null;default:0.00"`
 	ReceivedAt        time.Time        `json:"received_at" gorm:"type:timestamp;not null"`
 }
 
@@ -21,3 +23,12 @@ func (PromotionReward) TableName() string {
 type PromotionRewardEvent struct {
 	OrderID string `json:"order_id"`
 }
+
+// PromotionRewardRevokedEvent announces a revoked reward: a cancelled order gives the Reason,
+// a return that drops the order below the promotion threshold gives its ReturnID
+type PromotionRewardRevokedEvent struct {
+	RewardID string `json:"reward_id"`
+	OrderID  string `json:"order_id"`
+	ReturnID string `json:"return_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
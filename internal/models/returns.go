@@ -0,0 +1,51 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "REQUESTED"
+	ReturnStatusApproved  ReturnStatus = "APPROVED"
+	ReturnStatusRejected  ReturnStatus = "REJECTED"
+	ReturnStatusReceived  ReturnStatus = "RECEIVED"
+	ReturnStatusInspected ReturnStatus = "INSPECTED"
+	ReturnStatusCompleted ReturnStatus = "COMPLETED"
+)
+
+// ReturnableOrderStatuses are the order statuses that accept return requests
+var ReturnableOrderStatuses = []OrderStatus{OrderStatusAuthorized, OrderStatusCompleted}
+
+// ReturnRequest is a customer's request to return a quantity of one order item (RMA).
+// It moves REQUESTED -> APPROVED -> RECEIVED -> INSPECTED -> COMPLETED; a request can be
+// rejected on review or when the inspection fails.
+type ReturnRequest struct {
+	BaseModel
+	TenantModel
+	OrderID        uuid.UUID    `json:"order_id" gorm:"type:uuid;not null;index"`
+	OrderItemID    uuid.UUID    `json:"order_item_id" gorm:"type:uuid;not null;index"`
+	CustomerID     uuid.UUID    `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Quantity       int          `json:"quantity" gorm:"type:int;not null"`
+	Reason         string       `json:"reason" gorm:"type:text;not null"`
+	Status         ReturnStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	ReviewNote     string       `json:"review_note,omitempty" gorm:"type:text"`
+	InspectionNote string       `json:"inspection_note,omitempty" gorm:"type:text"`
+	RefundAmount   float64      `json:"refund_amount" gorm:"type:decimal(10,2);not null;default:0.00"`
+	ReviewedAt     *time.Time   `json:"reviewed_at"`
+	ReceivedAt     *time.Time   `json:"received_at"`
+	InspectedAt    *time.Time   `json:"inspected_at"`
+	CompletedAt    *time.Time   `json:"completed_at"`
+}
+
+func (ReturnRequest) TableName() string {
+	return "return_requests"
+}
+
+type CreateReturnRequest struct {
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	Quantity    int       `json:"quantity"`
+	Reason      string    `json:"reason"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"order/pkg/http/utils"
+	"time"
+)
+
+type SagaStatus string
+
+const (
+	SagaStatusRunning      SagaStatus = "RUNNING"
+	SagaStatusWaiting      SagaStatus = "WAITING"
+	SagaStatusCompleted    SagaStatus = "COMPLETED"
+	SagaStatusCompensating SagaStatus = "COMPENSATING"
+	SagaStatusCompensated  SagaStatus = "COMPENSATED"
+	SagaStatusFailed       SagaStatus = "FAILED"
+)
+
+type SagaPhase string
+
+const (
+	SagaPhaseAction       SagaPhase = "action"
+	SagaPhaseCompensation SagaPhase = "compensation"
+)
+
+// Saga is the persisted state of one saga run for an order.
+// While running StepIndex is the step to execute next; while compensating it is the
+// number of steps still to compensate.
+type Saga struct {
+	BaseModel
+	TenantModel
+	Type        string     `json:"type" gorm:"type:varchar(50);not null;uniqueIndex:idx_sagas_type_order"`
+	OrderID     uuid.UUID  `json:"order_id" gorm:"type:uuid;not null;uniqueIndex:idx_sagas_type_order"`
+	Status      SagaStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	CurrentStep string     `json:"current_step" gorm:"type:varchar(50)"`
+	StepIndex   int        `json:"step_index" gorm:"not null;default:0"`
+	Deadline    *time.Time `json:"deadline" gorm:"index"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	Steps       []SagaStep `json:"steps,omitempty" gorm:"foreignKey:SagaID"`
+}
+
+func (Saga) TableName() string {
+	return "sagas"
+}
+
+// IsActive reports whether the saga still executes steps
+func (s *Saga) IsActive() bool {
+	return s.Status == SagaStatusRunning || s.Status == SagaStatusWaiting
+}
+
+// SagaStep is the execution log of a saga, one row per step outcome
+type SagaStep struct {
+	BaseModel
+	TenantModel
+	SagaID uuid.UUID `json:"saga_id" gorm:"type:uuid;not null;index"`
+	Name   string    `json:"name" gorm:"type:varchar(50);not null"`
+	Phase  SagaPhase `json:"phase" gorm:"type:varchar(20);not null"`
+	Status string    `json:"status" gorm:"type:varchar(20);not null"`
+	Error  string    `json:"error,omitempty" gorm:"type:text"`
+}
+
+func (SagaStep) TableName() string {
+	return "saga_steps"
+}
+
+type ListSagasRequest struct {
+	Status SagaStatus `form:"status" binding:"omitempty,oneof=RUNNING WAITING COMPLETED COMPENSATING COMPENSATED FAILED"`
+	Limit  int        `form:"limit" binding:"omitempty,gt=0,lte=500"`
+}
+
+type ListSagasResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data []Saga          `json:"data"`
+}
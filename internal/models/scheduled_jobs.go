@@ -0,0 +1,63 @@
+package models
+
+import (
+	"order/pkg/http/utils"
+	"time"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   JobRunStatus = "RUNNING"
+	JobRunStatusSucceeded JobRunStatus = "SUCCEEDED"
+	JobRunStatusFailed    JobRunStatus = "FAILED"
+)
+
+// ScheduledJob holds the schedule and the execution lease of a job, shared by every replica
+type ScheduledJob struct {
+	BaseModel
+	Name        string       `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Schedule    string       `json:"schedule" gorm:"type:varchar(100);not null"`
+	Enabled     bool         `json:"enabled" gorm:"type:boolean;not null;default:true"`
+	NextRunAt   time.Time    `json:"next_run_at" gorm:"not null;index"`
+	LastRunAt   *time.Time   `json:"last_run_at"`
+	LastStatus  JobRunStatus `json:"last_status" gorm:"type:varchar(20)"`
+	LockedBy    string       `json:"locked_by" gorm:"type:varchar(255)"`
+	LockedUntil *time.Time   `json:"locked_until"`
+}
+
+func (ScheduledJob) TableName() string {
+	return "scheduled_jobs"
+}
+
+// JobRun is one execution of a ScheduledJob
+type JobRun struct {
+	BaseModel
+	JobName     string       `json:"job_name" gorm:"type:varchar(100);not null;index"`
+	InstanceID  string       `json:"instance_id" gorm:"type:varchar(255);not null"`
+	Status      JobRunStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	ScheduledAt time.Time    `json:"scheduled_at" gorm:"not null"`
+	StartedAt   time.Time    `json:"started_at" gorm:"not null"`
+	FinishedAt  *time.Time   `json:"finished_at"`
+	DurationMs  int64        `json:"duration_ms" gorm:"not null;default:0"`
+	Affected    int64        `json:"affected" gorm:"not null;default:0"`
+	Error       string       `json:"error,omitempty" gorm:"type:text"`
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
+
+type ListJobRunsRequest struct {
+	Limit int `form:"limit" binding:"omitempty,gt=0,lte=500"`
+}
+
+type ListScheduledJobsResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data []ScheduledJob  `json:"data"`
+}
+
+type ListJobRunsResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data []JobRun        `json:"data"`
+}
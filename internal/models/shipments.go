@@ -0,0 +1,108 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ShipmentStatus string
+
+const (
+	ShipmentStatusPacked    ShipmentStatus = "PACKED"
+	ShipmentStatusShipped   ShipmentStatus = "SHIPPED"
+	ShipmentStatusDelivered ShipmentStatus = "DELIVERED"
+	ShipmentStatusReturned  ShipmentStatus = "RETURNED"
+)
+
+// shipmentStatusRank orders the shipment lifecycle; a shipment only ever moves forward
+var shipmentStatusRank = map[ShipmentStatus]int{
+	ShipmentStatusPacked:    1,
+	ShipmentStatusShipped:   2,
+	ShipmentStatusDelivered: 3,
+	ShipmentStatusReturned:  4,
+}
+
+// IsValid reports whether s is a known shipment status
+func (s ShipmentStatus) IsValid() bool {
+	_, ok := shipmentStatusRank[s]
+	return ok
+}
+
+// CanMoveTo reports whether a shipment in status s may move to next.
+// A parcel can only be returned once it left the warehouse.
+func (s ShipmentStatus) CanMoveTo(next ShipmentStatus) bool {
+	if next == ShipmentStatusReturned {
+		return s == ShipmentStatusShipped || s == ShipmentStatusDelivered
+	}
+	return shipmentStatusRank[next] > shipmentStatusRank[s]
+}
+
+// IsStale reports whether an update to next is older than status s, e.g. a carrier event
+// delivered out of order
+func (s ShipmentStatus) IsStale(next ShipmentStatus) bool {
+	return shipmentStatusRank[next] <= shipmentStatusRank[s]
+}
+
+// OutboundShipmentStatuses are the statuses of shipments whose items left the warehouse
+var OutboundShipmentStatuses = []ShipmentStatus{ShipmentStatusShipped, ShipmentStatusDelivered, ShipmentStatusReturned}
+
+// IsOutbound reports whether the items of a shipment in status s left the warehouse
+func (s ShipmentStatus) IsOutbound() bool {
+	return s == ShipmentStatusShipped || s == ShipmentStatusDelivered || s == ShipmentStatusReturned
+}
+
+// Shipment is a parcel carrying a subset of the items of an order.
+// An order can be split over several shipments.
+type Shipment struct {
+	BaseModel
+	TenantModel
+	OrderID        uuid.UUID      `json:"order_id" gorm:"type:uuid;not null;index"`
+	Carrier        string         `json:"carrier" gorm:"type:varchar(50);not null;uniqueIndex:idx_shipments_tracking,where:tracking_number <> ''"`
+	TrackingNumber string         `json:"tracking_number" gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_shipments_tracking,where:tracking_number <> ''"`
+	Status         ShipmentStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	ShippedAt      *time.Time     `json:"shipped_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	ReturnedAt     *time.Time     `json:"returned_at"`
+	Items          []ShipmentItem `json:"items" gorm:"foreignKey:ShipmentID"`
+}
+
+func (Shipment) TableName() string {
+	return "shipments"
+}
+
+// ShipmentItem is the quantity of one order item packed into a shipment
+type ShipmentItem struct {
+	BaseModel
+	TenantModel
+	ShipmentID  uuid.UUID `json:"shipment_id" gorm:"type:uuid;not null;index"`
+	OrderItemID uuid.UUID `json:"order_item_id" gorm:"type:uuid;not null;index"`
+	Quantity    int       `json:"quantity" gorm:"type:int;not null"`
+}
+
+func (ShipmentItem) TableName() string {
+	return "shipment_items"
+}
+
+type CreateShipmentRequest struct {
+	Carrier        string                      `json:"carrier"`
+	TrackingNumber string                      `json:"tracking_number"`
+	Items          []CreateShipmentItemRequest `json:"items"`
+}
+
+type CreateShipmentItemRequest struct {
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	Quantity    int       `json:"quantity"`
+}
+
+// ShipmentUpdate is a status update of a shipment, sent by the warehouse or a carrier.
+// Carriers identify the shipment by carrier and tracking number; ShipmentID takes
+// precedence when set, and then a tracking number is attached to the shipment.
+type ShipmentUpdate struct {
+	ShipmentID     *uuid.UUID     `json:"shipment_id,omitempty"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+	Status         ShipmentStatus `json:"status"`
+	OccurredAt     *time.Time     `json:"occurred_at,omitempty"`
+	Source         ChangeSource   `json:"-"`
+	SourceRef      string         `json:"-"`
+}
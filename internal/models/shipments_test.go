@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestShipmentStatusLifecycle(t *testing.T) {
+	tests := []struct {
+		from, to ShipmentStatus
+		canMove  bool
+		stale    bool
+	}{
+		{ShipmentStatusPacked, ShipmentStatusShipped, true, false},
+		{ShipmentStatusPacked, ShipmentStatusDelivered, true, false},
+		{ShipmentStatusShipped, ShipmentStatusDelivered, true, false},
+		{ShipmentStatusShipped, ShipmentStatusReturned, true, false},
+		{ShipmentStatusDelivered, ShipmentStatusReturned, true, false},
+		{ShipmentStatusPacked, ShipmentStatusReturned, false, false},
+		{ShipmentStatusShipped, ShipmentStatusPacked, false, true},
+		{ShipmentStatusDelivered, ShipmentStatusShipped, false, true},
+		{ShipmentStatusShipped, ShipmentStatusShipped, false, true},
+		{ShipmentStatusReturned, ShipmentStatusDelivered, false, true},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanMoveTo(tt.to); got != tt.canMove {
+			t.Errorf("%s.CanMoveTo(%s) = %v, want %v", tt.from, tt.to, got, tt.canMove)
+		}
+		if got := tt.from.IsStale(tt.to); got != tt.stale {
+			t.Errorf("%s.IsStale(%s) = %v, want %v", tt.from, tt.to, got, tt.stale)
+		}
+	}
+
+	if ShipmentStatus("LOST").IsValid() {
+		t.Error("LOST is a valid shipment status")
+	}
+	for _, status := range OutboundShipmentStatuses {
+		if !status.IsOutbound() {
+			t.Errorf("%s is not outbound", status)
+		}
+	}
+	if ShipmentStatusPacked.IsOutbound() {
+		t.Errorf("%s is outbound", ShipmentStatusPacked)
+	}
+}
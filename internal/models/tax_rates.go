@@ -0,0 +1,35 @@
+package models
+
+import "order/pkg/http/utils"
+
+// TaxRate is the rate of a product tax class in a country, or in one region of it.
+// An empty Region holds the country-wide rate.
+type TaxRate struct {
+	BaseModel
+	TenantID string  `json:"-" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_tax_rates_tenant_lookup"`
+	Country  string  `json:"country" gorm:"type:varchar(2);not null;uniqueIndex:idx_tax_rates_tenant_lookup"`
+	Region   string  `json:"region" gorm:"type:varchar(50);not null;default:'';uniqueIndex:idx_tax_rates_tenant_lookup"`
+	TaxClass string  `json:"tax_class" gorm:"type:varchar(30);not null;uniqueIndex:idx_tax_rates_tenant_lookup"`
+	Rate     float64 `json:"rate" gorm:"type:decimal(7,5);not null"`
+}
+
+func (TaxRate) TableName() string {
+	return "tax_rates"
+}
+
+type UpsertTaxRateRequest struct {
+	Country  string  `json:"country" binding:"required,len=2"`
+	Region   string  `json:"region"`
+	TaxClass string  `json:"tax_class" binding:"required"`
+	Rate     float64 `json:"rate" binding:"gte=0,lt=1"`
+}
+
+type ListTaxRatesResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data []TaxRate       `json:"data"`
+}
+
+type TaxRateResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data *TaxRate        `json:"data"`
+}
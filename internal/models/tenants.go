@@ -0,0 +1,45 @@
+package models
+
+import (
+	"order/pkg/http/utils"
+	"time"
+)
+
+// Tenant is a storefront hosted on the deployment. Settings left empty fall back to the
+// service config, so a tenant only stores what differs.
+type Tenant struct {
+	ID                   string    `json:"id" gorm:"type:varchar(64);primaryKey"`
+	Name                 string    `json:"name" gorm:"type:varchar(100);not null"`
+	IsActive             bool      `json:"is_active" gorm:"type:boolean;not null"`
+	BaseCurrency         string    `json:"base_currency,omitempty" gorm:"type:varchar(3);not null;default:''"`
+	InvoiceSellerName    string    `json:"invoice_seller_name,omitempty" gorm:"type:varchar(200);not null;default:''"`
+	InvoiceSellerTaxID   string    `json:"invoice_seller_tax_id,omitempty" gorm:"type:varchar(50);not null;default:''"`
+	InvoiceSellerAddress string    `json:"invoice_seller_address,omitempty" gorm:"type:varchar(500);not null;default:''"`
+	InvoiceSellerCountry string    `json:"invoice_seller_country,omitempty" gorm:"type:varchar(2);not null;default:''"`
+	CreatedAt            time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt            time.Time `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+type UpsertTenantRequest struct {
+	Name                 string `json:"name" binding:"required"`
+	IsActive             *bool  `json:"is_active"`
+	BaseCurrency         string `json:"base_currency" binding:"omitempty,len=3"`
+	InvoiceSellerName    string `json:"invoice_seller_name"`
+	InvoiceSellerTaxID   string `json:"invoice_seller_tax_id"`
+	InvoiceSellerAddress string `json:"invoice_seller_address"`
+	InvoiceSellerCountry string `json:"invoice_seller_country" binding:"omitempty,len=2"`
+}
+
+type ListTenantsResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data []Tenant        `json:"data"`
+}
+
+type TenantResponse struct {
+	Meta *utils.MetaData `json:"meta"`
+	Data *Tenant         `json:"data"`
+}
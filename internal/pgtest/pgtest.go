@@ -0,0 +1,114 @@
+// Package pgtest gives tests a migrated Postgres schema of their own. Tests using it are skipped
+// unless TEST_POSTGRES_DSN points at a database they may create schemas in.
+package pgtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"order/internal/migrations"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/tenant"
+)
+
+// EnvDSN names the variable holding the connection string of the test database
+const EnvDSN = "TEST_POSTGRES_DSN"
+
+// Open creates a schema, applies the migrations to it and returns a repo bound to it. The
+// schema is dropped when the test ends.
+func Open(t testing.TB) pgGorm.PGInterface {
+	t.Helper()
+
+	db := OpenEmpty(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return pgGorm.NewPGRepo(db)
+}
+
+// OpenEmpty creates a schema without migrating it and returns a connection bound to it. The
+// schema is dropped when the test ends.
+func OpenEmpty(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(EnvDSN)
+	if dsn == "" {
+		t.Skip(EnvDSN + " not set")
+	}
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	admin, err := gorm.Open(postgres.New(postgres.Config{DSN: dsn, PreferSimpleProtocol: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open %s: %v", EnvDSN, err)
+	}
+	// the extensions go to public once, rather than to the first test schema migrated
+	err = admin.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range []string{
+			"SELECT pg_advisory_xact_lock(7260437312950017)",
+			`CREATE EXTENSION IF NOT EXISTS "uuid-ossp" SCHEMA public`,
+			"CREATE EXTENSION IF NOT EXISTS pg_trgm SCHEMA public",
+			"CREATE SCHEMA " + schema,
+		} {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error
+		if sqlDB, err := admin.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  withSearchPath(dsn, schema),
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open schema %s: %v", schema, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+// withSearchPath puts schema first on the search path of dsn, in URL or keyword/value form
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema + ",public"
+	}
+	return fmt.Sprintf("%s search_path=%s,public", dsn, schema)
+}
+
+// Context is a background context of the default tenant
+func Context() context.Context {
+	return tenant.NewContext(context.Background(), tenant.Default)
+}
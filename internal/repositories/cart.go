@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"time"
+)
+
+type CartRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewCartRepository(newPgRepo pgGorm.PGInterface) *CartRepository {
+	return &CartRepository{db: newPgRepo}
+}
+
+type CartRepoInterface interface {
+	Create(ctx context.Context, tx *gorm.DB, cart *model.Cart) error
+	GetByID(ctx context.Context, cartID uuid.UUID) (*model.Cart, error)
+	GetForUpdate(ctx context.Context, tx *gorm.DB, cartID uuid.UUID) (*model.Cart, error)
+	GetActiveByCustomer(ctx context.Context, tx *gorm.DB, customerID uuid.UUID) (*model.Cart, error)
+	Save(ctx context.Context, tx *gorm.DB, cart *model.Cart) error
+	UpsertItem(ctx context.Context, tx *gorm.DB, item *model.CartItem) error
+	RemoveItem(ctx context.Context, tx *gorm.DB, cartID, productID uuid.UUID) error
+	ExpireAbandoned(ctx context.Context, now time.Time) (int64, error)
+}
+
+func (a *CartRepository) Create(ctx context.Context, tx *gorm.DB, cart *model.Cart) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "CartRepository.Create")
+		defer cancel()
+	}
+	return tx.Omit(clause.Associations).Create(cart).Error
+}
+
+func (a *CartRepository) GetByID(ctx context.Context, cartID uuid.UUID) (*model.Cart, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "CartRepository.GetByID")
+	defer cancel()
+
+	var cart model.Cart
+	if err := tx.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).Where("id = ?", cartID).First(&cart).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetForUpdate locks the cart row for the rest of tx and loads its items
+func (a *CartRepository) GetForUpdate(ctx context.Context, tx *gorm.DB, cartID uuid.UUID) (*model.Cart, error) {
+	var cart model.Cart
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", cartID).
+		First(&cart).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.WithContext(ctx).Where("cart_id = ?", cartID).
+		Order("created_at").
+		Find(&cart.Items).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetActiveByCustomer locks and returns the active cart of a customer, or gorm.ErrRecordNotFound
+func (a *CartRepository) GetActiveByCustomer(ctx context.Context, tx *gorm.DB, customerID uuid.UUID) (*model.Cart, error) {
+	var cart model.Cart
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("customer_id = ? AND status = ?", customerID, model.CartStatusActive).
+		Order("created_at desc").
+		First(&cart).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.WithContext(ctx).Where("cart_id = ?", cart.ID).
+		Order("created_at").
+		Find(&cart.Items).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// Save updates the cart row; items are written through UpsertItem / RemoveItem
+func (a *CartRepository) Save(ctx context.Context, tx *gorm.DB, cart *model.Cart) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "CartRepository.Save")
+		defer cancel()
+	}
+	return tx.Omit(clause.Associations).Save(cart).Error
+}
+
+// UpsertItem inserts the item or overwrites quantity and price of the same product in the cart
+func (a *CartRepository) UpsertItem(ctx context.Context, tx *gorm.DB, item *model.CartItem) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "CartRepository.UpsertItem")
+		defer cancel()
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cart_id"}, {Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "unit_price", "updated_at"}),
+	}).Create(item).Error
+}
+
+func (a *CartRepository) RemoveItem(ctx context.Context, tx *gorm.DB, cartID, productID uuid.UUID) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "CartRepository.RemoveItem")
+		defer cancel()
+	}
+	res := tx.Unscoped().Where("cart_id = ? AND product_id = ?", cartID, productID).Delete(&model.CartItem{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ExpireAbandoned marks active carts past their expiry as EXPIRED
+func (a *CartRepository) ExpireAbandoned(ctx context.Context, now time.Time) (int64, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "CartRepository.ExpireAbandoned")
+	defer cancel()
+	res := tx.Model(&model.Cart{}).
+		Where("status = ? AND expires_at < ?", model.CartStatusActive, now).
+		Updates(map[string]interface{}{"status": model.CartStatusExpired, "updated_at": now})
+	return res.RowsAffected, res.Error
+}
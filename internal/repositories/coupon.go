@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"time"
+)
+
+type CouponRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewCouponRepository(newPgRepo pgGorm.PGInterface) *CouponRepository {
+	return &CouponRepository{db: newPgRepo}
+}
+
+type CouponRepoInterface interface {
+	GetActiveByCode(ctx context.Context, code string, at time.Time) (*model.Coupon, error)
+	Redeem(ctx context.Context, tx *gorm.DB, couponID uuid.UUID) (bool, error)
+}
+
+// GetActiveByCode returns the coupon if it is active and within its validity window
+func (a *CouponRepository) GetActiveByCode(ctx context.Context, code string, at time.Time) (*model.Coupon, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "CouponRepository.GetActiveByCode")
+	defer cancel()
+
+	var coupon model.Coupon
+	if err := tx.Where("code = ? AND is_active = ? AND start_time <= ? AND end_time >= ?", code, true, at, at).
+		First(&coupon).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// Redeem counts one use of the coupon; it reports false once the usage limit is reached
+func (a *CouponRepository) Redeem(ctx context.Context, tx *gorm.DB, couponID uuid.UUID) (bool, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "CouponRepository.Redeem")
+		defer cancel()
+	}
+	res := tx.Model(&model.Coupon{}).
+		Where("id = ? AND (usage_limit = 0 OR used_count < usage_limit)", couponID).
+		Updates(map[string]interface{}{"used_count": gorm.Expr("used_count + 1"), "updated_at": time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"time"
+)
+
+type ExportJobRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewExportJobRepository(newPgRepo pgGorm.PGInterface) *ExportJobRepository {
+	return &ExportJobRepository{db: newPgRepo}
+}
+
+type ExportJobRepoInterface interface {
+	Create(ctx context.Context, job *model.ExportJob) error
+	Save(ctx context.Context, job *model.ExportJob) error
+	GetByID(ctx context.Context, jobID uuid.UUID) (*model.ExportJob, error)
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]model.ExportJob, error)
+}
+
+func (a *ExportJobRepository) Create(ctx context.Context, job *model.ExportJob) error {
+	tx, cancel := a.db.DBWithTimeout(ctx, "ExportJobRepository.Create")
+	defer cancel()
+	return tx.Create(job).Error
+}
+
+func (a *ExportJobRepository) Save(ctx context.Context, job *model.ExportJob) error {
+	tx, cancel := a.db.DBWithTimeout(ctx, "ExportJobRepository.Save")
+	defer cancel()
+	job.UpdatedAt = time.Now()
+	return tx.Save(job).Error
+}
+
+// GetByID reads the job from the primary, since clients poll it right after creating it
+func (a *ExportJobRepository) GetByID(ctx context.Context, jobID uuid.UUID) (*model.ExportJob, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "ExportJobRepository.GetByID")
+	defer cancel()
+
+	var job model.ExportJob
+	if err := tx.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListExpired returns finished exports whose file is past its retention
+func (a *ExportJobRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]model.ExportJob, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "ExportJobRepository.ListExpired")
+	defer cancel()
+
+	var jobs []model.ExportJob
+	if err := tx.Where("status = ? AND expires_at < ?", model.ExportStatusSucceeded, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
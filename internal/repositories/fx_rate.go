@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"strings"
+	"time"
+)
+
+type FxRateRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewFxRateRepository(newPgRepo pgGorm.PGInterface) *FxRateRepository {
+	return &FxRateRepository{db: newPgRepo}
+}
+
+type FxRateRepoInterface interface {
+	FindRate(ctx context.Context, currency string, at time.Time) (*model.FxRate, error)
+	List(ctx context.Context) ([]model.FxRate, error)
+	Upsert(ctx context.Context, rate *model.FxRate) error
+}
+
+// FindRate returns the rate of currency in effect at the given time
+func (a *FxRateRepository) FindRate(ctx context.Context, currency string, at time.Time) (*model.FxRate, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "FxRateRepository.FindRate")
+	defer cancel()
+
+	var rate model.FxRate
+	if err := tx.Where("currency = ? AND effective_at <= ?", strings.ToUpper(currency), at).
+		Order("effective_at DESC").
+		First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// List returns every rate, the latest of each currency first
+func (a *FxRateRepository) List(ctx context.Context) ([]model.FxRate, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "FxRateRepository.List")
+	defer cancel()
+
+	var rates []model.FxRate
+	if err := tx.Order("currency, effective_at DESC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Upsert creates the rate or overwrites the rate of the same currency and effective time
+func (a *FxRateRepository) Upsert(ctx context.Context, rate *model.FxRate) error {
+	tx, cancel := a.db.DBWithTimeout(ctx, "FxRateRepository.Upsert")
+	defer cancel()
+
+	rate.Currency = strings.ToUpper(rate.Currency)
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "currency"}, {Name: "effective_at"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(rate).Error
+}
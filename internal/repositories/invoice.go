@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/tenant"
+)
+
+type InvoiceRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewInvoiceRepository(newPgRepo pgGorm.PGInterface) *InvoiceRepository {
+	return &InvoiceRepository{db: newPgRepo}
+}
+
+type InvoiceRepoInterface interface {
+	NextSequence(ctx context.Context, tx *gorm.DB, kind model.InvoiceKind, year int) (int, error)
+	Create(ctx context.Context, tx *gorm.DB, invoice *model.Invoice) error
+	GetByID(ctx context.Context, invoiceID uuid.UUID) (*model.Invoice, error)
+	GetOrderInvoice(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) (*model.Invoice, error)
+	GetByReturnRequest(ctx context.Context, tx *gorm.DB, returnID uuid.UUID) (*model.Invoice, error)
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Invoice, error)
+}
+
+// NextSequence takes the next number of kind in year for the tenant of ctx. The sequence row stays locked until tx ends,
+// so a rolled back invoice gives its number back and the sequence has no gaps.
+func (a *InvoiceRepository) NextSequence(ctx context.Context, tx *gorm.DB, kind model.InvoiceKind, year int) (int, error) {
+	// raw SQL is not scoped by the tenant scope, so the tenant is named here
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return 0, tenant.ErrMissingTenant
+	}
+
+	var next int
+	if err := tx.WithContext(ctx).Raw(`
+		INSERT INTO invoice_sequences (tenant_id, kind, year, last_number) VALUES (?, ?, ?, 1)
+		ON CONFLICT (tenant_id, kind, year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`, tenantID, kind, year).
+		Scan(&next).Error; err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// Create writes the invoice together with its lines
+func (a *InvoiceRepository) Create(ctx context.Context, tx *gorm.DB, invoice *model.Invoice) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "InvoiceRepository.Create")
+		defer cancel()
+	}
+	if err := tx.Omit(clause.Associations).Create(invoice).Error; err != nil {
+		return err
+	}
+	for i := range invoice.Lines {
+		invoice.Lines[i].InvoiceID = invoice.ID
+	}
+	if len(invoice.Lines) == 0 {
+		return nil
+	}
+	return tx.Create(&invoice.Lines).Error
+}
+
+func (a *InvoiceRepository) GetByID(ctx context.Context, invoiceID uuid.UUID) (*model.Invoice, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "InvoiceRepository.GetByID")
+	defer cancel()
+
+	var invoice model.Invoice
+	if err := tx.Preload("Lines").Where("id = ?", invoiceID).First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetOrderInvoice returns the invoice of an order, without its credit notes
+func (a *InvoiceRepository) GetOrderInvoice(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) (*model.Invoice, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "InvoiceRepository.GetOrderInvoice")
+		defer cancel()
+	}
+
+	var invoice model.Invoice
+	if err := tx.WithContext(ctx).Preload("Lines").
+		Where("order_id = ? AND kind = ?", orderID, model.InvoiceKindInvoice).
+		First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetByReturnRequest returns the credit note issued for a return request
+func (a *InvoiceRepository) GetByReturnRequest(ctx context.Context, tx *gorm.DB, returnID uuid.UUID) (*model.Invoice, error) {
+	var invoice model.Invoice
+	if err := tx.WithContext(ctx).Preload("Lines").
+		Where("return_request_id = ?", returnID).
+		First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// ListByOrderID returns the invoice and credit notes of an order in the order they were issued
+func (a *InvoiceRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Invoice, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "InvoiceRepository.ListByOrderID")
+	defer cancel()
+
+	var invoices []model.Invoice
+	if err := tx.Preload("Lines").Where("order_id = ?", orderID).
+		Order("issued_at, kind DESC").
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
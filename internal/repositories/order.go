@@ -2,14 +2,22 @@ package repo
 
 import (
 	"context"
+	stdErrors "errors"
+	"fmt"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"order/internal/events"
 	model "order/internal/models"
 	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/http/paging"
 	"order/pkg/http/utils"
+	"time"
 )
 
+// ErrInvalidStatusTransition is returned when an order cannot move from its status to the requested one
+var ErrInvalidStatusTransition = stdErrors.New("order status transition not allowed")
+
 type OrderRepository struct {
 	db pgGorm.PGInterface
 }
@@ -20,13 +28,25 @@ func NewOrderRepository(newPgRepo pgGorm.PGInterface) *OrderRepository {
 
 type OrderRepoInterface interface {
 	CreateOrder(ctx context.Context, tx *gorm.DB, orderRequest *model.CreateOrderRequest) (*model.CreateOrderResponse, error)
-	UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status events.PaymentStatus) error
+	UpdateOrderStatus(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, status model.OrderStatus) (string, error)
 	GetByID(ctx context.Context, orderID uuid.UUID) (*model.Order, error)
+	ExpirePendingOrders(ctx context.Context, tx *gorm.DB, createdBefore time.Time) ([]model.Order, error)
+	ListStuckPendingOrders(ctx context.Context, createdBefore time.Time, limit int) ([]model.Order, error)
+	ListByCustomer(ctx context.Context, customerID uuid.UUID, pager *paging.CursorPager) ([]model.Order, error)
+	StreamOrders(ctx context.Context, filters []paging.Condition, batchSize int, fn func(orders []model.Order) error) error
+	GetForUpdate(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) (*model.Order, error)
+	AddItem(ctx context.Context, tx *gorm.DB, item *model.OrderItem) error
+	UpdateItemQuantity(ctx context.Context, tx *gorm.DB, orderID, itemID uuid.UUID, quantity int) error
+	RemoveItem(ctx context.Context, tx *gorm.DB, orderID, itemID uuid.UUID) error
+	UpdatePricing(ctx context.Context, tx *gorm.DB, order *model.Order) error
+	AddRefund(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, amount float64) error
 }
 
+// GetByID reads the order from the primary: orders are looked up right after they were created
+// or changed, which a lagging replica would not show yet
 func (a *OrderRepository) GetByID(ctx context.Context, orderID uuid.UUID) (*model.Order, error) {
 	var cancel context.CancelFunc
-	tx, cancel := a.db.DBWithTimeout(ctx)
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderRepository.GetByID")
 	defer cancel()
 
 	var order model.Order
@@ -41,30 +61,54 @@ func (a *OrderRepository) CreateOrder(ctx context.Context, tx *gorm.DB, orderReq
 
 	var cancel context.CancelFunc
 	if tx == nil {
-		tx, cancel = a.db.DBWithTimeout(ctx)
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderRepository.CreateOrder")
 		defer cancel()
 	}
 
 	orderRecord := &model.Order{
-		CustomerID:  orderRequest.CustomerID,
-		TotalAmount: orderRequest.TotalAmount,
-		Status:      orderRequest.Status,
+		CustomerID:      orderRequest.CustomerID,
+		TotalAmount:     orderRequest.TotalAmount,
+		DiscountAmount:  orderRequest.Discount,
+		TaxAmount:       orderRequest.TaxAmount,
+		TaxCountry:      orderRequest.Country,
+		TaxRegion:       orderRequest.Region,
+		Currency:        orderRequest.Currency,
+		FxRate:          orderRequest.FxRate,
+		Customer:        orderRequest.Customer,
+		ShippingAddress: orderRequest.Shipping,
+		BillingAddress:  orderRequest.Billing,
+		Status:          orderRequest.Status,
+	}
+	if orderRequest.PlacedAt != nil {
+		orderRecord.CreatedAt = *orderRequest.PlacedAt
 	}
 
 	if err := tx.Create(orderRecord).Error; err != nil {
 		return nil, err
 	}
 
+	var taxLines []model.TaxLine
 	for _, item := range orderRequest.OrderItems {
 		orderItem := &model.OrderItem{
 			OrderID:   orderRecord.ID,
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
 			UnitPrice: item.UniquePrice,
+			TaxClass:  item.TaxClass,
+			TaxRate:   item.TaxRate,
+			TaxAmount: item.TaxAmount,
 		}
 		if err := tx.Create(orderItem).Error; err != nil {
 			return nil, err
 		}
+		if orderRecord.TaxCountry != "" {
+			taxLines = append(taxLines, model.TaxLine{
+				ProductID: orderItem.ProductID,
+				TaxClass:  orderItem.TaxClass,
+				Rate:      orderItem.TaxRate,
+				Amount:    orderItem.TaxAmount,
+			})
+		}
 	}
 
 	response := &model.CreateOrderResponse{
@@ -73,6 +117,10 @@ func (a *OrderRepository) CreateOrder(ctx context.Context, tx *gorm.DB, orderReq
 			OrderID:     orderRecord.ID,
 			CustomerID:  orderRecord.CustomerID,
 			TotalAmount: orderRecord.TotalAmount,
+			TaxAmount:   orderRecord.TaxAmount,
+			TaxLines:    taxLines,
+			Currency:    orderRecord.Currency,
+			FxRate:      orderRecord.FxRate,
 			Status:      orderRecord.Status,
 		},
 	}
@@ -80,16 +128,274 @@ func (a *OrderRepository) CreateOrder(ctx context.Context, tx *gorm.DB, orderReq
 	return response, nil
 }
 
-func (a *OrderRepository) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status events.PaymentStatus) error {
+// UpdateOrderStatus locks the order row, sets the new status and returns the previous one.
+// Transitions the order status does not allow, e.g. a late authorization of an expired order,
+// fail with ErrInvalidStatusTransition.
+func (a *OrderRepository) UpdateOrderStatus(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, status model.OrderStatus) (string, error) {
 
 	var cancel context.CancelFunc
-	tx, cancel := a.db.DBWithTimeout(ctx)
-	defer cancel()
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderRepository.UpdateOrderStatus")
+		defer cancel()
+	}
+
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status").
+		Where("id = ?", orderID).
+		First(&order).Error; err != nil {
+		return "", err
+	}
+	if !model.CanTransitionOrder(order.Status, status) {
+		return order.Status, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, order.Status, status)
+	}
 
 	if err := tx.Model(&model.Order{}).Where("id = ?", orderID).Update("status", string(status)).Error; err != nil {
+		return "", err
+	}
+
+	return order.Status, nil
+
+}
+
+// ExpirePendingOrders moves orders that are still waiting for payment after the cutoff to EXPIRED
+// and returns them with their previous status. Rows locked by another transaction are skipped.
+func (a *OrderRepository) ExpirePendingOrders(ctx context.Context, tx *gorm.DB, createdBefore time.Time) ([]model.Order, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderRepository.ExpirePendingOrders")
+		defer cancel()
+	}
+
+	var orders []model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Select("id", "status").
+		Where("status IN ? AND created_at < ?", model.PendingOrderStatuses, createdBefore).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+	}
+
+	if err := tx.Model(&model.Order{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": string(model.OrderStatusExpired), "updated_at": time.Now()}).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ListStuckPendingOrders returns pending orders whose payment request is not healthy in the outbox:
+// either missing, FAILED, or out of retry attempts.
+func (a *OrderRepository) ListStuckPendingOrders(ctx context.Context, createdBefore time.Time, limit int) ([]model.Order, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderRepository.ListStuckPendingOrders")
+	defer cancel()
+
+	var orders []model.Order
+	err := tx.Preload("OrderItems").
+		Where("status IN ? AND created_at < ?", model.PendingOrderStatuses, createdBefore).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox ob
+			WHERE ob.aggregate_id = orders.id
+			  AND ob.event_type = ?
+			  AND (ob.status IN ? OR (ob.status = ? AND ob.attempts <= ?))
+		)`,
+			events.EventPaymentRequired.String(),
+			[]model.OutboxStatus{model.OutboxStatusPending, model.OutboxStatusDone},
+			model.OutboxStatusRetry,
+			model.NumberOfAttempts).
+		Order("created_at").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ListByCustomer returns one page of the orders of a customer, newest first
+func (a *OrderRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID, pager *paging.CursorPager) ([]model.Order, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "OrderRepository.ListByCustomer")
+	defer cancel()
+
+	return paging.CursorQuery(pager, tx.Preload("OrderItems").Where("customer_id = ?", customerID),
+		func(order model.Order) paging.Cursor {
+			return paging.Cursor{CreatedAt: order.CreatedAt, ID: order.ID}
+		})
+}
+
+// StreamOrders reads the orders matching filters oldest first through a database cursor and
+// hands them to fn in batches of batchSize with their items, so only one batch is held in memory.
+// It is not bound by the query timeout; ctx ends it.
+func (a *OrderRepository) StreamOrders(ctx context.Context, filters []paging.Condition, batchSize int, fn func(orders []model.Order) error) error {
+	// the items are read from the same replica as their orders
+	db := a.db.ReadRepo(ctx)
+	query, err := paging.ApplyFilters(db.Model(&model.Order{}), filters, model.Order{}.GetFilterableFields())
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.Order("created_at, id").Rows()
+	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
+	// items are loaded on another connection while the cursor holds this one
+	items := db
+	flush := func(batch []model.Order) error {
+		ids := make([]uuid.UUID, len(batch))
+		byID := make(map[uuid.UUID]*model.Order, len(batch))
+		for i := range batch {
+			ids[i] = batch[i].ID
+			byID[batch[i].ID] = &batch[i]
+		}
+		var orderItems []model.OrderItem
+		if err := items.Where("order_id IN ?", ids).Order("created_at, id").Find(&orderItems).Error; err != nil {
+			return err
+		}
+		for _, item := range orderItems {
+			order := byID[item.OrderID]
+			order.OrderItems = append(order.OrderItems, item)
+		}
+		return fn(batch)
+	}
+
+	batch := make([]model.Order, 0, batchSize)
+	for rows.Next() {
+		var order model.Order
+		if err = items.ScanRows(rows, &order); err != nil {
+			return err
+		}
+		batch = append(batch, order)
+		if len(batch) == batchSize {
+			if err = flush(batch); err != nil {
+				return err
+			}
+			batch = make([]model.Order, 0, batchSize)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return flush(batch)
+	}
+	return nil
+}
+
+// GetForUpdate locks the order row for the rest of tx and loads its items
+func (a *OrderRepository) GetForUpdate(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) (*model.Order, error) {
+	var order model.Order
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", orderID).
+		First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.WithContext(ctx).Where("order_id = ?", orderID).
+		Order("created_at").
+		Find(&order.OrderItems).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (a *OrderRepository) AddItem(ctx context.Context, tx *gorm.DB, item *model.OrderItem) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderRepository.AddItem")
+		defer cancel()
+	}
+	return tx.Omit(clause.Associations).Create(item).Error
+}
+
+func (a *OrderRepository) UpdateItemQuantity(ctx context.Context, tx *gorm.DB, orderID, itemID uuid.UUID, quantity int) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderRepository.UpdateItemQuantity")
+		defer cancel()
+	}
+	res := tx.Model(&model.OrderItem{}).
+		Where("id = ? AND order_id = ?", itemID, orderID).
+		Updates(map[string]interface{}{"quantity": quantity, "updated_at": time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 	return nil
+}
+
+func (a *OrderRepository) RemoveItem(ctx context.Context, tx *gorm.DB, orderID, itemID uuid.UUID) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderRepository.RemoveItem")
+		defer cancel()
+	}
+	res := tx.Where("id = ? AND order_id = ?", itemID, orderID).Delete(&model.OrderItem{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdatePricing stores the recomputed total, the tax of the order and its items,
+// and the promotion the order currently qualifies for
+func (a *OrderRepository) UpdatePricing(ctx context.Context, tx *gorm.DB, order *model.Order) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderRepository.UpdatePricing")
+		defer cancel()
+	}
 
+	now := time.Now()
+	if err := tx.Model(&model.Order{}).Where("id = ?", order.ID).
+		Updates(map[string]interface{}{
+			"total_amount":        order.TotalAmount,
+			"tax_amount":          order.TaxAmount,
+			"promotion_config_id": order.PromotionConfigID,
+			"updated_at":          now,
+		}).Error; err != nil {
+		return err
+	}
+
+	for _, item := range order.OrderItems {
+		if err := tx.Model(&model.OrderItem{}).Where("id = ? AND order_id = ?", item.ID, order.ID).
+			Updates(map[string]interface{}{
+				"tax_rate":   item.TaxRate,
+				"tax_amount": item.TaxAmount,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddRefund adds amount to the refunded total of an order
+func (a *OrderRepository) AddRefund(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, amount float64) error {
+	res := tx.WithContext(ctx).Model(&model.Order{}).
+		Where("id = ?", orderID).
+		Updates(map[string]interface{}{
+			"refunded_amount": gorm.Expr("refunded_amount + ?", amount),
+			"updated_at":      time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+type OrderEventRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewOrderEventRepository(newPgRepo pgGorm.PGInterface) *OrderEventRepository {
+	return &OrderEventRepository{db: newPgRepo}
+}
+
+type OrderEventRepoInterface interface {
+	Append(ctx context.Context, tx *gorm.DB, evts []*model.OrderEvent) error
+	LastVersion(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (int, error)
+	ListAfter(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, version int) ([]model.OrderEvent, error)
+	GetSnapshot(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (*model.OrderSnapshot, error)
+	SaveSnapshot(ctx context.Context, tx *gorm.DB, snapshot *model.OrderSnapshot) error
+	ListAggregateIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+}
+
+// Append inserts events; the unique (aggregate_id, version) index rejects concurrent writers
+func (a *OrderEventRepository) Append(ctx context.Context, tx *gorm.DB, evts []*model.OrderEvent) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderEventRepository.Append")
+		defer cancel()
+	}
+	return tx.Create(evts).Error
+}
+
+func (a *OrderEventRepository) LastVersion(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (int, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderEventRepository.LastVersion")
+		defer cancel()
+	}
+	var version int
+	if err := tx.Model(&model.OrderEvent{}).
+		Select("COALESCE(MAX(version), 0)").
+		Where("aggregate_id = ?", aggregateID).
+		Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func (a *OrderEventRepository) ListAfter(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, version int) ([]model.OrderEvent, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderEventRepository.ListAfter")
+		defer cancel()
+	}
+	var evts []model.OrderEvent
+	if err := tx.Where("aggregate_id = ? AND version > ?", aggregateID, version).
+		Order("version").
+		Find(&evts).Error; err != nil {
+		return nil, err
+	}
+	return evts, nil
+}
+
+// GetSnapshot returns nil without error when the stream has no snapshot yet
+func (a *OrderEventRepository) GetSnapshot(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID) (*model.OrderSnapshot, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderEventRepository.GetSnapshot")
+		defer cancel()
+	}
+	var snapshot model.OrderSnapshot
+	if err := tx.Where("aggregate_id = ?", aggregateID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (a *OrderEventRepository) SaveSnapshot(ctx context.Context, tx *gorm.DB, snapshot *model.OrderSnapshot) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderEventRepository.SaveSnapshot")
+		defer cancel()
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "aggregate_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version", "state", "updated_at"}),
+	}).Create(snapshot).Error
+}
+
+// ListAggregateIDs pages through every stream in the store ordered by aggregate id
+func (a *OrderEventRepository) ListAggregateIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderEventRepository.ListAggregateIDs")
+	defer cancel()
+	var ids []uuid.UUID
+	if err := tx.Model(&model.OrderEvent{}).
+		Distinct("aggregate_id").
+		Where("aggregate_id > ?", after).
+		Order("aggregate_id").
+		Limit(limit).
+		Pluck("aggregate_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,201 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/tenant"
+	"time"
+)
+
+// hourSQL and daySQL truncate a timestamp to its UTC hour or day
+const (
+	hourSQL = `date_trunc('hour', %s AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`
+	daySQL  = `date_trunc('day', %s AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`
+)
+
+type OrderStatsRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewOrderStatsRepository(newPgRepo pgGorm.PGInterface) *OrderStatsRepository {
+	return &OrderStatsRepository{db: newPgRepo}
+}
+
+type OrderStatsRepoInterface interface {
+	ChangedHours(ctx context.Context, since time.Time) ([]time.Time, error)
+	Rebuild(ctx context.Context, hours []time.Time, now time.Time) (int64, error)
+	SumBuckets(ctx context.Context, granularity model.StatsGranularity, from, to time.Time, byCurrency, byStatus bool) ([]model.OrderStatsBucket, error)
+	ListBucket(ctx context.Context, granularity model.StatsGranularity, start time.Time) ([]model.OrderStat, error)
+	GetWatermark(ctx context.Context) (*model.OrderStatsWatermark, error)
+	SaveWatermark(ctx context.Context, watermark *model.OrderStatsWatermark) error
+}
+
+// ChangedHours returns the UTC hours holding orders of the tenant of ctx created or updated since
+func (a *OrderStatsRepository) ChangedHours(ctx context.Context, since time.Time) ([]time.Time, error) {
+	// raw SQL is not scoped by the tenant scope, so the tenant is named here
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, tenant.ErrMissingTenant
+	}
+
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderStatsRepository.ChangedHours")
+	defer cancel()
+
+	var hours []time.Time
+	if err := tx.Raw(`
+		SELECT DISTINCT `+bucketOf(hourSQL, "created_at")+` AS hour
+		FROM orders
+		WHERE tenant_id = ? AND updated_at >= ?
+		ORDER BY hour`, tenantID, since).
+		Scan(&hours).Error; err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+// Rebuild recomputes the hourly stats of hours from the orders, and the daily stats of the days
+// holding them from the hourly stats, in one transaction. It returns the number of rows written.
+func (a *OrderStatsRepository) Rebuild(ctx context.Context, hours []time.Time, now time.Time) (int64, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return 0, tenant.ErrMissingTenant
+	}
+	if len(hours) == 0 {
+		return 0, nil
+	}
+
+	days := make([]time.Time, 0, len(hours))
+	seen := map[time.Time]bool{}
+	for _, hour := range hours {
+		hour = hour.UTC()
+		day := time.Date(hour.Year(), hour.Month(), hour.Day(), 0, 0, 0, 0, time.UTC)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderStatsRepository.Rebuild")
+	defer cancel()
+
+	var written int64
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM order_stats WHERE tenant_id = ? AND granularity = ? AND bucket_start IN ?`,
+			tenantID, model.StatsGranularityHour, hours).Error; err != nil {
+			return err
+		}
+		res := tx.Exec(`
+			INSERT INTO order_stats (tenant_id, granularity, bucket_start, currency, status, order_count, revenue, revenue_base, refreshed_at)
+			SELECT tenant_id, ?, `+bucketOf(hourSQL, "created_at")+`, currency, UPPER(status),
+			       COUNT(*), SUM(total_amount - refunded_amount), SUM((total_amount - refunded_amount) * fx_rate), ?
+			FROM orders
+			WHERE tenant_id = ? AND deleted_at IS NULL
+			  AND created_at >= ? AND created_at < ?
+			  AND `+bucketOf(hourSQL, "created_at")+` IN ?
+			GROUP BY 1, 3, 4, 5`,
+			model.StatsGranularityHour, now, tenantID, hours[0], hours[len(hours)-1].Add(time.Hour), hours)
+		if res.Error != nil {
+			return res.Error
+		}
+		written += res.RowsAffected
+
+		if err := tx.Exec(`DELETE FROM order_stats WHERE tenant_id = ? AND granularity = ? AND bucket_start IN ?`,
+			tenantID, model.StatsGranularityDay, days).Error; err != nil {
+			return err
+		}
+		res = tx.Exec(`
+			INSERT INTO order_stats (tenant_id, granularity, bucket_start, currency, status, order_count, revenue, revenue_base, refreshed_at)
+			SELECT tenant_id, ?, `+bucketOf(daySQL, "bucket_start")+`, currency, status,
+			       SUM(order_count), SUM(revenue), SUM(revenue_base), ?
+			FROM order_stats
+			WHERE tenant_id = ? AND granularity = ?
+			  AND bucket_start >= ? AND bucket_start < ?
+			  AND `+bucketOf(daySQL, "bucket_start")+` IN ?
+			GROUP BY 1, 3, 4, 5`,
+			model.StatsGranularityDay, now, tenantID, model.StatsGranularityHour, days[0], days[len(days)-1].AddDate(0, 0, 1), days)
+		if res.Error != nil {
+			return res.Error
+		}
+		written += res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// SumBuckets adds up the stats of the buckets starting in [from, to), per bucket and optionally
+// per currency and status. Paid orders and revenue only count RevenueOrderStatuses.
+func (a *OrderStatsRepository) SumBuckets(
+	ctx context.Context,
+	granularity model.StatsGranularity,
+	from, to time.Time,
+	byCurrency, byStatus bool,
+) ([]model.OrderStatsBucket, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "OrderStatsRepository.SumBuckets")
+	defer cancel()
+
+	group := "bucket_start"
+	if byCurrency {
+		group += ", currency"
+	}
+	if byStatus {
+		group += ", status"
+	}
+
+	var buckets []model.OrderStatsBucket
+	if err := tx.Model(&model.OrderStat{}).
+		Select(group+`,
+			SUM(order_count) AS orders,
+			COALESCE(SUM(order_count) FILTER (WHERE status IN ?), 0) AS paid_orders,
+			COALESCE(SUM(revenue) FILTER (WHERE status IN ?), 0) AS revenue,
+			COALESCE(SUM(revenue_base) FILTER (WHERE status IN ?), 0) AS revenue_base`,
+			model.RevenueOrderStatuses, model.RevenueOrderStatuses, model.RevenueOrderStatuses).
+		Where("granularity = ? AND bucket_start >= ? AND bucket_start < ?", granularity, from, to).
+		Group(group).
+		Order(group).
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// ListBucket returns the stats rows of the bucket starting at start, of every tenant when ctx is a system context
+func (a *OrderStatsRepository) ListBucket(ctx context.Context, granularity model.StatsGranularity, start time.Time) ([]model.OrderStat, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "OrderStatsRepository.ListBucket")
+	defer cancel()
+
+	var stats []model.OrderStat
+	if err := tx.Where("granularity = ? AND bucket_start = ?", granularity, start).
+		Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetWatermark returns gorm.ErrRecordNotFound before the first refresh of the tenant of ctx
+func (a *OrderStatsRepository) GetWatermark(ctx context.Context) (*model.OrderStatsWatermark, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderStatsRepository.GetWatermark")
+	defer cancel()
+
+	var watermark model.OrderStatsWatermark
+	if err := tx.First(&watermark).Error; err != nil {
+		return nil, err
+	}
+	return &watermark, nil
+}
+
+func (a *OrderStatsRepository) SaveWatermark(ctx context.Context, watermark *model.OrderStatsWatermark) error {
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderStatsRepository.SaveWatermark")
+	defer cancel()
+	watermark.UpdatedAt = time.Now()
+	return tx.Save(watermark).Error
+}
+
+func bucketOf(truncSQL, column string) string {
+	return fmt.Sprintf(truncSQL, column)
+}
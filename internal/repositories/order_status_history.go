@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+type OrderStatusHistoryRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewOrderStatusHistoryRepository(newPgRepo pgGorm.PGInterface) *OrderStatusHistoryRepository {
+	return &OrderStatusHistoryRepository{db: newPgRepo}
+}
+
+type OrderStatusHistoryRepoInterface interface {
+	Create(ctx context.Context, tx *gorm.DB, history ...*model.OrderStatusHistory) error
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.OrderStatusHistory, error)
+}
+
+func (a *OrderStatusHistoryRepository) Create(ctx context.Context, tx *gorm.DB, history ...*model.OrderStatusHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OrderStatusHistoryRepository.Create")
+		defer cancel()
+	}
+	return tx.Create(history).Error
+}
+
+// ListByOrderID reads the history from the primary, like GetByID reads its order: the history
+// is looked up right after a transition, which a lagging replica would not show yet
+func (a *OrderStatusHistoryRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.OrderStatusHistory, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "OrderStatusHistoryRepository.ListByOrderID")
+	defer cancel()
+
+	var history []model.OrderStatusHistory
+	if err := tx.Where("order_id = ?", orderID).Order("changed_at, created_at").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -2,9 +2,11 @@ package repo
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	models "order/internal/models"
 	pgGorm "order/internal/repositories/pg-gorm"
+	"time"
 )
 
 type OutboxRepository struct {
@@ -17,12 +19,16 @@ func NewOutboxRepository(newPgRepo pgGorm.PGInterface) *OutboxRepository {
 
 type OutboxRepoInterface interface {
 	CreateOutbox(ctx context.Context, tx *gorm.DB, outbox *models.Outbox) error
+	Requeue(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, eventType string) (int64, error)
+	PurgeDone(ctx context.Context, processedBefore time.Time) (int64, error)
+	Supersede(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, eventType string) (int64, error)
+	CountByStatus(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, eventType string, statuses ...models.OutboxStatus) (int64, error)
 }
 
 func (a *OutboxRepository) CreateOutbox(ctx context.Context, tx *gorm.DB, outbox *models.Outbox) error {
 	var cancel context.CancelFunc
 	if tx == nil {
-		tx, cancel = a.db.DBWithTimeout(ctx)
+		tx, cancel = a.db.DBWithTimeout(ctx, "OutboxRepository.CreateOutbox")
 		defer cancel()
 	}
 	if err := tx.Create(outbox).Error; err != nil {
@@ -30,3 +36,72 @@ func (a *OutboxRepository) CreateOutbox(ctx context.Context, tx *gorm.DB, outbox
 	}
 	return nil
 }
+
+// Requeue resets FAILED or exhausted rows of an aggregate so the outbox worker picks them up again
+func (a *OutboxRepository) Requeue(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, eventType string) (int64, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OutboxRepository.Requeue")
+		defer cancel()
+	}
+	res := tx.Model(&models.Outbox{}).
+		Where("aggregate_id = ? AND event_type = ? AND status IN ?", aggregateID, eventType,
+			[]models.OutboxStatus{models.OutboxStatusFailed, models.OutboxStatusRetry}).
+		Updates(map[string]interface{}{
+			"status":          models.OutboxStatusRetry,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"updated_at":      time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// PurgeDone hard deletes delivered and superseded rows processed before the given time
+func (a *OutboxRepository) PurgeDone(ctx context.Context, processedBefore time.Time) (int64, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "OutboxRepository.PurgeDone")
+	defer cancel()
+	res := tx.Unscoped().
+		Where("status IN ? AND processed_at < ?",
+			[]models.OutboxStatus{models.OutboxStatusDone, models.OutboxStatusSuperseded}, processedBefore).
+		Delete(&models.Outbox{})
+	return res.RowsAffected, res.Error
+}
+
+// Supersede retires the undelivered rows of an aggregate so a newer event can replace them
+func (a *OutboxRepository) Supersede(ctx context.Context, tx *gorm.DB, aggregateID uuid.UUID, eventType string) (int64, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OutboxRepository.Supersede")
+		defer cancel()
+	}
+	now := time.Now()
+	res := tx.Model(&models.Outbox{}).
+		Where("aggregate_id = ? AND event_type = ? AND status IN ?", aggregateID, eventType,
+			[]models.OutboxStatus{models.OutboxStatusPending, models.OutboxStatusRetry}).
+		Updates(map[string]interface{}{
+			"status":       models.OutboxStatusSuperseded,
+			"processed_at": now,
+			"updated_at":   now,
+		})
+	return res.RowsAffected, res.Error
+}
+
+// CountByStatus counts the rows of an aggregate and event type in any of the given statuses
+func (a *OutboxRepository) CountByStatus(
+	ctx context.Context,
+	tx *gorm.DB,
+	aggregateID uuid.UUID,
+	eventType string,
+	statuses ...models.OutboxStatus,
+) (int64, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "OutboxRepository.CountByStatus")
+		defer cancel()
+	}
+	var count int64
+	err := tx.Model(&models.Outbox{}).
+		Where("aggregate_id = ? AND event_type = ? AND status IN ?", aggregateID, eventType, statuses).
+		Count(&count).Error
+	return count, err
+}
@@ -4,28 +4,44 @@ import (
 	"context"
 	"gorm.io/gorm"
 	"order/pkg/http/utils"
+	"sync/atomic"
 )
 
 type RepoPG struct {
-	db    *gorm.DB
-	debug bool
+	db       *gorm.DB
+	debug    bool
+	timeouts QueryTimeouts
+
+	// replicas take the reads of ReadRepo and ReadDBWithTimeout, see NewPGRepoWithOptions
+	replicas []*replica
+	next     atomic.Uint64
 }
 
+// NewPGRepo wraps db and scopes it to the tenant of each statement context, see TenantScope
 func NewPGRepo(db *gorm.DB) PGInterface {
-	return &RepoPG{db: db}
+	// the scope is registered once per *gorm.DB; a second registration is a no-op
+	_ = db.Use(TenantScope{})
+	return &RepoPG{db: db, timeouts: QueryTimeouts{Default: utils.GeneralQueryTimeout}}
 }
 
+// PGInterface gives the primary for writes and for reads inside a transaction, and a healthy
+// replica, or the primary, for the other reads
 type PGInterface interface {
 	GetRepo() *gorm.DB
-	DBWithTimeout(ctx context.Context) (*gorm.DB, context.CancelFunc)
+	// DBWithTimeout bounds ctx by the timeout of operation, see QueryTimeouts
+	DBWithTimeout(ctx context.Context, operation string) (*gorm.DB, context.CancelFunc)
+	// ReadRepo is GetRepo().WithContext(ctx) for reads that may be served by a replica
+	ReadRepo(ctx context.Context) *gorm.DB
+	// ReadDBWithTimeout is DBWithTimeout for reads that may be served by a replica
+	ReadDBWithTimeout(ctx context.Context, operation string) (*gorm.DB, context.CancelFunc)
 }
 
 func (r *RepoPG) GetRepo() *gorm.DB {
 	return r.db
 }
 
-func (r *RepoPG) DBWithTimeout(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+func (r *RepoPG) DBWithTimeout(ctx context.Context, operation string) (*gorm.DB, context.CancelFunc) {
 	// init cancelable context with timeout
-	ctx, cancel := context.WithTimeout(ctx, utils.GeneralQueryTimeout)
+	ctx, cancel := r.timeouts.withTimeout(ctx, operation)
 	return r.db.WithContext(ctx), cancel
 }
@@ -0,0 +1,135 @@
+package pg_gorm
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"order/pkg/core/logger"
+	"order/pkg/http/utils"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	replicaCheckTimeout     = 2 * time.Second
+	defaultReplicaCheckTime = 5 * time.Second
+)
+
+// replicaLagSQL is the replication lag of a replica in seconds; a replica that replayed all it
+// received is not lagging however old its last transaction is
+const replicaLagSQL = `SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`
+
+type primaryKey struct{}
+
+// WithPrimary sends the reads of ctx to the primary, for reads that have to see the writes made
+// just before them, eg. a read-modify-write or a read right after a create
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether the reads of ctx go to the primary
+func UsesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// Options tunes a RepoPG
+type Options struct {
+	// ReplicaCheckInterval is the time between two health checks of the replicas
+	ReplicaCheckInterval time.Duration
+	// ReplicaMaxLag is the replication lag past which a replica stops taking reads
+	ReplicaMaxLag time.Duration
+	// QueryTimeouts default to utils.GeneralQueryTimeout
+	QueryTimeouts QueryTimeouts
+}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// NewPGRepoWithOptions is NewPGRepo with reads spread over replicas. Replicas take reads once
+// a health check passed and stop when one fails or they lag more than opts.ReplicaMaxLag; reads
+// fall back to the primary while no replica is healthy. Checks run until ctx is done.
+func NewPGRepoWithOptions(ctx context.Context, db *gorm.DB, replicas []*gorm.DB, opts Options) PGInterface {
+	_ = db.Use(TenantScope{})
+	if opts.QueryTimeouts.Default == 0 {
+		opts.QueryTimeouts.Default = utils.GeneralQueryTimeout
+	}
+	r := &RepoPG{db: db, timeouts: opts.QueryTimeouts}
+	for i, replicaDB := range replicas {
+		_ = replicaDB.Use(TenantScope{})
+		r.replicas = append(r.replicas, &replica{name: fmt.Sprintf("replica-%d", i), db: replicaDB})
+	}
+	if opts.ReplicaCheckInterval <= 0 {
+		opts.ReplicaCheckInterval = defaultReplicaCheckTime
+	}
+	if len(r.replicas) > 0 {
+		r.checkReplicas(ctx, opts.ReplicaMaxLag)
+		go r.watchReplicas(ctx, opts)
+	}
+	return r
+}
+
+func (r *RepoPG) ReadRepo(ctx context.Context) *gorm.DB {
+	return r.reader(ctx).WithContext(ctx)
+}
+
+func (r *RepoPG) ReadDBWithTimeout(ctx context.Context, operation string) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := r.timeouts.withTimeout(ctx, operation)
+	return r.reader(ctx).WithContext(ctx), cancel
+}
+
+// reader picks the next healthy replica in turn, or the primary
+func (r *RepoPG) reader(ctx context.Context) *gorm.DB {
+	if len(r.replicas) == 0 || UsesPrimary(ctx) {
+		return r.db
+	}
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		candidate := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if candidate.healthy.Load() {
+			return candidate.db
+		}
+	}
+	return r.db
+}
+
+func (r *RepoPG) watchReplicas(ctx context.Context, opts Options) {
+	ticker := time.NewTicker(opts.ReplicaCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkReplicas(ctx, opts.ReplicaMaxLag)
+		}
+	}
+}
+
+func (r *RepoPG) checkReplicas(ctx context.Context, maxLag time.Duration) {
+	log := logger.WithTag("RepoPG|checkReplicas")
+
+	for _, rep := range r.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+		var lag float64
+		err := rep.db.WithContext(checkCtx).Raw(replicaLagSQL).Scan(&lag).Error
+		cancel()
+
+		healthy := err == nil && time.Duration(lag*float64(time.Second)) <= maxLag
+		if rep.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		switch {
+		case healthy:
+			log.Infof("%s takes reads again", rep.name)
+		case err != nil:
+			logger.LogError(log, err, rep.name+" is unreachable, reading from the primary")
+		default:
+			log.Infof("%s lags %.1fs behind, reading elsewhere", rep.name, lag)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package pg_gorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// fakeReplica answers the replication lag query of the health check
+type fakeReplica struct {
+	lagMillis atomic.Int64
+	down      atomic.Bool
+}
+
+func (f *fakeReplica) Connect(context.Context) (driver.Conn, error) {
+	if f.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return fakeReplicaConn{f}, nil
+}
+
+func (f *fakeReplica) Driver() driver.Driver { return nil }
+
+type fakeReplicaConn struct{ replica *fakeReplica }
+
+func (c fakeReplicaConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.replica.down.Load() {
+		return nil, driver.ErrBadConn
+	}
+	if query != replicaLagSQL {
+		return nil, errors.New("unexpected query " + query)
+	}
+	return &lagRows{lag: float64(c.replica.lagMillis.Load()) / 1000}, nil
+}
+
+func (c fakeReplicaConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c fakeReplicaConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (c fakeReplicaConn) Close() error              { return nil }
+
+type lagRows struct {
+	lag  float64
+	read bool
+}
+
+func (r *lagRows) Columns() []string { return []string{"lag"} }
+func (r *lagRows) Close() error      { return nil }
+
+func (r *lagRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = r.lag
+	return nil
+}
+
+func fakeDB(t *testing.T, replica *fakeReplica) *gorm.DB {
+	t.Helper()
+	sqlDB := sql.OpenDB(replica)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}),
+		&gorm.Config{DisableAutomaticPing: true, Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// replicaSet opens a primary and a replica per fake; the health checks are left to the test
+func replicaSet(t *testing.T, fakes ...*fakeReplica) (*RepoPG, *gorm.DB, []*gorm.DB) {
+	t.Helper()
+	primary := fakeDB(t, &fakeReplica{})
+	var replicas []*gorm.DB
+	for _, f := range fakes {
+		replicas = append(replicas, fakeDB(t, f))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r := NewPGRepoWithOptions(ctx, primary, replicas, Options{ReplicaCheckInterval: time.Hour, ReplicaMaxLag: time.Second})
+	return r.(*RepoPG), primary, replicas
+}
+
+// reads counts the reads each database takes out of n
+func reads(r *RepoPG, ctx context.Context, n int) map[*gorm.DB]int {
+	counts := map[*gorm.DB]int{}
+	for range n {
+		counts[r.reader(ctx)]++
+	}
+	return counts
+}
+
+func TestReaderSpreadsReadsOverHealthyReplicas(t *testing.T) {
+	r, primary, replicas := replicaSet(t, &fakeReplica{}, &fakeReplica{}, &fakeReplica{})
+
+	counts := reads(r, context.Background(), 30)
+	for i, replica := range replicas {
+		if counts[replica] != 10 {
+			t.Errorf("replica %d took %d of 30 reads, want 10", i, counts[replica])
+		}
+	}
+	if counts[primary] != 0 {
+		t.Errorf("primary took %d reads while every replica is healthy", counts[primary])
+	}
+}
+
+func TestReaderSkipsUnhealthyReplicaUntilItRecovers(t *testing.T) {
+	tests := []struct {
+		name    string
+		degrade func(f *fakeReplica)
+		restore func(f *fakeReplica)
+	}{
+		{
+			name:    "unreachable",
+			degrade: func(f *fakeReplica) { f.down.Store(true) },
+			restore: func(f *fakeReplica) { f.down.Store(false) },
+		},
+		{
+			name:    "lagging past the max lag",
+			degrade: func(f *fakeReplica) { f.lagMillis.Store(1500) },
+			restore: func(f *fakeReplica) { f.lagMillis.Store(200) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sick := &fakeReplica{}
+			r, _, replicas := replicaSet(t, sick, &fakeReplica{})
+
+			tt.degrade(sick)
+			r.checkReplicas(context.Background(), time.Second)
+			counts := reads(r, context.Background(), 10)
+			if counts[replicas[0]] != 0 || counts[replicas[1]] != 10 {
+				t.Errorf("reads %d/%d, want all on the healthy replica", counts[replicas[0]], counts[replicas[1]])
+			}
+
+			tt.restore(sick)
+			r.checkReplicas(context.Background(), time.Second)
+			counts = reads(r, context.Background(), 10)
+			if counts[replicas[0]] != 5 || counts[replicas[1]] != 5 {
+				t.Errorf("reads %d/%d, want the recovered replica back in turn", counts[replicas[0]], counts[replicas[1]])
+			}
+		})
+	}
+}
+
+func TestReaderFallsBackToThePrimary(t *testing.T) {
+	first, second := &fakeReplica{}, &fakeReplica{}
+	r, primary, _ := replicaSet(t, first, second)
+
+	first.down.Store(true)
+	second.lagMillis.Store(5000)
+	r.checkReplicas(context.Background(), time.Second)
+	if counts := reads(r, context.Background(), 5); counts[primary] != 5 {
+		t.Errorf("primary took %d of 5 reads with every replica down", counts[primary])
+	}
+}
+
+func TestReaderWithoutReplicasUsesThePrimary(t *testing.T) {
+	r, primary, _ := replicaSet(t)
+	if counts := reads(r, context.Background(), 3); counts[primary] != 3 {
+		t.Errorf("primary took %d of 3 reads without replicas", counts[primary])
+	}
+}
+
+func TestReaderWithPrimary(t *testing.T) {
+	r, primary, _ := replicaSet(t, &fakeReplica{})
+
+	ctx := WithPrimary(context.Background())
+	if !UsesPrimary(ctx) || UsesPrimary(context.Background()) {
+		t.Fatal("UsesPrimary does not report WithPrimary")
+	}
+	if counts := reads(r, ctx, 3); counts[primary] != 3 {
+		t.Errorf("primary took %d of 3 reads made WithPrimary", counts[primary])
+	}
+}
+
+func TestNewPGRepoWithOptionsWatchesReplicas(t *testing.T) {
+	replica := &fakeReplica{}
+	replica.down.Store(true)
+	primary, replicaDB := fakeDB(t, &fakeReplica{}), fakeDB(t, replica)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewPGRepoWithOptions(ctx, primary, []*gorm.DB{replicaDB},
+		Options{ReplicaCheckInterval: 10 * time.Millisecond, ReplicaMaxLag: time.Second}).(*RepoPG)
+
+	// the replica is checked before the first read
+	if r.reader(context.Background()) != primary {
+		t.Fatal("replica that failed its first check takes reads")
+	}
+	replica.down.Store(false)
+	deadline := time.Now().Add(5 * time.Second)
+	for r.reader(context.Background()) != replicaDB {
+		if time.Now().After(deadline) {
+			t.Fatal("recovered replica never takes reads again")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,99 @@
+package pg_gorm
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"order/pkg/core/tenant"
+	"reflect"
+)
+
+// ErrForeignTenant is returned when a row of another tenant is written
+var ErrForeignTenant = errors.New("row belongs to another tenant")
+
+const tenantField = "TenantID"
+
+// TenantScope fills and filters the tenant_id column of models with a TenantID field from the
+// tenant of the statement context. Statements on those models fail without a tenant unless the
+// context was marked with tenant.System. Raw SQL is not scoped and has to filter on tenant_id itself.
+type TenantScope struct{}
+
+func (TenantScope) Name() string {
+	return "tenant_scope"
+}
+
+func (TenantScope) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant_scope:create", assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant_scope:query", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant_scope:row", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant_scope:update", scopeTenant); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant_scope:delete", scopeTenant)
+}
+
+func tenantFieldOf(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(tenantField)
+}
+
+// scopeTenant restricts queries, updates and deletes to the rows of the context tenant
+func scopeTenant(db *gorm.DB) {
+	field := tenantFieldOf(db)
+	if field == nil {
+		return
+	}
+
+	id, ok := tenant.FromContext(db.Statement.Context)
+	if !ok {
+		if !tenant.IsSystem(db.Statement.Context) {
+			_ = db.AddError(tenant.ErrMissingTenant)
+		}
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id},
+	}})
+}
+
+// assignTenant stamps new rows with the context tenant. Rows created in a system context
+// must already name their tenant.
+func assignTenant(db *gorm.DB) {
+	field := tenantFieldOf(db)
+	if field == nil {
+		return
+	}
+
+	ctx := db.Statement.Context
+	id, ok := tenant.FromContext(ctx)
+	assign := func(row reflect.Value) {
+		current, isZero := field.ValueOf(ctx, row)
+		switch {
+		case isZero && ok:
+			_ = db.AddError(field.Set(ctx, row, id))
+		case isZero:
+			_ = db.AddError(tenant.ErrMissingTenant)
+		case ok && current != id:
+			_ = db.AddError(ErrForeignTenant)
+		}
+	}
+
+	switch rows := db.Statement.ReflectValue; rows.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rows.Len(); i++ {
+			assign(reflect.Indirect(rows.Index(i)))
+		}
+	case reflect.Struct:
+		assign(rows)
+	}
+}
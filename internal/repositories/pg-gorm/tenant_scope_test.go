@@ -0,0 +1,192 @@
+package pg_gorm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"order/pkg/core/tenant"
+)
+
+type scopedRow struct {
+	ID       int
+	TenantID string
+	Name     string
+}
+
+type unscopedRow struct {
+	ID   int
+	Name string
+}
+
+// dryRunConn stands in for the database of a DryRun session, which never sends a statement
+type dryRunConn struct{ gorm.ConnPool }
+
+// tenantScopeDB renders statements through the tenant scope without a database to connect to
+func tenantScopeDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: dryRunConn{}}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Use(TenantScope{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTenantScopeCreate(t *testing.T) {
+	acme := tenant.NewContext(context.Background(), "acme")
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		rows       []scopedRow
+		wantErr    error
+		wantTenant []string
+	}{
+		{
+			name:       "fills the context tenant",
+			ctx:        acme,
+			rows:       []scopedRow{{Name: "a"}, {Name: "b"}},
+			wantTenant: []string{"acme", "acme"},
+		},
+		{
+			name:       "keeps a row of the context tenant",
+			ctx:        acme,
+			rows:       []scopedRow{{TenantID: "acme", Name: "a"}},
+			wantTenant: []string{"acme"},
+		},
+		{
+			name:    "rejects a row of another tenant",
+			ctx:     acme,
+			rows:    []scopedRow{{Name: "a"}, {TenantID: "globex", Name: "b"}},
+			wantErr: ErrForeignTenant,
+		},
+		{
+			name:    "rejects a row without tenant outside a tenant",
+			ctx:     context.Background(),
+			rows:    []scopedRow{{Name: "a"}},
+			wantErr: tenant.ErrMissingTenant,
+		},
+		{
+			name:    "rejects a row without tenant in a system context",
+			ctx:     tenant.System(context.Background()),
+			rows:    []scopedRow{{Name: "a"}},
+			wantErr: tenant.ErrMissingTenant,
+		},
+		{
+			name:       "keeps the named tenant in a system context",
+			ctx:        tenant.System(context.Background()),
+			rows:       []scopedRow{{TenantID: "globex", Name: "a"}},
+			wantTenant: []string{"globex"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tenantScopeDB(t).WithContext(tt.ctx).Create(&tt.rows).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			var got []string
+			for _, row := range tt.rows {
+				got = append(got, row.TenantID)
+			}
+			if !slices.Equal(got, tt.wantTenant) {
+				t.Errorf("tenants = %v, want %v", got, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestTenantScopeCreateSingleRow(t *testing.T) {
+	row := scopedRow{Name: "a"}
+	stmt := tenantScopeDB(t).WithContext(tenant.NewContext(context.Background(), "acme")).Create(&row)
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if row.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme", row.TenantID)
+	}
+	if !slices.Contains(stmt.Statement.Vars, any("acme")) {
+		t.Errorf("insert vars %v do not carry the tenant", stmt.Statement.Vars)
+	}
+
+	foreign := scopedRow{TenantID: "globex", Name: "b"}
+	err := tenantScopeDB(t).WithContext(tenant.NewContext(context.Background(), "acme")).Create(&foreign).Error
+	if !errors.Is(err, ErrForeignTenant) {
+		t.Errorf("Create() error = %v, want %v", err, ErrForeignTenant)
+	}
+}
+
+func TestTenantScopeFilters(t *testing.T) {
+	statements := map[string]func(db *gorm.DB) *gorm.DB{
+		"query": func(db *gorm.DB) *gorm.DB {
+			var rows []scopedRow
+			return db.Where("name = ?", "a").Find(&rows)
+		},
+		"row": func(db *gorm.DB) *gorm.DB {
+			var count int64
+			return db.Model(&scopedRow{}).Where("name = ?", "a").Count(&count)
+		},
+		"update": func(db *gorm.DB) *gorm.DB {
+			return db.Model(&scopedRow{}).Where("name = ?", "a").Update("name", "b")
+		},
+		"delete": func(db *gorm.DB) *gorm.DB {
+			return db.Where("name = ?", "a").Delete(&scopedRow{})
+		},
+	}
+
+	for name, run := range statements {
+		t.Run(name+" of a tenant", func(t *testing.T) {
+			stmt := run(tenantScopeDB(t).WithContext(tenant.NewContext(context.Background(), "acme")))
+			if stmt.Error != nil {
+				t.Fatal(stmt.Error)
+			}
+			sql := stmt.Statement.SQL.String()
+			if !strings.Contains(sql, `"scoped_rows"."tenant_id" = $`) {
+				t.Errorf("SQL %q does not filter on tenant_id", sql)
+			}
+			if !slices.Contains(stmt.Statement.Vars, any("acme")) {
+				t.Errorf("vars %v do not carry the tenant", stmt.Statement.Vars)
+			}
+		})
+
+		t.Run(name+" in a system context", func(t *testing.T) {
+			stmt := run(tenantScopeDB(t).WithContext(tenant.System(context.Background())))
+			if stmt.Error != nil {
+				t.Fatal(stmt.Error)
+			}
+			if sql := stmt.Statement.SQL.String(); strings.Contains(sql, "tenant_id") {
+				t.Errorf("SQL %q filters on tenant_id in a system context", sql)
+			}
+		})
+
+		t.Run(name+" without tenant", func(t *testing.T) {
+			err := run(tenantScopeDB(t).WithContext(context.Background())).Error
+			if !errors.Is(err, tenant.ErrMissingTenant) {
+				t.Errorf("error = %v, want %v", err, tenant.ErrMissingTenant)
+			}
+		})
+	}
+}
+
+func TestTenantScopeSkipsModelsWithoutTenant(t *testing.T) {
+	var rows []unscopedRow
+	stmt := tenantScopeDB(t).WithContext(context.Background()).Where("name = ?", "a").Find(&rows)
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	if sql := stmt.Statement.SQL.String(); strings.Contains(sql, "tenant_id") {
+		t.Errorf("SQL %q filters a model without tenant", sql)
+	}
+}
@@ -0,0 +1,29 @@
+package pg_gorm
+
+import (
+	"context"
+	"time"
+)
+
+// QueryTimeouts bounds the statements run through DBWithTimeout and ReadDBWithTimeout
+type QueryTimeouts struct {
+	Default time.Duration
+	// Operations overrides Default by operation name, eg. "OrderStatsRepository.Rebuild";
+	// a zero timeout leaves the operation bound by its context only
+	Operations map[string]time.Duration
+}
+
+// For returns the timeout of operation
+func (t QueryTimeouts) For(operation string) time.Duration {
+	if timeout, ok := t.Operations[operation]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+func (t QueryTimeouts) withTimeout(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	if timeout := t.For(operation); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
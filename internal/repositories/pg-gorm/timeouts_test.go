@@ -0,0 +1,68 @@
+package pg_gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"order/pkg/http/utils"
+)
+
+func TestQueryTimeoutsFor(t *testing.T) {
+	timeouts := QueryTimeouts{
+		Default: time.Minute,
+		Operations: map[string]time.Duration{
+			"OrderStatsRepository.Rebuild": 5 * time.Minute,
+			"ExportRepository.Stream":      0,
+		},
+	}
+
+	tests := []struct {
+		operation string
+		want      time.Duration
+	}{
+		{operation: "OrderStatsRepository.Rebuild", want: 5 * time.Minute},
+		{operation: "ExportRepository.Stream", want: 0},
+		{operation: "OrderRepository.GetByID", want: time.Minute},
+		{operation: "", want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			if got := timeouts.For(tt.operation); got != tt.want {
+				t.Errorf("For(%q) = %v, want %v", tt.operation, got, tt.want)
+			}
+		})
+	}
+
+	if got := (QueryTimeouts{Default: time.Second}).For("OrderRepository.GetByID"); got != time.Second {
+		t.Errorf("For() without operation timeouts = %v, want the default", got)
+	}
+}
+
+func TestQueryTimeoutsWithTimeout(t *testing.T) {
+	timeouts := QueryTimeouts{Default: time.Minute, Operations: map[string]time.Duration{"ExportRepository.Stream": 0}}
+
+	ctx, cancel := timeouts.withTimeout(context.Background(), "OrderRepository.GetByID")
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("deadline %v, %v, want a minute from now", deadline, ok)
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("cancel does not end the context")
+	}
+
+	// a zero timeout leaves the operation bound by its context only
+	ctx, cancel = timeouts.withTimeout(context.Background(), "ExportRepository.Stream")
+	defer cancel()
+	if _, ok = ctx.Deadline(); ok {
+		t.Error("operation without timeout got a deadline")
+	}
+}
+
+func TestNewPGRepoWithOptionsDefaultsTheQueryTimeout(t *testing.T) {
+	r := NewPGRepoWithOptions(context.Background(), tenantScopeDB(t), nil, Options{}).(*RepoPG)
+	if r.timeouts.Default != utils.GeneralQueryTimeout {
+		t.Errorf("default query timeout = %v, want %v", r.timeouts.Default, utils.GeneralQueryTimeout)
+	}
+}
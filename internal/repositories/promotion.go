@@ -2,9 +2,12 @@ package repo
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"order/internal/models"
 	pgGorm "order/internal/repositories/pg-gorm"
 )
@@ -20,27 +23,46 @@ func NewPromotionRepository(newPgRepo pgGorm.PGInterface) *PromotionRepository {
 // --- Interface for DI (add methods used by service) ---
 type PromotionRepoInterface interface {
 	GetActivePromotion(ctx context.Context, at time.Time) (*models.PromotionConfig, error)
-	HasCustomerReceived(ctx context.Context, promoID uuid.UUID, customerID uuid.UUID) (bool, error)
-	CountDistinctCustomers(ctx context.Context, promoID uuid.UUID) (int64, error)
-	CountRewards(ctx context.Context, promoID uuid.UUID) (int64, error)
-	CreateReward(ctx context.Context, reward *models.PromotionReward) error
+	GetForUpdate(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (*models.PromotionConfig, error)
+	HasCustomerReceived(ctx context.Context, tx *gorm.DB, promoID uuid.UUID, customerID uuid.UUID) (bool, error)
+	CountDistinctCustomers(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (int64, error)
+	CountRewards(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (int64, error)
+	CreateReward(ctx context.Context, tx *gorm.DB, reward *models.PromotionReward) error
+	GetByID(ctx context.Context, promoID uuid.UUID) (*models.PromotionConfig, error)
+	ListPromotions(ctx context.Context) ([]models.PromotionConfig, error)
+	SumRewardAmount(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (float64, error)
+	Deactivate(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) error
+	GetQualifyingStats(ctx context.Context, promoID uuid.UUID) (*models.PromotionQualifyingStats, error)
+	GetNonQualifyingStats(ctx context.Context, promo *models.PromotionConfig) (*models.PromotionNonQualifyingStats, error)
+	GetRewardTimeline(ctx context.Context, promoID uuid.UUID, interval models.PromotionRewardInterval, from, to time.Time) ([]models.PromotionRewardBucket, error)
+	ActivateStarted(ctx context.Context, since, now time.Time) (int64, error)
+	DeactivateEnded(ctx context.Context, now time.Time) (int64, error)
+	ListRewardsByOrder(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) ([]models.PromotionReward, error)
+	RevokeReward(ctx context.Context, tx *gorm.DB, rewardID uuid.UUID, at time.Time) error
+	UpsertThreshold(ctx context.Context, threshold *models.PromotionThreshold) error
 }
 
 // implementations
 
 func (r *PromotionRepository) GetActivePromotion(ctx context.Context, at time.Time) (*models.PromotionConfig, error) {
-	tx, cancel := r.db.DBWithTimeout(ctx)
+	tx, cancel := r.db.DBWithTimeout(ctx, "PromotionRepository.GetActivePromotion")
 	defer cancel()
 	var promo models.PromotionConfig
-	if err := tx.Where("start_time <= ? AND end_time >= ? AND is_active = ?", at, at, true).Order("start_time desc").First(&promo).Error; err != nil {
+	if err := tx.Preload("Thresholds").
+		Where("start_time <= ? AND end_time >= ? AND is_active = ?", at, at, true).
+		Order("start_time desc").
+		First(&promo).Error; err != nil {
 		return nil, err
 	}
 	return &promo, nil
 }
 
-func (r *PromotionRepository) HasCustomerReceived(ctx context.Context, promoID uuid.UUID, customerID uuid.UUID) (bool, error) {
-	tx, cancel := r.db.DBWithTimeout(ctx)
-	defer cancel()
+func (r *PromotionRepository) HasCustomerReceived(ctx context.Context, tx *gorm.DB, promoID uuid.UUID, customerID uuid.UUID) (bool, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.HasCustomerReceived")
+		defer cancel()
+	}
 	var count int64
 	if err := tx.Model(&models.PromotionReward{}).
 		Where("promotion_config_id = ? AND customer_id = ?", promoID, customerID).
@@ -50,13 +72,16 @@ func (r *PromotionRepository) HasCustomerReceived(ctx context.Context, promoID u
 	return count > 0, nil
 }
 
-func (r *PromotionRepository) CountDistinctCustomers(ctx context.Context, promoID uuid.UUID) (int64, error) {
-	tx, cancel := r.db.DBWithTimeout(ctx)
-	defer cancel()
+func (r *PromotionRepository) CountDistinctCustomers(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (int64, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.CountDistinctCustomers")
+		defer cancel()
+	}
 	var count int64
 	// count distinct customers who already received reward for this promotion
 	if err := tx.Model(&models.PromotionReward{}).
-		Select("customer_id").
+		Distinct("customer_id").
 		Where("promotion_config_id = ?", promoID).
 		Count(&count).Error; err != nil {
 		return 0, err
@@ -64,9 +89,12 @@ func (r *PromotionRepository) CountDistinctCustomers(ctx context.Context, promoI
 	return count, nil
 }
 
-func (r *PromotionRepository) CountRewards(ctx context.Context, promoID uuid.UUID) (int64, error) {
-	tx, cancel := r.db.DBWithTimeout(ctx)
-	defer cancel()
+func (r *PromotionRepository) CountRewards(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (int64, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.CountRewards")
+		defer cancel()
+	}
 	var count int64
 	if err := tx.Model(&models.PromotionReward{}).
 		Where("promotion_config_id = ?", promoID).
@@ -76,12 +104,224 @@ func (r *PromotionRepository) CountRewards(ctx context.Context, promoID uuid.UUI
 	return count, nil
 }
 
-func (r *PromotionRepository) CreateReward(ctx context.Context, reward *models.PromotionReward) error {
+func (r *PromotionRepository) CreateReward(ctx context.Context, tx *gorm.DB, reward *models.PromotionReward) error {
 	var cancel context.CancelFunc
-	tx, cancel := r.db.DBWithTimeout(ctx)
-	defer cancel()
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.CreateReward")
+		defer cancel()
+	}
 	if err := tx.Create(reward).Error; err != nil {
 		return err
 	}
 	return nil
 }
+
+// GetForUpdate locks the promotion row for the rest of tx and loads its thresholds, so rewards
+// of the promotion are issued one at a time
+func (r *PromotionRepository) GetForUpdate(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (*models.PromotionConfig, error) {
+	var promo models.PromotionConfig
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", promoID).
+		First(&promo).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.WithContext(ctx).Where("promotion_config_id = ?", promoID).Find(&promo.Thresholds).Error; err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *PromotionRepository) GetByID(ctx context.Context, promoID uuid.UUID) (*models.PromotionConfig, error) {
+	tx, cancel := r.db.ReadDBWithTimeout(ctx, "PromotionRepository.GetByID")
+	defer cancel()
+	var promo models.PromotionConfig
+	if err := tx.Preload("Thresholds").Where("id = ?", promoID).First(&promo).Error; err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *PromotionRepository) ListPromotions(ctx context.Context) ([]models.PromotionConfig, error) {
+	tx, cancel := r.db.ReadDBWithTimeout(ctx, "PromotionRepository.ListPromotions")
+	defer cancel()
+	var promos []models.PromotionConfig
+	if err := tx.Order("start_time desc").Find(&promos).Error; err != nil {
+		return nil, err
+	}
+	return promos, nil
+}
+
+func (r *PromotionRepository) SumRewardAmount(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) (float64, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.SumRewardAmount")
+		defer cancel()
+	}
+	var spend float64
+	if err := tx.Model(&models.PromotionReward{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("promotion_config_id = ?", promoID).
+		Scan(&spend).Error; err != nil {
+		return 0, err
+	}
+	return spend, nil
+}
+
+func (r *PromotionRepository) Deactivate(ctx context.Context, tx *gorm.DB, promoID uuid.UUID) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.Deactivate")
+		defer cancel()
+	}
+	return tx.Model(&models.PromotionConfig{}).
+		Where("id = ? AND is_active = ?", promoID, true).
+		Updates(map[string]interface{}{"is_active": false, "updated_at": time.Now()}).Error
+}
+
+// GetQualifyingStats joins promotion_rewards with the rewarded orders. Order amounts are
+// converted to the base currency with the rate stored on each order.
+func (r *PromotionRepository) GetQualifyingStats(ctx context.Context, promoID uuid.UUID) (*models.PromotionQualifyingStats, error) {
+	tx, cancel := r.db.ReadDBWithTimeout(ctx, "PromotionRepository.GetQualifyingStats")
+	defer cancel()
+
+	var stats models.PromotionQualifyingStats
+	err := tx.Raw(`
+		SELECT COUNT(pr.id)                       AS rewards_issued,
+		       COUNT(DISTINCT pr.customer_id)     AS distinct_customers,
+		       COUNT(DISTINCT o.id)               AS qualifying_orders,
+		       COALESCE(SUM(o.total_amount * o.fx_rate), 0) AS qualifying_revenue,
+		       COALESCE(AVG(o.total_amount * o.fx_rate), 0) AS qualifying_aov,
+		       COALESCE(SUM(pr.amount), 0)        AS spend
+		FROM promotion_rewards pr
+		JOIN orders o ON o.id = pr.order_id AND o.deleted_at IS NULL
+		WHERE pr.promotion_config_id = ? AND pr.deleted_at IS NULL`, promoID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetNonQualifyingStats looks at paid orders of the promotion's tenant, including those since
+// completed, placed during the promotion window that were not rewarded, in the base currency
+func (r *PromotionRepository) GetNonQualifyingStats(ctx context.Context, promo *models.PromotionConfig) (*models.PromotionNonQualifyingStats, error) {
+	tx, cancel := r.db.ReadDBWithTimeout(ctx, "PromotionRepository.GetNonQualifyingStats")
+	defer cancel()
+
+	var stats models.PromotionNonQualifyingStats
+	err := tx.Raw(`
+		SELECT COUNT(o.id)                     AS orders,
+		       COALESCE(AVG(o.total_amount * o.fx_rate), 0) AS aov
+		FROM orders o
+		WHERE o.deleted_at IS NULL
+		  AND o.tenant_id = ?
+		  AND o.status IN ?
+		  AND o.created_at BETWEEN ? AND ?
+		  AND NOT EXISTS (
+		      SELECT 1 FROM promotion_rewards pr
+		      WHERE pr.order_id = o.id AND pr.promotion_config_id = ? AND pr.deleted_at IS NULL
+		  )`, promo.TenantID, models.InvoicedOrderStatuses, promo.StartTime, promo.EndTime, promo.ID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetRewardTimeline buckets the rewards of a promotion by interval, with the revenue of the
+// rewarded orders in the base currency
+func (r *PromotionRepository) GetRewardTimeline(
+	ctx context.Context,
+	promoID uuid.UUID,
+	interval models.PromotionRewardInterval,
+	from, to time.Time,
+) ([]models.PromotionRewardBucket, error) {
+	switch interval {
+	case models.PromotionRewardIntervalHour, models.PromotionRewardIntervalDay, models.PromotionRewardIntervalWeek:
+	default:
+		return nil, fmt.Errorf("unsupported interval %q", interval)
+	}
+
+	tx, cancel := r.db.ReadDBWithTimeout(ctx, "PromotionRepository.GetRewardTimeline")
+	defer cancel()
+
+	var buckets []models.PromotionRewardBucket
+	err := tx.Raw(`
+		SELECT date_trunc(?, pr.received_at)      AS bucket,
+		       COUNT(pr.id)                       AS rewards_issued,
+		       COUNT(DISTINCT pr.customer_id)     AS distinct_customers,
+		       COALESCE(SUM(o.total_amount * o.fx_rate), 0) AS qualifying_revenue,
+		       COALESCE(SUM(pr.amount), 0)        AS spend
+		FROM promotion_rewards pr
+		JOIN orders o ON o.id = pr.order_id AND o.deleted_at IS NULL
+		WHERE pr.promotion_config_id = ?
+		  AND pr.deleted_at IS NULL
+		  AND pr.received_at BETWEEN ? AND ?
+		GROUP BY bucket
+		ORDER BY bucket`, string(interval), promoID, from, to).
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// ActivateStarted activates promotions whose StartTime passed within (since, now].
+// Promotions deactivated manually or by budget before that window are left alone.
+func (r *PromotionRepository) ActivateStarted(ctx context.Context, since, now time.Time) (int64, error) {
+	tx, cancel := r.db.DBWithTimeout(ctx, "PromotionRepository.ActivateStarted")
+	defer cancel()
+	res := tx.Model(&models.PromotionConfig{}).
+		Where("is_active = ? AND start_time > ? AND start_time <= ? AND end_time > ?", false, since, now, now).
+		Updates(map[string]interface{}{"is_active": true, "updated_at": now})
+	return res.RowsAffected, res.Error
+}
+
+// DeactivateEnded deactivates promotions whose EndTime has passed
+func (r *PromotionRepository) DeactivateEnded(ctx context.Context, now time.Time) (int64, error) {
+	tx, cancel := r.db.DBWithTimeout(ctx, "PromotionRepository.DeactivateEnded")
+	defer cancel()
+	res := tx.Model(&models.PromotionConfig{}).
+		Where("is_active = ? AND end_time <= ?", true, now).
+		Updates(map[string]interface{}{"is_active": false, "updated_at": now})
+	return res.RowsAffected, res.Error
+}
+
+// ListRewardsByOrder returns the rewards issued for an order that were not revoked, with their
+// promotion and its thresholds
+func (r *PromotionRepository) ListRewardsByOrder(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) ([]models.PromotionReward, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.ListRewardsByOrder")
+		defer cancel()
+	}
+	var rewards []models.PromotionReward
+	if err := tx.WithContext(ctx).Preload("PromotionConfig.Thresholds").
+		Where("order_id = ? AND deleted_at IS NULL", orderID).
+		Find(&rewards).Error; err != nil {
+		return nil, err
+	}
+	return rewards, nil
+}
+
+// RevokeReward soft deletes a reward, which drops it from the promotion analytics
+func (r *PromotionRepository) RevokeReward(ctx context.Context, tx *gorm.DB, rewardID uuid.UUID, at time.Time) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.db.DBWithTimeout(ctx, "PromotionRepository.RevokeReward")
+		defer cancel()
+	}
+	return tx.WithContext(ctx).Model(&models.PromotionReward{}).
+		Where("id = ? AND deleted_at IS NULL", rewardID).
+		Updates(map[string]interface{}{"deleted_at": at, "updated_at": at}).Error
+}
+
+// UpsertThreshold creates the threshold or replaces the one of the same promotion and currency
+func (r *PromotionRepository) UpsertThreshold(ctx context.Context, threshold *models.PromotionThreshold) error {
+	tx, cancel := r.db.DBWithTimeout(ctx, "PromotionRepository.UpsertThreshold")
+	defer cancel()
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "promotion_config_id"}, {Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"min_order_value", "updated_at"}),
+	}).Create(threshold).Error
+}
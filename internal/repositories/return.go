@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+type ReturnRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewReturnRepository(newPgRepo pgGorm.PGInterface) *ReturnRepository {
+	return &ReturnRepository{db: newPgRepo}
+}
+
+type ReturnRepoInterface interface {
+	Create(ctx context.Context, tx *gorm.DB, ret *model.ReturnRequest) error
+	Save(ctx context.Context, tx *gorm.DB, ret *model.ReturnRequest) error
+	GetByID(ctx context.Context, returnID uuid.UUID) (*model.ReturnRequest, error)
+	GetForUpdate(ctx context.Context, tx *gorm.DB, returnID uuid.UUID) (*model.ReturnRequest, error)
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.ReturnRequest, error)
+	SumOpenQuantity(ctx context.Context, tx *gorm.DB, orderItemID uuid.UUID) (int, error)
+}
+
+func (a *ReturnRepository) Create(ctx context.Context, tx *gorm.DB, ret *model.ReturnRequest) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "ReturnRepository.Create")
+		defer cancel()
+	}
+	return tx.Create(ret).Error
+}
+
+func (a *ReturnRepository) Save(ctx context.Context, tx *gorm.DB, ret *model.ReturnRequest) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "ReturnRepository.Save")
+		defer cancel()
+	}
+	return tx.Save(ret).Error
+}
+
+func (a *ReturnRepository) GetByID(ctx context.Context, returnID uuid.UUID) (*model.ReturnRequest, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "ReturnRepository.GetByID")
+	defer cancel()
+
+	var ret model.ReturnRequest
+	if err := tx.Where("id = ?", returnID).First(&ret).Error; err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// GetForUpdate locks the return request row for the rest of tx
+func (a *ReturnRepository) GetForUpdate(ctx context.Context, tx *gorm.DB, returnID uuid.UUID) (*model.ReturnRequest, error) {
+	var ret model.ReturnRequest
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", returnID).
+		First(&ret).Error; err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// ListByOrderID returns the return requests of an order, oldest first
+func (a *ReturnRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.ReturnRequest, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "ReturnRepository.ListByOrderID")
+	defer cancel()
+
+	var rets []model.ReturnRequest
+	if err := tx.Where("order_id = ?", orderID).Order("created_at").Find(&rets).Error; err != nil {
+		return nil, err
+	}
+	return rets, nil
+}
+
+// SumOpenQuantity returns the quantity of an order item already claimed by returns that were not rejected
+func (a *ReturnRepository) SumOpenQuantity(ctx context.Context, tx *gorm.DB, orderItemID uuid.UUID) (int, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "ReturnRepository.SumOpenQuantity")
+		defer cancel()
+	}
+
+	var quantity int
+	if err := tx.WithContext(ctx).Model(&model.ReturnRequest{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("order_item_id = ? AND status <> ?", orderItemID, model.ReturnStatusRejected).
+		Scan(&quantity).Error; err != nil {
+		return 0, err
+	}
+	return quantity, nil
+}
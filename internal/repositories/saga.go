@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"time"
+)
+
+type SagaRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewSagaRepository(newPgRepo pgGorm.PGInterface) *SagaRepository {
+	return &SagaRepository{db: newPgRepo}
+}
+
+type SagaRepoInterface interface {
+	Create(ctx context.Context, tx *gorm.DB, saga *model.Saga) error
+	Save(ctx context.Context, tx *gorm.DB, saga *model.Saga) error
+	AppendStep(ctx context.Context, tx *gorm.DB, step *model.SagaStep) error
+	GetForUpdate(ctx context.Context, tx *gorm.DB, sagaType string, orderID uuid.UUID) (*model.Saga, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Saga, error)
+	List(ctx context.Context, status model.SagaStatus, limit int) ([]model.Saga, error)
+	ListStalled(ctx context.Context, updatedBefore time.Time, limit int) ([]model.Saga, error)
+	ListTimedOut(ctx context.Context, now time.Time, limit int) ([]model.Saga, error)
+}
+
+func (a *SagaRepository) Create(ctx context.Context, tx *gorm.DB, saga *model.Saga) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "SagaRepository.Create")
+		defer cancel()
+	}
+	return tx.Omit(clause.Associations).Create(saga).Error
+}
+
+func (a *SagaRepository) Save(ctx context.Context, tx *gorm.DB, saga *model.Saga) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "SagaRepository.Save")
+		defer cancel()
+	}
+	saga.UpdatedAt = time.Now()
+	return tx.Omit(clause.Associations).Save(saga).Error
+}
+
+func (a *SagaRepository) AppendStep(ctx context.Context, tx *gorm.DB, step *model.SagaStep) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "SagaRepository.AppendStep")
+		defer cancel()
+	}
+	return tx.Create(step).Error
+}
+
+// GetForUpdate locks the saga of an order for the rest of tx
+func (a *SagaRepository) GetForUpdate(ctx context.Context, tx *gorm.DB, sagaType string, orderID uuid.UUID) (*model.Saga, error) {
+	var saga model.Saga
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("type = ? AND order_id = ?", sagaType, orderID).
+		First(&saga).Error; err != nil {
+		return nil, err
+	}
+	return &saga, nil
+}
+
+// GetByOrderID returns every saga of an order with its step log, oldest step first
+func (a *SagaRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Saga, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "SagaRepository.GetByOrderID")
+	defer cancel()
+
+	var sagas []model.Saga
+	if err := tx.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).Where("order_id = ?", orderID).Order("created_at").Find(&sagas).Error; err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
+// List returns the most recently updated sagas, optionally filtered by status
+func (a *SagaRepository) List(ctx context.Context, status model.SagaStatus, limit int) ([]model.Saga, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "SagaRepository.List")
+	defer cancel()
+
+	query := tx.Order("updated_at desc").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var sagas []model.Saga
+	if err := query.Find(&sagas).Error; err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
+// ListStalled returns sagas left running or compensating, e.g. after a failed step or a crash
+func (a *SagaRepository) ListStalled(ctx context.Context, updatedBefore time.Time, limit int) ([]model.Saga, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "SagaRepository.ListStalled")
+	defer cancel()
+
+	var sagas []model.Saga
+	if err := tx.Where("status IN ? AND updated_at < ?",
+		[]model.SagaStatus{model.SagaStatusRunning, model.SagaStatusCompensating}, updatedBefore).
+		Order("updated_at").
+		Limit(limit).
+		Find(&sagas).Error; err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
+// ListTimedOut returns sagas waiting for a signal past their deadline
+func (a *SagaRepository) ListTimedOut(ctx context.Context, now time.Time, limit int) ([]model.Saga, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "SagaRepository.ListTimedOut")
+	defer cancel()
+
+	var sagas []model.Saga
+	if err := tx.Where("status = ? AND deadline < ?", model.SagaStatusWaiting, now).
+		Order("deadline").
+		Limit(limit).
+		Find(&sagas).Error; err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
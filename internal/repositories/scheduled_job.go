@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+// ErrLeaseLost is returned by Release when the lease of the job has passed to another instance
+var ErrLeaseLost = errors.New("job lease held by another instance")
+
+type ScheduledJobRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewScheduledJobRepository(newPgRepo pgGorm.PGInterface) *ScheduledJobRepository {
+	return &ScheduledJobRepository{db: newPgRepo}
+}
+
+type ScheduledJobRepoInterface interface {
+	EnsureJob(ctx context.Context, job *models.ScheduledJob) (*models.ScheduledJob, error)
+	Claim(ctx context.Context, name, instanceID string, now, lockedUntil time.Time) (*models.ScheduledJob, error)
+	Release(ctx context.Context, name, instanceID string, nextRunAt time.Time, lastRunAt *time.Time, status models.JobRunStatus) error
+	CreateRun(ctx context.Context, run *models.JobRun) error
+	FinishRun(ctx context.Context, run *models.JobRun) error
+	ListJobs(ctx context.Context) ([]models.ScheduledJob, error)
+	ListRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error)
+}
+
+// EnsureJob registers the job if it is unknown, or updates its schedule when the spec changed.
+func (r *ScheduledJobRepository) EnsureJob(ctx context.Context, job *models.ScheduledJob) (*models.ScheduledJob, error) {
+	tx, cancel := r.db.DBWithTimeout(ctx, "ScheduledJobRepository.EnsureJob")
+	defer cancel()
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(job).Error; err != nil {
+		return nil, err
+	}
+
+	var stored models.ScheduledJob
+	if err := tx.Where("name = ?", job.Name).First(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	if stored.Schedule != job.Schedule {
+		if err := tx.Model(&stored).Updates(map[string]interface{}{
+			"schedule":    job.Schedule,
+			"next_run_at": job.NextRunAt,
+			"updated_at":  time.Now(),
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &stored, nil
+}
+
+// Claim takes the execution lease of a due job. It returns gorm.ErrRecordNotFound when
+// the job is not due yet or another instance holds the lease.
+func (r *ScheduledJobRepository) Claim(ctx context.Context, name, instanceID string, now, lockedUntil time.Time) (*models.ScheduledJob, error) {
+	tx, cancel := r.db.DBWithTimeout(ctx, "ScheduledJobRepository.Claim")
+	defer cancel()
+
+	var claimed *models.ScheduledJob
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		var job models.ScheduledJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("name = ? AND enabled = ? AND next_run_at <= ?", name, true, now).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&job).Updates(map[string]interface{}{
+			"locked_by":    instanceID,
+			"locked_until": lockedUntil,
+			"updated_at":   now,
+		}).Error; err != nil {
+			return err
+		}
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Release ends the lease instanceID holds on the job. A nil lastRunAt keeps the last_run_at of
+// the previous successful run. It returns ErrLeaseLost and leaves the job alone when the lease
+// expired and another instance claimed it meanwhile.
+func (r *ScheduledJobRepository) Release(ctx context.Context, name, instanceID string, nextRunAt time.Time, lastRunAt *time.Time, status models.JobRunStatus) error {
+	tx, cancel := r.db.DBWithTimeout(ctx, "ScheduledJobRepository.Release")
+	defer cancel()
+
+	updates := map[string]interface{}{
+		"next_run_at":  nextRunAt,
+		"last_status":  status,
+		"locked_by":    "",
+		"locked_until": nil,
+		"updated_at":   time.Now(),
+	}
+	if lastRunAt != nil {
+		updates["last_run_at"] = *lastRunAt
+	}
+	result := tx.Model(&models.ScheduledJob{}).
+		Where("name = ? AND locked_by = ?", name, instanceID).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *ScheduledJobRepository) CreateRun(ctx context.Context, run *models.JobRun) error {
+	tx, cancel := r.db.DBWithTimeout(ctx, "ScheduledJobRepository.CreateRun")
+	defer cancel()
+	return tx.Create(run).Error
+}
+
+func (r *ScheduledJobRepository) FinishRun(ctx context.Context, run *models.JobRun) error {
+	tx, cancel := r.db.DBWithTimeout(ctx, "ScheduledJobRepository.FinishRun")
+	defer cancel()
+	return tx.Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"finished_at": run.FinishedAt,
+		"duration_ms": run.DurationMs,
+		"affected":    run.Affected,
+		"error":       run.Error,
+		"updated_at":  time.Now(),
+	}).Error
+}
+
+func (r *ScheduledJobRepository) ListJobs(ctx context.Context) ([]models.ScheduledJob, error) {
+	tx, cancel := r.db.ReadDBWithTimeout(ctx, "ScheduledJobRepository.ListJobs")
+	defer cancel()
+	var jobs []models.ScheduledJob
+	if err := tx.Order("name").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *ScheduledJobRepository) ListRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	tx, cancel := r.db.ReadDBWithTimeout(ctx, "ScheduledJobRepository.ListRuns")
+	defer cancel()
+	var runs []models.JobRun
+	q := tx.Order("started_at desc").Limit(limit)
+	if jobName != "" {
+		q = q.Where("job_name = ?", jobName)
+	}
+	if err := q.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+type ShipmentRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewShipmentRepository(newPgRepo pgGorm.PGInterface) *ShipmentRepository {
+	return &ShipmentRepository{db: newPgRepo}
+}
+
+type ShipmentRepoInterface interface {
+	Create(ctx context.Context, tx *gorm.DB, shipment *model.Shipment) error
+	Save(ctx context.Context, tx *gorm.DB, shipment *model.Shipment) error
+	GetForUpdate(ctx context.Context, tx *gorm.DB, shipmentID uuid.UUID) (*model.Shipment, error)
+	GetByTrackingForUpdate(ctx context.Context, tx *gorm.DB, carrier, trackingNumber string) (*model.Shipment, error)
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Shipment, error)
+	SumQuantities(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, statuses []model.ShipmentStatus) (map[uuid.UUID]int, error)
+}
+
+// Create writes the shipment together with its items
+func (a *ShipmentRepository) Create(ctx context.Context, tx *gorm.DB, shipment *model.Shipment) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "ShipmentRepository.Create")
+		defer cancel()
+	}
+	if err := tx.Omit(clause.Associations).Create(shipment).Error; err != nil {
+		return err
+	}
+	for i := range shipment.Items {
+		shipment.Items[i].ShipmentID = shipment.ID
+	}
+	if len(shipment.Items) == 0 {
+		return nil
+	}
+	return tx.Create(&shipment.Items).Error
+}
+
+// Save updates the shipment row; items never change after creation
+func (a *ShipmentRepository) Save(ctx context.Context, tx *gorm.DB, shipment *model.Shipment) error {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "ShipmentRepository.Save")
+		defer cancel()
+	}
+	return tx.Omit(clause.Associations).Save(shipment).Error
+}
+
+// GetForUpdate locks the shipment row for the rest of tx and loads its items
+func (a *ShipmentRepository) GetForUpdate(ctx context.Context, tx *gorm.DB, shipmentID uuid.UUID) (*model.Shipment, error) {
+	return a.lock(ctx, tx, tx.WithContext(ctx).Where("id = ?", shipmentID))
+}
+
+// GetByTrackingForUpdate locks the shipment a carrier knows by trackingNumber
+func (a *ShipmentRepository) GetByTrackingForUpdate(ctx context.Context, tx *gorm.DB, carrier, trackingNumber string) (*model.Shipment, error) {
+	return a.lock(ctx, tx, tx.WithContext(ctx).Where("carrier = ? AND tracking_number = ?", carrier, trackingNumber))
+}
+
+func (a *ShipmentRepository) lock(ctx context.Context, tx *gorm.DB, query *gorm.DB) (*model.Shipment, error) {
+	var shipment model.Shipment
+	if err := query.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shipment).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.WithContext(ctx).Where("shipment_id = ?", shipment.ID).Find(&shipment.Items).Error; err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+// ListByOrderID returns the shipments of an order with their items, oldest first
+func (a *ShipmentRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Shipment, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "ShipmentRepository.ListByOrderID")
+	defer cancel()
+
+	var shipments []model.Shipment
+	if err := tx.Preload("Items").
+		Where("order_id = ?", orderID).
+		Order("created_at").
+		Find(&shipments).Error; err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+// SumQuantities returns per order item the quantity packed into shipments of the order,
+// counting only shipments in one of statuses, or all of them when statuses is empty
+func (a *ShipmentRepository) SumQuantities(
+	ctx context.Context,
+	tx *gorm.DB,
+	orderID uuid.UUID,
+	statuses []model.ShipmentStatus,
+) (map[uuid.UUID]int, error) {
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = a.db.DBWithTimeout(ctx, "ShipmentRepository.SumQuantities")
+		defer cancel()
+	}
+
+	query := tx.WithContext(ctx).Model(&model.ShipmentItem{}).
+		Select("shipment_items.order_item_id, SUM(shipment_items.quantity) AS quantity").
+		Joins("JOIN shipments ON shipments.id = shipment_items.shipment_id").
+		Where("shipments.order_id = ? AND shipments.deleted_at IS NULL AND shipment_items.deleted_at IS NULL", orderID).
+		Group("shipment_items.order_item_id")
+	if len(statuses) > 0 {
+		query = query.Where("shipments.status IN ?", statuses)
+	}
+
+	var rows []struct {
+		OrderItemID uuid.UUID
+		Quantity    int
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
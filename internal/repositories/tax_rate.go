@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"strings"
+)
+
+type TaxRateRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewTaxRateRepository(newPgRepo pgGorm.PGInterface) *TaxRateRepository {
+	return &TaxRateRepository{db: newPgRepo}
+}
+
+type TaxRateRepoInterface interface {
+	FindRate(ctx context.Context, country, region, taxClass string) (*model.TaxRate, error)
+	List(ctx context.Context) ([]model.TaxRate, error)
+	Upsert(ctx context.Context, rate *model.TaxRate) error
+}
+
+// FindRate returns the rate of taxClass in region, falling back to the country-wide rate
+func (a *TaxRateRepository) FindRate(ctx context.Context, country, region, taxClass string) (*model.TaxRate, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "TaxRateRepository.FindRate")
+	defer cancel()
+
+	var rate model.TaxRate
+	// the region specific row sorts before the country-wide one
+	if err := tx.Where("country = ? AND tax_class = ? AND region IN ?",
+		strings.ToUpper(country), taxClass, []string{region, ""}).
+		Order("region DESC").
+		First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (a *TaxRateRepository) List(ctx context.Context) ([]model.TaxRate, error) {
+	tx, cancel := a.db.ReadDBWithTimeout(ctx, "TaxRateRepository.List")
+	defer cancel()
+
+	var rates []model.TaxRate
+	if err := tx.Order("country, region, tax_class").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Upsert creates the rate or overwrites the rate of the same country, region and class
+func (a *TaxRateRepository) Upsert(ctx context.Context, rate *model.TaxRate) error {
+	tx, cancel := a.db.DBWithTimeout(ctx, "TaxRateRepository.Upsert")
+	defer cancel()
+
+	rate.Country = strings.ToUpper(rate.Country)
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "country"}, {Name: "region"}, {Name: "tax_class"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(rate).Error
+}
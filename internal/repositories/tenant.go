@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"gorm.io/gorm/clause"
+	model "order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+type TenantRepository struct {
+	db pgGorm.PGInterface
+}
+
+func NewTenantRepository(newPgRepo pgGorm.PGInterface) *TenantRepository {
+	return &TenantRepository{db: newPgRepo}
+}
+
+type TenantRepoInterface interface {
+	GetByID(ctx context.Context, id string) (*model.Tenant, error)
+	List(ctx context.Context) ([]model.Tenant, error)
+	Upsert(ctx context.Context, t *model.Tenant) error
+}
+
+func (a *TenantRepository) GetByID(ctx context.Context, id string) (*model.Tenant, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "TenantRepository.GetByID")
+	defer cancel()
+
+	var t model.Tenant
+	if err := tx.Where("id = ?", id).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (a *TenantRepository) List(ctx context.Context) ([]model.Tenant, error) {
+	tx, cancel := a.db.DBWithTimeout(ctx, "TenantRepository.List")
+	defer cancel()
+
+	var tenants []model.Tenant
+	if err := tx.Order("id").Find(&tenants).Error; err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// Upsert creates the tenant or overwrites its name, state and settings
+func (a *TenantRepository) Upsert(ctx context.Context, t *model.Tenant) error {
+	tx, cancel := a.db.DBWithTimeout(ctx, "TenantRepository.Upsert")
+	defer cancel()
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "is_active", "base_currency", "invoice_seller_name", "invoice_seller_tax_id",
+			"invoice_seller_address", "invoice_seller_country", "updated_at",
+		}),
+	}).Create(t).Error
+}
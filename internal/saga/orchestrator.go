@@ -0,0 +1,351 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/logger"
+)
+
+// Outcome tells the orchestrator whether a step is done or waits for a signal
+type Outcome int
+
+const (
+	Completed Outcome = iota
+	Waiting
+)
+
+const (
+	// maxStepAttempts is how often a failing step is retried before the saga gives up
+	maxStepAttempts = 5
+	savepoint       = "saga_step"
+
+	stepCompleted   = "COMPLETED"
+	stepWaiting     = "WAITING"
+	stepFailed      = "FAILED"
+	stepCompensated = "COMPENSATED"
+)
+
+var (
+	ErrSagaNotFound = errors.New("saga not found")
+	ErrUnknownSaga  = errors.New("unknown saga definition")
+)
+
+// Step is one unit of a saga. Action and Compensate run inside the orchestrator transaction
+// and must be idempotent, since a failed step is retried by Resume.
+type Step struct {
+	Name       string
+	Action     func(ctx context.Context, tx *gorm.DB, s *models.Saga) (Outcome, error)
+	Compensate func(ctx context.Context, tx *gorm.DB, s *models.Saga) error
+	// Timeout bounds how long the step may wait for its signal; zero waits forever
+	Timeout time.Duration
+}
+
+type Definition struct {
+	Name  string
+	Steps []Step
+	// Track, when set, wraps each saga Resume retries in its own transaction: the steps run
+	// with the returned context and settle learns whether the transaction committed, so
+	// steps can undo what they did outside the database. Start, Signal and Abort run in the
+	// transaction of their caller, who tracks such effects instead.
+	Track func(ctx context.Context) (context.Context, func(committed bool))
+}
+
+// Orchestrator runs saga definitions and persists their state per order
+type Orchestrator struct {
+	pg          pgGorm.PGInterface
+	repo        repo.SagaRepoInterface
+	definitions map[string]*Definition
+	nowFunc     func() time.Time
+}
+
+func NewOrchestrator(pg pgGorm.PGInterface, sagaRepo repo.SagaRepoInterface) *Orchestrator {
+	return &Orchestrator{
+		pg:          pg,
+		repo:        sagaRepo,
+		definitions: map[string]*Definition{},
+		nowFunc:     time.Now,
+	}
+}
+
+func (o *Orchestrator) Register(def *Definition) {
+	o.definitions[def.Name] = def
+}
+
+// Start creates the saga of an order and runs its steps until one waits.
+// A failing step fails Start, so the caller rolls back the whole transaction.
+func (o *Orchestrator) Start(ctx context.Context, tx *gorm.DB, name string, orderID uuid.UUID) (*models.Saga, error) {
+	def, ok := o.definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSaga, name)
+	}
+
+	ctx, span := o.span(ctx, "Orchestrator.Start", name, orderID)
+	defer span.End()
+
+	s := &models.Saga{Type: name, OrderID: orderID, Status: models.SagaStatusRunning}
+	if err := o.repo.Create(ctx, tx, s); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := o.run(ctx, tx, def, s, false); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Signal completes or fails the step the saga is waiting on. Signals for any other step,
+// e.g. redelivered messages, are ignored. A failed step compensates the saga.
+func (o *Orchestrator) Signal(ctx context.Context, tx *gorm.DB, name string, orderID uuid.UUID, step string, stepErr error) error {
+	def, s, err := o.lock(ctx, tx, name, orderID)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := o.span(ctx, "Orchestrator.Signal", name, orderID)
+	span.SetAttributes(attribute.String("saga.step", step))
+	defer span.End()
+
+	if s.Status != models.SagaStatusWaiting || s.CurrentStep != step {
+		return nil
+	}
+
+	if stepErr != nil {
+		if err = o.log(ctx, tx, s, step, models.SagaPhaseAction, stepFailed, stepErr.Error()); err != nil {
+			return err
+		}
+		return o.compensate(ctx, tx, def, s, stepErr.Error())
+	}
+
+	if err = o.log(ctx, tx, s, step, models.SagaPhaseAction, stepCompleted, ""); err != nil {
+		return err
+	}
+	s.StepIndex++
+	s.Status = models.SagaStatusRunning
+	s.Deadline = nil
+	return o.run(ctx, tx, def, s, true)
+}
+
+// Abort compensates an active or completed saga, e.g. when its order is cancelled after the
+// payment went through. Compensated and failed sagas are left untouched.
+func (o *Orchestrator) Abort(ctx context.Context, tx *gorm.DB, name string, orderID uuid.UUID, reason string) error {
+	def, s, err := o.lock(ctx, tx, name, orderID)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := o.span(ctx, "Orchestrator.Abort", name, orderID)
+	defer span.End()
+
+	if !s.IsActive() && s.Status != models.SagaStatusCompleted {
+		return nil
+	}
+	return o.compensate(ctx, tx, def, s, reason)
+}
+
+// Resume continues sagas that stopped running or compensating before updatedBefore,
+// one transaction per saga, and returns how many it picked up.
+func (o *Orchestrator) Resume(ctx context.Context, updatedBefore time.Time, limit int) (int64, error) {
+	log := logger.WithTag("Orchestrator|Resume")
+
+	stalled, err := o.repo.ListStalled(ctx, updatedBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var resumed int64
+	for _, candidate := range stalled {
+		sagaCtx, settle := o.track(ctx, candidate.Type)
+		db, cancel := o.pg.DBWithTimeout(sagaCtx, "Orchestrator.Resume")
+		err = db.Transaction(func(tx *gorm.DB) error {
+			def, s, err := o.lock(sagaCtx, tx, candidate.Type, candidate.OrderID)
+			if err != nil {
+				return err
+			}
+			switch s.Status {
+			case models.SagaStatusRunning:
+				return o.run(sagaCtx, tx, def, s, true)
+			case models.SagaStatusCompensating:
+				return o.compensateRemaining(sagaCtx, tx, def, s)
+			}
+			return nil
+		})
+		cancel()
+		settle(err == nil)
+		if err != nil {
+			logger.LogError(log, err, "failed to resume saga of order "+candidate.OrderID.String())
+			continue
+		}
+		resumed++
+	}
+	return resumed, nil
+}
+
+// track starts tracking the effects of a resumed saga of type name, see Definition.Track
+func (o *Orchestrator) track(ctx context.Context, name string) (context.Context, func(committed bool)) {
+	if def, ok := o.definitions[name]; ok && def.Track != nil {
+		return def.Track(ctx)
+	}
+	return ctx, func(bool) {}
+}
+
+// TimedOut returns the sagas waiting on a step past its timeout
+func (o *Orchestrator) TimedOut(ctx context.Context, limit int) ([]models.Saga, error) {
+	return o.repo.ListTimedOut(ctx, o.nowFunc(), limit)
+}
+
+// run executes steps from s.StepIndex until one waits or all completed. With retry a failing
+// step is rolled back to a savepoint and recorded for Resume instead of failing the caller.
+func (o *Orchestrator) run(ctx context.Context, tx *gorm.DB, def *Definition, s *models.Saga, retry bool) error {
+	for s.StepIndex < len(def.Steps) {
+		step := def.Steps[s.StepIndex]
+		s.CurrentStep = step.Name
+
+		if retry {
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+		}
+		outcome, err := step.Action(ctx, tx, s)
+		if err != nil {
+			if !retry {
+				return err
+			}
+			if rbErr := tx.RollbackTo(savepoint).Error; rbErr != nil {
+				return rbErr
+			}
+			return o.stepFailed(ctx, tx, def, s, step.Name, models.SagaPhaseAction, err)
+		}
+		s.Attempts = 0
+		s.LastError = ""
+
+		if outcome == Waiting {
+			s.Status = models.SagaStatusWaiting
+			if step.Timeout > 0 {
+				deadline := o.nowFunc().Add(step.Timeout)
+				s.Deadline = &deadline
+			}
+			if err = o.log(ctx, tx, s, step.Name, models.SagaPhaseAction, stepWaiting, ""); err != nil {
+				return err
+			}
+			return o.repo.Save(ctx, tx, s)
+		}
+
+		if err = o.log(ctx, tx, s, step.Name, models.SagaPhaseAction, stepCompleted, ""); err != nil {
+			return err
+		}
+		s.StepIndex++
+	}
+
+	s.Status = models.SagaStatusCompleted
+	s.CurrentStep = ""
+	s.Deadline = nil
+	return o.repo.Save(ctx, tx, s)
+}
+
+// compensate switches the saga to compensation. Completed steps are compensated in reverse
+// order; a step still waiting for its signal is compensated as well since its action ran.
+func (o *Orchestrator) compensate(ctx context.Context, tx *gorm.DB, def *Definition, s *models.Saga, reason string) error {
+	if s.Status == models.SagaStatusWaiting {
+		s.StepIndex++
+	}
+	s.Status = models.SagaStatusCompensating
+	s.Deadline = nil
+	s.Attempts = 0
+	s.LastError = reason
+	return o.compensateRemaining(ctx, tx, def, s)
+}
+
+func (o *Orchestrator) compensateRemaining(ctx context.Context, tx *gorm.DB, def *Definition, s *models.Saga) error {
+	for s.StepIndex > 0 {
+		step := def.Steps[s.StepIndex-1]
+		s.CurrentStep = step.Name
+
+		if step.Compensate != nil {
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+			if err := step.Compensate(ctx, tx, s); err != nil {
+				if rbErr := tx.RollbackTo(savepoint).Error; rbErr != nil {
+					return rbErr
+				}
+				return o.stepFailed(ctx, tx, def, s, step.Name, models.SagaPhaseCompensation, err)
+			}
+			if err := o.log(ctx, tx, s, step.Name, models.SagaPhaseCompensation, stepCompensated, ""); err != nil {
+				return err
+			}
+		}
+		s.StepIndex--
+		s.Attempts = 0
+	}
+
+	s.Status = models.SagaStatusCompensated
+	s.CurrentStep = ""
+	return o.repo.Save(ctx, tx, s)
+}
+
+// stepFailed records a failed attempt. After maxStepAttempts a failing action compensates the
+// saga and a failing compensation marks it FAILED for manual intervention.
+func (o *Orchestrator) stepFailed(
+	ctx context.Context,
+	tx *gorm.DB,
+	def *Definition,
+	s *models.Saga,
+	step string,
+	phase models.SagaPhase,
+	stepErr error,
+) error {
+	s.Attempts++
+	s.LastError = stepErr.Error()
+	if err := o.log(ctx, tx, s, step, phase, stepFailed, stepErr.Error()); err != nil {
+		return err
+	}
+
+	if s.Attempts >= maxStepAttempts {
+		if phase == models.SagaPhaseAction {
+			return o.compensate(ctx, tx, def, s, stepErr.Error())
+		}
+		s.Status = models.SagaStatusFailed
+	}
+	return o.repo.Save(ctx, tx, s)
+}
+
+func (o *Orchestrator) lock(ctx context.Context, tx *gorm.DB, name string, orderID uuid.UUID) (*Definition, *models.Saga, error) {
+	def, ok := o.definitions[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownSaga, name)
+	}
+	s, err := o.repo.GetForUpdate(ctx, tx, name, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrSagaNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return def, s, nil
+}
+
+func (o *Orchestrator) log(ctx context.Context, tx *gorm.DB, s *models.Saga, step string, phase models.SagaPhase, status, errMsg string) error {
+	return o.repo.AppendStep(ctx, tx, &models.SagaStep{
+		SagaID: s.ID,
+		Name:   step,
+		Phase:  phase,
+		Status: status,
+		Error:  errMsg,
+	})
+}
+
+func (o *Orchestrator) span(ctx context.Context, op, name string, orderID uuid.UUID) (context.Context, trace.Span) {
+	return otel.Tracer("order/saga").Start(ctx, op,
+		trace.WithAttributes(attribute.String("saga.type", name), attribute.String("order_id", orderID.String())))
+}
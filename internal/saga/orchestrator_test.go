@@ -0,0 +1,484 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+// dryRunConn stands in for the database of a DryRun session, which never sends a statement
+// but still begins and ends transactions
+type dryRunConn struct{ gorm.ConnPool }
+
+func (c dryRunConn) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &dryRunTx{c}, nil
+}
+
+type dryRunTx struct{ gorm.ConnPool }
+
+func (*dryRunTx) Commit() error   { return nil }
+func (*dryRunTx) Rollback() error { return nil }
+
+type fakePG struct {
+	pgGorm.PGInterface
+	db *gorm.DB
+}
+
+func (f fakePG) DBWithTimeout(ctx context.Context, operation string) (*gorm.DB, context.CancelFunc) {
+	return f.db.WithContext(ctx), func() {}
+}
+
+// fakeSagaRepo keeps one saga per order in memory
+type fakeSagaRepo struct {
+	repo.SagaRepoInterface
+	sagas   map[uuid.UUID]models.Saga
+	saveErr error
+}
+
+func (f *fakeSagaRepo) Create(ctx context.Context, tx *gorm.DB, s *models.Saga) error {
+	s.ID = uuid.New()
+	f.sagas[s.OrderID] = *s
+	return nil
+}
+
+func (f *fakeSagaRepo) Save(ctx context.Context, tx *gorm.DB, s *models.Saga) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.sagas[s.OrderID] = *s
+	return nil
+}
+
+func (f *fakeSagaRepo) AppendStep(ctx context.Context, tx *gorm.DB, step *models.SagaStep) error {
+	return nil
+}
+
+func (f *fakeSagaRepo) GetForUpdate(ctx context.Context, tx *gorm.DB, sagaType string, orderID uuid.UUID) (*models.Saga, error) {
+	s, ok := f.sagas[orderID]
+	if !ok || s.Type != sagaType {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &s, nil
+}
+
+func (f *fakeSagaRepo) ListStalled(ctx context.Context, updatedBefore time.Time, limit int) ([]models.Saga, error) {
+	var stalled []models.Saga
+	for _, s := range f.sagas {
+		if s.Status == models.SagaStatusRunning || s.Status == models.SagaStatusCompensating {
+			stalled = append(stalled, s)
+		}
+	}
+	return stalled, nil
+}
+
+// testSteps is a reserve, pay, confirm checkout whose pay step waits for a signal
+type testSteps struct {
+	journal         []string
+	payCompletes    bool
+	failReserve     error
+	failConfirm     error
+	failUndoReserve error
+}
+
+func (ts *testSteps) definition() *Definition {
+	return &Definition{Name: "checkout", Steps: []Step{
+		{
+			Name: "reserve",
+			Action: func(ctx context.Context, tx *gorm.DB, s *models.Saga) (Outcome, error) {
+				if ts.failReserve != nil {
+					return Completed, ts.failReserve
+				}
+				ts.journal = append(ts.journal, "reserve")
+				return Completed, nil
+			},
+			Compensate: func(ctx context.Context, tx *gorm.DB, s *models.Saga) error {
+				if ts.failUndoReserve != nil {
+					return ts.failUndoReserve
+				}
+				ts.journal = append(ts.journal, "undo reserve")
+				return nil
+			},
+		},
+		{
+			Name: "pay",
+			Action: func(ctx context.Context, tx *gorm.DB, s *models.Saga) (Outcome, error) {
+				ts.journal = append(ts.journal, "pay")
+				if ts.payCompletes {
+					return Completed, nil
+				}
+				return Waiting, nil
+			},
+			Compensate: func(ctx context.Context, tx *gorm.DB, s *models.Saga) error {
+				ts.journal = append(ts.journal, "undo pay")
+				return nil
+			},
+			Timeout: 15 * time.Minute,
+		},
+		{
+			Name: "confirm",
+			Action: func(ctx context.Context, tx *gorm.DB, s *models.Saga) (Outcome, error) {
+				if ts.failConfirm != nil {
+					return Completed, ts.failConfirm
+				}
+				ts.journal = append(ts.journal, "confirm")
+				return Completed, nil
+			},
+		},
+	}}
+}
+
+var (
+	errDeclined = errors.New("card declined")
+	errBroken   = errors.New("connection reset")
+	testNow     = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func newTestOrchestrator(t *testing.T, ts *testSteps) (*Orchestrator, *fakeSagaRepo, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: dryRunConn{}}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sagas := &fakeSagaRepo{sagas: map[uuid.UUID]models.Saga{}}
+	o := NewOrchestrator(fakePG{db: db}, sagas)
+	o.nowFunc = func() time.Time { return testNow }
+	o.Register(ts.definition())
+	return o, sagas, db
+}
+
+func TestOrchestratorStart(t *testing.T) {
+	tests := []struct {
+		name         string
+		sagaType     string
+		steps        testSteps
+		wantErr      error
+		wantStatus   models.SagaStatus
+		wantStep     string
+		wantIndex    int
+		wantDeadline bool
+		wantJournal  []string
+	}{
+		{
+			name:         "waits on the step that needs a signal",
+			sagaType:     "checkout",
+			wantStatus:   models.SagaStatusWaiting,
+			wantStep:     "pay",
+			wantIndex:    1,
+			wantDeadline: true,
+			wantJournal:  []string{"reserve", "pay"},
+		},
+		{
+			name:        "runs every step without a wait",
+			sagaType:    "checkout",
+			steps:       testSteps{payCompletes: true},
+			wantStatus:  models.SagaStatusCompleted,
+			wantIndex:   3,
+			wantJournal: []string{"reserve", "pay", "confirm"},
+		},
+		{name: "unknown definition", sagaType: "refund", wantErr: ErrUnknownSaga},
+		{name: "failing step fails the caller", sagaType: "checkout", steps: testSteps{failReserve: errBroken}, wantErr: errBroken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, _, db := newTestOrchestrator(t, &tt.steps)
+
+			s, err := o.Start(context.Background(), db, tt.sagaType, uuid.New())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Status != tt.wantStatus || s.CurrentStep != tt.wantStep || s.StepIndex != tt.wantIndex {
+				t.Errorf("saga at %s step %q index %d, want %s step %q index %d",
+					s.Status, s.CurrentStep, s.StepIndex, tt.wantStatus, tt.wantStep, tt.wantIndex)
+			}
+			if got := s.Deadline != nil && s.Deadline.Equal(testNow.Add(15*time.Minute)); got != tt.wantDeadline {
+				t.Errorf("deadline = %v, want one 15 minutes out: %v", s.Deadline, tt.wantDeadline)
+			}
+			if !slices.Equal(tt.steps.journal, tt.wantJournal) {
+				t.Errorf("journal = %v, want %v", tt.steps.journal, tt.wantJournal)
+			}
+		})
+	}
+}
+
+func TestOrchestratorSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   testSteps
+		step    string
+		stepErr error
+		// unknownOrder signals an order without a saga, brokenTx a transaction that already failed
+		unknownOrder bool
+		brokenTx     bool
+
+		wantErr      error
+		wantStatus   models.SagaStatus
+		wantIndex    int
+		wantAttempts int
+		wantJournal  []string
+	}{
+		{
+			name:       "redelivered signal of an earlier step is ignored",
+			step:       "reserve",
+			wantStatus: models.SagaStatusWaiting,
+			wantIndex:  1,
+		},
+		{
+			name:        "completed step runs the rest",
+			step:        "pay",
+			wantStatus:  models.SagaStatusCompleted,
+			wantIndex:   3,
+			wantJournal: []string{"confirm"},
+		},
+		{
+			name:        "failed step compensates the waiting and completed steps newest first",
+			step:        "pay",
+			stepErr:     errDeclined,
+			wantStatus:  models.SagaStatusCompensated,
+			wantJournal: []string{"undo pay", "undo reserve"},
+		},
+		{
+			name:         "failing next step is kept for a retry",
+			steps:        testSteps{failConfirm: errBroken},
+			step:         "pay",
+			wantStatus:   models.SagaStatusRunning,
+			wantIndex:    2,
+			wantAttempts: 1,
+		},
+		{
+			name:         "failing compensation is kept for a retry",
+			steps:        testSteps{failUndoReserve: errBroken},
+			step:         "pay",
+			stepErr:      errDeclined,
+			wantStatus:   models.SagaStatusCompensating,
+			wantIndex:    1,
+			wantAttempts: 1,
+			wantJournal:  []string{"undo pay"},
+		},
+		{name: "order without a saga", step: "pay", unknownOrder: true, wantErr: ErrSagaNotFound},
+		{name: "savepoint on a failed transaction", step: "pay", brokenTx: true, wantErr: errBroken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			o, sagas, db := newTestOrchestrator(t, &tt.steps)
+			orderID := uuid.New()
+			if _, err := o.Start(ctx, db, "checkout", orderID); err != nil {
+				t.Fatal(err)
+			}
+			tt.steps.journal = nil
+
+			if tt.unknownOrder {
+				orderID = uuid.New()
+			}
+			tx := db.Session(&gorm.Session{})
+			if tt.brokenTx {
+				_ = tx.AddError(errBroken)
+			}
+			err := o.Signal(ctx, tx, "checkout", orderID, tt.step, tt.stepErr)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := sagas.sagas[orderID]
+			if s.Status != tt.wantStatus || s.StepIndex != tt.wantIndex || s.Attempts != tt.wantAttempts {
+				t.Errorf("saga at %s index %d attempts %d, want %s index %d attempts %d",
+					s.Status, s.StepIndex, s.Attempts, tt.wantStatus, tt.wantIndex, tt.wantAttempts)
+			}
+			if s.Status != models.SagaStatusWaiting && s.Deadline != nil {
+				t.Errorf("deadline %v kept after the wait ended", s.Deadline)
+			}
+			if !slices.Equal(tt.steps.journal, tt.wantJournal) {
+				t.Errorf("journal = %v, want %v", tt.steps.journal, tt.wantJournal)
+			}
+		})
+	}
+}
+
+func TestOrchestratorAbort(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      models.SagaStatus
+		wantStatus  models.SagaStatus
+		wantJournal []string
+	}{
+		{
+			name:        "waiting saga",
+			status:      models.SagaStatusWaiting,
+			wantStatus:  models.SagaStatusCompensated,
+			wantJournal: []string{"undo pay", "undo reserve"},
+		},
+		{
+			name:        "completed saga",
+			status:      models.SagaStatusCompleted,
+			wantStatus:  models.SagaStatusCompensated,
+			wantJournal: []string{"undo pay", "undo reserve"},
+		},
+		{name: "compensated saga", status: models.SagaStatusCompensated, wantStatus: models.SagaStatusCompensated},
+		{name: "failed saga", status: models.SagaStatusFailed, wantStatus: models.SagaStatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			steps := &testSteps{}
+			o, sagas, db := newTestOrchestrator(t, steps)
+			orderID := uuid.New()
+			if _, err := o.Start(ctx, db, "checkout", orderID); err != nil {
+				t.Fatal(err)
+			}
+			if tt.status == models.SagaStatusCompleted {
+				if err := o.Signal(ctx, db, "checkout", orderID, "pay", nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := sagas.sagas[orderID]
+			s.Status = tt.status
+			sagas.sagas[orderID] = s
+			steps.journal = nil
+
+			if err := o.Abort(ctx, db, "checkout", orderID, "cancelled by customer"); err != nil {
+				t.Fatal(err)
+			}
+			if got := sagas.sagas[orderID].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if !slices.Equal(steps.journal, tt.wantJournal) {
+				t.Errorf("journal = %v, want %v", steps.journal, tt.wantJournal)
+			}
+		})
+	}
+}
+
+func TestOrchestratorResumeGivesUpAfterMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       testSteps
+		stepErr     error
+		wantStatus  models.SagaStatus
+		wantIndex   int
+		wantJournal []string
+	}{
+		{
+			name:        "failing action compensates the saga",
+			steps:       testSteps{failConfirm: errBroken},
+			wantStatus:  models.SagaStatusCompensated,
+			wantJournal: []string{"undo pay", "undo reserve"},
+		},
+		{
+			name:       "failing compensation fails the saga",
+			steps:      testSteps{failUndoReserve: errBroken},
+			stepErr:    errDeclined,
+			wantStatus: models.SagaStatusFailed,
+			wantIndex:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			o, sagas, db := newTestOrchestrator(t, &tt.steps)
+			orderID := uuid.New()
+			if _, err := o.Start(ctx, db, "checkout", orderID); err != nil {
+				t.Fatal(err)
+			}
+			// the signal makes the first failed attempt
+			if err := o.Signal(ctx, db, "checkout", orderID, "pay", tt.stepErr); err != nil {
+				t.Fatal(err)
+			}
+			tt.steps.journal = nil
+
+			for attempt := 2; attempt <= maxStepAttempts; attempt++ {
+				resumed, err := o.Resume(ctx, testNow, 10)
+				if err != nil || resumed != 1 {
+					t.Fatalf("attempt %d: resumed %d, err %v", attempt, resumed, err)
+				}
+			}
+			if resumed, _ := o.Resume(ctx, testNow, 10); resumed != 0 {
+				t.Errorf("resumed %d sagas after giving up", resumed)
+			}
+
+			s := sagas.sagas[orderID]
+			if s.Status != tt.wantStatus || s.StepIndex != tt.wantIndex || s.LastError != errBroken.Error() {
+				t.Errorf("saga at %s index %d error %q, want %s index %d error %q",
+					s.Status, s.StepIndex, s.LastError, tt.wantStatus, tt.wantIndex, errBroken)
+			}
+			if !slices.Equal(tt.steps.journal, tt.wantJournal) {
+				t.Errorf("journal = %v, want %v", tt.steps.journal, tt.wantJournal)
+			}
+		})
+	}
+}
+
+type trackedKey struct{}
+
+func TestOrchestratorResumeSettlesTrackedEffects(t *testing.T) {
+	tests := []struct {
+		name        string
+		saveErr     error
+		wantJournal []string
+	}{
+		{name: "committed", wantJournal: []string{"track", "reserve tracked", "pay", "settle committed"}},
+		{name: "rolled back", saveErr: errBroken, wantJournal: []string{"track", "reserve tracked", "pay", "settle rolled back"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			steps := &testSteps{failReserve: errBroken}
+			o, sagas, db := newTestOrchestrator(t, steps)
+			orderID := uuid.New()
+			// the failed start leaves the saga at its first step for Resume
+			if _, err := o.Start(ctx, db, "checkout", orderID); !errors.Is(err, errBroken) {
+				t.Fatalf("Start() error = %v, want %v", err, errBroken)
+			}
+
+			def := steps.definition()
+			def.Track = func(ctx context.Context) (context.Context, func(committed bool)) {
+				steps.journal = append(steps.journal, "track")
+				return context.WithValue(ctx, trackedKey{}, true), func(committed bool) {
+					if committed {
+						steps.journal = append(steps.journal, "settle committed")
+					} else {
+						steps.journal = append(steps.journal, "settle rolled back")
+					}
+				}
+			}
+			def.Steps[0].Action = func(ctx context.Context, tx *gorm.DB, s *models.Saga) (Outcome, error) {
+				if ctx.Value(trackedKey{}) != nil {
+					steps.journal = append(steps.journal, "reserve tracked")
+				}
+				return Completed, nil
+			}
+			o.Register(def)
+			sagas.saveErr = tt.saveErr
+
+			if _, err := o.Resume(ctx, testNow, 10); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(steps.journal, tt.wantJournal) {
+				t.Errorf("journal = %v, want %v", steps.journal, tt.wantJournal)
+			}
+		})
+	}
+}
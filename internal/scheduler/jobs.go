@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	repo "order/internal/repositories"
+	"order/internal/services"
+	"order/pkg/core/configloader"
+	"order/pkg/core/tenant"
+)
+
+const (
+	JobExpireUnpaidOrders   = "expire_unpaid_orders"
+	JobSyncPromotionWindows = "sync_promotion_windows"
+	JobPurgeOutbox          = "purge_outbox"
+	JobReconcileStuckOrders = "reconcile_stuck_orders"
+	JobExpireAbandonedCarts = "expire_abandoned_carts"
+	JobResumeCheckoutSagas  = "resume_checkout_sagas"
+	JobTimeoutCheckoutSagas = "timeout_checkout_sagas"
+	JobRefreshOrderStats    = "refresh_order_stats"
+
+	reconcileBatchSize = 100
+)
+
+// BuiltinJobs returns the housekeeping jobs shipped with the service. Jobs on tenant data run
+// once for every active tenant.
+func BuiltinJobs(
+	cfg *configloader.Config,
+	tenants services.TenantServiceInterface,
+	orderService services.OrderServiceInterface,
+	promotionService services.PromotionServiceInterface,
+	cartService services.CartServiceInterface,
+	reportService services.ReportServiceInterface,
+	outboxRepo repo.OutboxRepoInterface,
+) []Job {
+	paymentTimeout := time.Duration(cfg.OrderPaymentTimeoutMinutes) * time.Minute
+	reconcileAfter := time.Duration(cfg.OrderReconcileAfterMinutes) * time.Minute
+	outboxRetention := time.Duration(cfg.OutboxRetentionHours) * time.Hour
+
+	return []Job{
+		{
+			// expire orders that were never paid
+			Name: JobExpireUnpaidOrders,
+			Spec: "* * * * *",
+			Handler: perTenant(tenants, func(ctx context.Context, run RunContext) (int64, error) {
+				return orderService.ExpireUnpaidOrders(ctx, time.Now().Add(-paymentTimeout))
+			}),
+		},
+		{
+			// activate and deactivate promotions at StartTime / EndTime
+			Name: JobSyncPromotionWindows,
+			Spec: "* * * * *",
+			Handler: perTenant(tenants, func(ctx context.Context, run RunContext) (int64, error) {
+				since := run.ScheduledAt.Add(-time.Minute)
+				if run.LastRunAt != nil {
+					since = *run.LastRunAt
+				}
+				return promotionService.SyncPromotionSchedule(ctx, since, time.Now())
+			}),
+		},
+		{
+			// delete delivered outbox rows past retention
+			Name:    JobPurgeOutbox,
+			Spec:    "0 3 * * *",
+			Timeout: 30 * time.Minute,
+			Handler: func(ctx context.Context, run RunContext) (int64, error) {
+				return outboxRepo.PurgeDone(tenant.System(ctx), time.Now().Add(-outboxRetention))
+			},
+		},
+		{
+			// re-queue payment requests of orders stuck in pending
+			Name: JobReconcileStuckOrders,
+			Spec: "*/5 * * * *",
+			Handler: perTenant(tenants, func(ctx context.Context, run RunContext) (int64, error) {
+				return orderService.ReconcileStuckOrders(ctx, time.Now().Add(-reconcileAfter), reconcileBatchSize)
+			}),
+		},
+		{
+			// expire carts that were not touched within CART_TTL_HOURS
+			Name: JobExpireAbandonedCarts,
+			Spec: "*/15 * * * *",
+			Handler: perTenant(tenants, func(ctx context.Context, run RunContext) (int64, error) {
+				return cartService.ExpireAbandonedCarts(ctx)
+			}),
+		},
+		{
+			// retry checkout sagas left behind by a failed step or a crashed instance
+			Name: JobResumeCheckoutSagas,
+			Spec: "* * * * *",
+			Handler: perTenant(tenants, func(ctx context.Context, run RunContext) (int64, error) {
+				return orderService.ResumeCheckoutSagas(ctx)
+			}),
+		},
+		{
+			// expire orders whose checkout saga waited past its step timeout
+			Name: JobTimeoutCheckoutSagas,
+			Spec: "* * * * *",
+			Handler: perTenant(tenants, func(ctx context.Context, run RunContext) (int64, error) {
+				return orderService.TimeoutCheckoutSagas(ctx)
+			}),
+		},
+		{
+			// rebuild the hourly and daily order stats of the orders changed since the last refresh
+			Name:    JobRefreshOrderStats,
+			Spec:    "* * * * *",
+			Timeout: 10 * time.Minute,
+			Handler: perTenant(tenants, func(ctx context.Context, run RunContext) (int64, error) {
+				return reportService.RefreshOrderStats(ctx)
+			}),
+		},
+	}
+}
+
+// perTenant runs handler once in the context of every active tenant and sums the affected rows.
+// A failing tenant does not keep the others from running; the first error is returned.
+func perTenant(tenants services.TenantServiceInterface, handler Handler) Handler {
+	return func(ctx context.Context, run RunContext) (int64, error) {
+		ids, err := tenants.ActiveTenants(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		var (
+			total    int64
+			firstErr error
+		)
+		for _, id := range ids {
+			affected, err := handler(tenant.NewContext(ctx, id), run)
+			total += affected
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("tenant %s: %w", id, err)
+			}
+		}
+		return total, firstErr
+	}
+}
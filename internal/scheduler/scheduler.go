@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/pkg/core/logger"
+)
+
+// RunContext is handed to a job handler for a single execution
+type RunContext struct {
+	// ScheduledAt is the time the run was due
+	ScheduledAt time.Time
+	// LastRunAt is the start of the last successful run, nil until a run succeeded. Failed runs
+	// leave it alone so that the next run covers their period again.
+	LastRunAt *time.Time
+}
+
+// Handler executes a job and returns the number of affected records
+type Handler func(ctx context.Context, run RunContext) (int64, error)
+
+// Job is a named handler triggered by a standard 5-field cron expression
+type Job struct {
+	Name    string
+	Spec    string
+	Timeout time.Duration
+	Handler Handler
+}
+
+// leaseGrace is how much longer the lease of a run lasts than its timeout, so that a run is
+// cancelled and released before another instance may claim the job
+const leaseGrace = time.Minute
+
+type registeredJob struct {
+	Job
+	schedule cron.Schedule
+}
+
+// Scheduler executes cron jobs. Every replica may run a Scheduler: a job run is only
+// executed by the instance that claims its lease in the scheduled_jobs table.
+type Scheduler struct {
+	jobRepo    repo.ScheduledJobRepoInterface
+	jobs       []registeredJob
+	instanceID string
+	interval   time.Duration
+	parser     cron.Parser
+	wg         sync.WaitGroup
+}
+
+func NewScheduler(jobRepo repo.ScheduledJobRepoInterface) *Scheduler {
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		jobRepo:    jobRepo,
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		interval:   10 * time.Second,
+		parser:     cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor),
+	}
+}
+
+// Register adds a job; it fails on an invalid cron expression or a duplicate name.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Handler == nil {
+		return errors.New("scheduler: job name and handler are required")
+	}
+	for _, j := range s.jobs {
+		if j.Name == job.Name {
+			return fmt.Errorf("scheduler: job %q already registered", job.Name)
+		}
+	}
+
+	schedule, err := s.parser.Parse(job.Spec)
+	if err != nil {
+		return fmt.Errorf("scheduler: invalid spec %q for job %q: %w", job.Spec, job.Name, err)
+	}
+	if job.Timeout == 0 {
+		job.Timeout = 5 * time.Minute
+	}
+
+	s.jobs = append(s.jobs, registeredJob{Job: job, schedule: schedule})
+	return nil
+}
+
+// Run registers the jobs in the database then polls for due runs until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	log := logger.WithTag("Scheduler|Run")
+
+	for _, job := range s.jobs {
+		if _, err := s.jobRepo.EnsureJob(ctx, &models.ScheduledJob{
+			Name:      job.Name,
+			Schedule:  job.Spec,
+			Enabled:   true,
+			NextRunAt: job.schedule.Next(time.Now()),
+		}); err != nil {
+			logger.LogError(log, err, "failed to register job "+job.Name)
+		}
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return
+		case <-ticker.C:
+			for _, job := range s.jobs {
+				s.tryRun(ctx, job)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) tryRun(ctx context.Context, job registeredJob) {
+	log := logger.WithTag("Scheduler|tryRun")
+
+	now := time.Now()
+	claimed, err := s.jobRepo.Claim(ctx, job.Name, s.instanceID, now, now.Add(job.Timeout+leaseGrace))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.LogError(log, err, "failed to claim job "+job.Name)
+		}
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(ctx, job, claimed)
+	}()
+}
+
+func (s *Scheduler) execute(ctx context.Context, job registeredJob, claimed *models.ScheduledJob) {
+	log := logger.WithTag("Scheduler|execute")
+
+	tracer := otel.Tracer("order/scheduler")
+	ctx, span := tracer.Start(ctx, "Scheduler.execute",
+		trace.WithAttributes(attribute.String("job", job.Name)))
+	defer span.End()
+
+	startedAt := time.Now()
+	run := &models.JobRun{
+		JobName:     job.Name,
+		InstanceID:  s.instanceID,
+		Status:      models.JobRunStatusRunning,
+		ScheduledAt: claimed.NextRunAt,
+		StartedAt:   startedAt,
+	}
+	if err := s.jobRepo.CreateRun(ctx, run); err != nil {
+		logger.LogError(log, err, "failed to record run of job "+job.Name)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	affected, err := s.safeHandle(runCtx, job, RunContext{ScheduledAt: claimed.NextRunAt, LastRunAt: claimed.LastRunAt})
+	cancel()
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(startedAt).Milliseconds()
+	run.Affected = affected
+	run.Status = models.JobRunStatusSucceeded
+	lastRunAt := &startedAt
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "job failed")
+		logger.LogError(log, err, "job "+job.Name+" failed")
+		run.Status = models.JobRunStatusFailed
+		run.Error = err.Error()
+		lastRunAt = nil
+	}
+
+	// use a fresh context so the lease is released even during shutdown
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer releaseCancel()
+
+	if run.ID != uuid.Nil {
+		if err := s.jobRepo.FinishRun(releaseCtx, run); err != nil {
+			logger.LogError(log, err, "failed to finish run of job "+job.Name)
+		}
+	}
+	if err := s.jobRepo.Release(releaseCtx, job.Name, s.instanceID, job.schedule.Next(finishedAt), lastRunAt, run.Status); err != nil {
+		logger.LogError(log, err, "failed to release job "+job.Name)
+	}
+}
+
+// safeHandle turns a panicking handler into a failed run instead of crashing the process
+func (s *Scheduler) safeHandle(ctx context.Context, job registeredJob, run RunContext) (affected int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked: %v", job.Name, r)
+		}
+	}()
+	return job.Handler(ctx, run)
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/internal/services"
+	"order/pkg/core/configloader"
+	"order/pkg/core/tenant"
+)
+
+// fakeJobRepo grants every claim and records the lease and its release
+type fakeJobRepo struct {
+	repo.ScheduledJobRepoInterface
+	lockedUntil   time.Time
+	lastRunAt     *time.Time
+	releasedBy    string
+	releaseStatus models.JobRunStatus
+	releaseErr    error
+}
+
+func (f *fakeJobRepo) Claim(ctx context.Context, name, instanceID string, now, lockedUntil time.Time) (*models.ScheduledJob, error) {
+	f.lockedUntil = lockedUntil
+	return &models.ScheduledJob{Name: name, LockedBy: instanceID, NextRunAt: now, LastRunAt: f.lastRunAt}, nil
+}
+
+func (f *fakeJobRepo) CreateRun(ctx context.Context, run *models.JobRun) error {
+	run.ID = uuid.New()
+	return nil
+}
+
+func (f *fakeJobRepo) FinishRun(ctx context.Context, run *models.JobRun) error {
+	return nil
+}
+
+func (f *fakeJobRepo) Release(ctx context.Context, name, instanceID string, nextRunAt time.Time, lastRunAt *time.Time, status models.JobRunStatus) error {
+	f.releasedBy = instanceID
+	f.releaseStatus = status
+	if lastRunAt != nil {
+		f.lastRunAt = lastRunAt
+	}
+	return f.releaseErr
+}
+
+func TestSchedulerRunEndsWithinItsLease(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    Handler
+		releaseErr error
+		wantStatus models.JobRunStatus
+	}{
+		{
+			name:       "succeeded",
+			handler:    func(ctx context.Context, run RunContext) (int64, error) { return 3, nil },
+			wantStatus: models.JobRunStatusSucceeded,
+		},
+		{
+			name:       "failed",
+			handler:    func(ctx context.Context, run RunContext) (int64, error) { return 0, errors.New("boom") },
+			wantStatus: models.JobRunStatusFailed,
+		},
+		{
+			name:       "panicked",
+			handler:    func(ctx context.Context, run RunContext) (int64, error) { panic("boom") },
+			wantStatus: models.JobRunStatusFailed,
+		},
+		{
+			name:       "lease taken over meanwhile",
+			handler:    func(ctx context.Context, run RunContext) (int64, error) { return 0, nil },
+			releaseErr: repo.ErrLeaseLost,
+			wantStatus: models.JobRunStatusSucceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &fakeJobRepo{releaseErr: tt.releaseErr}
+			s := NewScheduler(jobs)
+
+			var deadline time.Time
+			err := s.Register(Job{Name: "expire", Spec: "* * * * *", Timeout: time.Minute,
+				Handler: func(ctx context.Context, run RunContext) (int64, error) {
+					deadline, _ = ctx.Deadline()
+					return tt.handler(ctx, run)
+				}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s.tryRun(context.Background(), s.jobs[0])
+			s.wg.Wait()
+
+			if deadline.IsZero() || !deadline.Before(jobs.lockedUntil) {
+				t.Errorf("run deadline %v not before the end of the lease %v", deadline, jobs.lockedUntil)
+			}
+			if jobs.releasedBy != s.instanceID {
+				t.Errorf("released by %q, want the claiming instance %q", jobs.releasedBy, s.instanceID)
+			}
+			if jobs.releaseStatus != tt.wantStatus {
+				t.Errorf("released with status %s, want %s", jobs.releaseStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// fakeTenants lists a fixed set of active tenants
+type fakeTenants struct {
+	services.TenantServiceInterface
+	ids []string
+}
+
+func (f fakeTenants) ActiveTenants(ctx context.Context) ([]string, error) {
+	return f.ids, nil
+}
+
+// fakePromotionWindows activates the promotions of each tenant that started within the synced
+// period, failing the sync of the tenants in failOnce the first time
+type fakePromotionWindows struct {
+	services.PromotionServiceInterface
+	startTimes map[string]time.Time
+	failOnce   map[string]bool
+	activated  map[string]bool
+}
+
+func (f *fakePromotionWindows) SyncPromotionSchedule(ctx context.Context, since, now time.Time) (int64, error) {
+	id, _ := tenant.FromContext(ctx)
+	if f.failOnce[id] {
+		delete(f.failOnce, id)
+		return 0, errors.New("connection reset")
+	}
+	if start := f.startTimes[id]; !f.activated[id] && start.After(since) && !start.After(now) {
+		f.activated[id] = true
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func TestSyncPromotionWindowsCoversTheFailedRunAgain(t *testing.T) {
+	cfg := &configloader.Config{}
+	promotions := &fakePromotionWindows{
+		startTimes: map[string]time.Time{"acme": time.Now().Add(-10 * time.Second), "globex": time.Now().Add(-10 * time.Second)},
+		failOnce:   map[string]bool{"acme": true},
+		activated:  map[string]bool{},
+	}
+	jobs := &fakeJobRepo{}
+	s := NewScheduler(jobs)
+	for _, job := range BuiltinJobs(cfg, fakeTenants{ids: []string{"acme", "globex"}}, nil, promotions, nil, nil, nil) {
+		if job.Name != JobSyncPromotionWindows {
+			continue
+		}
+		if err := s.Register(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.tryRun(context.Background(), s.jobs[0])
+	s.wg.Wait()
+	if jobs.releaseStatus != models.JobRunStatusFailed {
+		t.Fatalf("first run released with status %s, want %s", jobs.releaseStatus, models.JobRunStatusFailed)
+	}
+	if jobs.lastRunAt != nil {
+		t.Errorf("failed run moved last_run_at to %v", jobs.lastRunAt)
+	}
+	if promotions.activated["acme"] || !promotions.activated["globex"] {
+		t.Fatalf("activated after the first run = %v, want only globex", promotions.activated)
+	}
+
+	s.tryRun(context.Background(), s.jobs[0])
+	s.wg.Wait()
+	if jobs.releaseStatus != models.JobRunStatusSucceeded {
+		t.Fatalf("second run released with status %s, want %s", jobs.releaseStatus, models.JobRunStatusSucceeded)
+	}
+	if jobs.lastRunAt == nil {
+		t.Error("successful run did not set last_run_at")
+	}
+	if !promotions.activated["acme"] {
+		t.Error("promotion of the failed tenant was not activated by the next run")
+	}
+}
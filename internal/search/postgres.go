@@ -0,0 +1,167 @@
+package search
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+	"order/internal/models"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"strings"
+)
+
+// ProviderPostgres is the built-in provider backed by the trigram and full-text indexes of the orders tables
+const ProviderPostgres = "postgres"
+
+// documentSQL is the full-text document of an order; it has to stay the expression of
+// idx_orders_search_document for the index to be used
+const documentSQL = `to_tsvector('simple', customer_name || ' ' || customer_email || ' ' || customer_phone || ' ' ||
+	shipping_name || ' ' || shipping_city || ' ' || shipping_postal_code || ' ' || billing_name || ' ' || billing_city)`
+
+// PostgresSearcher matches partial order IDs, emails and product IDs with trigram indexes and the
+// words of the customer and address snapshots with a full-text index
+type PostgresSearcher struct {
+	db pgGorm.PGInterface
+}
+
+func NewPostgresSearcher(db pgGorm.PGInterface) *PostgresSearcher {
+	return &PostgresSearcher{db: db}
+}
+
+type scoredID struct {
+	ID    uuid.UUID
+	Score float64
+}
+
+func (s *PostgresSearcher) Search(ctx context.Context, req models.OrderSearchRequest) (*models.OrderSearchResult, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "PostgresSearcher.Search")
+	defer span.End()
+
+	result, err := s.search(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "search failed")
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int64("total", result.Total))
+	return result, nil
+}
+
+func (s *PostgresSearcher) search(ctx context.Context, req models.OrderSearchRequest) (*models.OrderSearchResult, error) {
+	db, cancel := s.db.ReadDBWithTimeout(ctx, "PostgresSearcher.search")
+	defer cancel()
+
+	page, pageSize := max(req.Page, 1), req.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	interval := req.Interval
+	if interval == "" {
+		interval = defaultInterval
+	}
+
+	result := &models.OrderSearchResult{Hits: []models.OrderSearchHit{}}
+	if err := s.matching(db, req).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := s.matching(db, req).
+		Select("UPPER(status) AS status, count(*) AS count").
+		Group("1").Order("count DESC").
+		Scan(&result.Facets.Status).Error; err != nil {
+		return nil, err
+	}
+	if err := s.matching(db, req).
+		Select("date_trunc(?, created_at) AS start, count(*) AS count", interval).
+		Group("1").Order("1").
+		Scan(&result.Facets.CreatedAt).Error; err != nil {
+		return nil, err
+	}
+
+	score := "0"
+	var scoreArgs []interface{}
+	if q := strings.TrimSpace(req.Q); q != "" {
+		score = "ts_rank(" + documentSQL + ", websearch_to_tsquery('simple', ?)) + GREATEST(similarity(id::text, ?), similarity(customer_email, ?))"
+		scoreArgs = []interface{}{q, q, q}
+	}
+	var ids []scoredID
+	if err := s.matching(db, req).
+		Select("id, "+score+" AS score", scoreArgs...).
+		Order("score DESC, created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	orderIDs := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		orderIDs = append(orderIDs, id.ID)
+	}
+	var orders []models.Order
+	if err := db.Preload("OrderItems").Where("id IN ?", orderIDs).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Order, len(orders))
+	for i := range orders {
+		byID[orders[i].ID] = &orders[i]
+	}
+
+	terms := Terms(req)
+	for _, id := range ids {
+		order, ok := byID[id.ID]
+		if !ok {
+			continue
+		}
+		result.Hits = append(result.Hits, models.OrderSearchHit{
+			Order:      *order,
+			Score:      id.Score,
+			Highlights: Highlights(order, terms),
+		})
+	}
+	return result, nil
+}
+
+// matching returns a fresh query of the orders matching req
+func (s *PostgresSearcher) matching(db *gorm.DB, req models.OrderSearchRequest) *gorm.DB {
+	query := db.Model(&models.Order{})
+
+	if q := strings.TrimSpace(req.Q); q != "" {
+		pattern := "%" + escapeLike(q) + "%"
+		query = query.Where(`(id::text ILIKE ? OR customer_email ILIKE ? OR `+documentSQL+` @@ websearch_to_tsquery('simple', ?)
+			OR EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = orders.id AND oi.product_id::text ILIKE ?))`,
+			pattern, pattern, q, pattern)
+	}
+	if orderID := strings.TrimSpace(req.OrderID); orderID != "" {
+		query = query.Where("id::text ILIKE ?", escapeLike(orderID)+"%")
+	}
+	if email := strings.TrimSpace(req.CustomerEmail); email != "" {
+		query = query.Where("customer_email ILIKE ?", "%"+escapeLike(email)+"%")
+	}
+	if req.ProductID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = orders.id AND oi.product_id = ?)", req.ProductID)
+	}
+	if len(req.Status) > 0 {
+		statuses := make([]string, 0, len(req.Status))
+		for _, status := range req.Status {
+			statuses = append(statuses, strings.ToUpper(status))
+		}
+		query = query.Where("UPPER(status) IN ?", statuses)
+	}
+	if req.From != nil {
+		query = query.Where("created_at >= ?", *req.From)
+	}
+	if req.To != nil {
+		query = query.Where("created_at < ?", *req.To)
+	}
+	return query
+}
+
+// escapeLike makes the wildcards of a search term match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
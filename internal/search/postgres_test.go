@@ -0,0 +1,84 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/models"
+	"order/internal/pgtest"
+)
+
+func TestPostgresSearcherMatchesFiltersAndCountsFacets(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	searcher := NewPostgresSearcher(pg)
+
+	day := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	product := uuid.New()
+	newOrder := func(name, email, city, status string, createdAt time.Time, productID uuid.UUID) *models.Order {
+		order := &models.Order{
+			CustomerID:      uuid.New(),
+			TotalAmount:     10,
+			Currency:        "EUR",
+			Status:          status,
+			Customer:        models.CustomerSnapshot{Name: name, Email: email},
+			ShippingAddress: models.Address{Name: name, City: city},
+			OrderItems:      []models.OrderItem{{ProductID: productID, Quantity: 1, UnitPrice: 10}},
+		}
+		order.ID = uuid.New()
+		order.CreatedAt = createdAt
+		if err := pg.GetRepo().WithContext(ctx).Create(order).Error; err != nil {
+			t.Fatal(err)
+		}
+		return order
+	}
+	ann := newOrder("Ann Smith", "ann@example.com", "Berlin", "AUTHORIZED", day, product)
+	newOrder("Ann Jones", "jones@example.com", "Hamburg", "PENDING", day.AddDate(0, 0, 1), uuid.New())
+	newOrder("Bob Brown", "bob_b@example.com", "Berlin", "PENDING", day.AddDate(0, 0, 1), product)
+
+	for _, tc := range []struct {
+		name  string
+		req   models.OrderSearchRequest
+		total int64
+	}{
+		{name: "word of the name", req: models.OrderSearchRequest{Q: "ann"}, total: 2},
+		{name: "word of the city", req: models.OrderSearchRequest{Q: "berlin"}, total: 2},
+		{name: "partial email", req: models.OrderSearchRequest{Q: "jones@exa"}, total: 1},
+		{name: "partial order id", req: models.OrderSearchRequest{OrderID: ann.ID.String()[:8]}, total: 1},
+		{name: "wildcards match literally", req: models.OrderSearchRequest{CustomerEmail: "_b@"}, total: 1},
+		{name: "product", req: models.OrderSearchRequest{ProductID: product.String()}, total: 2},
+		{name: "status", req: models.OrderSearchRequest{Q: "ann", Status: []string{"pending"}}, total: 1},
+		{name: "date range", req: models.OrderSearchRequest{From: &day, To: ptr(day.AddDate(0, 0, 1))}, total: 1},
+		{name: "no match", req: models.OrderSearchRequest{Q: "carol"}, total: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := searcher.Search(ctx, tc.req)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if result.Total != tc.total || len(result.Hits) != int(tc.total) {
+				t.Errorf("%d matches with %d hits, want %d", result.Total, len(result.Hits), tc.total)
+			}
+		})
+	}
+
+	// the facets count every match while the page holds one
+	result, err := searcher.Search(ctx, models.OrderSearchRequest{Q: "berlin", PageSize: 1})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if result.Total != 2 || len(result.Hits) != 1 {
+		t.Fatalf("%d matches with %d hits, want 2 with 1", result.Total, len(result.Hits))
+	}
+	if len(result.Facets.Status) != 2 || len(result.Facets.CreatedAt) != 2 {
+		t.Errorf("facets = %+v, want 2 statuses and 2 days", result.Facets)
+	}
+	if highlights := result.Hits[0].Highlights["shipping_city"]; len(highlights) != 1 || highlights[0] != "<em>Berlin</em>" {
+		t.Errorf("shipping_city highlights = %q, want <em>Berlin</em>", highlights)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"order/internal/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultPageSize = 20
+	defaultInterval = "day"
+)
+
+var ErrUnknownProvider = errors.New("unknown search provider")
+
+// OrderSearcher finds orders for support agents. The built-in provider queries the orders
+// tables directly; a provider backed by an external index would be fed by a consumer of the
+// order events published through the outbox and registered under its own name.
+type OrderSearcher interface {
+	Search(ctx context.Context, req models.OrderSearchRequest) (*models.OrderSearchResult, error)
+}
+
+// Factory builds the searcher of a provider
+type Factory func() (OrderSearcher, error)
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Factory{}
+)
+
+// Register makes a provider available under name, to be selected with SEARCH_PROVIDER
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[name] = factory
+}
+
+// New builds the searcher registered under name
+func New(name string) (OrderSearcher, error) {
+	mu.RLock()
+	factory, ok := providers[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return factory()
+}
+
+// Terms returns the words of a request that hits are highlighted with
+func Terms(req models.OrderSearchRequest) []string {
+	terms := strings.Fields(req.Q)
+	for _, term := range []string{req.OrderID, req.CustomerEmail, req.ProductID} {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Highlights returns the fields of order that contain one of terms, with the matches wrapped in <em>
+func Highlights(order *models.Order, terms []string) map[string][]string {
+	if len(terms) == 0 {
+		return nil
+	}
+	fields := map[string][]string{
+		"order_id":       {order.ID.String()},
+		"customer_name":  {order.Customer.Name},
+		"customer_email": {order.Customer.Email},
+		"customer_phone": {order.Customer.Phone},
+		"shipping_name":  {order.ShippingAddress.Name},
+		"shipping_city":  {order.ShippingAddress.City},
+		"billing_name":   {order.BillingAddress.Name},
+	}
+	for i := range order.OrderItems {
+		fields["product_id"] = append(fields["product_id"], order.OrderItems[i].ProductID.String())
+	}
+
+	highlights := map[string][]string{}
+	for field, values := range fields {
+		for _, value := range values {
+			if marked, ok := Highlight(value, terms); ok {
+				highlights[field] = append(highlights[field], marked)
+			}
+		}
+	}
+	return highlights
+}
+
+// Highlight wraps the case-insensitive matches of terms in value with <em>; the rest of value
+// is HTML escaped as it holds text entered by customers
+func Highlight(value string, terms []string) (string, bool) {
+	lower := strings.ToLower(value)
+	if len(lower) != len(value) {
+		// offsets into the lowered text would not fit value
+		lower = value
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		if term == "" {
+			continue
+		}
+		for from := 0; ; {
+			i := strings.Index(lower[from:], term)
+			if i < 0 {
+				break
+			}
+			spans = append(spans, span{from + i, from + i + len(term)})
+			from += i + len(term)
+		}
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(spans); {
+		start, end := spans[i].start, spans[i].end
+		for i++; i < len(spans) && spans[i].start <= end; i++ {
+			end = max(end, spans[i].end)
+		}
+		b.WriteString(html.EscapeString(value[last:start]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(value[start:end]))
+		b.WriteString("</em>")
+		last = end
+	}
+	b.WriteString(html.EscapeString(value[last:]))
+	return b.String(), true
+}
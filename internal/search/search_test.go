@@ -0,0 +1,80 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"order/internal/models"
+)
+
+type fakeSearcher struct{}
+
+func (fakeSearcher) Search(ctx context.Context, req models.OrderSearchRequest) (*models.OrderSearchResult, error) {
+	return &models.OrderSearchResult{}, nil
+}
+
+func TestNewBuildsTheRegisteredProvider(t *testing.T) {
+	Register("fake", func() (OrderSearcher, error) { return fakeSearcher{}, nil })
+
+	if searcher, err := New("fake"); err != nil || searcher != (fakeSearcher{}) {
+		t.Errorf("New(fake) = %v, %v; want the fake searcher", searcher, err)
+	}
+	if _, err := New("elastic"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("New(elastic) err = %v, want %v", err, ErrUnknownProvider)
+	}
+}
+
+func TestTermsTakeTheWordsAndTheIdentifiers(t *testing.T) {
+	terms := Terms(models.OrderSearchRequest{Q: " ann  berlin ", OrderID: "3f2a", CustomerEmail: " ", ProductID: "9c1e"})
+	if want := []string{"ann", "berlin", "3f2a", "9c1e"}; !reflect.DeepEqual(terms, want) {
+		t.Errorf("Terms = %q, want %q", terms, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	for _, tc := range []struct {
+		name, value string
+		terms       []string
+		want        string
+		ok          bool
+	}{
+		{name: "case insensitive", value: "Ann Smith", terms: []string{"ann"}, want: "<em>Ann</em> Smith", ok: true},
+		{name: "every match", value: "anna", terms: []string{"a"}, want: "<em>a</em>nn<em>a</em>", ok: true},
+		{name: "overlaps merge", value: "berlin", terms: []string{"berl", "rlin"}, want: "<em>berlin</em>", ok: true},
+		{name: "escapes the rest", value: "<b>Ann</b>", terms: []string{"ann"}, want: "&lt;b&gt;<em>Ann</em>&lt;/b&gt;", ok: true},
+		{name: "no match", value: "Ann", terms: []string{"bob"}},
+		{name: "empty term", value: "Ann", terms: []string{""}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := Highlight(tc.value, tc.terms)
+			if got != tc.want || ok != tc.ok {
+				t.Errorf("Highlight(%q, %q) = %q, %v; want %q, %v", tc.value, tc.terms, got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestHighlightsNameTheMatchingFields(t *testing.T) {
+	order := &models.Order{
+		Customer:        models.CustomerSnapshot{Name: "Ann Smith", Email: "ann@example.com"},
+		ShippingAddress: models.Address{City: "Berlin"},
+		OrderItems:      []models.OrderItem{{ProductID: uuid.New()}},
+	}
+	order.ID = uuid.New()
+
+	highlights := Highlights(order, []string{"ann", "berlin"})
+	want := map[string][]string{
+		"customer_name":  {"<em>Ann</em> Smith"},
+		"customer_email": {"<em>ann</em>@example.com"},
+		"shipping_city":  {"<em>Berlin</em>"},
+	}
+	if !reflect.DeepEqual(highlights, want) {
+		t.Errorf("Highlights = %v, want %v", highlights, want)
+	}
+	if highlights = Highlights(order, nil); highlights != nil {
+		t.Errorf("Highlights without terms = %v, want none", highlights)
+	}
+}
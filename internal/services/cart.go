@@ -0,0 +1,482 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/logger"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCartNotActive       = errors.New("cart is no longer active")
+	ErrCartEmpty           = errors.New("cart has no items")
+	ErrCartItemNotFound    = errors.New("product is not in the cart")
+	ErrCartNoCustomer      = errors.New("guest carts must be merged into a customer cart before checkout")
+	ErrCartNotGuest        = errors.New("only guest carts can be merged")
+	ErrCouponInvalid       = errors.New("coupon is invalid or expired")
+	ErrCouponNotApplicable = errors.New("cart does not reach the coupon minimum value")
+	ErrCouponExhausted     = errors.New("coupon usage limit reached")
+	ErrCartNotOwned        = errors.New("cart belongs to another customer")
+	ErrCartCurrency        = errors.New("carts priced in different currencies cannot be merged")
+)
+
+type CartService struct {
+	cartRepo     repo.CartRepoInterface
+	couponRepo   repo.CouponRepoInterface
+	newPgRepo    pgGorm.PGInterface
+	orderService OrderServiceInterface
+	ttl          time.Duration
+	nowFunc      func() time.Time
+}
+
+type CartServiceInterface interface {
+	CreateCart(ctx context.Context, customerID *uuid.UUID, currency string) (*models.Cart, error)
+	GetCart(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID) (*models.Cart, error)
+	AddItem(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, item models.CreateOrderItemRequest) (*models.Cart, error)
+	RemoveItem(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, productID uuid.UUID) (*models.Cart, error)
+	ApplyCoupon(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, code string) (*models.Cart, error)
+	SetShipping(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, shipping models.Address, country, region string) (*models.Cart, error)
+	Preview(ctx context.Context, cart *models.Cart) (*models.CartPricing, error)
+	Checkout(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, details models.CartCheckout) (*models.CreateOrderResponse, error)
+	MergeGuestCart(ctx context.Context, guestCartID, customerID uuid.UUID) (*models.Cart, error)
+	ExpireAbandonedCarts(ctx context.Context) (int64, error)
+}
+
+func NewCartService(
+	cartRepo repo.CartRepoInterface,
+	couponRepo repo.CouponRepoInterface,
+	newRepo pgGorm.PGInterface,
+	orderService OrderServiceInterface,
+	ttl time.Duration,
+) *CartService {
+	return &CartService{
+		cartRepo:     cartRepo,
+		couponRepo:   couponRepo,
+		newPgRepo:    newRepo,
+		orderService: orderService,
+		ttl:          ttl,
+		nowFunc:      time.Now,
+	}
+}
+
+// CreateCart opens a cart priced in currency, or in the base currency when it is empty.
+// Without customerID the cart is a guest cart.
+func (cS *CartService) CreateCart(ctx context.Context, customerID *uuid.UUID, currency string) (*models.Cart, error) {
+	cart := &models.Cart{
+		CustomerID: customerID,
+		Currency:   strings.ToUpper(strings.TrimSpace(currency)),
+		Status:     models.CartStatusActive,
+		ExpiresAt:  cS.nowFunc().Add(cS.ttl),
+	}
+	if err := cS.cartRepo.Create(ctx, nil, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// GetCart returns a cart of the caller, who is nil for guests
+func (cS *CartService) GetCart(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID) (*models.Cart, error) {
+	cart, err := cS.cartRepo.GetByID(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkCartOwner(cart, caller); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// AddItem adds quantity of a product to the cart; the latest price wins
+func (cS *CartService) AddItem(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, item models.CreateOrderItemRequest) (*models.Cart, error) {
+	return cS.modifyCart(ctx, "AddItem", cartID, caller, func(tx *gorm.DB, cart *models.Cart) error {
+		row := models.CartItem{CartID: cart.ID, ProductID: item.ProductID, Quantity: item.Quantity, UnitPrice: item.UniquePrice}
+		idx := findCartItem(cart, item.ProductID)
+		if idx >= 0 {
+			row.Quantity += cart.Items[idx].Quantity
+		}
+		if err := cS.cartRepo.UpsertItem(ctx, tx, &row); err != nil {
+			return err
+		}
+		if idx >= 0 {
+			cart.Items[idx].Quantity = row.Quantity
+			cart.Items[idx].UnitPrice = row.UnitPrice
+		} else {
+			cart.Items = append(cart.Items, row)
+		}
+		return nil
+	})
+}
+
+func (cS *CartService) RemoveItem(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, productID uuid.UUID) (*models.Cart, error) {
+	return cS.modifyCart(ctx, "RemoveItem", cartID, caller, func(tx *gorm.DB, cart *models.Cart) error {
+		idx := findCartItem(cart, productID)
+		if idx < 0 {
+			return ErrCartItemNotFound
+		}
+		if err := cS.cartRepo.RemoveItem(ctx, tx, cartID, productID); err != nil {
+			return err
+		}
+		cart.Items = append(cart.Items[:idx], cart.Items[idx+1:]...)
+		return nil
+	})
+}
+
+// ApplyCoupon validates the coupon against the current cart and stores it; an empty code removes it
+func (cS *CartService) ApplyCoupon(ctx context.Context, cartID uuid.UUID, caller *uuid.UUID, code string) (*models.Cart, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return cS.modifyCart(ctx, "ApplyCoupon", cartID, caller, func(tx *gorm.DB, cart *models.Cart) error {
+		if code != "" {
+			coupon, err := cS.couponRepo.GetActiveByCode(ctx, code, cS.nowFunc())
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCouponInvalid
+			} else if err != nil {
+				return err
+			}
+			if cart.Subtotal() < coupon.MinOrderValue {
+				return ErrCouponNotApplicable
+			}
+		}
+		cart.CouponCode = code
+		return nil
+	})
+}
+
+// SetShipping stores where the cart is shipped and taxed. The order is taxed for country and
+// region, or for the shipping address when country is empty.
+func (cS *CartService) SetShipping(
+	ctx context.Context,
+	cartID uuid.UUID,
+	caller *uuid.UUID,
+	shipping models.Address,
+	country, region string,
+) (*models.Cart, error) {
+	if !shipping.IsZero() {
+		var err error
+		if shipping, err = normalizeAddress("shipping", shipping); err != nil {
+			return nil, err
+		}
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country != "" && !countryPattern.MatchString(country) {
+		return nil, fmt.Errorf("%w: tax country %q is not an ISO 3166-1 alpha-2 code", ErrInvalidAddress, country)
+	}
+	return cS.modifyCart(ctx, "SetShipping", cartID, caller, func(tx *gorm.DB, cart *models.Cart) error {
+		cart.ShippingAddress = shipping
+		cart.TaxCountry = country
+		cart.TaxRegion = strings.TrimSpace(region)
+		return nil
+	})
+}
+
+// Preview prices the cart. A coupon that stopped applying is ignored rather than rejected.
+func (cS *CartService) Preview(ctx context.Context, cart *models.Cart) (*models.CartPricing, error) {
+	pricing, _, err := cS.price(ctx, cart)
+	return pricing, err
+}
+
+// Checkout turns the cart of the caller into an order placed with the contact details of the
+// checkout. The order, the coupon redemption and the cart conversion are committed in one transaction.
+func (cS *CartService) Checkout(
+	ctx context.Context,
+	cartID uuid.UUID,
+	caller *uuid.UUID,
+	details models.CartCheckout,
+) (*models.CreateOrderResponse, error) {
+	log := logger.WithTag("CartService|Checkout")
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "CartService.Checkout",
+		trace.WithAttributes(attribute.String("cart_id", cartID.String())))
+	defer span.End()
+
+	// stock reserved for an order that is not committed is given back
+	ctx, reserved := trackReservations(ctx)
+	committed := false
+	defer func() { reserved.settle(ctx, committed) }()
+
+	tx := cS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	cart, err := cS.cartRepo.GetForUpdate(ctx, tx, cartID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err = checkCartOwner(cart, caller); err != nil {
+		return nil, err
+	}
+	if !cart.IsActive() {
+		return nil, ErrCartNotActive
+	}
+	if cart.CustomerID == nil {
+		return nil, ErrCartNoCustomer
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	pricing, coupon, err := cS.price(ctx, cart)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if coupon != nil {
+		redeemed, err := cS.couponRepo.Redeem(ctx, tx, coupon.ID)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if !redeemed {
+			return nil, ErrCouponExhausted
+		}
+	}
+
+	orderRequest := models.CreateOrderRequest{
+		CustomerID:  *cart.CustomerID,
+		TotalAmount: pricing.Total,
+		Discount:    pricing.Discount,
+		Status:      string(models.OrderStatusPending),
+		Currency:    cart.Currency,
+		Country:     cart.TaxCountry,
+		Region:      cart.TaxRegion,
+		Customer:    details.Customer,
+		Shipping:    cart.ShippingAddress,
+		Billing:     details.Billing,
+		Audit: models.StatusChange{
+			ActorType: models.ActorTypeUser,
+			ActorID:   cart.CustomerID.String(),
+			Source:    models.ChangeSourceGRPC,
+			SourceRef: "cart/" + cart.ID.String(),
+			Reason:    "cart checkout",
+		},
+	}
+	for _, item := range cart.Items {
+		orderRequest.OrderItems = append(orderRequest.OrderItems, models.CreateOrderItemRequest{
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+			UniquePrice: item.UnitPrice,
+		})
+	}
+
+	resp, err := cS.orderService.CreateOrderInTx(ctx, tx, orderRequest)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "create order failed")
+		return nil, err
+	}
+
+	cart.Status = models.CartStatusConverted
+	cart.OrderID = &resp.Data.OrderID
+	if err = cS.cartRepo.Save(ctx, tx, cart); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to mark cart converted")
+		return nil, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		logger.LogError(log, err, "failed to commit tx")
+		return nil, err
+	}
+
+	committed = true
+
+	span.SetStatus(codes.Ok, "checked out")
+	return resp, nil
+}
+
+// MergeGuestCart moves the items of a guest cart into the customer's active cart, e.g. on login.
+// customerID has to be the authenticated caller.
+// Quantities of products present in both carts are added up. Without an active customer cart
+// the guest cart itself is assigned to the customer.
+func (cS *CartService) MergeGuestCart(ctx context.Context, guestCartID, customerID uuid.UUID) (*models.Cart, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "CartService.MergeGuestCart",
+		trace.WithAttributes(attribute.String("cart_id", guestCartID.String()),
+			attribute.String("customer_id", customerID.String())))
+	defer span.End()
+
+	tx := cS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	guest, err := cS.cartRepo.GetForUpdate(ctx, tx, guestCartID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !guest.IsActive() {
+		return nil, ErrCartNotActive
+	}
+	if guest.CustomerID != nil {
+		return nil, ErrCartNotGuest
+	}
+
+	target, err := cS.cartRepo.GetActiveByCustomer(ctx, tx, customerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		guest.CustomerID = &customerID
+		guest.ExpiresAt = cS.nowFunc().Add(cS.ttl)
+		if err = cS.cartRepo.Save(ctx, tx, guest); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if err = tx.Commit().Error; err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		return guest, nil
+	} else if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if target.Currency != guest.Currency {
+		return nil, ErrCartCurrency
+	}
+
+	for _, item := range guest.Items {
+		row := models.CartItem{CartID: target.ID, ProductID: item.ProductID, Quantity: item.Quantity, UnitPrice: item.UnitPrice}
+		idx := findCartItem(target, item.ProductID)
+		if idx >= 0 {
+			row.Quantity += target.Items[idx].Quantity
+		}
+		if err = cS.cartRepo.UpsertItem(ctx, tx, &row); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if idx >= 0 {
+			target.Items[idx].Quantity = row.Quantity
+			target.Items[idx].UnitPrice = row.UnitPrice
+		} else {
+			target.Items = append(target.Items, row)
+		}
+	}
+	if target.CouponCode == "" {
+		target.CouponCode = guest.CouponCode
+	}
+	if target.ShippingAddress.IsZero() && target.TaxCountry == "" {
+		target.ShippingAddress = guest.ShippingAddress
+		target.TaxCountry, target.TaxRegion = guest.TaxCountry, guest.TaxRegion
+	}
+	target.ExpiresAt = cS.nowFunc().Add(cS.ttl)
+	guest.Status = models.CartStatusMerged
+
+	if err = cS.cartRepo.Save(ctx, tx, target); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err = cS.cartRepo.Save(ctx, tx, guest); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return target, nil
+}
+
+// ExpireAbandonedCarts marks active carts that were not touched within the TTL as EXPIRED
+func (cS *CartService) ExpireAbandonedCarts(ctx context.Context) (int64, error) {
+	return cS.cartRepo.ExpireAbandoned(ctx, cS.nowFunc())
+}
+
+// modifyCart applies change to an active cart under its row lock and extends its expiry
+func (cS *CartService) modifyCart(
+	ctx context.Context,
+	op string,
+	cartID uuid.UUID,
+	caller *uuid.UUID,
+	change func(tx *gorm.DB, cart *models.Cart) error,
+) (*models.Cart, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "CartService."+op,
+		trace.WithAttributes(attribute.String("cart_id", cartID.String())))
+	defer span.End()
+
+	tx := cS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	cart, err := cS.cartRepo.GetForUpdate(ctx, tx, cartID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err = checkCartOwner(cart, caller); err != nil {
+		return nil, err
+	}
+	if !cart.IsActive() {
+		return nil, ErrCartNotActive
+	}
+
+	if err = change(tx, cart); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	cart.ExpiresAt = cS.nowFunc().Add(cS.ttl)
+	if err = cS.cartRepo.Save(ctx, tx, cart); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		return nil, err
+	}
+	return cart, nil
+}
+
+// price computes the cart pricing and returns the coupon that applies, if any
+func (cS *CartService) price(ctx context.Context, cart *models.Cart) (*models.CartPricing, *models.Coupon, error) {
+	pricing := &models.CartPricing{Subtotal: cart.Subtotal()}
+	pricing.Total = pricing.Subtotal
+
+	if cart.CouponCode == "" {
+		return pricing, nil, nil
+	}
+	coupon, err := cS.couponRepo.GetActiveByCode(ctx, cart.CouponCode, cS.nowFunc())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return pricing, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	discount := coupon.Discount(pricing.Subtotal)
+	if discount == 0 {
+		return pricing, nil, nil
+	}
+	pricing.Discount = discount
+	pricing.Total = pricing.Subtotal - discount
+	pricing.CouponCode = coupon.Code
+	return pricing, coupon, nil
+}
+
+// checkCartOwner lets anyone holding the ID of a guest cart use it, but a customer cart only its
+// customer. caller is nil for guests.
+func checkCartOwner(cart *models.Cart, caller *uuid.UUID) error {
+	if cart.CustomerID != nil && (caller == nil || *caller != *cart.CustomerID) {
+		return ErrCartNotOwned
+	}
+	return nil
+}
+
+func findCartItem(cart *models.Cart, productID uuid.UUID) int {
+	for i, item := range cart.Items {
+		if item.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/internal/tax"
+)
+
+func testCartService(t *testing.T, pg pgGorm.PGInterface) *CartService {
+	t.Helper()
+	oS, _ := testOrderService(t, pg)
+	return NewCartService(repo.NewCartRepository(pg), repo.NewCouponRepository(pg), pg, oS, time.Hour)
+}
+
+func TestCustomerCartsAreOnlyServedToTheirCustomer(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	owner, other := uuid.New(), uuid.New()
+	cart, err := cS.CreateCart(ctx, &owner, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}
+
+	for _, caller := range []*uuid.UUID{nil, &other} {
+		if _, err = cS.GetCart(ctx, cart.ID, caller); !errors.Is(err, ErrCartNotOwned) {
+			t.Errorf("GetCart by %v err = %v, want %v", caller, err, ErrCartNotOwned)
+		}
+		if _, err = cS.AddItem(ctx, cart.ID, caller, item); !errors.Is(err, ErrCartNotOwned) {
+			t.Errorf("AddItem by %v err = %v, want %v", caller, err, ErrCartNotOwned)
+		}
+		if _, err = cS.ApplyCoupon(ctx, cart.ID, caller, ""); !errors.Is(err, ErrCartNotOwned) {
+			t.Errorf("ApplyCoupon by %v err = %v, want %v", caller, err, ErrCartNotOwned)
+		}
+		if _, err = cS.Checkout(ctx, cart.ID, caller, models.CartCheckout{}); !errors.Is(err, ErrCartNotOwned) {
+			t.Errorf("Checkout by %v err = %v, want %v", caller, err, ErrCartNotOwned)
+		}
+	}
+
+	if _, err = cS.AddItem(ctx, cart.ID, &owner, item); err != nil {
+		t.Fatalf("AddItem by the owner: %v", err)
+	}
+	if _, err = cS.RemoveItem(ctx, cart.ID, &other, item.ProductID); !errors.Is(err, ErrCartNotOwned) {
+		t.Errorf("RemoveItem by another customer err = %v, want %v", err, ErrCartNotOwned)
+	}
+	if got, err := cS.GetCart(ctx, cart.ID, &owner); err != nil || len(got.Items) != 1 {
+		t.Errorf("GetCart by the owner = %+v, %v; want the cart with its item", got, err)
+	}
+}
+
+func TestGuestCartsAreServedToWhoeverHoldsTheirID(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	guest, err := cS.CreateCart(ctx, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer := uuid.New()
+	for _, caller := range []*uuid.UUID{nil, &customer} {
+		if _, err = cS.AddItem(ctx, guest.ID, caller, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}); err != nil {
+			t.Errorf("AddItem to a guest cart by %v: %v", caller, err)
+		}
+	}
+
+	merged, err := cS.MergeGuestCart(ctx, guest.ID, customer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.GetCart(ctx, merged.ID, nil); !errors.Is(err, ErrCartNotOwned) {
+		t.Errorf("GetCart of the merged cart as guest err = %v, want %v", err, ErrCartNotOwned)
+	}
+}
+
+func TestCheckoutTaxesTheCartWhereItIsShipped(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	rates := repo.NewTaxRateRepository(pg)
+	if err := rates.Upsert(ctx, &models.TaxRate{Country: "DE", TaxClass: tax.ClassStandard, Rate: 0.19}); err != nil {
+		t.Fatal(err)
+	}
+	cS.orderService.(*OrderService).EnableTax(tax.NewTableCalculator(rates))
+
+	customer := uuid.New()
+	cart, err := cS.CreateCart(ctx, &customer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.AddItem(ctx, cart.ID, &customer, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 2, UniquePrice: 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	shipping := models.Address{Name: "Erika Mustermann", Line1: "Hauptstr. 1", City: "Berlin", PostalCode: "10115", Country: "de"}
+	if _, err = cS.SetShipping(ctx, cart.ID, &customer, shipping, "XYZ", ""); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("SetShipping with tax country XYZ err = %v, want %v", err, ErrInvalidAddress)
+	}
+	if _, err = cS.SetShipping(ctx, cart.ID, &customer, shipping, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cS.Checkout(ctx, cart.ID, &customer, models.CartCheckout{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := repo.NewOrderRepository(pg).GetByID(ctx, resp.Data.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.TaxCountry != "DE" || order.ShippingAddress.City != "Berlin" || order.ShippingAddress.Country != "DE" {
+		t.Errorf("order taxed for %q and shipped to %+v, want the shipping address of the cart in DE", order.TaxCountry, order.ShippingAddress)
+	}
+	if order.TaxAmount != 19 || order.TotalAmount != 119 {
+		t.Errorf("order tax = %v and total = %v, want 19 and 119", order.TaxAmount, order.TotalAmount)
+	}
+}
+
+func TestCheckoutSnapshotsTheContactDetailsOfTheCheckout(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	customer := uuid.New()
+	cart, err := cS.CreateCart(ctx, &customer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.AddItem(ctx, cart.ID, &customer, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}); err != nil {
+		t.Fatal(err)
+	}
+	shipping := models.Address{Name: "Jane Doe", Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"}
+	if _, err = cS.SetShipping(ctx, cart.ID, &customer, shipping, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	details := models.CartCheckout{Customer: models.CustomerSnapshot{Name: "Jane Doe", Email: "not an email"}}
+	if _, err = cS.Checkout(ctx, cart.ID, &customer, details); !errors.Is(err, ErrInvalidContact) {
+		t.Fatalf("Checkout with an invalid email err = %v, want %v", err, ErrInvalidContact)
+	}
+
+	details.Customer.Email = "jane@example.com"
+	details.Billing = models.Address{Name: "Doe Inc.", Line1: "2 Market St", City: "Springfield", PostalCode: "12345", Country: "US"}
+	resp, err := cS.Checkout(ctx, cart.ID, &customer, details)
+	if err != nil {
+		t.Fatalf("Checkout after the invalid attempt: %v", err)
+	}
+	order, err := repo.NewOrderRepository(pg).GetByID(ctx, resp.Data.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Customer != details.Customer {
+		t.Errorf("order customer = %+v, want %+v", order.Customer, details.Customer)
+	}
+	if order.ShippingAddress != shipping || order.BillingAddress != details.Billing {
+		t.Errorf("order shipped to %+v and billed to %+v, want %+v and %+v",
+			order.ShippingAddress, order.BillingAddress, shipping, details.Billing)
+	}
+}
+
+func TestCartOrdersArePlacedInTheCurrencyOfTheCart(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	customer := uuid.New()
+	cart, err := cS.CreateCart(ctx, &customer, "usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.AddItem(ctx, cart.ID, &customer, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	guest, err := cS.CreateCart(ctx, nil, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.MergeGuestCart(ctx, guest.ID, customer); !errors.Is(err, ErrCartCurrency) {
+		t.Errorf("merging a EUR cart into a USD cart err = %v, want %v", err, ErrCartCurrency)
+	}
+
+	resp, err := cS.Checkout(ctx, cart.ID, &customer, models.CartCheckout{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := repo.NewOrderRepository(pg).GetByID(ctx, resp.Data.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Currency != "USD" {
+		t.Errorf("order currency = %q, want USD", order.Currency)
+	}
+}
+
+func TestCartCheckoutRedeemsTheCouponAndConvertsTheCart(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	coupon := &models.Coupon{
+		Code:            "SAVE10",
+		DiscountPercent: 10,
+		MinOrderValue:   20,
+		UsageLimit:      1,
+		IsActive:        true,
+		StartTime:       time.Now().Add(-24 * time.Hour),
+		EndTime:         time.Now().Add(24 * time.Hour),
+	}
+	if err := pg.GetRepo().WithContext(ctx).Create(coupon).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	customer := uuid.New()
+	cart, err := cS.CreateCart(ctx, &customer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}
+	if _, err = cS.AddItem(ctx, cart.ID, &customer, item); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.ApplyCoupon(ctx, cart.ID, &customer, "save10"); !errors.Is(err, ErrCouponNotApplicable) {
+		t.Errorf("ApplyCoupon below the minimum err = %v, want %v", err, ErrCouponNotApplicable)
+	}
+	if _, err = cS.AddItem(ctx, cart.ID, &customer, item); err != nil {
+		t.Fatal(err)
+	}
+	if cart, err = cS.ApplyCoupon(ctx, cart.ID, &customer, "save10"); err != nil {
+		t.Fatalf("ApplyCoupon: %v", err)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 2 || cart.CouponCode != "SAVE10" {
+		t.Fatalf("cart = %+v, want one item of quantity 2 with coupon SAVE10", cart)
+	}
+
+	pricing, err := cS.Preview(ctx, cart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pricing.Subtotal != 20 || pricing.Discount != 2 || pricing.Total != 18 {
+		t.Errorf("pricing = %+v, want subtotal 20, discount 2 and total 18", pricing)
+	}
+
+	resp, err := cS.Checkout(ctx, cart.ID, &customer, models.CartCheckout{})
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if resp.Data.TotalAmount != 18 {
+		t.Errorf("order total = %v, want 18", resp.Data.TotalAmount)
+	}
+	converted, err := cS.GetCart(ctx, cart.ID, &customer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted.Status != models.CartStatusConverted || converted.OrderID == nil || *converted.OrderID != resp.Data.OrderID {
+		t.Errorf("cart = %s with order %v, want %s with order %s", converted.Status, converted.OrderID, models.CartStatusConverted, resp.Data.OrderID)
+	}
+	if _, err = cS.Checkout(ctx, cart.ID, &customer, models.CartCheckout{}); !errors.Is(err, ErrCartNotActive) {
+		t.Errorf("second Checkout err = %v, want %v", err, ErrCartNotActive)
+	}
+
+	// the only use of the coupon is taken
+	other, err := cS.CreateCart(ctx, &customer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.AddItem(ctx, other.ID, &customer, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 3, UniquePrice: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.ApplyCoupon(ctx, other.ID, &customer, "SAVE10"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.Checkout(ctx, other.ID, &customer, models.CartCheckout{}); !errors.Is(err, ErrCouponExhausted) {
+		t.Errorf("Checkout with a used up coupon err = %v, want %v", err, ErrCouponExhausted)
+	}
+}
+
+func TestMergeGuestCartAddsItsItemsToTheCustomerCart(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	customer := uuid.New()
+	shared, guestOnly := uuid.New(), uuid.New()
+	target, err := cS.CreateCart(ctx, &customer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cS.AddItem(ctx, target.ID, &customer, models.CreateOrderItemRequest{ProductID: shared, Quantity: 1, UniquePrice: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	guest, err := cS.CreateCart(ctx, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []models.CreateOrderItemRequest{
+		{ProductID: shared, Quantity: 2, UniquePrice: 12},
+		{ProductID: guestOnly, Quantity: 1, UniquePrice: 5},
+	} {
+		if _, err = cS.AddItem(ctx, guest.ID, nil, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merged, err := cS.MergeGuestCart(ctx, guest.ID, customer)
+	if err != nil {
+		t.Fatalf("MergeGuestCart: %v", err)
+	}
+	if merged.ID != target.ID {
+		t.Fatalf("merged into cart %s, want the customer cart %s", merged.ID, target.ID)
+	}
+	quantities := map[uuid.UUID]int{}
+	for _, item := range merged.Items {
+		quantities[item.ProductID] = item.Quantity
+	}
+	if len(quantities) != 2 || quantities[shared] != 3 || quantities[guestOnly] != 1 {
+		t.Errorf("merged quantities = %v, want 3 of the shared product and 1 of the guest one", quantities)
+	}
+	if merged.Subtotal() != 41 {
+		t.Errorf("merged subtotal = %v, want 41 at the latest prices", merged.Subtotal())
+	}
+
+	emptied, err := cS.GetCart(ctx, guest.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emptied.Status != models.CartStatusMerged {
+		t.Errorf("guest cart status = %s, want %s", emptied.Status, models.CartStatusMerged)
+	}
+	if _, err = cS.MergeGuestCart(ctx, target.ID, customer); !errors.Is(err, ErrCartNotGuest) {
+		t.Errorf("merging a customer cart err = %v, want %v", err, ErrCartNotGuest)
+	}
+}
+
+func TestAbandonedCartsExpire(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	cS := testCartService(t, pg)
+
+	cart, err := cS.CreateCart(ctx, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired, err := cS.ExpireAbandonedCarts(ctx); err != nil || expired != 0 {
+		t.Fatalf("ExpireAbandonedCarts within the TTL = %d, %v; want none", expired, err)
+	}
+
+	cS.nowFunc = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if expired, err := cS.ExpireAbandonedCarts(ctx); err != nil || expired != 1 {
+		t.Fatalf("ExpireAbandonedCarts past the TTL = %d, %v; want 1", expired, err)
+	}
+	if _, err = cS.AddItem(ctx, cart.ID, nil, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}); !errors.Is(err, ErrCartNotActive) {
+		t.Errorf("AddItem to an expired cart err = %v, want %v", err, ErrCartNotActive)
+	}
+}
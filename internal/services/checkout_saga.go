@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	stdErrors "errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/models"
+	"order/internal/repositories"
+	"order/internal/saga"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	CheckoutSaga = "checkout"
+
+	StepReserveInventory = "reserve_inventory"
+	StepRequestPayment   = "request_payment"
+	StepConfirmInventory = "confirm_inventory"
+	StepRequestPromotion = "request_promotion"
+
+	sagaBatchSize = 100
+	// sagaResumeAfter leaves a failed step alone for a while before Resume retries it
+	sagaResumeAfter = time.Minute
+)
+
+// EnableCheckoutSaga drives order creation and payment follow-ups through the checkout saga.
+// An order waiting for its payment longer than paymentTimeout is expired.
+func (oS *OrderService) EnableCheckoutSaga(orchestrator *saga.Orchestrator, paymentTimeout time.Duration) {
+	orchestrator.Register(oS.checkoutDefinition(paymentTimeout))
+	oS.orchestrator = orchestrator
+}
+
+// checkoutDefinition reserves stock, waits for the payment, then confirms the stock and
+// requests the promotion reward. A declined, cancelled or expired order releases the stock
+// and withdraws its payment request, or voids the payment once it was authorized. An order
+// cancelled after that also loses its promotion reward.
+func (oS *OrderService) checkoutDefinition(paymentTimeout time.Duration) *saga.Definition {
+	return &saga.Definition{
+		Name: CheckoutSaga,
+		// stock reserved by a resumed saga that does not commit is given back, as CreateOrder
+		// and Checkout do for the sagas they start
+		Track: func(ctx context.Context) (context.Context, func(committed bool)) {
+			ctx, reserved := trackReservations(ctx)
+			return ctx, func(committed bool) { reserved.settle(ctx, committed) }
+		},
+		Steps: []saga.Step{
+			{
+				Name: StepReserveInventory,
+				Action: func(ctx context.Context, tx *gorm.DB, s *models.Saga) (saga.Outcome, error) {
+					var items []models.OrderItem
+					if err := tx.WithContext(ctx).Where("order_id = ?", s.OrderID).Find(&items).Error; err != nil {
+						return saga.Completed, err
+					}
+					return saga.Completed, oS.reserveStock(ctx, s.OrderID, items, nil)
+				},
+				Compensate: func(ctx context.Context, tx *gorm.DB, s *models.Saga) error {
+					return oS.outboxRepo.CreateOutbox(ctx, tx, newInventoryReleaseOutbox(s.OrderID, s.LastError))
+				},
+			},
+			{
+				Name:    StepRequestPayment,
+				Timeout: paymentTimeout,
+				Action: func(ctx context.Context, tx *gorm.DB, s *models.Saga) (saga.Outcome, error) {
+					var order models.Order
+					if err := tx.WithContext(ctx).Where("id = ?", s.OrderID).First(&order).Error; err != nil {
+						return saga.Waiting, err
+					}
+					outbox := newPaymentOutbox(order.ID, order.CustomerID, order.TotalAmount, order.Currency, order.Status)
+					return saga.Waiting, oS.outboxRepo.CreateOutbox(ctx, tx, outbox)
+				},
+				Compensate: func(ctx context.Context, tx *gorm.DB, s *models.Saga) error {
+					// a request still in the outbox is simply withdrawn, a delivered one is voided
+					// unless the payment was declined, which leaves nothing to void
+					withdrawn, err := oS.outboxRepo.Supersede(ctx, tx, s.OrderID, events.EventPaymentRequired.String())
+					if err != nil || withdrawn > 0 {
+						return err
+					}
+					var order models.Order
+					if err = tx.WithContext(ctx).Select("status").Where("id = ?", s.OrderID).First(&order).Error; err != nil {
+						return err
+					}
+					if strings.EqualFold(order.Status, string(models.OrderStatusDeclined)) {
+						return nil
+					}
+					return oS.outboxRepo.CreateOutbox(ctx, tx, newOrderOutbox(s.OrderID, events.EventPaymentVoidRequested,
+						models.PaymentVoidEvent{OrderID: s.OrderID.String(), Reason: s.LastError}))
+				},
+			},
+			{
+				Name: StepConfirmInventory,
+				Action: func(ctx context.Context, tx *gorm.DB, s *models.Saga) (saga.Outcome, error) {
+					return saga.Completed, oS.outboxRepo.CreateOutbox(ctx, tx, newInventoryConfirmOutbox(s.OrderID))
+				},
+			},
+			{
+				Name: StepRequestPromotion,
+				Action: func(ctx context.Context, tx *gorm.DB, s *models.Saga) (saga.Outcome, error) {
+					return saga.Completed, oS.outboxRepo.CreateOutbox(ctx, tx, newPromotionOutbox(s.OrderID))
+				},
+				Compensate: func(ctx context.Context, tx *gorm.DB, s *models.Saga) error {
+					return oS.revokePromotionRewards(ctx, tx, s.OrderID, s.LastError)
+				},
+			},
+		},
+	}
+}
+
+// afterStatusChange runs the follow-ups of a status change of an order from status from. The
+// checkout saga takes care of the stock, payment and reward of the orders it runs; orders
+// created without the saga, because it is disabled or did not exist yet, get them directly.
+// Invoices are issued and undelivered payment requests of orders that can no longer be paid are
+// withdrawn either way.
+func (oS *OrderService) afterStatusChange(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, from string, status models.OrderStatus, reason string) error {
+	handled, err := oS.signalCheckoutSaga(ctx, tx, orderID, status, reason)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case models.OrderStatusDeclined, models.OrderStatusCancelled, models.OrderStatusExpired:
+		if _, err = oS.outboxRepo.Supersede(ctx, tx, orderID, events.EventPaymentRequired.String()); err != nil {
+			return err
+		}
+	}
+
+	if !handled {
+		if outbox := newInventoryOutbox(orderID, status, reason); outbox != nil {
+			if err = oS.outboxRepo.CreateOutbox(ctx, tx, outbox); err != nil {
+				return err
+			}
+		}
+		switch {
+		case status == models.OrderStatusAuthorized:
+			if err = oS.outboxRepo.CreateOutbox(ctx, tx, newPromotionOutbox(orderID)); err != nil {
+				return err
+			}
+		case status == models.OrderStatusCancelled && strings.EqualFold(from, string(models.OrderStatusAuthorized)):
+			// as the saga compensates a cancellation after the payment: the payment is voided
+			// and the order loses its promotion reward
+			void := newOrderOutbox(orderID, events.EventPaymentVoidRequested, models.PaymentVoidEvent{OrderID: orderID.String(), Reason: reason})
+			if err = oS.outboxRepo.CreateOutbox(ctx, tx, void); err != nil {
+				return err
+			}
+			if err = oS.revokePromotionRewards(ctx, tx, orderID, reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	if oS.invoices != nil && slices.Contains(models.InvoicedOrderStatuses, status) {
+		if _, err = oS.invoices.IssueInvoice(ctx, tx, orderID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signalCheckoutSaga moves the checkout saga of the order along with its status and reports
+// whether the saga took care of the follow-ups
+func (oS *OrderService) signalCheckoutSaga(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, status models.OrderStatus, reason string) (bool, error) {
+	if oS.orchestrator == nil {
+		return false, nil
+	}
+
+	var err error
+	switch status {
+	case models.OrderStatusAuthorized:
+		err = oS.orchestrator.Signal(ctx, tx, CheckoutSaga, orderID, StepRequestPayment, nil)
+	case models.OrderStatusDeclined:
+		err = oS.orchestrator.Signal(ctx, tx, CheckoutSaga, orderID, StepRequestPayment, stdErrors.New("payment declined: "+reason))
+	case models.OrderStatusCancelled, models.OrderStatusExpired:
+		err = oS.orchestrator.Abort(ctx, tx, CheckoutSaga, orderID, reason)
+	default:
+		return false, nil
+	}
+	if stdErrors.Is(err, saga.ErrSagaNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ResumeCheckoutSagas retries checkout sagas left behind by a failed step or a crash
+func (oS *OrderService) ResumeCheckoutSagas(ctx context.Context) (int64, error) {
+	if oS.orchestrator == nil {
+		return 0, nil
+	}
+	return oS.orchestrator.Resume(ctx, time.Now().Add(-sagaResumeAfter), sagaBatchSize)
+}
+
+// TimeoutCheckoutSagas expires the orders whose checkout saga waited past its step timeout;
+// expiring the order compensates the saga.
+func (oS *OrderService) TimeoutCheckoutSagas(ctx context.Context) (int64, error) {
+	if oS.orchestrator == nil {
+		return 0, nil
+	}
+
+	sagas, err := oS.orchestrator.TimedOut(ctx, sagaBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, s := range sagas {
+		change := models.StatusChange{
+			ActorType: models.ActorTypeSystem,
+			Source:    models.ChangeSourceScheduler,
+			SourceRef: "saga/" + s.ID.String(),
+			Reason:    "checkout step " + s.CurrentStep + " timed out",
+		}
+		err = oS.UpdateOrderStatus(ctx, s.OrderID, models.OrderStatusExpired, change)
+		if stdErrors.Is(err, repo.ErrInvalidStatusTransition) {
+			// the order moved on while its saga waited, e.g. it was cancelled
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// revokePromotionRewards withdraws the reward request of the order while it is still in the
+// outbox and revokes the rewards already issued for the order
+func (oS *OrderService) revokePromotionRewards(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, reason string) error {
+	if _, err := oS.outboxRepo.Supersede(ctx, tx, orderID, events.EventPromotionRewardRequested.String()); err != nil {
+		return err
+	}
+
+	rewards, err := oS.promoRepo.ListRewardsByOrder(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, reward := range rewards {
+		if err = oS.promoRepo.RevokeReward(ctx, tx, reward.ID, now); err != nil {
+			return err
+		}
+
+		event := models.PromotionRewardRevokedEvent{RewardID: reward.ID.String(), OrderID: orderID.String(), Reason: reason}
+		if err = oS.outboxRepo.CreateOutbox(ctx, tx, newRewardRevokedOutbox(event, reward.ID, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newPromotionOutbox(orderID uuid.UUID) *models.Outbox {
+	return newOrderOutbox(orderID, events.EventPromotionRewardRequested, models.PromotionRewardEvent{OrderID: orderID.String()})
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/events"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+)
+
+func TestCheckoutSagaInvoicesAuthorizedOrder(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, invoices := testOrderService(t, pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	if _, err = invoices.GetOrderInvoice(ctx, orderID); err == nil {
+		t.Fatal("pending order already has an invoice")
+	}
+
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("UpdateOrderStatus: %v", err)
+	}
+
+	sagas, err := repo.NewSagaRepository(pg).GetByOrderID(ctx, orderID)
+	if err != nil || len(sagas) != 1 {
+		t.Fatalf("sagas = %v, %v; want one", sagas, err)
+	}
+	if sagas[0].Status != models.SagaStatusCompleted {
+		t.Fatalf("saga status = %s, want %s", sagas[0].Status, models.SagaStatusCompleted)
+	}
+
+	invoice, err := invoices.GetOrderInvoice(ctx, orderID)
+	if err != nil {
+		t.Fatalf("authorized saga order has no invoice: %v", err)
+	}
+	if invoice.TotalAmount != created.Data.TotalAmount {
+		t.Errorf("invoice total = %v, want %v", invoice.TotalAmount, created.Data.TotalAmount)
+	}
+	if len(invoice.Lines) != 2 {
+		t.Errorf("invoice has %d lines, want 2", len(invoice.Lines))
+	}
+}
+
+func TestLateAuthorizationOfExpiredOrderIsRejected(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusExpired, testStatusChange("timed out")); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid late"))
+	if !errors.Is(err, repo.ErrInvalidStatusTransition) {
+		t.Fatalf("late authorization err = %v, want %v", err, repo.ErrInvalidStatusTransition)
+	}
+
+	order, err := repo.NewOrderRepository(pg).GetByID(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != string(models.OrderStatusExpired) {
+		t.Errorf("status = %s, want %s", order.Status, models.OrderStatusExpired)
+	}
+}
+
+func TestCancellingAuthorizedOrderCompensatesCompletedSaga(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	outbox := repo.NewOutboxRepository(pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	// the payment request went out before the payment was authorized
+	err = pg.GetRepo().WithContext(ctx).Model(&models.Outbox{}).
+		Where("aggregate_id = ? AND event_type = ?", orderID, events.EventPaymentRequired.String()).
+		Update("status", models.OutboxStatusDone).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusCancelled, testStatusChange("customer cancelled")); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	sagas, err := repo.NewSagaRepository(pg).GetByOrderID(ctx, orderID)
+	if err != nil || len(sagas) != 1 {
+		t.Fatalf("sagas = %v, %v; want one", sagas, err)
+	}
+	if sagas[0].Status != models.SagaStatusCompensated {
+		t.Errorf("saga status = %s, want %s", sagas[0].Status, models.SagaStatusCompensated)
+	}
+
+	for _, eventType := range []events.EventType{events.EventInventoryRelease, events.EventPaymentVoidRequested} {
+		pending, err := outbox.CountByStatus(ctx, nil, orderID, eventType.String(), models.OutboxStatusPending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending != 1 {
+			t.Errorf("%d pending %s rows, want 1", pending, eventType)
+		}
+	}
+}
+
+func TestDeclinedPaymentIsNotVoided(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	outbox := repo.NewOutboxRepository(pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	// the payment request went out and was declined
+	err = pg.GetRepo().WithContext(ctx).Model(&models.Outbox{}).
+		Where("aggregate_id = ? AND event_type = ?", orderID, events.EventPaymentRequired.String()).
+		Update("status", models.OutboxStatusDone).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusDeclined, testStatusChange("card declined")); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+
+	sagas, err := repo.NewSagaRepository(pg).GetByOrderID(ctx, orderID)
+	if err != nil || len(sagas) != 1 {
+		t.Fatalf("sagas = %v, %v; want one", sagas, err)
+	}
+	if sagas[0].Status != models.SagaStatusCompensated {
+		t.Errorf("saga status = %s, want %s", sagas[0].Status, models.SagaStatusCompensated)
+	}
+
+	for eventType, want := range map[events.EventType]int64{events.EventInventoryRelease: 1, events.EventPaymentVoidRequested: 0} {
+		pending, err := outbox.CountByStatus(ctx, nil, orderID, eventType.String(), models.OutboxStatusPending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending != want {
+			t.Errorf("%d pending %s rows, want %d", pending, eventType, want)
+		}
+	}
+}
+
+// rewardedOrder creates an order, authorizes it and rewards it through a running promotion
+func rewardedOrder(t *testing.T, pg pgGorm.PGInterface, oS *OrderService) (uuid.UUID, models.PromotionReward, *PromotionService) {
+	t.Helper()
+	ctx := pgtest.Context()
+	promoRepo := repo.NewPromotionRepository(pg)
+	prom := NewPromotionService(promoRepo, repo.NewOutboxRepository(pg), repo.NewOrderRepository(pg), pg)
+
+	promo := &models.PromotionConfig{
+		Name:          "spring",
+		CustomerLimit: 10,
+		RewardLimit:   10,
+		RewardValue:   5,
+		IsActive:      true,
+		StartTime:     time.Now().Add(-time.Hour),
+		EndTime:       time.Now().Add(time.Hour),
+	}
+	if err := pg.GetRepo().WithContext(ctx).Create(promo).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if err = prom.HandlePromotion(ctx, models.PromotionRewardEvent{OrderID: orderID.String()}); err != nil {
+		t.Fatalf("HandlePromotion: %v", err)
+	}
+	rewards, err := promoRepo.ListRewardsByOrder(ctx, nil, orderID)
+	if err != nil || len(rewards) != 1 {
+		t.Fatalf("rewards = %v, %v; want one", rewards, err)
+	}
+	return orderID, rewards[0], prom
+}
+
+// assertRewardRevoked checks the reward of the order is gone and its revocation announced
+func assertRewardRevoked(t *testing.T, pg pgGorm.PGInterface, orderID uuid.UUID, reward models.PromotionReward) {
+	t.Helper()
+	ctx := pgtest.Context()
+
+	if left, err := repo.NewPromotionRepository(pg).ListRewardsByOrder(ctx, nil, orderID); err != nil || len(left) != 0 {
+		t.Errorf("rewards after the cancellation = %v, %v; want none", left, err)
+	}
+	revoked, err := repo.NewOutboxRepository(pg).CountByStatus(ctx, nil, reward.ID, events.EventPromotionRewardRevoked.String(), models.OutboxStatusPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked != 1 {
+		t.Errorf("%d pending %s rows, want 1", revoked, events.EventPromotionRewardRevoked)
+	}
+}
+
+func TestCancellingRewardedOrderRevokesItsReward(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	orderID, reward, prom := rewardedOrder(t, pg, oS)
+
+	if err := oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusCancelled, testStatusChange("customer cancelled")); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	assertRewardRevoked(t, pg, orderID, reward)
+	// the reward request that is redelivered after the cancellation earns nothing
+	if err := prom.HandlePromotion(ctx, models.PromotionRewardEvent{OrderID: orderID.String()}); !errors.Is(err, ErrOrderNotPaid) {
+		t.Errorf("HandlePromotion of the cancelled order err = %v, want %v", err, ErrOrderNotPaid)
+	}
+}
+
+func TestCancellingAuthorizedOrderWithoutTheSagaVoidsItsPayment(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderServiceWithoutSaga(t, pg)
+	outbox := repo.NewOutboxRepository(pg)
+	orderID, reward, _ := rewardedOrder(t, pg, oS)
+
+	if err := oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusCancelled, testStatusChange("customer cancelled")); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	for _, eventType := range []events.EventType{events.EventInventoryRelease, events.EventPaymentVoidRequested} {
+		pending, err := outbox.CountByStatus(ctx, nil, orderID, eventType.String(), models.OutboxStatusPending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending != 1 {
+			t.Errorf("%d pending %s rows, want 1", pending, eventType)
+		}
+	}
+	assertRewardRevoked(t, pg, orderID, reward)
+}
+
+func TestCancellingPendingOrderWithoutTheSagaVoidsNothing(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderServiceWithoutSaga(t, pg)
+	outbox := repo.NewOutboxRepository(pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusCancelled, testStatusChange("customer cancelled")); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	voids, err := outbox.CountByStatus(ctx, nil, orderID, events.EventPaymentVoidRequested.String(), models.OutboxStatusPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voids != 0 {
+		t.Errorf("%d pending %s rows for an order that was never paid, want 0", voids, events.EventPaymentVoidRequested)
+	}
+}
+
+func TestOrdersWithoutTheSagaReserveStockAndRequestPaymentDirectly(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, invoices := testOrderServiceWithoutSaga(t, pg)
+	outbox := repo.NewOutboxRepository(pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	if sagas, err := repo.NewSagaRepository(pg).GetByOrderID(ctx, orderID); err != nil || len(sagas) != 0 {
+		t.Fatalf("sagas = %v, %v; want none", sagas, err)
+	}
+	payments, err := outbox.CountByStatus(ctx, nil, orderID, events.EventPaymentRequired.String(), models.OutboxStatusPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payments != 1 {
+		t.Errorf("%d pending %s rows, want 1", payments, events.EventPaymentRequired)
+	}
+
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	for _, eventType := range []events.EventType{events.EventInventoryConfirm, events.EventPromotionRewardRequested} {
+		pending, err := outbox.CountByStatus(ctx, nil, orderID, eventType.String(), models.OutboxStatusPending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending != 1 {
+			t.Errorf("%d pending %s rows, want 1", pending, eventType)
+		}
+	}
+	if _, err = invoices.GetOrderInvoice(ctx, orderID); err != nil {
+		t.Errorf("authorized order has no invoice: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"order/internal/fx"
+	"order/internal/models"
+	"strings"
+	"time"
+)
+
+// EnableMultiCurrency accepts orders in any currency with an exchange rate. Orders without a
+// currency are placed in the base currency of their tenant.
+func (oS *OrderService) EnableMultiCurrency(converter *fx.Converter) {
+	oS.fx = converter
+}
+
+// applyCurrency snapshots the exchange rate of the order currency into the base currency
+func (oS *OrderService) applyCurrency(ctx context.Context, orderRequest *models.CreateOrderRequest) error {
+	orderRequest.Currency = strings.ToUpper(orderRequest.Currency)
+	orderRequest.FxRate = 1
+	if oS.fx == nil {
+		return nil
+	}
+	if orderRequest.Currency == "" {
+		orderRequest.Currency = oS.fx.Base(ctx)
+	}
+
+	// backdated orders are converted at the rate of the day they were placed
+	at := time.Now()
+	if orderRequest.PlacedAt != nil {
+		at = *orderRequest.PlacedAt
+	}
+	rate, err := oS.fx.Rate(ctx, orderRequest.Currency, at)
+	if err != nil {
+		return err
+	}
+	orderRequest.FxRate = rate
+	return nil
+}
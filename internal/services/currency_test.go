@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"order/internal/fx"
+	"order/internal/models"
+	repo "order/internal/repositories"
+)
+
+// fakeFxRates holds the rates of one currency ordered by the time they take effect
+type fakeFxRates struct {
+	repo.FxRateRepoInterface
+	rates []models.FxRate
+}
+
+func (f fakeFxRates) FindRate(ctx context.Context, currency string, at time.Time) (*models.FxRate, error) {
+	var found *models.FxRate
+	for i := range f.rates {
+		if f.rates[i].Currency == currency && !f.rates[i].EffectiveAt.After(at) {
+			found = &f.rates[i]
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return found, nil
+}
+
+func TestApplyCurrencyUsesRateAtPlacedAt(t *testing.T) {
+	now := time.Now()
+	lastYear := now.AddDate(-1, 0, 0)
+	rates := fakeFxRates{rates: []models.FxRate{
+		{Currency: "EUR", Rate: 1.25, EffectiveAt: lastYear.AddDate(0, 0, -1)},
+		{Currency: "EUR", Rate: 1.1, EffectiveAt: now.AddDate(0, 0, -1)},
+	}}
+	oS := &OrderService{fx: fx.NewConverter(rates, func(context.Context) string { return "USD" })}
+
+	tests := []struct {
+		name     string
+		placedAt *time.Time
+		want     float64
+	}{
+		{name: "placed now", want: 1.1},
+		{name: "imported with its placing time", placedAt: &lastYear, want: 1.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.CreateOrderRequest{Currency: "eur", PlacedAt: tt.placedAt}
+			if err := oS.applyCurrency(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			if req.FxRate != tt.want {
+				t.Errorf("fx rate = %v, want %v", req.FxRate, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"order/internal/export"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/pkg/core/filestore"
+	"order/pkg/core/logger"
+	"order/pkg/http/paging"
+	"os"
+	"time"
+)
+
+const (
+	exportBatchSize  = 500
+	exportPurgeLimit = 100
+	// maxRunningExports bounds the background exports of an instance; more wait their turn
+	maxRunningExports = 2
+)
+
+var (
+	ErrExportNotReady = errors.New("export is not ready")
+	ErrExportExpired  = errors.New("export has expired")
+)
+
+type ExportServiceInterface interface {
+	StreamOrders(ctx context.Context, w export.OrderWriter, filters []paging.Condition) (int64, error)
+	StartOrderExport(ctx context.Context, req models.ExportOrdersRequest, filters []paging.Condition) (*models.ExportJob, error)
+	GetExport(ctx context.Context, jobID uuid.UUID) (*models.ExportJob, error)
+	OpenExport(ctx context.Context, jobID uuid.UUID) (*models.ExportJob, *os.File, error)
+}
+
+// ExportService exports orders, either streamed to the caller or written in the background to
+// the file store for a later download
+type ExportService struct {
+	orderRepo repo.OrderRepoInterface
+	jobRepo   repo.ExportJobRepoInterface
+	store     *filestore.Local
+	retention time.Duration
+	running   chan struct{}
+}
+
+func NewExportService(
+	orderRepo repo.OrderRepoInterface,
+	jobRepo repo.ExportJobRepoInterface,
+	store *filestore.Local,
+	retention time.Duration,
+) *ExportService {
+	return &ExportService{
+		orderRepo: orderRepo,
+		jobRepo:   jobRepo,
+		store:     store,
+		retention: retention,
+		running:   make(chan struct{}, maxRunningExports),
+	}
+}
+
+// StreamOrders writes the orders matching filters to w and returns how many were written.
+// w is not closed.
+func (eS *ExportService) StreamOrders(ctx context.Context, w export.OrderWriter, filters []paging.Condition) (int64, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ExportService.StreamOrders")
+	defer span.End()
+
+	var written int64
+	err := eS.orderRepo.StreamOrders(ctx, filters, exportBatchSize, func(orders []models.Order) error {
+		for i := range orders {
+			if err := w.Write(&orders[i]); err != nil {
+				return err
+			}
+		}
+		written += int64(len(orders))
+		return nil
+	})
+	span.SetAttributes(attribute.Int64("orders", written))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "export failed")
+		return written, err
+	}
+	return written, nil
+}
+
+// StartOrderExport records an export of the orders matching filters and writes it in the
+// background. The job outlives the request that started it.
+func (eS *ExportService) StartOrderExport(ctx context.Context, req models.ExportOrdersRequest, filters []paging.Condition) (*models.ExportJob, error) {
+	log := logger.WithTag("ExportService|StartOrderExport")
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ExportService.StartOrderExport")
+	defer span.End()
+
+	// reject what the background run would fail on
+	if _, err := export.NewOrderWriter(nil, req.Format, req.Items); err != nil {
+		return nil, err
+	}
+	if err := paging.ValidateFilters(filters, models.Order{}.GetFilterableFields()); err != nil {
+		return nil, err
+	}
+	rawFilters, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	eS.purgeExpired(ctx)
+
+	job := &models.ExportJob{
+		Kind:    models.ExportKindOrders,
+		Format:  req.Format,
+		Items:   req.Items,
+		Filters: string(rawFilters),
+		Status:  models.ExportStatusPending,
+	}
+	if job.Format == "" {
+		job.Format = export.FormatCSV
+	}
+	if err = eS.jobRepo.Create(ctx, job); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to create export job")
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("export_id", job.ID.String()))
+
+	go eS.runOrderExport(context.WithoutCancel(ctx), *job, filters)
+	return job, nil
+}
+
+func (eS *ExportService) runOrderExport(ctx context.Context, job models.ExportJob, filters []paging.Condition) {
+	log := logger.WithTag("ExportService|runOrderExport")
+
+	eS.running <- struct{}{}
+	defer func() { <-eS.running }()
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ExportService.runOrderExport",
+		trace.WithAttributes(attribute.String("export_id", job.ID.String())))
+	defer span.End()
+
+	now := time.Now()
+	job.Status = models.ExportStatusRunning
+	job.StartedAt = &now
+	if err := eS.jobRepo.Save(ctx, &job); err != nil {
+		logger.LogError(log, err, "failed to mark export running")
+	}
+
+	rows, fileName, err := eS.writeOrderExport(ctx, &job, filters)
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.RowCount = rows
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "export failed")
+		logger.LogError(log, err, "export failed")
+		job.Status = models.ExportStatusFailed
+		job.Error = err.Error()
+	} else {
+		expires := finished.Add(eS.retention)
+		job.Status = models.ExportStatusSucceeded
+		job.FileName = fileName
+		job.ExpiresAt = &expires
+	}
+	if err = eS.jobRepo.Save(ctx, &job); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to record export result")
+	}
+}
+
+// writeOrderExport writes the export file; it is only committed to the store when complete
+func (eS *ExportService) writeOrderExport(ctx context.Context, job *models.ExportJob, filters []paging.Condition) (int64, string, error) {
+	_, ext := export.ContentType(job.Format)
+	fileName := fmt.Sprintf("%s.%s", job.ID, ext)
+
+	file, err := eS.store.Create(fileName)
+	if err != nil {
+		return 0, "", err
+	}
+	w, err := export.NewOrderWriter(file, job.Format, job.Items)
+	if err != nil {
+		file.Abort()
+		return 0, "", err
+	}
+	rows, err := eS.StreamOrders(ctx, w, filters)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		file.Abort()
+		return rows, "", err
+	}
+	return rows, fileName, file.Commit()
+}
+
+func (eS *ExportService) GetExport(ctx context.Context, jobID uuid.UUID) (*models.ExportJob, error) {
+	return eS.jobRepo.GetByID(ctx, jobID)
+}
+
+// OpenExport opens the file of a finished export; the caller closes it
+func (eS *ExportService) OpenExport(ctx context.Context, jobID uuid.UUID) (*models.ExportJob, *os.File, error) {
+	job, err := eS.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case job.Status == models.ExportStatusExpired,
+		job.Status == models.ExportStatusSucceeded && job.ExpiresAt != nil && job.ExpiresAt.Before(time.Now()):
+		return job, nil, ErrExportExpired
+	case job.Status != models.ExportStatusSucceeded:
+		return job, nil, ErrExportNotReady
+	}
+
+	file, err := eS.store.Open(job.FileName)
+	if err != nil {
+		return job, nil, err
+	}
+	return job, file, nil
+}
+
+// purgeExpired deletes the files of exports past retention. Failures are retried on the next export.
+func (eS *ExportService) purgeExpired(ctx context.Context) {
+	log := logger.WithTag("ExportService|purgeExpired")
+
+	jobs, err := eS.jobRepo.ListExpired(ctx, time.Now(), exportPurgeLimit)
+	if err != nil {
+		logger.LogError(log, err, "failed to list expired exports")
+		return
+	}
+	for i := range jobs {
+		if err = eS.store.Remove(jobs[i].FileName); err != nil {
+			logger.LogError(log, err, "failed to remove expired export")
+			continue
+		}
+		jobs[i].Status = models.ExportStatusExpired
+		jobs[i].FileName = ""
+		if err = eS.jobRepo.Save(ctx, &jobs[i]); err != nil {
+			logger.LogError(log, err, "failed to mark export expired")
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"order/internal/export"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+	"order/pkg/core/filestore"
+	"order/pkg/http/paging"
+)
+
+func TestBackgroundExportWritesTheMatchingOrdersToTheStore(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	store, err := filestore.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	eS := NewExportService(repo.NewOrderRepository(pg), repo.NewExportJobRepository(pg), store, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		created, err := oS.CreateOrder(ctx, testOrderRequest())
+		if err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		if i == 0 {
+			continue
+		}
+		if err = oS.UpdateOrderStatus(ctx, created.Data.OrderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+			t.Fatalf("authorize: %v", err)
+		}
+	}
+
+	authorized := []paging.Condition{{Field: "status", Operator: "equals", Values: []string{string(models.OrderStatusAuthorized)}}}
+	if _, err = eS.StartOrderExport(ctx, models.ExportOrdersRequest{Format: "xlsx"}, authorized); !errors.Is(err, export.ErrUnknownFormat) {
+		t.Errorf("export to xlsx err = %v, want %v", err, export.ErrUnknownFormat)
+	}
+	unknownField := []paging.Condition{{Field: "customer_id", Operator: "equals", Values: []string{"x"}}}
+	if _, err = eS.StartOrderExport(ctx, models.ExportOrdersRequest{}, unknownField); !errors.Is(err, paging.ErrInvalidFilter) {
+		t.Errorf("export filtered by an unknown field err = %v, want %v", err, paging.ErrInvalidFilter)
+	}
+
+	job, err := eS.StartOrderExport(ctx, models.ExportOrdersRequest{Items: export.ItemsFlattened}, authorized)
+	if err != nil {
+		t.Fatalf("StartOrderExport: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for job.Status != models.ExportStatusSucceeded && job.Status != models.ExportStatusFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("export still %s", job.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if job, err = eS.GetExport(ctx, job.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if job.Status != models.ExportStatusSucceeded || job.RowCount != 2 || job.ExpiresAt == nil {
+		t.Fatalf("export = %s with %d orders, %q; want %s with 2", job.Status, job.RowCount, job.Error, models.ExportStatusSucceeded)
+	}
+
+	_, file, err := eS.OpenExport(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("OpenExport: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a header and a row per item of the two authorized orders
+	if len(records) != 5 {
+		t.Errorf("%d records, want 5", len(records))
+	}
+}
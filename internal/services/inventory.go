@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"github.com/google/uuid"
+	"order/internal/events"
+	"order/internal/models"
+	"order/pkg/core/logger"
+	"order/pkg/proto/inventorypb"
+	"time"
+)
+
+var ErrInsufficientStock = stdErrors.New("insufficient stock")
+
+// StockError reports every line item that could not be reserved
+type StockError struct {
+	Shortages []models.StockShortage
+}
+
+func (e *StockError) Error() string {
+	return fmt.Sprintf("%v for %d item(s)", ErrInsufficientStock, len(e.Shortages))
+}
+
+func (e *StockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// reserveStock reserves the items of an order for the reservation TTL. The order id is the
+// reservation id, so reserving again replaces the previous reservation of the order.
+// The reservation is made right away while the order is still uncommitted, so it is recorded
+// with the reservations tracked by ctx: previous is reserved again when the transaction does
+// not commit, or the reservation is released when previous is nil.
+func (oS *OrderService) reserveStock(ctx context.Context, orderID uuid.UUID, items, previous []models.OrderItem) error {
+	resp, err := oS.inventory.Reserve(ctx, oS.newReserveRequest(orderID, items))
+	if err != nil {
+		return err
+	}
+	if resp.GetReserved() {
+		if tracked, ok := ctx.Value(reservationsKey{}).(*reservations); ok {
+			tracked.record(func(ctx context.Context) error {
+				if previous != nil {
+					_, err := oS.inventory.Reserve(ctx, oS.newReserveRequest(orderID, previous))
+					return err
+				}
+				_, err := oS.inventory.Release(ctx, &inventorypb.ReleaseRequest{
+					ReservationId: orderID.String(),
+					Reason:        "order transaction rolled back",
+				})
+				return err
+			})
+		}
+		return nil
+	}
+
+	stockErr := &StockError{}
+	for _, line := range resp.GetLines() {
+		if line.GetReserved() {
+			continue
+		}
+		productID, _ := uuid.Parse(line.GetProductId())
+		stockErr.Shortages = append(stockErr.Shortages, models.StockShortage{
+			ProductID: productID,
+			Requested: int(line.GetRequested()),
+			Available: int(line.GetAvailable()),
+			Reason:    line.GetReason(),
+		})
+	}
+	return stockErr
+}
+
+func (oS *OrderService) newReserveRequest(orderID uuid.UUID, items []models.OrderItem) *inventorypb.ReserveRequest {
+	req := &inventorypb.ReserveRequest{
+		ReservationId: orderID.String(),
+		OrderId:       orderID.String(),
+		TtlSeconds:    int64(oS.reservationTTL.Seconds()),
+	}
+	for _, item := range items {
+		req.Lines = append(req.Lines, &inventorypb.ReserveLine{
+			ProductId: item.ProductID.String(),
+			Quantity:  int32(item.Quantity),
+		})
+	}
+	return req
+}
+
+type reservationsKey struct{}
+
+// reservations collects the undo of the stock reserved while a transaction is open. The
+// inventory service knows nothing of the transaction, so its owner settles them once it
+// knows whether it committed.
+type reservations struct {
+	parent *reservations
+	undo   []func(ctx context.Context) error
+}
+
+// trackReservations returns a context recording the reservations made with it. Tracking
+// inside a tracked context, e.g. behind a savepoint, hands committed reservations to the
+// outer tracker, so they are still undone when the outer transaction rolls back.
+func trackReservations(ctx context.Context) (context.Context, *reservations) {
+	tracked := &reservations{}
+	tracked.parent, _ = ctx.Value(reservationsKey{}).(*reservations)
+	return context.WithValue(ctx, reservationsKey{}, tracked), tracked
+}
+
+func (r *reservations) record(undo func(ctx context.Context) error) {
+	r.undo = append(r.undo, undo)
+}
+
+// settle undoes the recorded reservations, newest first, unless committed. An undo that fails
+// is only logged; the reservation then expires with its TTL.
+func (r *reservations) settle(ctx context.Context, committed bool) {
+	undo := r.undo
+	r.undo = nil
+	if committed {
+		if r.parent != nil {
+			r.parent.undo = append(r.parent.undo, undo...)
+		}
+		return
+	}
+
+	log := logger.WithTag("OrderService|settleReservations")
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	for i := len(undo) - 1; i >= 0; i-- {
+		if err := undo[i](ctx); err != nil {
+			logger.LogError(log, err, "failed to undo stock reservation")
+		}
+	}
+}
+
+// newInventoryOutbox builds the outbox row that confirms or releases the reservation of an order
+// entering status, or nil when the status does not affect the reservation
+func newInventoryOutbox(orderID uuid.UUID, status models.OrderStatus, reason string) *models.Outbox {
+	switch status {
+	case models.OrderStatusAuthorized:
+		return newInventoryConfirmOutbox(orderID)
+	case models.OrderStatusCancelled, models.OrderStatusDeclined, models.OrderStatusExpired:
+		return newInventoryReleaseOutbox(orderID, reason)
+	default:
+		return nil
+	}
+}
+
+func newInventoryConfirmOutbox(orderID uuid.UUID) *models.Outbox {
+	return newOrderOutbox(orderID, events.EventInventoryConfirm, &inventorypb.ConfirmRequest{ReservationId: orderID.String()})
+}
+
+func newInventoryReleaseOutbox(orderID uuid.UUID, reason string) *models.Outbox {
+	return newOrderOutbox(orderID, events.EventInventoryRelease, &inventorypb.ReleaseRequest{ReservationId: orderID.String(), Reason: reason})
+}
+
+// newOrderOutbox builds a pending outbox row of the order aggregate
+func newOrderOutbox(orderID uuid.UUID, eventType events.EventType, payload interface{}) *models.Outbox {
+	bs, _ := json.Marshal(payload)
+
+	return &models.Outbox{
+		EventID:       uuid.New(),
+		EventType:     eventType.String(),
+		AggregateType: events.AggregateOrder.String(),
+		AggregateID:   orderID,
+		Payload:       string(bs),
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/grpc/clients/inventory"
+	"order/internal/models"
+)
+
+func TestReservationsSettle(t *testing.T) {
+	productID := uuid.New()
+	items := func(quantity int) []models.OrderItem {
+		return []models.OrderItem{{ProductID: productID, Quantity: quantity}}
+	}
+
+	tests := []struct {
+		name string
+		// run reserves in a tracked context and settles it
+		run func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error
+		// available is the stock left for another order afterwards
+		available int
+	}{
+		{
+			name: "committed keeps the reservation",
+			run: func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error {
+				ctx, reserved := trackReservations(ctx)
+				err := oS.reserveStock(ctx, orderID, items(3), nil)
+				reserved.settle(ctx, true)
+				return err
+			},
+			available: 2,
+		},
+		{
+			name: "rolled back releases the reservation",
+			run: func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error {
+				ctx, reserved := trackReservations(ctx)
+				err := oS.reserveStock(ctx, orderID, items(3), nil)
+				reserved.settle(ctx, false)
+				return err
+			},
+			available: 5,
+		},
+		{
+			name: "rolled back restores the previous reservation",
+			run: func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error {
+				if err := oS.reserveStock(ctx, orderID, items(1), nil); err != nil {
+					return err
+				}
+				ctx, reserved := trackReservations(ctx)
+				err := oS.reserveStock(ctx, orderID, items(4), items(1))
+				reserved.settle(ctx, false)
+				return err
+			},
+			available: 4,
+		},
+		{
+			name: "savepoint committed is released with the outer transaction",
+			run: func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error {
+				ctx, outer := trackReservations(ctx)
+				rowCtx, row := trackReservations(ctx)
+				err := oS.reserveStock(rowCtx, orderID, items(3), nil)
+				row.settle(ctx, true)
+				outer.settle(ctx, false)
+				return err
+			},
+			available: 5,
+		},
+		{
+			name: "resumed checkout saga that rolls back releases the reservation",
+			run: func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error {
+				ctx, settle := oS.checkoutDefinition(time.Minute).Track(ctx)
+				err := oS.reserveStock(ctx, orderID, items(3), nil)
+				settle(false)
+				return err
+			},
+			available: 5,
+		},
+		{
+			name: "resumed checkout saga that commits keeps the reservation",
+			run: func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error {
+				ctx, settle := oS.checkoutDefinition(time.Minute).Track(ctx)
+				err := oS.reserveStock(ctx, orderID, items(3), nil)
+				settle(true)
+				return err
+			},
+			available: 2,
+		},
+		{
+			name: "untracked reservation is kept",
+			run: func(ctx context.Context, oS *OrderService, orderID uuid.UUID) error {
+				return oS.reserveStock(ctx, orderID, items(3), nil)
+			},
+			available: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := inventoryclient.NewFakeInventoryClient(map[string]int32{productID.String(): 5})
+			oS := &OrderService{inventory: inventory, reservationTTL: time.Hour}
+			ctx := context.Background()
+
+			if err := tt.run(ctx, oS, uuid.New()); err != nil {
+				t.Fatalf("reserve: %v", err)
+			}
+
+			other := uuid.New()
+			if err := oS.reserveStock(ctx, other, items(tt.available), nil); err != nil {
+				t.Errorf("reserving the %d available: %v", tt.available, err)
+			}
+			if err := oS.reserveStock(ctx, other, items(tt.available+1), nil); !errors.Is(err, ErrInsufficientStock) {
+				t.Errorf("reserving %d err = %v, want %v", tt.available+1, err, ErrInsufficientStock)
+			}
+		})
+	}
+}
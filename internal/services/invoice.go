@@ -0,0 +1,214 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"math"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/internal/tax"
+	"strings"
+	"time"
+)
+
+type InvoiceService struct {
+	invoiceRepo repo.InvoiceRepoInterface
+	orderRepo   repo.OrderRepoInterface
+	currency    func(ctx context.Context) string
+	nowFunc     func() time.Time
+}
+
+type InvoiceServiceInterface interface {
+	IssueInvoice(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) (*models.Invoice, error)
+	IssueCreditNote(ctx context.Context, tx *gorm.DB, order *models.Order, item models.OrderItem, ret *models.ReturnRequest) (*models.Invoice, error)
+	GetInvoice(ctx context.Context, invoiceID uuid.UUID) (*models.Invoice, error)
+	GetOrderInvoice(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error)
+	ListOrderInvoices(ctx context.Context, orderID uuid.UUID) ([]models.Invoice, error)
+}
+
+// NewInvoiceService builds the invoice service; documents of orders without a currency are
+// issued in the currency returned for the tenant of the context
+func NewInvoiceService(invoiceRepo repo.InvoiceRepoInterface, orderRepo repo.OrderRepoInterface, currency func(ctx context.Context) string) *InvoiceService {
+	return &InvoiceService{
+		invoiceRepo: invoiceRepo,
+		orderRepo:   orderRepo,
+		currency:    currency,
+		nowFunc:     time.Now,
+	}
+}
+
+// EnableInvoicing issues the invoice of an order when it reaches one of models.InvoicedOrderStatuses
+func (oS *OrderService) EnableInvoicing(invoices InvoiceServiceInterface) {
+	oS.invoices = invoices
+}
+
+// IssueInvoice issues the invoice of an order in tx, or returns the one already issued.
+// The order row is locked first so concurrent status changes issue a single invoice.
+func (iS *InvoiceService) IssueInvoice(ctx context.Context, tx *gorm.DB, orderID uuid.UUID) (*models.Invoice, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "InvoiceService.IssueInvoice",
+		trace.WithAttributes(attribute.String("order_id", orderID.String())))
+	defer span.End()
+
+	order, err := iS.orderRepo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	invoice, err := iS.invoiceRepo.GetOrderInvoice(ctx, tx, orderID)
+	if err == nil {
+		return invoice, nil
+	}
+	if !stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	invoice = iS.newDocument(ctx, order, models.InvoiceKindInvoice)
+	invoice.Subtotal = order.Subtotal()
+	invoice.DiscountAmount = order.DiscountAmount
+	invoice.TaxAmount = order.TaxAmount
+	invoice.TotalAmount = order.TotalAmount
+
+	// the discount is spread over the lines in proportion to their value
+	net := order.TotalAmount - order.TaxAmount
+	for _, item := range order.OrderItems {
+		value := float64(item.Quantity) * item.UnitPrice
+		lineNet := value
+		if invoice.Subtotal > 0 {
+			lineNet = tax.Round(value * net / invoice.Subtotal)
+		}
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			NetAmount:   lineNet,
+			TaxClass:    item.TaxClass,
+			TaxRate:     item.TaxRate,
+			TaxAmount:   item.TaxAmount,
+			TotalAmount: tax.Round(lineNet + item.TaxAmount),
+		})
+	}
+
+	if err = iS.create(ctx, tx, invoice); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("invoice_number", invoice.Number))
+	return invoice, nil
+}
+
+// IssueCreditNote credits the refund of a completed return against the invoice of the order.
+// The caller holds the order row lock. Nothing is issued for returns without a refund.
+func (iS *InvoiceService) IssueCreditNote(
+	ctx context.Context,
+	tx *gorm.DB,
+	order *models.Order,
+	item models.OrderItem,
+	ret *models.ReturnRequest,
+) (*models.Invoice, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "InvoiceService.IssueCreditNote",
+		trace.WithAttributes(attribute.String("order_id", order.ID.String()),
+			attribute.String("return_id", ret.ID.String())))
+	defer span.End()
+
+	if ret.RefundAmount <= 0 {
+		return nil, nil
+	}
+
+	creditNote, err := iS.invoiceRepo.GetByReturnRequest(ctx, tx, ret.ID)
+	if err == nil {
+		return creditNote, nil
+	}
+	if !stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// orders paid before invoicing was enabled get their invoice first
+	invoice, err := iS.IssueInvoice(ctx, tx, order.ID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	lineTax := 0.0
+	if item.Quantity > 0 {
+		lineTax = math.Min(tax.Round(item.TaxAmount*float64(ret.Quantity)/float64(item.Quantity)), ret.RefundAmount)
+	}
+	lineNet := tax.Round(ret.RefundAmount - lineTax)
+
+	creditNote = iS.newDocument(ctx, order, models.InvoiceKindCreditNote)
+	creditNote.CreditedInvoiceID = &invoice.ID
+	creditNote.CreditedNumber = invoice.Number
+	creditNote.ReturnRequestID = &ret.ID
+	creditNote.Subtotal = tax.Round(float64(ret.Quantity) * item.UnitPrice)
+	creditNote.DiscountAmount = math.Max(tax.Round(creditNote.Subtotal-lineNet), 0)
+	creditNote.TaxAmount = lineTax
+	creditNote.TotalAmount = ret.RefundAmount
+	creditNote.Lines = []models.InvoiceLine{{
+		OrderItemID: item.ID,
+		ProductID:   item.ProductID,
+		Quantity:    ret.Quantity,
+		UnitPrice:   item.UnitPrice,
+		NetAmount:   lineNet,
+		TaxClass:    item.TaxClass,
+		TaxRate:     item.TaxRate,
+		TaxAmount:   lineTax,
+		TotalAmount: ret.RefundAmount,
+	}}
+
+	if err = iS.create(ctx, tx, creditNote); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("credit_note_number", creditNote.Number))
+	return creditNote, nil
+}
+
+func (iS *InvoiceService) GetInvoice(ctx context.Context, invoiceID uuid.UUID) (*models.Invoice, error) {
+	return iS.invoiceRepo.GetByID(ctx, invoiceID)
+}
+
+func (iS *InvoiceService) GetOrderInvoice(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	return iS.invoiceRepo.GetOrderInvoice(ctx, nil, orderID)
+}
+
+func (iS *InvoiceService) ListOrderInvoices(ctx context.Context, orderID uuid.UUID) ([]models.Invoice, error) {
+	return iS.invoiceRepo.ListByOrderID(ctx, orderID)
+}
+
+// newDocument copies the customer snapshot of order onto a new document of kind
+func (iS *InvoiceService) newDocument(ctx context.Context, order *models.Order, kind models.InvoiceKind) *models.Invoice {
+	return &models.Invoice{
+		OrderID:        order.ID,
+		Kind:           kind,
+		IssuedAt:       iS.nowFunc().UTC(),
+		CustomerID:     order.CustomerID,
+		Customer:       order.Customer,
+		BillingAddress: order.BillingAddress,
+		Currency:       strings.ToUpper(cmp.Or(order.Currency, iS.currency(ctx))),
+	}
+}
+
+// create numbers the document from the sequence of its kind and year and stores it
+func (iS *InvoiceService) create(ctx context.Context, tx *gorm.DB, invoice *models.Invoice) error {
+	invoice.Year = invoice.IssuedAt.Year()
+	sequence, err := iS.invoiceRepo.NextSequence(ctx, tx, invoice.Kind, invoice.Year)
+	if err != nil {
+		return err
+	}
+	invoice.Sequence = sequence
+	invoice.Number = fmt.Sprintf("%s-%d-%06d", invoice.Kind.NumberPrefix(), invoice.Year, sequence)
+	return iS.invoiceRepo.Create(ctx, tx, invoice)
+}
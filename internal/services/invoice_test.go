@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+	"order/pkg/core/tenant"
+)
+
+func TestInvoiceNumbersRunWithoutGaps(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, invoices := testOrderService(t, pg)
+	year := time.Now().UTC().Year()
+
+	createOrder := func() uuid.UUID {
+		t.Helper()
+		created, err := oS.CreateOrder(ctx, testOrderRequest())
+		if err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		return created.Data.OrderID
+	}
+
+	// authorizing an order issues its invoice
+	first := createOrder()
+	if err := oS.UpdateOrderStatus(ctx, first, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	invoice, err := invoices.GetOrderInvoice(ctx, first)
+	if err != nil {
+		t.Fatalf("GetOrderInvoice: %v", err)
+	}
+	if want := fmt.Sprintf("INV-%d-000001", year); invoice.Number != want || invoice.TotalAmount != 30 || len(invoice.Lines) != 2 {
+		t.Errorf("invoice = %s of %v with %d lines, want %s of 30 with 2 lines", invoice.Number, invoice.TotalAmount, len(invoice.Lines), want)
+	}
+
+	// an invoice rolled back with its transaction gives its number back
+	second := createOrder()
+	rollback := errors.New("rollback")
+	err = pg.GetRepo().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := invoices.IssueInvoice(ctx, tx, second); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("rolled back IssueInvoice err = %v, want %v", err, rollback)
+	}
+	var issued *models.Invoice
+	err = pg.GetRepo().WithContext(ctx).Transaction(func(tx *gorm.DB) (err error) {
+		issued, err = invoices.IssueInvoice(ctx, tx, second)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	if want := fmt.Sprintf("INV-%d-000002", year); issued.Number != want {
+		t.Errorf("invoice after the rollback = %s, want %s", issued.Number, want)
+	}
+
+	// issuing again returns the invoice already issued
+	if err = oS.UpdateOrderStatus(ctx, second, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if again, err := invoices.GetOrderInvoice(ctx, second); err != nil || again.ID != issued.ID {
+		t.Errorf("invoice of the authorized order = %v, %v; want %s", again, err, issued.Number)
+	}
+
+	// credit notes have a sequence of their own and name the invoice they credit
+	order, err := repo.NewOrderRepository(pg).GetByID(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := &models.ReturnRequest{OrderID: first, OrderItemID: order.OrderItems[0].ID, Quantity: 1, RefundAmount: 10}
+	ret.ID = uuid.New()
+	var creditNote *models.Invoice
+	err = pg.GetRepo().WithContext(ctx).Transaction(func(tx *gorm.DB) (err error) {
+		creditNote, err = invoices.IssueCreditNote(ctx, tx, order, order.OrderItems[0], ret)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("IssueCreditNote: %v", err)
+	}
+	if want := fmt.Sprintf("CN-%d-000001", year); creditNote.Number != want || creditNote.CreditedNumber != invoice.Number || creditNote.TotalAmount != 10 {
+		t.Errorf("credit note = %s crediting %s of %v, want %s crediting %s of 10",
+			creditNote.Number, creditNote.CreditedNumber, creditNote.TotalAmount, want, invoice.Number)
+	}
+	documents, err := invoices.ListOrderInvoices(ctx, first)
+	if err != nil || len(documents) != 2 {
+		t.Errorf("ListOrderInvoices = %d documents, %v; want the invoice and the credit note", len(documents), err)
+	}
+
+	// every tenant and every year starts at 1
+	acme := tenant.NewContext(ctx, "acme")
+	created, err := oS.CreateOrder(acme, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder of acme: %v", err)
+	}
+	err = pg.GetRepo().WithContext(acme).Transaction(func(tx *gorm.DB) (err error) {
+		issued, err = invoices.IssueInvoice(acme, tx, created.Data.OrderID)
+		return err
+	})
+	if want := fmt.Sprintf("INV-%d-000001", year); err != nil || issued.Number != want {
+		t.Errorf("first invoice of acme = %v, %v; want %s", issued, err, want)
+	}
+	invoices.nowFunc = func() time.Time { return time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC) }
+	third := createOrder()
+	err = pg.GetRepo().WithContext(ctx).Transaction(func(tx *gorm.DB) (err error) {
+		issued, err = invoices.IssueInvoice(ctx, tx, third)
+		return err
+	})
+	if want := fmt.Sprintf("INV-%d-000001", year+1); err != nil || issued.Number != want {
+		t.Errorf("first invoice of the next year = %v, %v; want %s", issued, err, want)
+	}
+}
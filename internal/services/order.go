@@ -3,48 +3,105 @@ package services
 import (
 	"context"
 	"encoding/json"
+	stdErrors "errors"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 	"order/internal/events"
+	"order/internal/eventsourcing"
+	"order/internal/fx"
+	"order/internal/grpc/clients/inventory"
 	"order/internal/grpc/clients/payment"
+	"order/internal/importer"
 	"order/internal/models"
 	"order/internal/repositories"
 	pgGorm "order/internal/repositories/pg-gorm"
+	"order/internal/saga"
+	"order/internal/tax"
 	"order/pkg/core/logger"
+	"order/pkg/http/utils"
 	"order/pkg/http/utils/errors"
 	"order/pkg/proto/paymentpb"
 	"time"
 )
 
 type OrderService struct {
-	repo       repo.OrderRepoInterface
-	newPgRepo  pgGorm.PGInterface
-	payment    paymentclient.PaymentClient
-	outboxRepo *repo.OutboxRepository
+	repo        repo.OrderRepoInterface
+	newPgRepo   pgGorm.PGInterface
+	payment     paymentclient.PaymentClient
+	inventory   inventoryclient.InventoryClient
+	outboxRepo  *repo.OutboxRepository
+	historyRepo repo.OrderStatusHistoryRepoInterface
+	promoRepo   repo.PromotionRepoInterface
+
+	// reservationTTL is how long stock stays reserved for an unpaid order
+	reservationTTL time.Duration
+
+	// taxCalculator is set when orders are taxed
+	taxCalculator tax.TaxCalculator
+
+	// fx is set when orders can be placed in other currencies than the base currency
+	fx *fx.Converter
+
+	// invoices is set when paid orders are invoiced
+	invoices InvoiceServiceInterface
+
+	// orchestrator is set when checkouts run as a saga
+	orchestrator *saga.Orchestrator
+
+	// eventStore and projector are set when orders are event sourced
+	eventStore *eventsourcing.Store
+	projector  *eventsourcing.Projector
 }
 
 type OrderServiceInterface interface {
 	CreateOrder(ctx context.Context, orderRequest models.CreateOrderRequest) (*models.CreateOrderResponse, error)
-	UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status events.PaymentStatus) error
+	CreateOrderInTx(ctx context.Context, tx *gorm.DB, orderRequest models.CreateOrderRequest) (*models.CreateOrderResponse, error)
+	UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status models.OrderStatus, change models.StatusChange) error
+	UpdateOrderStatusInTx(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, status models.OrderStatus, change models.StatusChange) error
+	ApplyPaymentResult(ctx context.Context, orderID uuid.UUID, result models.PaymentAuthorizedEvent, status models.OrderStatus, change models.StatusChange) error
+	GetOrderHistory(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusHistory, error)
+	ExpireUnpaidOrders(ctx context.Context, createdBefore time.Time) (int64, error)
+	ReconcileStuckOrders(ctx context.Context, createdBefore time.Time, limit int) (int64, error)
+	AddOrderItem(ctx context.Context, orderID uuid.UUID, item models.CreateOrderItemRequest) (*models.Order, error)
+	UpdateOrderItem(ctx context.Context, orderID, itemID uuid.UUID, quantity int) (*models.Order, error)
+	RemoveOrderItem(ctx context.Context, orderID, itemID uuid.UUID) (*models.Order, error)
+	ResumeCheckoutSagas(ctx context.Context) (int64, error)
+	TimeoutCheckoutSagas(ctx context.Context) (int64, error)
+	ImportOrders(ctx context.Context, reader importer.OrderReader, opts ImportOptions) (*models.ImportReport, error)
 }
 
 func NewOrderService(
 	repo repo.OrderRepoInterface,
 	newRepo pgGorm.PGInterface,
 	payment paymentclient.PaymentClient,
+	inventory inventoryclient.InventoryClient,
 	outbox *repo.OutboxRepository,
+	history repo.OrderStatusHistoryRepoInterface,
+	promo repo.PromotionRepoInterface,
+	reservationTTL time.Duration,
 ) *OrderService {
 	return &OrderService{
-		repo:       repo,
-		newPgRepo:  newRepo,
-		payment:    payment,
-		outboxRepo: outbox,
+		repo:           repo,
+		newPgRepo:      newRepo,
+		payment:        payment,
+		inventory:      inventory,
+		outboxRepo:     outbox,
+		historyRepo:    history,
+		promoRepo:      promo,
+		reservationTTL: reservationTTL,
 	}
 }
 
+// EnableEventSourcing stores orders as event streams; the orders table becomes a projection.
+func (oS *OrderService) EnableEventSourcing(store *eventsourcing.Store, projector *eventsourcing.Projector) {
+	oS.eventStore = store
+	oS.projector = projector
+}
+
 func (oS *OrderService) CreateOrder(
 	ctx context.Context,
 	orderRequest models.CreateOrderRequest,
@@ -57,43 +114,92 @@ func (oS *OrderService) CreateOrder(
 		trace.WithAttributes(attribute.String("customer_id", orderRequest.CustomerID.String())))
 	defer span.End()
 
+	// stock reserved for an order that is not committed is given back
+	ctx, reserved := trackReservations(ctx)
+	committed := false
+	defer func() { reserved.settle(ctx, committed) }()
+
 	span.AddEvent("begin tx")
-	tx := oS.newPgRepo.GetRepo().Begin()
+	tx := oS.newPgRepo.GetRepo().WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	// Create order in DB
-	createOrderResp, err := oS.repo.CreateOrder(ctx, tx, &orderRequest)
+	createOrderResp, err := oS.CreateOrderInTx(ctx, tx, orderRequest)
 	if err != nil {
-		// tracer
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "create order failed")
 		return nil, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+
 		err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
-		// logger
-		logger.LogError(log, err, "failed to create order")
+		logger.LogError(log, err, "failed to commit tx")
 		return nil, err
 	}
 
-	payReq := &paymentpb.PayRequest{
-		OrderId:    createOrderResp.Data.OrderID.String(),
-		CustomerId: createOrderResp.Data.CustomerID.String(),
-		Amount:     createOrderResp.Data.TotalAmount,
-		Status:     createOrderResp.Data.Status,
+	committed = true
+
+	span.SetStatus(codes.Ok, "created")
+	return createOrderResp, nil
+}
+
+// CreateOrderInTx writes the order, its first history entry and its payment request in tx.
+// The caller owns tx, so the order can be created atomically with other changes.
+func (oS *OrderService) CreateOrderInTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	orderRequest models.CreateOrderRequest,
+) (*models.CreateOrderResponse, error) {
+
+	log := logger.WithTag("OrderService|CreateOrderInTx")
+	span := trace.SpanFromContext(ctx)
+
+	createOrderResp, err := oS.createOrderRecord(ctx, tx, &orderRequest)
+	if err != nil {
+		return nil, err
 	}
 
-	bs, _ := json.Marshal(payReq)
+	if oS.orchestrator != nil {
+		// the saga reserves the stock and requests the payment
+		if _, err = oS.orchestrator.Start(ctx, tx, CheckoutSaga, createOrderResp.Data.OrderID); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "start checkout saga failed")
 
-	outbox := &models.Outbox{
-		EventID:       uuid.New(),
-		EventType:     events.EventPaymentRequired.String(),
-		AggregateType: events.AggregateOrder.String(),
-		AggregateID:   createOrderResp.Data.OrderID,
-		Payload:       string(bs),
-		Status:        models.OutboxStatusPending,
-		Attempts:      0,
-		NextAttemptAt: time.Now(),
+			logger.LogError(log, err, "failed to start checkout saga")
+			if !stdErrors.Is(err, ErrInsufficientStock) {
+				err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+			}
+			return nil, err
+		}
+		return createOrderResp, nil
 	}
 
+	// stock is held until the payment is authorized or the order ends otherwise
+	reserved := make([]models.OrderItem, 0, len(orderRequest.OrderItems))
+	for _, item := range orderRequest.OrderItems {
+		reserved = append(reserved, models.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	if err = oS.reserveStock(ctx, createOrderResp.Data.OrderID, reserved, nil); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "reserve stock failed")
+
+		logger.LogError(log, err, "failed to reserve stock")
+		if !stdErrors.Is(err, ErrInsufficientStock) {
+			err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+		}
+		return nil, err
+	}
+
+	outbox := newPaymentOutbox(
+		createOrderResp.Data.OrderID,
+		createOrderResp.Data.CustomerID,
+		createOrderResp.Data.TotalAmount,
+		createOrderResp.Data.Currency,
+		createOrderResp.Data.Status,
+	)
+
 	// prepare outbox payload...
 	span.AddEvent("create outbox", trace.WithAttributes(attribute.String("aggregate_id", createOrderResp.Data.OrderID.String())))
 	if err = oS.outboxRepo.CreateOutbox(ctx, tx, outbox); err != nil {
@@ -105,32 +211,135 @@ func (oS *OrderService) CreateOrder(
 		return nil, err
 	}
 
-	if err = tx.Commit().Error; err != nil {
+	return createOrderResp, nil
+}
+
+// createOrderRecord prices the order and writes it with the first entry of its status history.
+// Stock and payment are left to the caller.
+func (oS *OrderService) createOrderRecord(
+	ctx context.Context,
+	tx *gorm.DB,
+	orderRequest *models.CreateOrderRequest,
+) (*models.CreateOrderResponse, error) {
+
+	log := logger.WithTag("OrderService|createOrderRecord")
+	span := trace.SpanFromContext(ctx)
+
+	if err := normalizeOrderContact(orderRequest); err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "commit failed")
+		span.SetStatus(codes.Error, "invalid order contact")
+		return nil, err
+	}
+
+	if err := oS.applyCurrency(ctx, orderRequest); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "resolve exchange rate failed")
+
+		logger.LogError(log, err, "failed to resolve exchange rate")
+		if !stdErrors.Is(err, fx.ErrUnsupportedCurrency) {
+			err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+		}
+		return nil, err
+	}
+
+	if err := oS.applyRequestTax(ctx, orderRequest); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "calculate tax failed")
+
+		logger.LogError(log, err, "failed to calculate tax")
+		return nil, errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+	}
+
+	// Create order in DB
+	var createOrderResp *models.CreateOrderResponse
+	var err error
+	if oS.eventStore != nil {
+		createOrderResp, err = oS.createOrderEventSourced(ctx, tx, orderRequest)
+	} else {
+		createOrderResp, err = oS.repo.CreateOrder(ctx, tx, orderRequest)
+	}
+	if err != nil {
+		// tracer
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "create order failed")
 
 		err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
-		logger.LogError(log, err, "failed to commit tx")
+		// logger
+		logger.LogError(log, err, "failed to create order")
+		return nil, err
+	}
+
+	// initial status is the first entry of the audit trail
+	history := orderRequest.Audit.NewHistory(createOrderResp.Data.OrderID, "", createOrderResp.Data.Status)
+	if orderRequest.PlacedAt != nil {
+		history.ChangedAt = *orderRequest.PlacedAt
+	}
+	if err = oS.historyRepo.Create(ctx, tx, history); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "create status history failed")
+
+		err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+		logger.LogError(log, err, "failed to create order status history")
 		return nil, err
 	}
 
-	span.SetStatus(codes.Ok, "created")
 	return createOrderResp, nil
 }
 
-func (oS *OrderService) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status events.PaymentStatus) error {
+func (oS *OrderService) UpdateOrderStatus(
+	ctx context.Context,
+	orderID uuid.UUID,
+	status models.OrderStatus,
+	change models.StatusChange,
+) error {
 	log := logger.WithTag("OrderService|UpdateOrderStatus")
 
 	tracer := otel.Tracer("order/service")
 	ctx, span := tracer.Start(ctx, "OrderService.UpdateOrderStatus",
 		trace.WithAttributes(attribute.String("order_id", orderID.String()),
-			attribute.String("status", string(status))))
+			attribute.String("status", string(status)),
+			attribute.String("source", string(change.Source))))
 	defer span.End()
 
-	tx := oS.newPgRepo.GetRepo().Begin()
+	tx := oS.newPgRepo.GetRepo().WithContext(ctx).Begin()
 	defer tx.Rollback()
 
-	err := oS.repo.UpdateOrderStatus(ctx, orderID, status)
+	if err := oS.UpdateOrderStatusInTx(ctx, tx, orderID, status, change); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+
+		err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+		logger.LogError(log, err, "failed to commit tx")
+		return err
+	}
+
+	span.SetStatus(codes.Ok, "updated order status")
+	return nil
+}
+
+// UpdateOrderStatusInTx records a status change with its history entry and follow-ups in tx.
+// The caller owns tx, so the change can be made atomically with other writes.
+func (oS *OrderService) UpdateOrderStatusInTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	orderID uuid.UUID,
+	status models.OrderStatus,
+	change models.StatusChange,
+) error {
+	log := logger.WithTag("OrderService|UpdateOrderStatusInTx")
+	span := trace.SpanFromContext(ctx)
+
+	fromStatus, err := oS.repo.UpdateOrderStatus(ctx, tx, orderID, status)
+	if stdErrors.Is(err, repo.ErrInvalidStatusTransition) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid status transition")
+		logger.LogError(log, err, "rejected order status change")
+		return err
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "update order status failed")
@@ -140,15 +349,288 @@ func (oS *OrderService) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID
 		return err
 	}
 
-	if err = tx.Commit().Error; err != nil {
+	if err = oS.appendStatusEvent(ctx, tx, orderID, fromStatus, status, change.Reason); err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "commit failed")
+		span.SetStatus(codes.Error, "append status event failed")
 
 		err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
-		logger.LogError(log, err, "failed to commit tx")
+		logger.LogError(log, err, "failed to append order status event")
 		return err
 	}
 
-	span.SetStatus(codes.Ok, "updated order status")
+	if err = oS.historyRepo.Create(ctx, tx, change.NewHistory(orderID, fromStatus, string(status))); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "create status history failed")
+
+		err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+		logger.LogError(log, err, "failed to create order status history")
+		return err
+	}
+
+	if err = oS.afterStatusChange(ctx, tx, orderID, fromStatus, status, change.Reason); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "status follow-up failed")
+
+		err = errors.Error(errors.StatusInternalServerError, errors.StatusInternalServerError)
+		logger.LogError(log, err, "failed to run order status follow-up")
+		return err
+	}
 	return nil
 }
+
+// GetOrderHistory returns the status transitions of an order, oldest first.
+func (oS *OrderService) GetOrderHistory(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusHistory, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrderHistory",
+		trace.WithAttributes(attribute.String("order_id", orderID.String())))
+	defer span.End()
+
+	if _, err := oS.repo.GetByID(ctx, orderID); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	history, err := oS.historyRepo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "list status history failed")
+		return nil, err
+	}
+	return history, nil
+}
+
+// newPaymentOutbox builds the payment_required outbox row consumed by the outbox worker
+func newPaymentOutbox(orderID, customerID uuid.UUID, amount float64, currency, status string) *models.Outbox {
+	payReq := &paymentpb.PayRequest{
+		OrderId:    orderID.String(),
+		CustomerId: customerID.String(),
+		Amount:     amount,
+		Currency:   currency,
+		Status:     status,
+	}
+
+	bs, _ := json.Marshal(payReq)
+
+	return &models.Outbox{
+		EventID:       uuid.New(),
+		EventType:     events.EventPaymentRequired.String(),
+		AggregateType: events.AggregateOrder.String(),
+		AggregateID:   orderID,
+		Payload:       string(bs),
+		Status:        models.OutboxStatusPending,
+		Attempts:      0,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+// ExpireUnpaidOrders marks orders still pending payment since before the cutoff as EXPIRED.
+func (oS *OrderService) ExpireUnpaidOrders(ctx context.Context, createdBefore time.Time) (int64, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "OrderService.ExpireUnpaidOrders")
+	defer span.End()
+
+	tx := oS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	expired, err := oS.repo.ExpirePendingOrders(ctx, tx, createdBefore)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "expire orders failed")
+		return 0, err
+	}
+
+	change := models.StatusChange{
+		ActorType: models.ActorTypeSystem,
+		Source:    models.ChangeSourceScheduler,
+		SourceRef: "expire_unpaid_orders",
+		Reason:    "payment not received before " + createdBefore.Format(time.RFC3339),
+	}
+	history := make([]*models.OrderStatusHistory, 0, len(expired))
+	for _, order := range expired {
+		history = append(history, change.NewHistory(order.ID, order.Status, string(models.OrderStatusExpired)))
+
+		if err = oS.appendStatusEvent(ctx, tx, order.ID, order.Status, models.OrderStatusExpired, change.Reason); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "append status event failed")
+			return 0, err
+		}
+
+		if err = oS.afterStatusChange(ctx, tx, order.ID, order.Status, models.OrderStatusExpired, change.Reason); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "status follow-up failed")
+			return 0, err
+		}
+	}
+	if err = oS.historyRepo.Create(ctx, tx, history...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "create status history failed")
+		return 0, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		return 0, err
+	}
+
+	span.SetAttributes(attribute.Int("expired", len(expired)))
+	return int64(len(expired)), nil
+}
+
+// ReconcileStuckOrders re-queues the payment request of pending orders whose outbox row failed,
+// ran out of attempts or is missing altogether.
+func (oS *OrderService) ReconcileStuckOrders(ctx context.Context, createdBefore time.Time, limit int) (int64, error) {
+	log := logger.WithTag("OrderService|ReconcileStuckOrders")
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "OrderService.ReconcileStuckOrders")
+	defer span.End()
+
+	orders, err := oS.repo.ListStuckPendingOrders(ctx, createdBefore, limit)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "list stuck orders failed")
+		return 0, err
+	}
+
+	var reconciled int64
+	for _, order := range orders {
+		requeued, err := oS.outboxRepo.Requeue(ctx, nil, order.ID, events.EventPaymentRequired.String())
+		if err != nil {
+			logger.LogError(log, err, "failed to requeue payment outbox for order "+order.ID.String())
+			continue
+		}
+
+		if requeued == 0 {
+			outbox := newPaymentOutbox(order.ID, order.CustomerID, order.TotalAmount, order.Currency, order.Status)
+			if err = oS.outboxRepo.CreateOutbox(ctx, nil, outbox); err != nil {
+				logger.LogError(log, err, "failed to recreate payment outbox for order "+order.ID.String())
+				continue
+			}
+		}
+		reconciled++
+	}
+
+	span.SetAttributes(attribute.Int64("reconciled", reconciled))
+	return reconciled, nil
+}
+
+// createOrderEventSourced opens the order stream and projects it into the orders tables
+func (oS *OrderService) createOrderEventSourced(
+	ctx context.Context,
+	tx *gorm.DB,
+	orderRequest *models.CreateOrderRequest,
+) (*models.CreateOrderResponse, error) {
+	agg := eventsourcing.NewOrderAggregate(uuid.New())
+
+	changes := []eventsourcing.Change{{
+		Type: events.EventOrderCreated,
+		Payload: eventsourcing.OrderCreated{
+			CustomerID:     orderRequest.CustomerID,
+			Status:         orderRequest.Status,
+			TotalAmount:    orderRequest.TotalAmount,
+			DiscountAmount: orderRequest.Discount,
+			TaxAmount:      orderRequest.TaxAmount,
+			TaxCountry:     orderRequest.Country,
+			TaxRegion:      orderRequest.Region,
+			Currency:       orderRequest.Currency,
+			FxRate:         orderRequest.FxRate,
+			Customer:       orderRequest.Customer,
+			Shipping:       orderRequest.Shipping,
+			Billing:        orderRequest.Billing,
+			PlacedAt:       orderRequest.PlacedAt,
+		},
+	}}
+	var taxLines []models.TaxLine
+	for _, item := range orderRequest.OrderItems {
+		changes = append(changes, eventsourcing.Change{
+			Type: events.EventOrderItemAdded,
+			Payload: eventsourcing.ItemAdded{
+				ItemID:    uuid.New(),
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				UnitPrice: item.UniquePrice,
+				TaxClass:  item.TaxClass,
+				TaxRate:   item.TaxRate,
+				TaxAmount: item.TaxAmount,
+			},
+		})
+		if orderRequest.Country != "" {
+			taxLines = append(taxLines, models.TaxLine{
+				ProductID: item.ProductID,
+				TaxClass:  item.TaxClass,
+				Rate:      item.TaxRate,
+				Amount:    item.TaxAmount,
+			})
+		}
+	}
+
+	if _, err := oS.eventStore.Save(ctx, tx, agg, changes...); err != nil {
+		return nil, err
+	}
+	if err := oS.projector.Project(ctx, tx, agg); err != nil {
+		return nil, err
+	}
+
+	return &models.CreateOrderResponse{
+		Meta: utils.NewMetaData(ctx),
+		Data: models.CreateOrderResponseData{
+			OrderID:     agg.ID,
+			CustomerID:  agg.CustomerID,
+			TotalAmount: agg.TotalAmount,
+			TaxAmount:   agg.TaxAmount,
+			TaxLines:    taxLines,
+			Currency:    agg.Currency,
+			FxRate:      agg.FxRate,
+			Status:      agg.Status,
+		},
+	}, nil
+}
+
+// appendStatusEvent records a status transition in the order stream when event sourcing is enabled.
+// Orders created before it was enabled get their genesis events written from the current row first.
+func (oS *OrderService) appendStatusEvent(
+	ctx context.Context,
+	tx *gorm.DB,
+	orderID uuid.UUID,
+	from string,
+	to models.OrderStatus,
+	reason string,
+) error {
+	if oS.eventStore == nil {
+		return nil
+	}
+
+	var order models.Order
+	if err := tx.WithContext(ctx).Preload("OrderItems").Where("id = ?", orderID).First(&order).Error; err != nil {
+		return err
+	}
+	order.Status = from
+
+	agg, err := oS.loadOrderStream(ctx, tx, &order)
+	if err != nil {
+		return err
+	}
+
+	if _, err = oS.eventStore.Save(ctx, tx, agg, eventsourcing.Change{
+		Type:    eventsourcing.StatusEventType(to),
+		Payload: eventsourcing.StatusChanged{From: from, To: string(to), Reason: reason},
+	}); err != nil {
+		return err
+	}
+	return oS.projector.Project(ctx, tx, agg)
+}
+
+// loadOrderStream loads the stream of order. Orders created before event sourcing was enabled
+// get their genesis events written from order first, which must hold the state before the change.
+func (oS *OrderService) loadOrderStream(ctx context.Context, tx *gorm.DB, order *models.Order) (*eventsourcing.OrderAggregate, error) {
+	agg, err := oS.eventStore.Load(ctx, tx, order.ID)
+	if stdErrors.Is(err, eventsourcing.ErrStreamNotFound) {
+		agg = eventsourcing.NewOrderAggregate(order.ID)
+		if _, err = oS.eventStore.Save(ctx, tx, agg, eventsourcing.FromOrder(order)...); err != nil {
+			return nil, err
+		}
+		return agg, nil
+	}
+	return agg, err
+}
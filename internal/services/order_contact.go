@@ -0,0 +1,184 @@
+package services
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"net/mail"
+	"order/internal/models"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidAddress = stdErrors.New("invalid address")
+	ErrInvalidContact = stdErrors.New("invalid customer contact")
+)
+
+// postalCodePatterns are the postal code formats checked per country. Countries that are
+// not listed accept any short alphanumeric code.
+var postalCodePatterns = map[string]*regexp.Regexp{
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"BR": regexp.MustCompile(`^\d{5}-?\d{3}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"ID": regexp.MustCompile(`^\d{5}$`),
+	"SG": regexp.MustCompile(`^\d{6}$`),
+	"VN": regexp.MustCompile(`^\d{6}$`),
+}
+
+// countriesWithoutPostalCode do not use postal codes, so an empty one is accepted
+var countriesWithoutPostalCode = map[string]bool{
+	"AE": true, "HK": true, "IE": true, "QA": true,
+}
+
+// column lengths of the customer snapshot and address columns, in characters
+const (
+	maxNameLength       = 200
+	maxEmailLength      = 254
+	maxPhoneLength      = 30
+	maxLineLength       = 200
+	maxCityLength       = 100
+	maxRegionLength     = 100
+	maxPostalCodeLength = 20
+)
+
+var (
+	countryPattern     = regexp.MustCompile(`^[A-Z]{2}$`)
+	postalCodeFallback = regexp.MustCompile(`^[A-Z\d][A-Z\d -]{1,9}$`)
+	phonePattern       = regexp.MustCompile(`^\+?[\d ()-]{7,20}$`)
+)
+
+// normalizeOrderContact validates the customer snapshot and the addresses of a new order and
+// brings them into their stored form. The billing address defaults to the shipping address,
+// and the order is taxed where it is shipped unless the request names a tax country.
+func normalizeOrderContact(orderRequest *models.CreateOrderRequest) error {
+	if !orderRequest.Customer.IsZero() {
+		customer, err := normalizeCustomer(orderRequest.Customer)
+		if err != nil {
+			return err
+		}
+		orderRequest.Customer = customer
+	}
+
+	if !orderRequest.Shipping.IsZero() {
+		shipping, err := normalizeAddress("shipping", orderRequest.Shipping)
+		if err != nil {
+			return err
+		}
+		orderRequest.Shipping = shipping
+	}
+
+	if orderRequest.Billing.IsZero() {
+		orderRequest.Billing = orderRequest.Shipping
+	} else {
+		billing, err := normalizeAddress("billing", orderRequest.Billing)
+		if err != nil {
+			return err
+		}
+		orderRequest.Billing = billing
+	}
+
+	if orderRequest.Country == "" && !orderRequest.Shipping.IsZero() {
+		orderRequest.Country = orderRequest.Shipping.Country
+		orderRequest.Region = orderRequest.Shipping.Region
+	}
+	return nil
+}
+
+func normalizeCustomer(customer models.CustomerSnapshot) (models.CustomerSnapshot, error) {
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.Email = strings.TrimSpace(customer.Email)
+	customer.Phone = strings.TrimSpace(customer.Phone)
+
+	if customer.Name == "" {
+		return customer, fmt.Errorf("%w: name is required", ErrInvalidContact)
+	}
+	if field, max, ok := firstTooLong(
+		lengthCheck{"name", customer.Name, maxNameLength},
+		lengthCheck{"email", customer.Email, maxEmailLength},
+		lengthCheck{"phone", customer.Phone, maxPhoneLength},
+	); ok {
+		return customer, fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidContact, field, max)
+	}
+	if customer.Email == "" {
+		return customer, fmt.Errorf("%w: email is required", ErrInvalidContact)
+	}
+	if addr, err := mail.ParseAddress(customer.Email); err != nil || addr.Address != customer.Email {
+		return customer, fmt.Errorf("%w: email %q is not valid", ErrInvalidContact, customer.Email)
+	}
+	if customer.Phone != "" && !phonePattern.MatchString(customer.Phone) {
+		return customer, fmt.Errorf("%w: phone %q is not valid", ErrInvalidContact, customer.Phone)
+	}
+	return customer, nil
+}
+
+func normalizeAddress(kind string, address models.Address) (models.Address, error) {
+	address.Name = strings.TrimSpace(address.Name)
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.Line2 = strings.TrimSpace(address.Line2)
+	address.City = strings.TrimSpace(address.City)
+	address.Region = strings.TrimSpace(address.Region)
+	address.PostalCode = strings.ToUpper(strings.TrimSpace(address.PostalCode))
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+
+	if field, max, ok := firstTooLong(
+		lengthCheck{"name", address.Name, maxNameLength},
+		lengthCheck{"line1", address.Line1, maxLineLength},
+		lengthCheck{"line2", address.Line2, maxLineLength},
+		lengthCheck{"city", address.City, maxCityLength},
+		lengthCheck{"region", address.Region, maxRegionLength},
+		lengthCheck{"postal code", address.PostalCode, maxPostalCodeLength},
+	); ok {
+		return address, fmt.Errorf("%w: %s %s is longer than %d characters", ErrInvalidAddress, kind, field, max)
+	}
+
+	switch {
+	case address.Line1 == "":
+		return address, fmt.Errorf("%w: %s line1 is required", ErrInvalidAddress, kind)
+	case address.City == "":
+		return address, fmt.Errorf("%w: %s city is required", ErrInvalidAddress, kind)
+	case !countryPattern.MatchString(address.Country):
+		return address, fmt.Errorf("%w: %s country %q is not an ISO 3166-1 alpha-2 code", ErrInvalidAddress, kind, address.Country)
+	}
+
+	if address.PostalCode == "" {
+		if countriesWithoutPostalCode[address.Country] {
+			return address, nil
+		}
+		return address, fmt.Errorf("%w: %s postal code is required in %s", ErrInvalidAddress, kind, address.Country)
+	}
+
+	pattern, ok := postalCodePatterns[address.Country]
+	if !ok {
+		pattern = postalCodeFallback
+	}
+	if !pattern.MatchString(address.PostalCode) {
+		return address, fmt.Errorf("%w: %s postal code %q is not valid in %s", ErrInvalidAddress, kind, address.PostalCode, address.Country)
+	}
+	return address, nil
+}
+
+type lengthCheck struct {
+	field string
+	value string
+	max   int
+}
+
+// firstTooLong returns the first field whose value does not fit its column
+func firstTooLong(checks ...lengthCheck) (string, int, bool) {
+	for _, check := range checks {
+		if utf8.RuneCountInString(check.value) > check.max {
+			return check.field, check.max, true
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"order/internal/models"
+)
+
+func TestNormalizeAddressPostalCodes(t *testing.T) {
+	tests := []struct {
+		country string
+		valid   []string
+		invalid []string
+	}{
+		{country: "US", valid: []string{"94105", "94105-1234"}, invalid: []string{"9410", "94105-12", "ABCDE"}},
+		{country: "CA", valid: []string{"K1A 0B1", "k1a0b1"}, invalid: []string{"K1A 0B", "123 456"}},
+		{country: "GB", valid: []string{"SW1A 1AA", "M1 1AE", "b338th"}, invalid: []string{"SW1A", "12345"}},
+		{country: "DE", valid: []string{"10115"}, invalid: []string{"1011", "101155"}},
+		{country: "FR", valid: []string{"75008"}, invalid: []string{"7500"}},
+		{country: "ES", valid: []string{"28013"}, invalid: []string{"2801A"}},
+		{country: "IT", valid: []string{"00184"}, invalid: []string{"184"}},
+		{country: "NL", valid: []string{"1012 JS", "1012js"}, invalid: []string{"1012", "JS 1012"}},
+		{country: "BR", valid: []string{"01310-100", "01310100"}, invalid: []string{"0131-0100"}},
+		{country: "JP", valid: []string{"100-0001", "1000001"}, invalid: []string{"10-00001"}},
+		{country: "AU", valid: []string{"2000"}, invalid: []string{"200", "20000"}},
+		{country: "IN", valid: []string{"110001"}, invalid: []string{"11000"}},
+		{country: "ID", valid: []string{"10110"}, invalid: []string{"1011"}},
+		{country: "SG", valid: []string{"018956"}, invalid: []string{"18956"}},
+		{country: "VN", valid: []string{"100000"}, invalid: []string{"10000"}},
+		{country: "SE", valid: []string{"114 55", "11455"}, invalid: []string{"1", "#11455"}},
+		{country: "IE", valid: []string{"", "D02 X285"}},
+		{country: "AE", valid: []string{""}},
+		{country: "HK", valid: []string{""}},
+		{country: "QA", valid: []string{""}},
+		{country: "PL", invalid: []string{""}},
+	}
+	for _, tt := range tests {
+		for _, code := range tt.valid {
+			t.Run(tt.country+" accepts "+code, func(t *testing.T) {
+				address := models.Address{Line1: "1 Main St", City: "Town", Country: tt.country, PostalCode: code}
+				got, err := normalizeAddress("shipping", address)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.PostalCode != strings.ToUpper(code) {
+					t.Errorf("postal code = %q, want %q", got.PostalCode, strings.ToUpper(code))
+				}
+			})
+		}
+		for _, code := range tt.invalid {
+			t.Run(tt.country+" rejects "+code, func(t *testing.T) {
+				address := models.Address{Line1: "1 Main St", City: "Town", Country: tt.country, PostalCode: code}
+				if _, err := normalizeAddress("shipping", address); !errors.Is(err, ErrInvalidAddress) {
+					t.Errorf("error = %v, want %v", err, ErrInvalidAddress)
+				}
+			})
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	valid := models.Address{Name: "Ada", Line1: "1 Main St", City: "Berlin", Country: "DE", PostalCode: "10115"}
+	with := func(change func(a *models.Address)) models.Address {
+		a := valid
+		change(&a)
+		return a
+	}
+
+	tests := []struct {
+		name    string
+		address models.Address
+		want    models.Address
+		wantErr bool
+	}{
+		{
+			name:    "trimmed and upper cased",
+			address: models.Address{Name: " Ada ", Line1: " 1 Main St ", City: " Berlin ", Region: " BE ", Country: " de ", PostalCode: " 10115 "},
+			want:    models.Address{Name: "Ada", Line1: "1 Main St", City: "Berlin", Region: "BE", Country: "DE", PostalCode: "10115"},
+		},
+		{name: "line1 missing", address: with(func(a *models.Address) { a.Line1 = " " }), wantErr: true},
+		{name: "city missing", address: with(func(a *models.Address) { a.City = "" }), wantErr: true},
+		{name: "country not alpha-2", address: with(func(a *models.Address) { a.Country = "DEU" }), wantErr: true},
+		{name: "name at its column length", address: with(func(a *models.Address) { a.Name = strings.Repeat("ä", maxNameLength) }),
+			want: with(func(a *models.Address) { a.Name = strings.Repeat("ä", maxNameLength) })},
+		{name: "name too long", address: with(func(a *models.Address) { a.Name = strings.Repeat("a", maxNameLength+1) }), wantErr: true},
+		{name: "line1 too long", address: with(func(a *models.Address) { a.Line1 = strings.Repeat("a", maxLineLength+1) }), wantErr: true},
+		{name: "line2 too long", address: with(func(a *models.Address) { a.Line2 = strings.Repeat("a", maxLineLength+1) }), wantErr: true},
+		{name: "city too long", address: with(func(a *models.Address) { a.City = strings.Repeat("a", maxCityLength+1) }), wantErr: true},
+		{name: "region too long", address: with(func(a *models.Address) { a.Region = strings.Repeat("a", maxRegionLength+1) }), wantErr: true},
+		{name: "postal code too long", address: with(func(a *models.Address) { a.PostalCode = strings.Repeat("1", maxPostalCodeLength+1) }), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeAddress("billing", tt.address)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAddress) {
+					t.Errorf("error = %v, want %v", err, ErrInvalidAddress)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("address = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCustomer(t *testing.T) {
+	tests := []struct {
+		name     string
+		customer models.CustomerSnapshot
+		want     models.CustomerSnapshot
+		wantErr  bool
+	}{
+		{
+			name:     "trimmed",
+			customer: models.CustomerSnapshot{Name: " Ada ", Email: " ada@example.com ", Phone: " +49 30 1234567 "},
+			want:     models.CustomerSnapshot{Name: "Ada", Email: "ada@example.com", Phone: "+49 30 1234567"},
+		},
+		{name: "without phone", customer: models.CustomerSnapshot{Name: "Ada", Email: "ada@example.com"},
+			want: models.CustomerSnapshot{Name: "Ada", Email: "ada@example.com"}},
+		{name: "name missing", customer: models.CustomerSnapshot{Email: "ada@example.com"}, wantErr: true},
+		{name: "email missing", customer: models.CustomerSnapshot{Name: "Ada"}, wantErr: true},
+		{name: "email with display name", customer: models.CustomerSnapshot{Name: "Ada", Email: "Ada <ada@example.com>"}, wantErr: true},
+		{name: "email malformed", customer: models.CustomerSnapshot{Name: "Ada", Email: "ada.example.com"}, wantErr: true},
+		{name: "phone malformed", customer: models.CustomerSnapshot{Name: "Ada", Email: "ada@example.com", Phone: "call me"}, wantErr: true},
+		{name: "name too long", customer: models.CustomerSnapshot{Name: strings.Repeat("a", maxNameLength+1), Email: "ada@example.com"}, wantErr: true},
+		{name: "email too long", customer: models.CustomerSnapshot{Name: "Ada", Email: strings.Repeat("a", maxEmailLength) + "@example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeCustomer(tt.customer)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidContact) {
+					t.Errorf("error = %v, want %v", err, ErrInvalidContact)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("customer = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOrderContact(t *testing.T) {
+	shipping := models.Address{Line1: "1 Main St", City: "San Francisco", Region: "CA", Country: "us", PostalCode: "94105"}
+	billing := models.Address{Line1: "2 Side St", City: "Berlin", Country: "DE", PostalCode: "10115"}
+
+	t.Run("billing and tax location default to shipping", func(t *testing.T) {
+		req := &models.CreateOrderRequest{Shipping: shipping}
+		if err := normalizeOrderContact(req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Billing != req.Shipping || req.Shipping.Country != "US" {
+			t.Errorf("billing = %+v, want the shipping address %+v", req.Billing, req.Shipping)
+		}
+		if req.Country != "US" || req.Region != "CA" {
+			t.Errorf("tax location = %s/%s, want US/CA", req.Country, req.Region)
+		}
+	})
+
+	t.Run("named billing and tax country are kept", func(t *testing.T) {
+		req := &models.CreateOrderRequest{Shipping: shipping, Billing: billing, Country: "GB"}
+		if err := normalizeOrderContact(req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Billing.City != "Berlin" || req.Country != "GB" {
+			t.Errorf("billing %+v, tax country %s, want Berlin and GB", req.Billing, req.Country)
+		}
+	})
+
+	t.Run("invalid billing is rejected", func(t *testing.T) {
+		req := &models.CreateOrderRequest{Shipping: shipping, Billing: models.Address{City: "Berlin", Country: "DE"}}
+		if err := normalizeOrderContact(req); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("error = %v, want %v", err, ErrInvalidAddress)
+		}
+	})
+
+	t.Run("invalid customer is rejected", func(t *testing.T) {
+		req := &models.CreateOrderRequest{Customer: models.CustomerSnapshot{Name: strings.Repeat("a", maxNameLength+1), Email: "ada@example.com"}}
+		if err := normalizeOrderContact(req); !errors.Is(err, ErrInvalidContact) {
+			t.Errorf("error = %v, want %v", err, ErrInvalidContact)
+		}
+	})
+
+	t.Run("orders without contact stay untaxed", func(t *testing.T) {
+		req := &models.CreateOrderRequest{}
+		if err := normalizeOrderContact(req); err != nil {
+			t.Fatal(err)
+		}
+		if !req.Billing.IsZero() || req.Country != "" {
+			t.Errorf("billing %+v, tax country %q, want none", req.Billing, req.Country)
+		}
+	})
+}
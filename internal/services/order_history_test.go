@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+)
+
+// transition is the part of a history row a test can predict
+type transition struct {
+	from, to  string
+	actor     models.ActorType
+	actorID   string
+	source    models.ChangeSource
+	sourceRef string
+	reason    string
+}
+
+func assertTransitions(t *testing.T, history []models.OrderStatusHistory, want []transition) {
+	t.Helper()
+	if len(history) != len(want) {
+		t.Fatalf("%d history rows %+v, want %d", len(history), history, len(want))
+	}
+	for i, row := range history {
+		got := transition{
+			from: row.FromStatus, to: row.ToStatus, actor: row.ActorType, actorID: row.ActorID,
+			source: row.Source, sourceRef: row.SourceRef, reason: row.Reason,
+		}
+		if got != want[i] {
+			t.Errorf("history row %d = %+v, want %+v", i, got, want[i])
+		}
+		if row.TenantID == "" || row.ChangedAt.IsZero() {
+			t.Errorf("history row %d misses its tenant or time: %+v", i, row)
+		}
+	}
+}
+
+func TestOrderHistoryRecordsEachTransition(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	req := testOrderRequest()
+	req.Audit = models.StatusChange{
+		ActorType: models.ActorTypeUser,
+		ActorID:   "customer-7",
+		Source:    models.ChangeSourceGRPC,
+		SourceRef: "/order.OrderService/CreateOrder",
+	}
+	created, err := oS.CreateOrder(ctx, req)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	paid := models.StatusChange{
+		ActorType: models.ActorTypeWorker,
+		ActorID:   "payment_event_worker",
+		Source:    models.ChangeSourceKafka,
+		SourceRef: "payments/3@42",
+		Reason:    "payment pay-1 authorized",
+	}
+	result := models.PaymentAuthorizedEvent{PaymentID: "pay-1", OrderID: orderID.String(), Amount: created.Data.TotalAmount, Status: events.PaymentAuthorized}
+	if err = oS.ApplyPaymentResult(ctx, orderID, result, models.OrderStatusAuthorized, paid); err != nil {
+		t.Fatalf("ApplyPaymentResult: %v", err)
+	}
+
+	cancelled := models.StatusChange{
+		ActorType: models.ActorTypeUser,
+		ActorID:   "support-1",
+		Source:    models.ChangeSourceHTTP,
+		SourceRef: "/api/v1/orders/:id/status",
+		Reason:    "customer asked",
+	}
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusCancelled, cancelled); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	history, err := oS.GetOrderHistory(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTransitions(t, history, []transition{
+		{to: created.Data.Status, actor: models.ActorTypeUser, actorID: "customer-7", source: models.ChangeSourceGRPC, sourceRef: "/order.OrderService/CreateOrder"},
+		{from: created.Data.Status, to: "AUTHORIZED", actor: models.ActorTypeWorker, actorID: "payment_event_worker",
+			source: models.ChangeSourceKafka, sourceRef: "payments/3@42", reason: "payment pay-1 authorized"},
+		{from: "AUTHORIZED", to: "CANCELLED", actor: models.ActorTypeUser, actorID: "support-1",
+			source: models.ChangeSourceHTTP, sourceRef: "/api/v1/orders/:id/status", reason: "customer asked"},
+	})
+}
+
+func TestOrderHistoryRecordsExpiry(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	cutoff := time.Now().Add(time.Minute)
+	if expired, err := oS.ExpireUnpaidOrders(ctx, cutoff); err != nil || expired != 1 {
+		t.Fatalf("ExpireUnpaidOrders = %d, %v; want 1 order", expired, err)
+	}
+
+	history, err := oS.GetOrderHistory(ctx, created.Data.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTransitions(t, history, []transition{
+		{to: created.Data.Status, actor: models.ActorTypeSystem, source: models.ChangeSourceGRPC},
+		{from: created.Data.Status, to: "EXPIRED", actor: models.ActorTypeSystem, source: models.ChangeSourceScheduler,
+			sourceRef: "expire_unpaid_orders", reason: "payment not received before " + cutoff.Format(time.RFC3339)},
+	})
+}
+
+func TestOrderHistoryKeepsOnlyCommittedTransitions(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	historyRepo := repo.NewOrderStatusHistoryRepository(pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	t.Run("rejected transition", func(t *testing.T) {
+		err := oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusCompleted, testStatusChange("skip the payment"))
+		if !errors.Is(err, repo.ErrInvalidStatusTransition) {
+			t.Fatalf("err = %v, want %v", err, repo.ErrInvalidStatusTransition)
+		}
+	})
+
+	t.Run("transaction rolled back", func(t *testing.T) {
+		tx := pg.GetRepo().WithContext(ctx).Begin()
+		if err := oS.UpdateOrderStatusInTx(ctx, tx, orderID, models.OrderStatusCancelled, testStatusChange("changed my mind")); err != nil {
+			tx.Rollback()
+			t.Fatal(err)
+		}
+		if err := tx.Rollback().Error; err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	history, err := historyRepo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].FromStatus != "" {
+		t.Errorf("history = %+v, want only the creation", history)
+	}
+}
+
+func TestGetOrderHistoryOfUnknownOrder(t *testing.T) {
+	pg := pgtest.Open(t)
+	oS, _ := testOrderService(t, pg)
+
+	if _, err := oS.GetOrderHistory(pgtest.Context(), uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("err = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
+
+func TestOrderStatusHistoryRepositoryListsOldestFirst(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	historyRepo := repo.NewOrderStatusHistoryRepository(pg)
+	orderID := uuid.New()
+
+	change := testStatusChange("")
+	later := change.NewHistory(orderID, "PENDING", "AUTHORIZED")
+	earlier := change.NewHistory(orderID, "", "PENDING")
+	earlier.ChangedAt = later.ChangedAt.Add(-time.Minute)
+	other := change.NewHistory(uuid.New(), "", "PENDING")
+	if err := historyRepo.Create(ctx, nil, later, earlier, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := historyRepo.Create(ctx, nil); err != nil {
+		t.Errorf("Create() of no rows: %v", err)
+	}
+
+	history, err := historyRepo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].ToStatus != "PENDING" || history[1].ToStatus != "AUTHORIZED" {
+		t.Errorf("history = %+v, want the two rows of the order oldest first", history)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"io"
+	"order/internal/importer"
+	"order/internal/models"
+	"order/pkg/core/logger"
+)
+
+const (
+	defaultImportBatchSize = 100
+	importSavepoint        = "import_order"
+)
+
+// ImportOptions controls how imported orders are created
+type ImportOptions struct {
+	// Historical stores the orders as they were placed elsewhere, dated by their placed_at:
+	// no stock is reserved and no checkout saga or payment request is started
+	Historical bool
+	// BatchSize is the number of orders committed per transaction
+	BatchSize int
+	// Audit is recorded as the origin of the first status of every order
+	Audit models.StatusChange
+}
+
+// ImportOrders creates the orders read from reader in batches, one transaction per batch.
+// Rows that are invalid or fail to insert are reported and skipped; the rest of their batch
+// is still committed. An error is only returned when the file or the database cannot be read on.
+func (oS *OrderService) ImportOrders(ctx context.Context, reader importer.OrderReader, opts ImportOptions) (*models.ImportReport, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "OrderService.ImportOrders",
+		trace.WithAttributes(attribute.Bool("historical", opts.Historical)))
+	defer span.End()
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultImportBatchSize
+	}
+
+	report := &models.ImportReport{Orders: []models.ImportedOrder{}, Errors: []models.ImportRowError{}}
+	for done := false; !done; {
+		batch := make([]*importer.Record, 0, opts.BatchSize)
+		for len(batch) < opts.BatchSize {
+			record, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "read import failed")
+				return report, err
+			}
+			report.Total++
+			if record.Err != nil {
+				report.Failed++
+				report.Errors = append(report.Errors, models.ImportRowError{Row: record.Row, Ref: record.Ref, Error: record.Err.Error()})
+				continue
+			}
+			batch = append(batch, record)
+		}
+
+		if len(batch) > 0 {
+			if err := oS.importBatch(ctx, batch, opts, report); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "import batch failed")
+				return report, err
+			}
+		}
+	}
+
+	span.SetAttributes(attribute.Int("imported", report.Imported), attribute.Int("failed", report.Failed))
+	span.SetStatus(codes.Ok, "imported")
+	return report, nil
+}
+
+// importBatch creates the orders of batch in one transaction, each behind a savepoint so a
+// failing order only rolls back itself
+func (oS *OrderService) importBatch(ctx context.Context, batch []*importer.Record, opts ImportOptions, report *models.ImportReport) error {
+	log := logger.WithTag("OrderService|importBatch")
+
+	// stock reserved for an order that is not committed is given back
+	ctx, reserved := trackReservations(ctx)
+	committed := false
+	defer func() { reserved.settle(ctx, committed) }()
+
+	tx := oS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	var (
+		imported []models.ImportedOrder
+		failed   []models.ImportRowError
+	)
+	for _, record := range batch {
+		if err := tx.SavePoint(importSavepoint).Error; err != nil {
+			return err
+		}
+		rowCtx, rowReserved := trackReservations(ctx)
+		resp, err := oS.importOrder(rowCtx, tx, record, opts)
+		rowReserved.settle(ctx, err == nil)
+		if err != nil {
+			if rbErr := tx.RollbackTo(importSavepoint).Error; rbErr != nil {
+				return rbErr
+			}
+			failed = append(failed, models.ImportRowError{Row: record.Row, Ref: record.Ref, Error: err.Error()})
+			continue
+		}
+		imported = append(imported, models.ImportedOrder{Row: record.Row, Ref: record.Ref, OrderID: resp.Data.OrderID})
+	}
+
+	err := tx.Commit().Error
+	committed = err == nil
+	if err != nil {
+		// nothing of the batch was written
+		logger.LogError(log, err, "failed to commit import batch")
+		for _, order := range imported {
+			failed = append(failed, models.ImportRowError{Row: order.Row, Ref: order.Ref, Error: "commit failed: " + err.Error()})
+		}
+		imported = nil
+	}
+
+	report.Imported += len(imported)
+	report.Failed += len(failed)
+	report.Orders = append(report.Orders, imported...)
+	report.Errors = append(report.Errors, failed...)
+	return nil
+}
+
+func (oS *OrderService) importOrder(ctx context.Context, tx *gorm.DB, record *importer.Record, opts ImportOptions) (*models.CreateOrderResponse, error) {
+	req := record.Request
+	req.Audit = opts.Audit
+	if !opts.Historical {
+		// a live order starts now; backdating it would expire it before it can be paid
+		return oS.CreateOrderInTx(ctx, tx, req)
+	}
+	req.PlacedAt = record.PlacedAt
+	return oS.createOrderRecord(ctx, tx, &req)
+}
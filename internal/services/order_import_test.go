@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/importer"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+)
+
+func TestImportSkipsFailingRowsAndCommitsTheRestOfTheirBatch(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	orderRepo := repo.NewOrderRepository(pg)
+
+	line := func(ref, email string) string {
+		return fmt.Sprintf(`{"ref":%q,"placed_at":"2024-05-01T10:00:00Z","customer_id":%q,"status":"completed","total_amount":10,`+
+			`"customer":{"name":"Ann","email":%q},"order_items":[{"product_id":%q,"quantity":1,"price":10}]}`,
+			ref, uuid.New(), email, uuid.New())
+	}
+	file := strings.Join([]string{
+		line("A-1", "ann@example.com"),
+		line("A-2", "not an address"),
+		"{not json",
+		line("A-3", "ann@example.com"),
+	}, "\n")
+	reader, err := importer.NewOrderReader(strings.NewReader(file), importer.FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := oS.ImportOrders(ctx, reader, ImportOptions{Historical: true, BatchSize: 2, Audit: testStatusChange("import")})
+	if err != nil {
+		t.Fatalf("ImportOrders: %v", err)
+	}
+	if report.Total != 4 || report.Imported != 2 || report.Failed != 2 {
+		t.Fatalf("report = %d read, %d imported, %d failed; want 4, 2, 2", report.Total, report.Imported, report.Failed)
+	}
+	failed := map[string]bool{}
+	for _, rowErr := range report.Errors {
+		failed[fmt.Sprintf("%d %s", rowErr.Row, rowErr.Ref)] = true
+	}
+	// the order failing at insert only rolls back itself, the line that cannot be read has no ref
+	if !failed["2 A-2"] || !failed["3 "] {
+		t.Errorf("errors = %+v, want rows 2 (A-2) and 3", report.Errors)
+	}
+
+	placedAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	for _, imported := range report.Orders {
+		order, err := orderRepo.GetByID(ctx, imported.OrderID)
+		if err != nil {
+			t.Fatalf("imported order %s: %v", imported.Ref, err)
+		}
+		// historical orders keep the date they were placed on elsewhere
+		if !order.CreatedAt.Equal(placedAt) || order.Customer.Email != "ann@example.com" {
+			t.Errorf("order %s created %v for %q, want %v for ann@example.com", imported.Ref, order.CreatedAt, order.Customer.Email, placedAt)
+		}
+	}
+}
+
+func TestLiveImportsStartNow(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	file := fmt.Sprintf("ref,placed_at,customer_id,status,total_amount,product_id,quantity,price\n"+
+		"A-1,2024-05-01T10:00:00Z,%s,pending,30,%s,2,10\n"+
+		"A-1,,,,,%s,1,10\n", uuid.New(), uuid.New(), uuid.New())
+	reader, err := importer.NewOrderReader(strings.NewReader(file), importer.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now().Add(-time.Minute)
+	report, err := oS.ImportOrders(ctx, reader, ImportOptions{Audit: testStatusChange("import")})
+	if err != nil || report.Imported != 1 {
+		t.Fatalf("ImportOrders = %+v, %v; want one order imported", report, err)
+	}
+	order, err := repo.NewOrderRepository(pg).GetByID(ctx, report.Orders[0].OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a backdated live order would expire before it can be paid
+	if order.CreatedAt.Before(started) || order.Status != string(models.OrderStatusPending) || len(order.OrderItems) != 2 {
+		t.Errorf("order = %s created %v with %d items, want %s created now with 2 items",
+			order.Status, order.CreatedAt, len(order.OrderItems), models.OrderStatusPending)
+	}
+}
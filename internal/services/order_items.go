@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	stdErrors "errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/eventsourcing"
+	"order/internal/models"
+	"order/internal/tax"
+	"order/pkg/core/logger"
+	"slices"
+	"time"
+)
+
+var (
+	ErrOrderNotModifiable = stdErrors.New("order is no longer pending payment")
+	ErrOrderItemNotFound  = stdErrors.New("order item not found")
+	ErrOrderLastItem      = stdErrors.New("order must keep at least one item")
+)
+
+// itemChange is one modification of an order's line items, both as stream event and as direct write
+type itemChange struct {
+	event   eventsourcing.Change
+	persist func(tx *gorm.DB) error
+}
+
+// AddOrderItem appends a line item to an order that is still pending payment
+func (oS *OrderService) AddOrderItem(ctx context.Context, orderID uuid.UUID, item models.CreateOrderItemRequest) (*models.Order, error) {
+	return oS.modifyOrderItems(ctx, "AddOrderItem", orderID, func(ctx context.Context, order *models.Order) (*itemChange, error) {
+		row := models.OrderItem{
+			OrderID:   order.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UniquePrice,
+			TaxClass:  item.TaxClass,
+		}
+		if row.TaxClass == "" {
+			row.TaxClass = tax.ClassStandard
+		}
+		row.ID = uuid.New()
+		order.OrderItems = append(order.OrderItems, row)
+
+		return &itemChange{
+			event: eventsourcing.Change{
+				Type: events.EventOrderItemAdded,
+				Payload: eventsourcing.ItemAdded{
+					ItemID:    row.ID,
+					ProductID: row.ProductID,
+					Quantity:  row.Quantity,
+					UnitPrice: row.UnitPrice,
+					TaxClass:  row.TaxClass,
+				},
+			},
+			persist: func(tx *gorm.DB) error {
+				return oS.repo.AddItem(ctx, tx, &row)
+			},
+		}, nil
+	})
+}
+
+// UpdateOrderItem changes the quantity of a line item of an order that is still pending payment
+func (oS *OrderService) UpdateOrderItem(ctx context.Context, orderID, itemID uuid.UUID, quantity int) (*models.Order, error) {
+	return oS.modifyOrderItems(ctx, "UpdateOrderItem", orderID, func(ctx context.Context, order *models.Order) (*itemChange, error) {
+		idx := findOrderItem(order, itemID)
+		if idx < 0 {
+			return nil, ErrOrderItemNotFound
+		}
+		order.OrderItems[idx].Quantity = quantity
+
+		return &itemChange{
+			event: eventsourcing.Change{
+				Type:    events.EventOrderItemUpdated,
+				Payload: eventsourcing.ItemUpdated{ItemID: itemID, Quantity: quantity},
+			},
+			persist: func(tx *gorm.DB) error {
+				return oS.repo.UpdateItemQuantity(ctx, tx, orderID, itemID, quantity)
+			},
+		}, nil
+	})
+}
+
+// RemoveOrderItem drops a line item from an order that is still pending payment.
+// The last item cannot be removed; cancel the order instead.
+func (oS *OrderService) RemoveOrderItem(ctx context.Context, orderID, itemID uuid.UUID) (*models.Order, error) {
+	return oS.modifyOrderItems(ctx, "RemoveOrderItem", orderID, func(ctx context.Context, order *models.Order) (*itemChange, error) {
+		idx := findOrderItem(order, itemID)
+		if idx < 0 {
+			return nil, ErrOrderItemNotFound
+		}
+		if len(order.OrderItems) == 1 {
+			return nil, ErrOrderLastItem
+		}
+		order.OrderItems = append(order.OrderItems[:idx], order.OrderItems[idx+1:]...)
+
+		return &itemChange{
+			event: eventsourcing.Change{
+				Type:    events.EventOrderItemRemoved,
+				Payload: eventsourcing.ItemRemoved{ItemID: itemID},
+			},
+			persist: func(tx *gorm.DB) error {
+				return oS.repo.RemoveItem(ctx, tx, orderID, itemID)
+			},
+		}, nil
+	})
+}
+
+// modifyOrderItems runs one item change under the order row lock: it applies the change,
+// recomputes the total and promotion, and re-issues the payment request for the new total.
+func (oS *OrderService) modifyOrderItems(
+	ctx context.Context,
+	op string,
+	orderID uuid.UUID,
+	mutate func(ctx context.Context, order *models.Order) (*itemChange, error),
+) (*models.Order, error) {
+	log := logger.WithTag("OrderService|" + op)
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "OrderService."+op,
+		trace.WithAttributes(attribute.String("order_id", orderID.String())))
+	defer span.End()
+
+	// stock reserved for an order that is not committed is given back
+	ctx, reserved := trackReservations(ctx)
+	committed := false
+	defer func() { reserved.settle(ctx, committed) }()
+
+	tx := oS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	order, err := oS.repo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !order.IsPendingPayment() {
+		span.SetStatus(codes.Error, "order not modifiable")
+		return nil, ErrOrderNotModifiable
+	}
+
+	// the stream has to be loaded before the change so legacy orders are adopted in their current state
+	var agg *eventsourcing.OrderAggregate
+	if oS.eventStore != nil {
+		if agg, err = oS.loadOrderStream(ctx, tx, order); err != nil {
+			span.RecordError(err)
+			logger.LogError(log, err, "failed to load order stream")
+			return nil, err
+		}
+	}
+
+	previous := slices.Clone(order.OrderItems)
+	change, err := mutate(ctx, order)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// the reservation follows the new line items
+	if err = oS.reserveStock(ctx, orderID, order.OrderItems, previous); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "reserve stock failed")
+		return nil, err
+	}
+
+	if err = oS.applyOrderTax(ctx, order); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to calculate tax")
+		return nil, err
+	}
+
+	order.TotalAmount = order.SumItems()
+	order.PromotionConfigID = oS.matchPromotion(ctx, order)
+	span.SetAttributes(attribute.Float64("total_amount", order.TotalAmount))
+
+	if agg != nil {
+		repriced := eventsourcing.Repriced{
+			TotalAmount:       order.TotalAmount,
+			TaxAmount:         order.TaxAmount,
+			PromotionConfigID: order.PromotionConfigID,
+		}
+		for _, item := range order.OrderItems {
+			repriced.ItemTaxes = append(repriced.ItemTaxes, eventsourcing.ItemTax{
+				ItemID:    item.ID,
+				TaxRate:   item.TaxRate,
+				TaxAmount: item.TaxAmount,
+			})
+		}
+		_, err = oS.eventStore.Save(ctx, tx, agg, change.event, eventsourcing.Change{
+			Type:    events.EventOrderRepriced,
+			Payload: repriced,
+		})
+		if err == nil {
+			err = oS.projector.Project(ctx, tx, agg)
+		}
+	} else if err = change.persist(tx); err == nil {
+		err = oS.repo.UpdatePricing(ctx, tx, order)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "persist order change failed")
+		logger.LogError(log, err, "failed to persist order change")
+		return nil, err
+	}
+
+	// the payment request for the new total replaces the old one, whether or not that one
+	// was delivered already; the order is pending until a payment is authorized
+	if _, err = oS.outboxRepo.Supersede(ctx, tx, orderID, events.EventPaymentRequired.String()); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to supersede payment outbox")
+		return nil, err
+	}
+	outbox := newPaymentOutbox(order.ID, order.CustomerID, order.TotalAmount, order.Currency, order.Status)
+	if err = oS.outboxRepo.CreateOutbox(ctx, tx, outbox); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to create payment outbox")
+		return nil, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		logger.LogError(log, err, "failed to commit tx")
+		return nil, err
+	}
+
+	committed = true
+
+	span.SetStatus(codes.Ok, "order modified")
+	return order, nil
+}
+
+// matchPromotion returns the active promotion the order qualifies for, if any. Promotions are
+// matched on the value of the goods, not on the tax. Rewards are still only granted once the
+// payment is authorized.
+func (oS *OrderService) matchPromotion(ctx context.Context, order *models.Order) *uuid.UUID {
+	if oS.promoRepo == nil {
+		return nil
+	}
+	promo, err := oS.promoRepo.GetActivePromotion(ctx, time.Now())
+	if err != nil || !promo.Qualifies(order.QualifyingAmount(), order.Currency, order.FxRate) {
+		return nil
+	}
+	return &promo.ID
+}
+
+func findOrderItem(order *models.Order, itemID uuid.UUID) int {
+	for i, item := range order.OrderItems {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
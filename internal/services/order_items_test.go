@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/models"
+	"order/internal/pgtest"
+	"order/pkg/proto/paymentpb"
+)
+
+func TestAddOrderItemReissuesDeliveredPaymentRequest(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+
+	paymentRequests := pg.GetRepo().WithContext(ctx).Model(&models.Outbox{}).
+		Where("aggregate_id = ? AND event_type = ?", orderID, events.EventPaymentRequired.String())
+	if err = paymentRequests.Session(&gorm.Session{}).Update("status", models.OutboxStatusDone).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := oS.AddOrderItem(ctx, orderID, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 5})
+	if err != nil {
+		t.Fatalf("AddOrderItem after the payment request was delivered: %v", err)
+	}
+
+	var pending []models.Outbox
+	if err = paymentRequests.Session(&gorm.Session{}).Where("status = ?", models.OutboxStatusPending).Find(&pending).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("%d pending payment requests, want 1", len(pending))
+	}
+	var request paymentpb.PayRequest
+	if err = json.Unmarshal([]byte(pending[0].Payload), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Amount != order.TotalAmount {
+		t.Errorf("payment request amount = %v, want the new total %v", request.Amount, order.TotalAmount)
+	}
+}
+
+func TestAddOrderItemRejectsAuthorizedOrder(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+
+	_, err = oS.AddOrderItem(ctx, orderID, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 5})
+	if !errors.Is(err, ErrOrderNotModifiable) {
+		t.Errorf("AddOrderItem err = %v, want %v", err, ErrOrderNotModifiable)
+	}
+}
+
+func TestApplyPaymentResultVoidsAuthorizationOfTheOldTotal(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+	before, err := oS.repo.GetByID(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := oS.AddOrderItem(ctx, orderID, models.CreateOrderItemRequest{ProductID: uuid.New(), Quantity: 1, UniquePrice: 5})
+	if err != nil {
+		t.Fatalf("AddOrderItem: %v", err)
+	}
+
+	stale := models.PaymentAuthorizedEvent{PaymentID: "pay-old", OrderID: orderID.String(), Amount: before.TotalAmount}
+	err = oS.ApplyPaymentResult(ctx, orderID, stale, models.OrderStatusAuthorized, testStatusChange("paid"))
+	if !errors.Is(err, ErrStalePayment) {
+		t.Fatalf("ApplyPaymentResult of the old total err = %v, want %v", err, ErrStalePayment)
+	}
+	if got, _ := oS.repo.GetByID(ctx, orderID); got.Status != string(models.OrderStatusPending) {
+		t.Errorf("status after the stale authorization = %s, want %s", got.Status, models.OrderStatusPending)
+	}
+	var voids []models.Outbox
+	if err = pg.GetRepo().WithContext(ctx).
+		Where("aggregate_id = ? AND event_type = ?", orderID, events.EventPaymentVoidRequested.String()).
+		Find(&voids).Error; err != nil {
+		t.Fatal(err)
+	}
+	var void models.PaymentVoidEvent
+	if len(voids) != 1 || json.Unmarshal([]byte(voids[0].Payload), &void) != nil || void.PaymentID != "pay-old" {
+		t.Fatalf("void requests = %+v, want one for payment pay-old", voids)
+	}
+
+	current := models.PaymentAuthorizedEvent{PaymentID: "pay-new", OrderID: orderID.String(), Amount: order.TotalAmount}
+	if err = oS.ApplyPaymentResult(ctx, orderID, current, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("ApplyPaymentResult of the new total: %v", err)
+	}
+	if got, _ := oS.repo.GetByID(ctx, orderID); got.Status != string(models.OrderStatusAuthorized) {
+		t.Errorf("status after the current authorization = %s, want %s", got.Status, models.OrderStatusAuthorized)
+	}
+}
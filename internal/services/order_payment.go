@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	stdErrors "errors"
+	"math"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"order/internal/events"
+	"order/internal/models"
+	"order/pkg/core/logger"
+)
+
+// ErrStalePayment is returned for a payment result that answers a payment request the order
+// has since replaced, because its items changed after the request was delivered
+var ErrStalePayment = stdErrors.New("payment does not match the current payment request")
+
+// ApplyPaymentResult moves the order to the status of a payment result. A result whose amount is
+// not the current order total answers a superseded payment request: it leaves the order pending,
+// and an authorization of it is voided so the customer is not charged the old total.
+func (oS *OrderService) ApplyPaymentResult(
+	ctx context.Context,
+	orderID uuid.UUID,
+	result models.PaymentAuthorizedEvent,
+	status models.OrderStatus,
+	change models.StatusChange,
+) error {
+	log := logger.WithTag("OrderService|ApplyPaymentResult")
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "OrderService.ApplyPaymentResult",
+		trace.WithAttributes(attribute.String("order_id", orderID.String()),
+			attribute.String("payment_id", result.PaymentID),
+			attribute.String("status", string(status))))
+	defer span.End()
+
+	tx := oS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// the lock orders this check after any item change that reprices the order
+	order, err := oS.repo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to load order")
+		return err
+	}
+
+	if math.Abs(result.Amount-order.TotalAmount) >= 0.005 {
+		span.RecordError(ErrStalePayment)
+		span.SetStatus(codes.Error, "stale payment")
+		if status != models.OrderStatusAuthorized {
+			return ErrStalePayment
+		}
+		void := newOrderOutbox(orderID, events.EventPaymentVoidRequested, models.PaymentVoidEvent{
+			OrderID:   orderID.String(),
+			PaymentID: result.PaymentID,
+			Reason:    ErrStalePayment.Error(),
+		})
+		if err = oS.outboxRepo.CreateOutbox(ctx, tx, void); err != nil {
+			logger.LogError(log, err, "failed to create payment void outbox")
+			return err
+		}
+		if err = tx.Commit().Error; err != nil {
+			logger.LogError(log, err, "failed to commit tx")
+			return err
+		}
+		return ErrStalePayment
+	}
+
+	if err = oS.UpdateOrderStatusInTx(ctx, tx, orderID, status, change); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		logger.LogError(log, err, "failed to commit tx")
+		return err
+	}
+
+	span.SetStatus(codes.Ok, "applied payment result")
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/events"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+)
+
+func TestExpireUnpaidOrdersWithdrawsPaymentRequests(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	outbox := repo.NewOutboxRepository(pg)
+
+	withSaga, _ := testOrderService(t, pg)
+	legacy, _ := testOrderService(t, pg)
+	legacy.orchestrator = nil
+
+	tests := []struct {
+		name string
+		oS   *OrderService
+	}{
+		{name: "checkout saga", oS: withSaga},
+		{name: "without saga", oS: legacy},
+	}
+	orderIDs := make([]uuid.UUID, len(tests))
+	for i, tt := range tests {
+		created, err := tt.oS.CreateOrder(ctx, testOrderRequest())
+		if err != nil {
+			t.Fatalf("%s: CreateOrder: %v", tt.name, err)
+		}
+		orderIDs[i] = created.Data.OrderID
+	}
+
+	expired, err := withSaga.ExpireUnpaidOrders(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ExpireUnpaidOrders: %v", err)
+	}
+	if expired != int64(len(tests)) {
+		t.Fatalf("expired %d orders, want %d", expired, len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for status, want := range map[models.OutboxStatus]int64{
+				models.OutboxStatusPending:    0,
+				models.OutboxStatusSuperseded: 1,
+			} {
+				got, err := outbox.CountByStatus(ctx, nil, orderIDs[i], events.EventPaymentRequired.String(), status)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%d %s payment requests, want %d", got, status, want)
+				}
+			}
+		})
+	}
+}
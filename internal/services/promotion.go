@@ -5,8 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/metrics"
 	"order/internal/models"
 	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/logger"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -16,30 +23,46 @@ var (
 	ErrCustomerAlreadyReward  = errors.New("customer already received promotion")
 	ErrPromotionCustomerLimit = errors.New("promotion customer limit reached")
 	ErrPromotionTotalExhaust  = errors.New("promotion total rewards exhausted")
+	ErrPromotionBudgetExhaust = errors.New("promotion budget exhausted")
+	ErrOrderNotPaid           = errors.New("order is not paid")
 )
 
 type PromotionService struct {
 	promoRepo  repo.PromotionRepoInterface
 	outboxRepo repo.OutboxRepoInterface
 	orderRepo  repo.OrderRepoInterface
+	newPgRepo  pgGorm.PGInterface
 	nowFunc    func() time.Time
 }
 
-func NewPromotionService(p repo.PromotionRepoInterface, o repo.OutboxRepoInterface, ord repo.OrderRepoInterface) *PromotionService {
+func NewPromotionService(
+	p repo.PromotionRepoInterface,
+	o repo.OutboxRepoInterface,
+	ord repo.OrderRepoInterface,
+	newPgRepo pgGorm.PGInterface,
+) *PromotionService {
 	return &PromotionService{
 		promoRepo:  p,
 		outboxRepo: o,
 		orderRepo:  ord,
+		newPgRepo:  newPgRepo,
 		nowFunc:    time.Now,
 	}
 }
 
 type PromotionServiceInterface interface {
 	HandlePromotion(ctx context.Context, evt models.PromotionRewardEvent) error
+	GetPromotionAnalytics(ctx context.Context, promoID uuid.UUID) (*models.PromotionAnalytics, error)
+	GetRewardTimeline(ctx context.Context, promoID uuid.UUID, req models.PromotionRewardTimelineRequest) ([]models.PromotionRewardBucket, error)
+	RefreshPromotionMetrics(ctx context.Context) error
+	SyncPromotionSchedule(ctx context.Context, since, now time.Time) (int64, error)
+	UpsertThreshold(ctx context.Context, promoID uuid.UUID, req models.UpsertPromotionThresholdRequest) (*models.PromotionThreshold, error)
 }
 
 // HandlePromotion processes a PromotionRewardEvent (sent after payment authorized).
-// It verifies campaign constraints, creates a PromotionReward record and an Outbox entry.
+// It verifies the order is still paid and the campaign constraints, creates a PromotionReward record and an Outbox entry.
+// The promotion row is locked while doing so, so concurrent rewards cannot overrun its
+// limits or budget.
 func (prom *PromotionService) HandlePromotion(ctx context.Context, evt models.PromotionRewardEvent) error {
 	// parse order id
 	orderID, err := uuid.Parse(evt.OrderID)
@@ -47,26 +70,45 @@ func (prom *PromotionService) HandlePromotion(ctx context.Context, evt models.Pr
 		return err
 	}
 
-	// fetch order (must provide TotalAmount and CustomerID via orderRepo)
-	order, err := prom.orderRepo.GetByID(ctx, orderID)
+	// the order was just authorized and the reward checks guard a write, so read from the primary
+	ctx = pgGorm.WithPrimary(ctx)
+
+	tx := prom.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	// the order stays locked until the reward is written, so a cancellation that revokes the
+	// rewards of the order either sees this one or makes the order ineligible first
+	order, err := prom.orderRepo.GetForUpdate(ctx, tx, orderID)
 	if err != nil {
 		return err
 	}
+	if !slices.Contains(models.InvoicedOrderStatuses, models.OrderStatus(strings.ToUpper(order.Status))) {
+		return ErrOrderNotPaid
+	}
 
 	// get active promotion (assumes single active campaign; adjust to name if needed)
 	now := prom.nowFunc()
-	promo, err := prom.promoRepo.GetActivePromotion(ctx, now)
+	active, err := prom.promoRepo.GetActivePromotion(ctx, now)
+	if err != nil {
+		return ErrNoActivePromotion
+	}
+
+	promo, err := prom.promoRepo.GetForUpdate(ctx, tx, active.ID)
 	if err != nil {
+		return err
+	}
+	// another reward may have deactivated it while we waited for the lock
+	if !promo.IsActive {
 		return ErrNoActivePromotion
 	}
 
 	// check order amount against promotion minimum
-	if order.TotalAmount < promo.MinOrderValue {
+	if !promo.Qualifies(order.QualifyingAmount(), order.Currency, order.FxRate) {
 		return ErrOrderBelowMinValue
 	}
 
 	// check per-customer: only one reward per customer
-	already, err := prom.promoRepo.HasCustomerReceived(ctx, promo.ID, order.CustomerID)
+	already, err := prom.promoRepo.HasCustomerReceived(ctx, tx, promo.ID, order.CustomerID)
 	if err != nil {
 		return err
 	}
@@ -75,7 +117,7 @@ func (prom *PromotionService) HandlePromotion(ctx context.Context, evt models.Pr
 	}
 
 	// check customer limit (first N customers who satisfy conditions)
-	customerCount, err := prom.promoRepo.CountDistinctCustomers(ctx, promo.ID)
+	customerCount, err := prom.promoRepo.CountDistinctCustomers(ctx, tx, promo.ID)
 	if err != nil {
 		return err
 	}
@@ -85,7 +127,7 @@ func (prom *PromotionService) HandlePromotion(ctx context.Context, evt models.Pr
 	}
 
 	// check total rewards given (global cap)
-	totalGiven, err := prom.promoRepo.CountRewards(ctx, promo.ID)
+	totalGiven, err := prom.promoRepo.CountRewards(ctx, tx, promo.ID)
 	if err != nil {
 		return err
 	}
@@ -94,17 +136,43 @@ func (prom *PromotionService) HandlePromotion(ctx context.Context, evt models.Pr
 		return ErrPromotionTotalExhaust
 	}
 
+	// check monetary budget before issuing another reward
+	var spend float64
+	if promo.HasBudget() {
+		spend, err = prom.promoRepo.SumRewardAmount(ctx, tx, promo.ID)
+		if err != nil {
+			return err
+		}
+		if spend+promo.RewardValue > promo.Budget {
+			if err = prom.deactivate(ctx, tx, promo, "budget exhausted"); err != nil {
+				return err
+			}
+			if err = tx.Commit().Error; err != nil {
+				return err
+			}
+			return ErrPromotionBudgetExhaust
+		}
+	}
+
 	// create PromotionReward
 	reward := &models.PromotionReward{
 		PromotionConfigID: promo.ID,
 		OrderID:           order.ID,
 		CustomerID:        order.CustomerID,
+		Amount:            promo.RewardValue,
 		ReceivedAt:        prom.nowFunc(),
 	}
-	if err := prom.promoRepo.CreateReward(ctx, reward); err != nil {
+	if err = prom.promoRepo.CreateReward(ctx, tx, reward); err != nil {
 		return err
 	}
 
+	// deactivate the promotion as soon as its spend reaches the budget
+	if promo.HasBudget() && spend+reward.Amount >= promo.Budget {
+		if err = prom.deactivate(ctx, tx, promo, "budget reached"); err != nil {
+			return err
+		}
+	}
+
 	// create outbox event (reliable publish)
 	outPayload, _ := json.Marshal(struct {
 		RewardID string `json:"reward_id"`
@@ -123,10 +191,189 @@ func (prom *PromotionService) HandlePromotion(ctx context.Context, evt models.Pr
 		Attempts:      0,
 		NextAttemptAt: prom.nowFunc(),
 	}
-	if err = prom.outboxRepo.CreateOutbox(ctx, nil, outbox); err != nil {
-		// NOTE: reward already persisted; outbox failure should be handled (retry by caller) — return error so it can be retried.
+	if err = prom.outboxRepo.CreateOutbox(ctx, tx, outbox); err != nil {
 		return err
 	}
 
+	return tx.Commit().Error
+}
+
+func (prom *PromotionService) deactivate(ctx context.Context, tx *gorm.DB, promo *models.PromotionConfig, reason string) error {
+	log := logger.WithTag("PromotionService|deactivate")
+	if err := prom.promoRepo.Deactivate(ctx, tx, promo.ID); err != nil {
+		logger.LogError(log, err, "failed to deactivate promotion "+promo.ID.String())
+		return err
+	}
+	log.Infof("promotion %s deactivated: %s", promo.ID, reason)
+	return nil
+}
+
+// GetPromotionAnalytics reports rewards, customers, revenue, AOV uplift and remaining caps for a promotion.
+func (prom *PromotionService) GetPromotionAnalytics(ctx context.Context, promoID uuid.UUID) (*models.PromotionAnalytics, error) {
+	promo, err := prom.promoRepo.GetByID(ctx, promoID)
+	if err != nil {
+		return nil, err
+	}
+	return prom.buildAnalytics(ctx, promo)
+}
+
+func (prom *PromotionService) buildAnalytics(ctx context.Context, promo *models.PromotionConfig) (*models.PromotionAnalytics, error) {
+	qualifying, err := prom.promoRepo.GetQualifyingStats(ctx, promo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	nonQualifying, err := prom.promoRepo.GetNonQualifyingStats(ctx, promo)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &models.PromotionAnalytics{
+		PromotionConfigID:   promo.ID,
+		Name:                promo.Name,
+		IsActive:            promo.IsActive,
+		RewardsIssued:       qualifying.RewardsIssued,
+		DistinctCustomers:   qualifying.DistinctCustomers,
+		QualifyingOrders:    qualifying.QualifyingOrders,
+		QualifyingRevenue:   qualifying.QualifyingRevenue,
+		QualifyingAOV:       qualifying.QualifyingAOV,
+		NonQualifyingOrders: nonQualifying.Orders,
+		NonQualifyingAOV:    nonQualifying.AOV,
+		Spend:               qualifying.Spend,
+		Budget:              promo.Budget,
+		StartTime:           promo.StartTime,
+		EndTime:             promo.EndTime,
+	}
+
+	if qualifying.QualifyingOrders > 0 && nonQualifying.Orders > 0 {
+		analytics.AOVUplift = qualifying.QualifyingAOV - nonQualifying.AOV
+		if nonQualifying.AOV > 0 {
+			analytics.AOVUpliftPercent = analytics.AOVUplift / nonQualifying.AOV * 100
+		}
+	}
+
+	if promo.RewardLimit > 0 {
+		remaining := max(int64(promo.RewardLimit)-qualifying.RewardsIssued, 0)
+		analytics.RemainingRewards = &remaining
+	}
+	if promo.CustomerLimit > 0 {
+		remaining := max(int64(promo.CustomerLimit)-qualifying.DistinctCustomers, 0)
+		analytics.RemainingCustomerSlots = &remaining
+	}
+	if promo.HasBudget() {
+		remaining := max(promo.Budget-qualifying.Spend, 0)
+		analytics.RemainingBudget = &remaining
+	}
+
+	return analytics, nil
+}
+
+// GetRewardTimeline returns rewards issued over time, bucketed by interval (default: day).
+func (prom *PromotionService) GetRewardTimeline(
+	ctx context.Context,
+	promoID uuid.UUID,
+	req models.PromotionRewardTimelineRequest,
+) ([]models.PromotionRewardBucket, error) {
+	promo, err := prom.promoRepo.GetByID(ctx, promoID)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := req.Interval
+	if interval == "" {
+		interval = models.PromotionRewardIntervalDay
+	}
+
+	from, to := promo.StartTime, promo.EndTime
+	if req.From != nil {
+		from = *req.From
+	}
+	if req.To != nil {
+		to = *req.To
+	}
+
+	return prom.promoRepo.GetRewardTimeline(ctx, promo.ID, interval, from, to)
+}
+
+// UpsertThreshold sets the minimum order value of a promotion for orders in one currency
+func (prom *PromotionService) UpsertThreshold(
+	ctx context.Context,
+	promoID uuid.UUID,
+	req models.UpsertPromotionThresholdRequest,
+) (*models.PromotionThreshold, error) {
+	// a promotion created a moment ago may not have reached the replicas yet
+	promo, err := prom.promoRepo.GetByID(pgGorm.WithPrimary(ctx), promoID)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := &models.PromotionThreshold{
+		PromotionConfigID: promo.ID,
+		Currency:          strings.ToUpper(req.Currency),
+		MinOrderValue:     req.MinOrderValue,
+	}
+	if err = prom.promoRepo.UpsertThreshold(ctx, threshold); err != nil {
+		return nil, err
+	}
+	return threshold, nil
+}
+
+// RefreshPromotionMetrics recomputes analytics for every promotion so the Prometheus gauges stay current.
+// It is the only writer of the promotion gauges; reading analytics over the API leaves them alone.
+func (prom *PromotionService) RefreshPromotionMetrics(ctx context.Context) error {
+	promos, err := prom.promoRepo.ListPromotions(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range promos {
+		analytics, err := prom.buildAnalytics(ctx, &promos[i])
+		if err != nil {
+			return err
+		}
+		metrics.ObservePromotion(metrics.PromotionStats{
+			Tenant:                 promos[i].TenantID,
+			ID:                     promos[i].ID.String(),
+			Name:                   promos[i].Name,
+			RewardsIssued:          analytics.RewardsIssued,
+			DistinctCustomers:      analytics.DistinctCustomers,
+			QualifyingRevenue:      analytics.QualifyingRevenue,
+			AOVUplift:              analytics.AOVUplift,
+			Spend:                  analytics.Spend,
+			RemainingRewards:       analytics.RemainingRewards,
+			RemainingCustomerSlots: analytics.RemainingCustomerSlots,
+			RemainingBudget:        analytics.RemainingBudget,
+		})
+	}
 	return nil
 }
+
+// SyncPromotionSchedule activates promotions that started since the previous sync and
+// deactivates the ones whose EndTime has passed. It returns the number of promotions changed.
+func (prom *PromotionService) SyncPromotionSchedule(ctx context.Context, since, now time.Time) (int64, error) {
+	activated, err := prom.promoRepo.ActivateStarted(ctx, since, now)
+	if err != nil {
+		return 0, err
+	}
+
+	deactivated, err := prom.promoRepo.DeactivateEnded(ctx, now)
+	if err != nil {
+		return activated, err
+	}
+
+	return activated + deactivated, nil
+}
+
+// newRewardRevokedOutbox publishes the revocation of a reward, whether its order was cancelled
+// or returned
+func newRewardRevokedOutbox(event models.PromotionRewardRevokedEvent, rewardID uuid.UUID, now time.Time) *models.Outbox {
+	payload, _ := json.Marshal(event)
+	return &models.Outbox{
+		EventID:       uuid.New(),
+		EventType:     events.EventPromotionRewardRevoked.String(),
+		Payload:       string(payload),
+		AggregateType: "promotion_reward",
+		AggregateID:   rewardID,
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: now,
+	}
+}
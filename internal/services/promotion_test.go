@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+	"order/pkg/core/tenant"
+)
+
+func TestHandlePromotionKeepsConcurrentRewardsWithinBudget(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	promo := &models.PromotionConfig{
+		Name:          "budget",
+		CustomerLimit: 10,
+		RewardLimit:   10,
+		RewardValue:   5,
+		Budget:        10,
+		IsActive:      true,
+		StartTime:     time.Now().Add(-time.Hour),
+		EndTime:       time.Now().Add(time.Hour),
+	}
+	if err := pg.GetRepo().WithContext(ctx).Create(promo).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	promoRepo := repo.NewPromotionRepository(pg)
+	prom := NewPromotionService(promoRepo, repo.NewOutboxRepository(pg), repo.NewOrderRepository(pg), pg)
+
+	var events []models.PromotionRewardEvent
+	for range 4 {
+		created, err := oS.CreateOrder(ctx, testOrderRequest())
+		if err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		if err = oS.UpdateOrderStatus(ctx, created.Data.OrderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+			t.Fatalf("authorize: %v", err)
+		}
+		events = append(events, models.PromotionRewardEvent{OrderID: created.Data.OrderID.String()})
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(events))
+	for i, evt := range events {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = prom.HandlePromotion(ctx, evt)
+		}()
+	}
+	wg.Wait()
+
+	var rewarded int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			rewarded++
+		case errors.Is(err, ErrPromotionBudgetExhaust), errors.Is(err, ErrNoActivePromotion):
+		default:
+			t.Errorf("HandlePromotion: %v", err)
+		}
+	}
+	if rewarded != 2 {
+		t.Errorf("%d rewards issued, want 2", rewarded)
+	}
+
+	spend, err := promoRepo.SumRewardAmount(ctx, nil, promo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spend != promo.Budget {
+		t.Errorf("spend = %v, want the budget %v", spend, promo.Budget)
+	}
+	stored, err := promoRepo.GetByID(ctx, promo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.IsActive {
+		t.Error("promotion still active with its budget spent")
+	}
+}
+
+func TestNonQualifyingStatsCountPaidAndCompletedOrders(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+
+	promo := &models.PromotionConfig{
+		Name:      "baseline",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+	}
+	if err := pg.GetRepo().WithContext(ctx).Create(promo).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, statuses := range [][]models.OrderStatus{
+		{},
+		{models.OrderStatusAuthorized},
+		{models.OrderStatusAuthorized, models.OrderStatusCompleted},
+		{models.OrderStatusDeclined},
+	} {
+		created, err := oS.CreateOrder(ctx, testOrderRequest())
+		if err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		for _, status := range statuses {
+			if err = oS.UpdateOrderStatus(ctx, created.Data.OrderID, status, testStatusChange("test")); err != nil {
+				t.Fatalf("move to %s: %v", status, err)
+			}
+		}
+	}
+
+	stats, err := repo.NewPromotionRepository(pg).GetNonQualifyingStats(ctx, promo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Orders != 2 {
+		t.Errorf("non-qualifying orders = %d, want the authorized and the completed order", stats.Orders)
+	}
+}
+
+// fakeStatsRepo serves the same figures for every promotion
+type fakeStatsRepo struct {
+	repo.PromotionRepoInterface
+	promos []models.PromotionConfig
+}
+
+func (f *fakeStatsRepo) GetByID(ctx context.Context, promoID uuid.UUID) (*models.PromotionConfig, error) {
+	for i := range f.promos {
+		if f.promos[i].ID == promoID {
+			return &f.promos[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeStatsRepo) ListPromotions(ctx context.Context) ([]models.PromotionConfig, error) {
+	return f.promos, nil
+}
+
+func (f *fakeStatsRepo) GetQualifyingStats(ctx context.Context, promoID uuid.UUID) (*models.PromotionQualifyingStats, error) {
+	return &models.PromotionQualifyingStats{RewardsIssued: 3, DistinctCustomers: 2}, nil
+}
+
+func (f *fakeStatsRepo) GetNonQualifyingStats(ctx context.Context, promo *models.PromotionConfig) (*models.PromotionNonQualifyingStats, error) {
+	return &models.PromotionNonQualifyingStats{}, nil
+}
+
+func TestPromotionGaugesAreSetPerTenantByTheRefreshOnly(t *testing.T) {
+	capped := models.PromotionConfig{Name: "spring", CustomerLimit: 5}
+	capped.ID, capped.TenantID = uuid.New(), "acme"
+	uncapped := models.PromotionConfig{Name: "spring"}
+	uncapped.ID, uncapped.TenantID = uuid.New(), "globex"
+	prom := NewPromotionService(&fakeStatsRepo{promos: []models.PromotionConfig{capped, uncapped}}, nil, nil, nil)
+
+	slots := func(p models.PromotionConfig) (float64, bool) {
+		families, err := prometheus.DefaultGatherer.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, family := range families {
+			if family.GetName() != "promotion_remaining_customer_slots" {
+				continue
+			}
+			for _, m := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, l := range m.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+				if labels["tenant"] == p.TenantID && labels["promotion_id"] == p.ID.String() {
+					return m.GetGauge().GetValue(), true
+				}
+			}
+		}
+		return 0, false
+	}
+
+	if _, err := prom.GetPromotionAnalytics(context.Background(), capped.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := slots(capped); ok {
+		t.Fatal("reading analytics set the promotion gauges")
+	}
+
+	if err := prom.RefreshPromotionMetrics(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := slots(capped); got != 3 {
+		t.Errorf("remaining slots of the capped promotion = %v, want 3", got)
+	}
+	if got, _ := slots(uncapped); got != -1 {
+		t.Errorf("remaining slots of the uncapped promotion = %v, want -1", got)
+	}
+}
+
+func TestPromotionNamesAreUniquePerTenant(t *testing.T) {
+	pg := pgtest.Open(t)
+	create := func(tenantID string) error {
+		promo := &models.PromotionConfig{Name: "spring", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+		return pg.GetRepo().WithContext(tenant.NewContext(context.Background(), tenantID)).Create(promo).Error
+	}
+
+	if err := create("acme"); err != nil {
+		t.Fatal(err)
+	}
+	if err := create("globex"); err != nil {
+		t.Errorf("same name in another tenant: %v", err)
+	}
+	if err := create("acme"); err == nil {
+		t.Error("same name twice in one tenant was accepted")
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+	"order/internal/metrics"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	// statsRebuildHours is the number of changed hours rebuilt per transaction
+	statsRebuildHours = 168
+	// statsRefreshOverlap looks back past the watermark for orders whose transaction
+	// committed after a refresh had started
+	statsRefreshOverlap = 5 * time.Minute
+
+	maxHourlyReportRange = 31 * 24 * time.Hour
+	maxDailyReportRange  = 731 * 24 * time.Hour
+)
+
+var ErrInvalidReport = errors.New("invalid report request")
+
+type ReportServiceInterface interface {
+	RefreshOrderStats(ctx context.Context) (int64, error)
+	OrderReport(ctx context.Context, req models.OrderReportRequest) (*models.OrderReport, error)
+	RefreshReportMetrics(ctx context.Context) error
+}
+
+// ReportService keeps the hourly and daily order stats current and reports from them, so
+// dashboards do not scan the orders table
+type ReportService struct {
+	statsRepo repo.OrderStatsRepoInterface
+	nowFunc   func() time.Time
+}
+
+func NewReportService(statsRepo repo.OrderStatsRepoInterface) *ReportService {
+	return &ReportService{statsRepo: statsRepo, nowFunc: time.Now}
+}
+
+// RefreshOrderStats rebuilds the stats of the hours holding orders of the tenant of ctx changed
+// since the last refresh, every hour on the first refresh. It returns the number of rows written.
+func (rS *ReportService) RefreshOrderStats(ctx context.Context) (int64, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ReportService.RefreshOrderStats")
+	defer span.End()
+
+	now := rS.nowFunc()
+	watermark, err := rS.statsRepo.GetWatermark(ctx)
+	var since time.Time
+	switch {
+	case err == nil:
+		since = watermark.RefreshedUntil.Add(-statsRefreshOverlap)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		watermark = &models.OrderStatsWatermark{}
+	default:
+		span.RecordError(err)
+		return 0, err
+	}
+
+	hours, err := rS.statsRepo.ChangedHours(ctx, since)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	var written int64
+	for start := 0; start < len(hours); start += statsRebuildHours {
+		end := min(start+statsRebuildHours, len(hours))
+		rows, err := rS.statsRepo.Rebuild(ctx, hours[start:end], now)
+		written += rows
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "rebuild failed")
+			return written, err
+		}
+	}
+
+	watermark.RefreshedUntil = now
+	if err = rS.statsRepo.SaveWatermark(ctx, watermark); err != nil {
+		span.RecordError(err)
+		return written, err
+	}
+	span.SetAttributes(attribute.Int("hours", len(hours)), attribute.Int64("rows", written))
+	return written, nil
+}
+
+// OrderReport returns the stats of the tenant of ctx between From and To, the last 30 days by
+// day or the last 48 hours by hour by default
+func (rS *ReportService) OrderReport(ctx context.Context, req models.OrderReportRequest) (*models.OrderReport, error) {
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ReportService.OrderReport")
+	defer span.End()
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = models.StatsGranularityDay
+	}
+	maxRange, defaultRange := maxDailyReportRange, 30*24*time.Hour
+	if granularity == models.StatsGranularityHour {
+		maxRange, defaultRange = maxHourlyReportRange, 48*time.Hour
+	}
+
+	to := rS.nowFunc()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.Add(-defaultRange)
+	if req.From != nil {
+		from = *req.From
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidReport)
+	}
+	if to.Sub(from) > maxRange {
+		return nil, fmt.Errorf("%w: at most %s by %s", ErrInvalidReport, maxRange, granularity)
+	}
+
+	groupBy := []string{}
+	var byCurrency, byStatus bool
+	for _, field := range strings.Split(req.GroupBy, ",") {
+		switch field = strings.TrimSpace(field); field {
+		case "":
+			continue
+		case "currency":
+			byCurrency = true
+		case "status":
+			byStatus = true
+		default:
+			return nil, fmt.Errorf("%w: cannot group by %q", ErrInvalidReport, field)
+		}
+		groupBy = append(groupBy, field)
+	}
+
+	buckets, err := rS.statsRepo.SumBuckets(ctx, granularity, from, to, byCurrency, byStatus)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	report := &models.OrderReport{
+		Granularity: granularity,
+		From:        from,
+		To:          to,
+		GroupBy:     groupBy,
+		Rows:        make([]models.OrderReportRow, 0, len(buckets)),
+	}
+	watermark, err := rS.statsRepo.GetWatermark(ctx)
+	if err == nil {
+		report.RefreshedUntil = &watermark.RefreshedUntil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// the status shares are taken of the orders of the bucket, and of the currency when grouped by it
+	totals := map[string]int64{}
+	shareKey := func(b models.OrderStatsBucket) string {
+		return b.BucketStart.UTC().Format(time.RFC3339) + "|" + b.Currency
+	}
+	for _, bucket := range buckets {
+		totals[shareKey(bucket)] += bucket.Orders
+	}
+
+	for _, bucket := range buckets {
+		row := models.OrderReportRow{
+			BucketStart: bucket.BucketStart.UTC(),
+			Currency:    bucket.Currency,
+			Status:      bucket.Status,
+			Orders:      bucket.Orders,
+			PaidOrders:  bucket.PaidOrders,
+			RevenueBase: bucket.RevenueBase,
+		}
+		if byCurrency {
+			revenue := bucket.Revenue
+			row.Revenue = &revenue
+		}
+		if bucket.PaidOrders > 0 {
+			row.AverageOrderValue = bucket.RevenueBase / float64(bucket.PaidOrders)
+		}
+		if byStatus {
+			share := float64(0)
+			if total := totals[shareKey(bucket)]; total > 0 {
+				share = float64(bucket.Orders) / float64(total)
+			}
+			row.StatusShare = &share
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// RefreshReportMetrics sets the headline gauges from today's daily stats of every tenant; ctx
+// has to be a system context
+func (rS *ReportService) RefreshReportMetrics(ctx context.Context) error {
+	now := rS.nowFunc().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	stats, err := rS.statsRepo.ListBucket(ctx, models.StatsGranularityDay, today)
+	if err != nil {
+		return err
+	}
+
+	paid := map[string]bool{}
+	for _, status := range models.RevenueOrderStatuses {
+		paid[status] = true
+	}
+	var observed []metrics.OrderReportStats
+	index := map[string]int{}
+	for _, stat := range stats {
+		key := stat.TenantID + "|" + stat.Currency
+		i, ok := index[key]
+		if !ok {
+			i = len(observed)
+			index[key] = i
+			observed = append(observed, metrics.OrderReportStats{
+				Tenant:   stat.TenantID,
+				Currency: stat.Currency,
+				Orders:   map[string]int64{},
+			})
+		}
+		observed[i].Orders[stat.Status] += stat.OrderCount
+		if paid[stat.Status] {
+			observed[i].PaidOrders += stat.OrderCount
+			observed[i].Revenue += stat.Revenue
+		}
+	}
+	metrics.ObserveOrderReport(observed)
+	return nil
+}
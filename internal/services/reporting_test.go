@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+)
+
+// fakeOrderStatsRepo records the refreshes and serves fixed buckets and stats
+type fakeOrderStatsRepo struct {
+	hours     []time.Time
+	since     []time.Time
+	rebuilt   [][]time.Time
+	watermark *models.OrderStatsWatermark
+	buckets   []models.OrderStatsBucket
+	stats     []models.OrderStat
+}
+
+func (f *fakeOrderStatsRepo) ChangedHours(ctx context.Context, since time.Time) ([]time.Time, error) {
+	f.since = append(f.since, since)
+	return f.hours, nil
+}
+
+func (f *fakeOrderStatsRepo) Rebuild(ctx context.Context, hours []time.Time, now time.Time) (int64, error) {
+	f.rebuilt = append(f.rebuilt, hours)
+	return int64(len(hours)), nil
+}
+
+func (f *fakeOrderStatsRepo) SumBuckets(
+	ctx context.Context,
+	granularity models.StatsGranularity,
+	from, to time.Time,
+	byCurrency, byStatus bool,
+) ([]models.OrderStatsBucket, error) {
+	return f.buckets, nil
+}
+
+func (f *fakeOrderStatsRepo) ListBucket(ctx context.Context, granularity models.StatsGranularity, start time.Time) ([]models.OrderStat, error) {
+	return f.stats, nil
+}
+
+func (f *fakeOrderStatsRepo) GetWatermark(ctx context.Context) (*models.OrderStatsWatermark, error) {
+	if f.watermark == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	watermark := *f.watermark
+	return &watermark, nil
+}
+
+func (f *fakeOrderStatsRepo) SaveWatermark(ctx context.Context, watermark *models.OrderStatsWatermark) error {
+	saved := *watermark
+	f.watermark = &saved
+	return nil
+}
+
+func TestRefreshOrderStatsRebuildsTheHoursChangedSinceTheLastRefresh(t *testing.T) {
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	stats := &fakeOrderStatsRepo{}
+	for i := 0; i < statsRebuildHours+2; i++ {
+		stats.hours = append(stats.hours, now.Add(-time.Duration(i)*time.Hour))
+	}
+	rS := NewReportService(stats)
+	rS.nowFunc = func() time.Time { return now }
+
+	written, err := rS.RefreshOrderStats(context.Background())
+	if err != nil {
+		t.Fatalf("RefreshOrderStats: %v", err)
+	}
+	if written != int64(len(stats.hours)) {
+		t.Errorf("written = %d, want %d", written, len(stats.hours))
+	}
+	// every hour on the first refresh, in transactions of at most statsRebuildHours hours
+	if !stats.since[0].IsZero() {
+		t.Errorf("first refresh since %v, want every hour", stats.since[0])
+	}
+	if len(stats.rebuilt) != 2 || len(stats.rebuilt[0]) != statsRebuildHours || len(stats.rebuilt[1]) != 2 {
+		t.Errorf("rebuilt %d batches, want %d hours and 2", len(stats.rebuilt), statsRebuildHours)
+	}
+
+	later := now.Add(time.Hour)
+	rS.nowFunc = func() time.Time { return later }
+	if _, err = rS.RefreshOrderStats(context.Background()); err != nil {
+		t.Fatalf("second RefreshOrderStats: %v", err)
+	}
+	if want := now.Add(-statsRefreshOverlap); !stats.since[1].Equal(want) {
+		t.Errorf("second refresh since %v, want %v", stats.since[1], want)
+	}
+	if !stats.watermark.RefreshedUntil.Equal(later) {
+		t.Errorf("watermark = %v, want %v", stats.watermark.RefreshedUntil, later)
+	}
+}
+
+func TestOrderReport(t *testing.T) {
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	day := time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)
+	stats := &fakeOrderStatsRepo{
+		watermark: &models.OrderStatsWatermark{RefreshedUntil: now},
+		buckets: []models.OrderStatsBucket{
+			{BucketStart: day, Currency: "EUR", Status: "AUTHORIZED", Orders: 3, PaidOrders: 3, Revenue: 90, RevenueBase: 90},
+			{BucketStart: day, Currency: "EUR", Status: "PENDING", Orders: 1},
+			{BucketStart: day, Currency: "USD", Status: "PENDING", Orders: 2},
+		},
+	}
+	rS := NewReportService(stats)
+	rS.nowFunc = func() time.Time { return now }
+
+	for name, req := range map[string]models.OrderReportRequest{
+		"from after to":       {From: &now, To: ptrTime(now.Add(-time.Hour))},
+		"too many hours":      {Granularity: models.StatsGranularityHour, From: ptrTime(now.AddDate(0, -2, 0))},
+		"unknown group field": {GroupBy: "customer"},
+	} {
+		if _, err := rS.OrderReport(context.Background(), req); !errors.Is(err, ErrInvalidReport) {
+			t.Errorf("%s err = %v, want %v", name, err, ErrInvalidReport)
+		}
+	}
+
+	report, err := rS.OrderReport(context.Background(), models.OrderReportRequest{GroupBy: "currency, status"})
+	if err != nil {
+		t.Fatalf("OrderReport: %v", err)
+	}
+	if report.Granularity != models.StatsGranularityDay || !report.From.Equal(now.AddDate(0, 0, -30)) || !report.To.Equal(now) {
+		t.Errorf("report by %s from %v to %v, want the last 30 days by day", report.Granularity, report.From, report.To)
+	}
+	if report.RefreshedUntil == nil || !report.RefreshedUntil.Equal(now) {
+		t.Errorf("refreshed until %v, want %v", report.RefreshedUntil, now)
+	}
+	if len(report.Rows) != 3 {
+		t.Fatalf("%d rows, want 3", len(report.Rows))
+	}
+	// the shares are taken within the currency of the bucket
+	for i, want := range []float64{0.75, 0.25, 1} {
+		if share := report.Rows[i].StatusShare; share == nil || *share != want {
+			t.Errorf("row %d share = %v, want %v", i, share, want)
+		}
+	}
+	if row := report.Rows[0]; row.Revenue == nil || *row.Revenue != 90 || row.AverageOrderValue != 30 {
+		t.Errorf("paid row revenue %v with average %v, want 90 with 30", row.Revenue, row.AverageOrderValue)
+	}
+
+	// without the currency, revenue is only reported in the base currency
+	stats.buckets = []models.OrderStatsBucket{{BucketStart: day, Orders: 6, PaidOrders: 3, Revenue: 90, RevenueBase: 90}}
+	if report, err = rS.OrderReport(context.Background(), models.OrderReportRequest{}); err != nil {
+		t.Fatalf("OrderReport: %v", err)
+	}
+	if row := report.Rows[0]; row.Revenue != nil || row.StatusShare != nil || row.RevenueBase != 90 {
+		t.Errorf("ungrouped row = %+v, want only the base revenue", row)
+	}
+}
+
+func TestReportGaugesCountTodaysPaidOrdersPerTenant(t *testing.T) {
+	stats := &fakeOrderStatsRepo{stats: []models.OrderStat{
+		{TenantModel: models.TenantModel{TenantID: "acme"}, Currency: "EUR", Status: "AUTHORIZED", OrderCount: 2, Revenue: 50},
+		{TenantModel: models.TenantModel{TenantID: "acme"}, Currency: "EUR", Status: "COMPLETED", OrderCount: 2, Revenue: 30},
+		{TenantModel: models.TenantModel{TenantID: "acme"}, Currency: "EUR", Status: "PENDING", OrderCount: 5, Revenue: 500},
+	}}
+	if err := NewReportService(stats).RefreshReportMetrics(context.Background()); err != nil {
+		t.Fatalf("RefreshReportMetrics: %v", err)
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gauges := map[string]float64{}
+	for _, family := range families {
+		switch family.GetName() {
+		case "order_report_revenue_today", "order_report_aov_today":
+			for _, m := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, l := range m.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+				if labels["tenant"] == "acme" && labels["currency"] == "EUR" {
+					gauges[family.GetName()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	// pending orders count towards the orders but not the revenue
+	if gauges["order_report_revenue_today"] != 80 || gauges["order_report_aov_today"] != 20 {
+		t.Errorf("revenue %v with average %v, want 80 with 20", gauges["order_report_revenue_today"], gauges["order_report_aov_today"])
+	}
+}
+
+func TestOrderStatsFollowStatusChanges(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	rS := NewReportService(repo.NewOrderStatsRepository(pg))
+
+	var orderIDs []uuid.UUID
+	for i := 0; i < 2; i++ {
+		created, err := oS.CreateOrder(ctx, testOrderRequest())
+		if err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		orderIDs = append(orderIDs, created.Data.OrderID)
+	}
+
+	report := func() []models.OrderReportRow {
+		t.Helper()
+		if _, err := rS.RefreshOrderStats(ctx); err != nil {
+			t.Fatalf("RefreshOrderStats: %v", err)
+		}
+		// the report ends past now so the orders just created fall inside it
+		report, err := rS.OrderReport(ctx, models.OrderReportRequest{To: ptrTime(time.Now().Add(time.Hour)), GroupBy: "status"})
+		if err != nil {
+			t.Fatalf("OrderReport: %v", err)
+		}
+		return report.Rows
+	}
+
+	if rows := report(); len(rows) != 1 || rows[0].Status != string(models.OrderStatusPending) || rows[0].Orders != 2 || rows[0].PaidOrders != 0 {
+		t.Fatalf("rows = %+v, want 2 pending orders", rows)
+	}
+
+	if err := oS.UpdateOrderStatus(ctx, orderIDs[0], models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	rows := report()
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v, want an authorized and a pending order", rows)
+	}
+	if paid := rows[0]; paid.Status != string(models.OrderStatusAuthorized) || paid.Orders != 1 || paid.PaidOrders != 1 || paid.RevenueBase != 30 {
+		t.Errorf("authorized row = %+v, want one paid order of 30", paid)
+	}
+	if pending := rows[1]; pending.Status != string(models.OrderStatusPending) || pending.Orders != 1 {
+		t.Errorf("pending row = %+v, want one order", pending)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
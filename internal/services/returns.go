@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"order/internal/events"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/logger"
+	"order/pkg/proto/inventorypb"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOrderNotReturnable   = errors.New("only authorized or completed orders accept returns")
+	ErrReturnQuantity       = errors.New("quantity exceeds the returnable quantity of the item")
+	ErrReturnReasonRequired = errors.New("a return reason is required")
+	ErrReturnTransition     = errors.New("return request is not in the required state")
+)
+
+type ReturnService struct {
+	returnRepo repo.ReturnRepoInterface
+	orderRepo  repo.OrderRepoInterface
+	promoRepo  repo.PromotionRepoInterface
+	outboxRepo repo.OutboxRepoInterface
+	invoices   InvoiceServiceInterface
+	newPgRepo  pgGorm.PGInterface
+	nowFunc    func() time.Time
+}
+
+type ReturnServiceInterface interface {
+	CreateReturn(ctx context.Context, orderID uuid.UUID, req models.CreateReturnRequest) (*models.ReturnRequest, error)
+	GetReturn(ctx context.Context, returnID uuid.UUID) (*models.ReturnRequest, error)
+	ListReturns(ctx context.Context, orderID uuid.UUID) ([]models.ReturnRequest, error)
+	ReviewReturn(ctx context.Context, returnID uuid.UUID, approved bool, note string) (*models.ReturnRequest, error)
+	ReceiveReturn(ctx context.Context, returnID uuid.UUID) (*models.ReturnRequest, error)
+	InspectReturn(ctx context.Context, returnID uuid.UUID, passed bool, note string) (*models.ReturnRequest, error)
+	CompleteReturn(ctx context.Context, returnID uuid.UUID) (*models.ReturnRequest, error)
+}
+
+func NewReturnService(
+	returnRepo repo.ReturnRepoInterface,
+	orderRepo repo.OrderRepoInterface,
+	promoRepo repo.PromotionRepoInterface,
+	outboxRepo repo.OutboxRepoInterface,
+	invoices InvoiceServiceInterface,
+	newRepo pgGorm.PGInterface,
+) *ReturnService {
+	return &ReturnService{
+		returnRepo: returnRepo,
+		orderRepo:  orderRepo,
+		promoRepo:  promoRepo,
+		outboxRepo: outboxRepo,
+		invoices:   invoices,
+		newPgRepo:  newRepo,
+		nowFunc:    time.Now,
+	}
+}
+
+// CreateReturn opens a return request for part of an order item. Quantities claimed by
+// earlier requests that were not rejected cannot be returned again.
+func (rS *ReturnService) CreateReturn(ctx context.Context, orderID uuid.UUID, req models.CreateReturnRequest) (*models.ReturnRequest, error) {
+	log := logger.WithTag("ReturnService|CreateReturn")
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ReturnService.CreateReturn",
+		trace.WithAttributes(attribute.String("order_id", orderID.String()),
+			attribute.String("order_item_id", req.OrderItemID.String())))
+	defer span.End()
+
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, ErrReturnReasonRequired
+	}
+
+	tx := rS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	order, err := rS.orderRepo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !isReturnable(order) {
+		return nil, ErrOrderNotReturnable
+	}
+	idx := findOrderItem(order, req.OrderItemID)
+	if idx < 0 {
+		return nil, ErrOrderItemNotFound
+	}
+
+	claimed, err := rS.returnRepo.SumOpenQuantity(ctx, tx, req.OrderItemID)
+	if err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to sum returned quantity")
+		return nil, err
+	}
+	if req.Quantity <= 0 || claimed+req.Quantity > order.OrderItems[idx].Quantity {
+		return nil, ErrReturnQuantity
+	}
+
+	ret := &models.ReturnRequest{
+		OrderID:     orderID,
+		OrderItemID: req.OrderItemID,
+		CustomerID:  order.CustomerID,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		Status:      models.ReturnStatusRequested,
+	}
+	if err = rS.returnRepo.Create(ctx, tx, ret); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to create return request")
+		return nil, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		logger.LogError(log, err, "failed to commit tx")
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "created return request")
+	return ret, nil
+}
+
+func (rS *ReturnService) GetReturn(ctx context.Context, returnID uuid.UUID) (*models.ReturnRequest, error) {
+	return rS.returnRepo.GetByID(ctx, returnID)
+}
+
+func (rS *ReturnService) ListReturns(ctx context.Context, orderID uuid.UUID) ([]models.ReturnRequest, error) {
+	if _, err := rS.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return rS.returnRepo.ListByOrderID(ctx, orderID)
+}
+
+// ReviewReturn approves or rejects a requested return
+func (rS *ReturnService) ReviewReturn(ctx context.Context, returnID uuid.UUID, approved bool, note string) (*models.ReturnRequest, error) {
+	return rS.transition(ctx, "ReviewReturn", returnID, models.ReturnStatusRequested, func(tx *gorm.DB, ret *models.ReturnRequest) error {
+		now := rS.nowFunc()
+		ret.Status = models.ReturnStatusRejected
+		if approved {
+			ret.Status = models.ReturnStatusApproved
+		}
+		ret.ReviewNote = note
+		ret.ReviewedAt = &now
+		return nil
+	})
+}
+
+// ReceiveReturn records that the returned items arrived at the warehouse
+func (rS *ReturnService) ReceiveReturn(ctx context.Context, returnID uuid.UUID) (*models.ReturnRequest, error) {
+	return rS.transition(ctx, "ReceiveReturn", returnID, models.ReturnStatusApproved, func(tx *gorm.DB, ret *models.ReturnRequest) error {
+		now := rS.nowFunc()
+		ret.Status = models.ReturnStatusReceived
+		ret.ReceivedAt = &now
+		return nil
+	})
+}
+
+// InspectReturn records the inspection of received items; items failing it are rejected
+func (rS *ReturnService) InspectReturn(ctx context.Context, returnID uuid.UUID, passed bool, note string) (*models.ReturnRequest, error) {
+	return rS.transition(ctx, "InspectReturn", returnID, models.ReturnStatusReceived, func(tx *gorm.DB, ret *models.ReturnRequest) error {
+		now := rS.nowFunc()
+		ret.Status = models.ReturnStatusRejected
+		if passed {
+			ret.Status = models.ReturnStatusInspected
+		}
+		ret.InspectionNote = note
+		ret.InspectedAt = &now
+		return nil
+	})
+}
+
+// CompleteReturn refunds and restocks an inspected return through the outbox. Rewards of the
+// order are revoked once the refunded order no longer reaches their promotion minimum.
+func (rS *ReturnService) CompleteReturn(ctx context.Context, returnID uuid.UUID) (*models.ReturnRequest, error) {
+	return rS.transition(ctx, "CompleteReturn", returnID, models.ReturnStatusInspected, func(tx *gorm.DB, ret *models.ReturnRequest) error {
+		order, err := rS.orderRepo.GetForUpdate(ctx, tx, ret.OrderID)
+		if err != nil {
+			return err
+		}
+		idx := findOrderItem(order, ret.OrderItemID)
+		if idx < 0 {
+			return ErrOrderItemNotFound
+		}
+		item := order.OrderItems[idx]
+
+		ret.RefundAmount = order.RefundFor(item, ret.Quantity)
+		if ret.RefundAmount > 0 {
+			if err = rS.orderRepo.AddRefund(ctx, tx, order.ID, ret.RefundAmount); err != nil {
+				return err
+			}
+			order.RefundedAmount += ret.RefundAmount
+
+			refund := newOrderOutbox(order.ID, events.EventPaymentRefundRequested, models.PaymentRefundEvent{
+				OrderID:  order.ID.String(),
+				ReturnID: ret.ID.String(),
+				Amount:   ret.RefundAmount,
+				Reason:   ret.Reason,
+			})
+			if err = rS.outboxRepo.CreateOutbox(ctx, tx, refund); err != nil {
+				return err
+			}
+			if _, err = rS.invoices.IssueCreditNote(ctx, tx, order, item, ret); err != nil {
+				return err
+			}
+		}
+
+		restock := newOrderOutbox(order.ID, events.EventInventoryRestock, &inventorypb.RestockRequest{
+			OrderId: order.ID.String(),
+			Lines:   []*inventorypb.ReserveLine{{ProductId: item.ProductID.String(), Quantity: int32(ret.Quantity)}},
+			Reason:  "return " + ret.ID.String(),
+		})
+		if err = rS.outboxRepo.CreateOutbox(ctx, tx, restock); err != nil {
+			return err
+		}
+
+		if err = rS.revokeRewards(ctx, tx, order, ret); err != nil {
+			return err
+		}
+
+		now := rS.nowFunc()
+		ret.Status = models.ReturnStatusCompleted
+		ret.CompletedAt = &now
+		return nil
+	})
+}
+
+// revokeRewards revokes the promotion rewards whose minimum order value the order no longer reaches
+func (rS *ReturnService) revokeRewards(ctx context.Context, tx *gorm.DB, order *models.Order, ret *models.ReturnRequest) error {
+	rewards, err := rS.promoRepo.ListRewardsByOrder(ctx, tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	now := rS.nowFunc()
+	for _, reward := range rewards {
+		if reward.PromotionConfig == nil || reward.PromotionConfig.Qualifies(order.QualifyingAmount(), order.Currency, order.FxRate) {
+			continue
+		}
+		if err = rS.promoRepo.RevokeReward(ctx, tx, reward.ID, now); err != nil {
+			return err
+		}
+
+		event := models.PromotionRewardRevokedEvent{RewardID: reward.ID.String(), OrderID: order.ID.String(), ReturnID: ret.ID.String()}
+		if err = rS.outboxRepo.CreateOutbox(ctx, tx, newRewardRevokedOutbox(event, reward.ID, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transition locks a return request, checks it is in status from, applies change and saves it
+func (rS *ReturnService) transition(
+	ctx context.Context,
+	op string,
+	returnID uuid.UUID,
+	from models.ReturnStatus,
+	change func(tx *gorm.DB, ret *models.ReturnRequest) error,
+) (*models.ReturnRequest, error) {
+	log := logger.WithTag("ReturnService|" + op)
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ReturnService."+op,
+		trace.WithAttributes(attribute.String("return_id", returnID.String())))
+	defer span.End()
+
+	tx := rS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	ret, err := rS.returnRepo.GetForUpdate(ctx, tx, returnID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if ret.Status != from {
+		return nil, ErrReturnTransition
+	}
+
+	if err = change(tx, ret); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "return transition failed")
+		logger.LogError(log, err, "failed to apply return transition")
+		return nil, err
+	}
+	if err = rS.returnRepo.Save(ctx, tx, ret); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to save return request")
+		return nil, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		logger.LogError(log, err, "failed to commit tx")
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "return is "+string(ret.Status))
+	return ret, nil
+}
+
+func isReturnable(order *models.Order) bool {
+	for _, status := range models.ReturnableOrderStatuses {
+		if strings.EqualFold(order.Status, string(status)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/events"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+)
+
+func TestCompletedReturnRefundsRestocksAndRevokesTheReward(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, invoices := testOrderService(t, pg)
+	orderRepo := repo.NewOrderRepository(pg)
+	outbox := repo.NewOutboxRepository(pg)
+	promoRepo := repo.NewPromotionRepository(pg)
+	prom := NewPromotionService(promoRepo, outbox, orderRepo, pg)
+	rS := NewReturnService(repo.NewReturnRepository(pg), orderRepo, promoRepo, outbox, invoices, pg)
+
+	promo := &models.PromotionConfig{
+		Name:          "spring",
+		CustomerLimit: 10,
+		RewardLimit:   10,
+		RewardValue:   5,
+		MinOrderValue: 25,
+		IsActive:      true,
+		StartTime:     time.Now().Add(-time.Hour),
+		EndTime:       time.Now().Add(time.Hour),
+	}
+	if err := pg.GetRepo().WithContext(ctx).Create(promo).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+	order, err := orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the test order has an item of quantity 2 and one of quantity 1
+	var pair uuid.UUID
+	for _, orderItem := range order.OrderItems {
+		if orderItem.Quantity == 2 {
+			pair = orderItem.ID
+		}
+	}
+
+	if _, err = rS.CreateReturn(ctx, orderID, models.CreateReturnRequest{OrderItemID: pair, Quantity: 1, Reason: "damaged"}); !errors.Is(err, ErrOrderNotReturnable) {
+		t.Errorf("returning a pending order err = %v, want %v", err, ErrOrderNotReturnable)
+	}
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if err = prom.HandlePromotion(ctx, models.PromotionRewardEvent{OrderID: orderID.String()}); err != nil {
+		t.Fatalf("HandlePromotion: %v", err)
+	}
+	rewards, err := promoRepo.ListRewardsByOrder(ctx, nil, orderID)
+	if err != nil || len(rewards) != 1 {
+		t.Fatalf("rewards = %v, %v; want one", rewards, err)
+	}
+
+	if _, err = rS.CreateReturn(ctx, orderID, models.CreateReturnRequest{OrderItemID: pair, Quantity: 1, Reason: " "}); !errors.Is(err, ErrReturnReasonRequired) {
+		t.Errorf("return without a reason err = %v, want %v", err, ErrReturnReasonRequired)
+	}
+	if _, err = rS.CreateReturn(ctx, orderID, models.CreateReturnRequest{OrderItemID: pair, Quantity: 3, Reason: "damaged"}); !errors.Is(err, ErrReturnQuantity) {
+		t.Errorf("returning more than was ordered err = %v, want %v", err, ErrReturnQuantity)
+	}
+
+	ret, err := rS.CreateReturn(ctx, orderID, models.CreateReturnRequest{OrderItemID: pair, Quantity: 1, Reason: "damaged"})
+	if err != nil {
+		t.Fatalf("CreateReturn: %v", err)
+	}
+	if _, err = rS.CompleteReturn(ctx, ret.ID); !errors.Is(err, ErrReturnTransition) {
+		t.Errorf("completing an unreviewed return err = %v, want %v", err, ErrReturnTransition)
+	}
+	if _, err = rS.ReviewReturn(ctx, ret.ID, true, ""); err != nil {
+		t.Fatalf("ReviewReturn: %v", err)
+	}
+	if _, err = rS.ReceiveReturn(ctx, ret.ID); err != nil {
+		t.Fatalf("ReceiveReturn: %v", err)
+	}
+	if _, err = rS.InspectReturn(ctx, ret.ID, true, "as described"); err != nil {
+		t.Fatalf("InspectReturn: %v", err)
+	}
+	if ret, err = rS.CompleteReturn(ctx, ret.ID); err != nil {
+		t.Fatalf("CompleteReturn: %v", err)
+	}
+
+	if ret.Status != models.ReturnStatusCompleted || ret.RefundAmount != 10 || ret.CompletedAt == nil {
+		t.Errorf("completed return = %s refunding %v, want %s refunding 10", ret.Status, ret.RefundAmount, models.ReturnStatusCompleted)
+	}
+	if order, err = orderRepo.GetByID(ctx, orderID); err != nil || order.RefundedAmount != 10 {
+		t.Errorf("order refunded = %v, %v; want 10", order.RefundedAmount, err)
+	}
+	for _, event := range []events.EventType{events.EventPaymentRefundRequested, events.EventInventoryRestock} {
+		count, err := outbox.CountByStatus(ctx, nil, orderID, event.String(), models.OutboxStatusPending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("%d pending %s rows, want 1", count, event)
+		}
+	}
+	// the refund takes the order below the minimum of the promotion
+	if left, err := promoRepo.ListRewardsByOrder(ctx, nil, orderID); err != nil || len(left) != 0 {
+		t.Errorf("rewards after the return = %v, %v; want none", left, err)
+	}
+
+	// a rejected request frees the quantity it claimed
+	rejected, err := rS.CreateReturn(ctx, orderID, models.CreateReturnRequest{OrderItemID: pair, Quantity: 1, Reason: "changed my mind"})
+	if err != nil {
+		t.Fatalf("CreateReturn of the last unit: %v", err)
+	}
+	if _, err = rS.CreateReturn(ctx, orderID, models.CreateReturnRequest{OrderItemID: pair, Quantity: 1, Reason: "damaged"}); !errors.Is(err, ErrReturnQuantity) {
+		t.Errorf("returning a claimed unit err = %v, want %v", err, ErrReturnQuantity)
+	}
+	if rejected, err = rS.ReviewReturn(ctx, rejected.ID, false, "outside the return window"); err != nil || rejected.Status != models.ReturnStatusRejected {
+		t.Fatalf("ReviewReturn = %v, %v; want %s", rejected.Status, err, models.ReturnStatusRejected)
+	}
+	if _, err = rS.CreateReturn(ctx, orderID, models.CreateReturnRequest{OrderItemID: pair, Quantity: 1, Reason: "damaged"}); err != nil {
+		t.Errorf("returning the unit of a rejected request: %v", err)
+	}
+
+	returns, err := rS.ListReturns(ctx, orderID)
+	if err != nil || len(returns) != 3 {
+		t.Errorf("ListReturns = %d returns, %v; want 3", len(returns), err)
+	}
+}
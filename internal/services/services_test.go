@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/grpc/clients/inventory"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/internal/saga"
+	"order/pkg/proto/paymentpb"
+)
+
+type fakePayment struct{}
+
+func (fakePayment) Pay(ctx context.Context, req *paymentpb.PayRequest) (*paymentpb.PayResponse, error) {
+	return &paymentpb.PayResponse{}, nil
+}
+
+// testOrderService is an order service over pg with invoicing and the checkout saga enabled,
+// as bootstrap.NewOrderCore builds it
+func testOrderService(t *testing.T, pg pgGorm.PGInterface) (*OrderService, *InvoiceService) {
+	t.Helper()
+
+	oS, invoiceService := testOrderServiceWithoutSaga(t, pg)
+	oS.EnableCheckoutSaga(saga.NewOrchestrator(pg, repo.NewSagaRepository(pg)), time.Hour)
+	return oS, invoiceService
+}
+
+// testOrderServiceWithoutSaga runs checkouts as deployments with CHECKOUT_SAGA_ENABLED=false do
+func testOrderServiceWithoutSaga(t *testing.T, pg pgGorm.PGInterface) (*OrderService, *InvoiceService) {
+	t.Helper()
+
+	baseCurrency := func(ctx context.Context) string { return "EUR" }
+	orderRepo := repo.NewOrderRepository(pg)
+	oS := NewOrderService(
+		orderRepo,
+		pg,
+		fakePayment{},
+		inventoryclient.NewFakeInventoryClient(nil),
+		repo.NewOutboxRepository(pg),
+		repo.NewOrderStatusHistoryRepository(pg),
+		repo.NewPromotionRepository(pg),
+		time.Hour,
+	)
+	invoiceService := NewInvoiceService(repo.NewInvoiceRepository(pg), orderRepo, baseCurrency)
+	oS.EnableInvoicing(invoiceService)
+	return oS, invoiceService
+}
+
+func testOrderRequest() models.CreateOrderRequest {
+	return models.CreateOrderRequest{
+		CustomerID:  uuid.New(),
+		TotalAmount: 30,
+		Status:      "pending",
+		Currency:    "EUR",
+		OrderItems: []models.CreateOrderItemRequest{
+			{ProductID: uuid.New(), Quantity: 2, UniquePrice: 10},
+			{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10},
+		},
+		Audit: models.StatusChange{ActorType: models.ActorTypeSystem, Source: models.ChangeSourceGRPC},
+	}
+}
+
+func testStatusChange(reason string) models.StatusChange {
+	return models.StatusChange{ActorType: models.ActorTypeSystem, Source: models.ChangeSourceGRPC, Reason: reason}
+}
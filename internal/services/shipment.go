@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	pgGorm "order/internal/repositories/pg-gorm"
+	"order/pkg/core/logger"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOrderNotShippable      = errors.New("only authorized orders can be shipped")
+	ErrShipmentEmpty          = errors.New("shipment has no items")
+	ErrShipmentItemInvalid    = errors.New("item does not belong to the order")
+	ErrShipmentQuantity       = errors.New("quantity exceeds the unshipped quantity of the item")
+	ErrShipmentStatusInvalid  = errors.New("unknown shipment status")
+	ErrShipmentTransition     = errors.New("shipment cannot move to the requested status")
+	ErrShipmentTrackingNeeded = errors.New("carrier and tracking number are required")
+)
+
+type ShipmentService struct {
+	shipmentRepo repo.ShipmentRepoInterface
+	orderRepo    repo.OrderRepoInterface
+	newPgRepo    pgGorm.PGInterface
+	orderService OrderServiceInterface
+	nowFunc      func() time.Time
+}
+
+type ShipmentServiceInterface interface {
+	CreateShipment(ctx context.Context, orderID uuid.UUID, req models.CreateShipmentRequest) (*models.Shipment, error)
+	ListShipments(ctx context.Context, orderID uuid.UUID) ([]models.Shipment, error)
+	UpdateShipmentStatus(ctx context.Context, update models.ShipmentUpdate) (*models.Shipment, error)
+}
+
+func NewShipmentService(
+	shipmentRepo repo.ShipmentRepoInterface,
+	orderRepo repo.OrderRepoInterface,
+	newRepo pgGorm.PGInterface,
+	orderService OrderServiceInterface,
+) *ShipmentService {
+	return &ShipmentService{
+		shipmentRepo: shipmentRepo,
+		orderRepo:    orderRepo,
+		newPgRepo:    newRepo,
+		orderService: orderService,
+		nowFunc:      time.Now,
+	}
+}
+
+// CreateShipment packs a subset of the unshipped items of an authorized order into a new shipment
+func (sS *ShipmentService) CreateShipment(ctx context.Context, orderID uuid.UUID, req models.CreateShipmentRequest) (*models.Shipment, error) {
+	log := logger.WithTag("ShipmentService|CreateShipment")
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ShipmentService.CreateShipment",
+		trace.WithAttributes(attribute.String("order_id", orderID.String())))
+	defer span.End()
+
+	if len(req.Items) == 0 {
+		return nil, ErrShipmentEmpty
+	}
+	if req.TrackingNumber != "" && req.Carrier == "" {
+		return nil, ErrShipmentTrackingNeeded
+	}
+
+	tx := sS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	order, err := sS.orderRepo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !strings.EqualFold(order.Status, string(models.OrderStatusAuthorized)) {
+		return nil, ErrOrderNotShippable
+	}
+
+	ordered := make(map[uuid.UUID]int, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		ordered[item.ID] = item.Quantity
+	}
+	allocated, err := sS.shipmentRepo.SumQuantities(ctx, tx, orderID, nil)
+	if err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to sum shipped quantities")
+		return nil, err
+	}
+
+	shipment := &models.Shipment{
+		OrderID:        orderID,
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		Status:         models.ShipmentStatusPacked,
+	}
+	for _, line := range req.Items {
+		quantity, ok := ordered[line.OrderItemID]
+		if !ok {
+			return nil, ErrShipmentItemInvalid
+		}
+		allocated[line.OrderItemID] += line.Quantity
+		if line.Quantity <= 0 || allocated[line.OrderItemID] > quantity {
+			return nil, ErrShipmentQuantity
+		}
+		shipment.Items = append(shipment.Items, models.ShipmentItem{
+			OrderItemID: line.OrderItemID,
+			Quantity:    line.Quantity,
+		})
+	}
+
+	if err = sS.shipmentRepo.Create(ctx, tx, shipment); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to create shipment")
+		return nil, err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		logger.LogError(log, err, "failed to commit tx")
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "created shipment")
+	return shipment, nil
+}
+
+func (sS *ShipmentService) ListShipments(ctx context.Context, orderID uuid.UUID) ([]models.Shipment, error) {
+	if _, err := sS.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return sS.shipmentRepo.ListByOrderID(ctx, orderID)
+}
+
+// UpdateShipmentStatus moves a shipment along its lifecycle. Updates older than the current
+// status are ignored, so redelivered or reordered carrier events are harmless. Once every item
+// of an authorized order has shipped, the order is completed in the same transaction.
+func (sS *ShipmentService) UpdateShipmentStatus(ctx context.Context, update models.ShipmentUpdate) (*models.Shipment, error) {
+	log := logger.WithTag("ShipmentService|UpdateShipmentStatus")
+
+	tracer := otel.Tracer("order/service")
+	ctx, span := tracer.Start(ctx, "ShipmentService.UpdateShipmentStatus",
+		trace.WithAttributes(attribute.String("status", string(update.Status)),
+			attribute.String("source", string(update.Source))))
+	defer span.End()
+
+	if !update.Status.IsValid() {
+		return nil, ErrShipmentStatusInvalid
+	}
+	if update.ShipmentID == nil && (update.Carrier == "" || update.TrackingNumber == "") {
+		return nil, ErrShipmentTrackingNeeded
+	}
+
+	tx := sS.newPgRepo.GetRepo().WithContext(ctx).Begin()
+	defer tx.Rollback()
+
+	var shipment *models.Shipment
+	var err error
+	if update.ShipmentID != nil {
+		shipment, err = sS.shipmentRepo.GetForUpdate(ctx, tx, *update.ShipmentID)
+	} else {
+		shipment, err = sS.shipmentRepo.GetByTrackingForUpdate(ctx, tx, update.Carrier, update.TrackingNumber)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("shipment_id", shipment.ID.String()),
+		attribute.String("order_id", shipment.OrderID.String()))
+
+	if shipment.Status.IsStale(update.Status) {
+		return shipment, nil
+	}
+	if !shipment.Status.CanMoveTo(update.Status) {
+		return nil, ErrShipmentTransition
+	}
+
+	// the warehouse attaches the tracking number when it hands the parcel to the carrier
+	if update.ShipmentID != nil && update.TrackingNumber != "" {
+		if update.Carrier != "" {
+			shipment.Carrier = update.Carrier
+		}
+		if shipment.Carrier == "" {
+			return nil, ErrShipmentTrackingNeeded
+		}
+		shipment.TrackingNumber = update.TrackingNumber
+	}
+
+	at := sS.nowFunc()
+	if update.OccurredAt != nil {
+		at = *update.OccurredAt
+	}
+	switch update.Status {
+	case models.ShipmentStatusShipped:
+		shipment.ShippedAt = &at
+	case models.ShipmentStatusDelivered:
+		if shipment.ShippedAt == nil {
+			shipment.ShippedAt = &at
+		}
+		shipment.DeliveredAt = &at
+	case models.ShipmentStatusReturned:
+		shipment.ReturnedAt = &at
+	}
+	fromStatus := shipment.Status
+	shipment.Status = update.Status
+
+	if err = sS.shipmentRepo.Save(ctx, tx, shipment); err != nil {
+		span.RecordError(err)
+		logger.LogError(log, err, "failed to save shipment")
+		return nil, err
+	}
+
+	if update.Status.IsOutbound() && !fromStatus.IsOutbound() {
+		if err = sS.completeIfShipped(ctx, tx, shipment, update); err != nil {
+			span.RecordError(err)
+			logger.LogError(log, err, "failed to complete shipped order")
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "commit failed")
+		logger.LogError(log, err, "failed to commit tx")
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "updated shipment status")
+	return shipment, nil
+}
+
+// completeIfShipped completes the order of shipment once all its items left the warehouse
+func (sS *ShipmentService) completeIfShipped(ctx context.Context, tx *gorm.DB, shipment *models.Shipment, update models.ShipmentUpdate) error {
+	order, err := sS.orderRepo.GetForUpdate(ctx, tx, shipment.OrderID)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(order.Status, string(models.OrderStatusAuthorized)) {
+		return nil
+	}
+
+	shipped, err := sS.shipmentRepo.SumQuantities(ctx, tx, order.ID, models.OutboundShipmentStatuses)
+	if err != nil {
+		return err
+	}
+	for _, item := range order.OrderItems {
+		if shipped[item.ID] < item.Quantity {
+			return nil
+		}
+	}
+
+	change := models.StatusChange{
+		ActorType: models.ActorTypeSystem,
+		ActorID:   shipment.Carrier,
+		Source:    update.Source,
+		SourceRef: update.SourceRef,
+		Reason:    "all items shipped",
+	}
+	if change.SourceRef == "" {
+		change.SourceRef = "shipment/" + shipment.ID.String()
+	}
+	return sS.orderService.UpdateOrderStatusInTx(ctx, tx, order.ID, models.OrderStatusCompleted, change)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+)
+
+func TestSplitShipmentsCompleteTheOrderOnceEverythingShipped(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	oS, _ := testOrderService(t, pg)
+	orderRepo := repo.NewOrderRepository(pg)
+	sS := NewShipmentService(repo.NewShipmentRepository(pg), orderRepo, pg, oS)
+
+	created, err := oS.CreateOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderID := created.Data.OrderID
+	pending := models.CreateShipmentRequest{Items: []models.CreateShipmentItemRequest{{OrderItemID: uuid.New(), Quantity: 1}}}
+	if _, err = sS.CreateShipment(ctx, orderID, pending); !errors.Is(err, ErrOrderNotShippable) {
+		t.Errorf("shipping a pending order err = %v, want %v", err, ErrOrderNotShippable)
+	}
+	if err = oS.UpdateOrderStatus(ctx, orderID, models.OrderStatusAuthorized, testStatusChange("paid")); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+
+	order, err := orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the test order has an item of quantity 2 and one of quantity 1
+	var pair, single uuid.UUID
+	for _, orderItem := range order.OrderItems {
+		if orderItem.Quantity == 2 {
+			pair = orderItem.ID
+		} else {
+			single = orderItem.ID
+		}
+	}
+
+	first, err := sS.CreateShipment(ctx, orderID, models.CreateShipmentRequest{
+		Items: []models.CreateShipmentItemRequest{{OrderItemID: pair, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("CreateShipment: %v", err)
+	}
+	if _, err = sS.CreateShipment(ctx, orderID, models.CreateShipmentRequest{
+		Items: []models.CreateShipmentItemRequest{{OrderItemID: pair, Quantity: 2}},
+	}); !errors.Is(err, ErrShipmentQuantity) {
+		t.Errorf("shipping more than is left err = %v, want %v", err, ErrShipmentQuantity)
+	}
+	if _, err = sS.CreateShipment(ctx, orderID, models.CreateShipmentRequest{
+		Items: []models.CreateShipmentItemRequest{{OrderItemID: uuid.New(), Quantity: 1}},
+	}); !errors.Is(err, ErrShipmentItemInvalid) {
+		t.Errorf("shipping an item of another order err = %v, want %v", err, ErrShipmentItemInvalid)
+	}
+	second, err := sS.CreateShipment(ctx, orderID, models.CreateShipmentRequest{
+		Carrier:        "dhl",
+		TrackingNumber: "T-2",
+		Items: []models.CreateShipmentItemRequest{
+			{OrderItemID: pair, Quantity: 1},
+			{OrderItemID: single, Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateShipment of the rest: %v", err)
+	}
+
+	// the warehouse ships the first parcel and attaches its tracking number
+	shipped, err := sS.UpdateShipmentStatus(ctx, models.ShipmentUpdate{
+		ShipmentID:     &first.ID,
+		Carrier:        "ups",
+		TrackingNumber: "T-1",
+		Status:         models.ShipmentStatusShipped,
+		Source:         models.ChangeSourceHTTP,
+	})
+	if err != nil {
+		t.Fatalf("ship the first parcel: %v", err)
+	}
+	if shipped.TrackingNumber != "T-1" || shipped.ShippedAt == nil {
+		t.Errorf("first shipment = %+v, want tracking number T-1 and a ship date", shipped)
+	}
+	if order, err = orderRepo.GetByID(ctx, orderID); err != nil || order.Status != string(models.OrderStatusAuthorized) {
+		t.Fatalf("order with items left to ship = %v, %v; want %s", order.Status, err, models.OrderStatusAuthorized)
+	}
+
+	// the carrier reports the second parcel by its tracking number
+	if _, err = sS.UpdateShipmentStatus(ctx, models.ShipmentUpdate{
+		Carrier:        "dhl",
+		TrackingNumber: "T-2",
+		Status:         models.ShipmentStatusShipped,
+		Source:         models.ChangeSourceKafka,
+	}); err != nil {
+		t.Fatalf("ship the second parcel: %v", err)
+	}
+	if order, err = orderRepo.GetByID(ctx, orderID); err != nil || order.Status != string(models.OrderStatusCompleted) {
+		t.Errorf("order with every item shipped = %v, %v; want %s", order.Status, err, models.OrderStatusCompleted)
+	}
+
+	// a redelivered older event leaves the shipment as it is
+	stale, err := sS.UpdateShipmentStatus(ctx, models.ShipmentUpdate{ShipmentID: &second.ID, Status: models.ShipmentStatusPacked})
+	if err != nil || stale.Status != models.ShipmentStatusShipped {
+		t.Errorf("stale update = %v, %v; want the shipment left %s", stale.Status, err, models.ShipmentStatusShipped)
+	}
+	if _, err = sS.UpdateShipmentStatus(ctx, models.ShipmentUpdate{ShipmentID: &second.ID, Status: "LOST"}); !errors.Is(err, ErrShipmentStatusInvalid) {
+		t.Errorf("unknown status err = %v, want %v", err, ErrShipmentStatusInvalid)
+	}
+
+	shipments, err := sS.ListShipments(ctx, orderID)
+	if err != nil || len(shipments) != 2 {
+		t.Errorf("ListShipments = %d shipments, %v; want 2", len(shipments), err)
+	}
+}
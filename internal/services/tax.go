@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"order/internal/models"
+	"order/internal/tax"
+)
+
+// EnableTax prices orders with calculator. Orders without a country are not taxed.
+func (oS *OrderService) EnableTax(calculator tax.TaxCalculator) {
+	oS.taxCalculator = calculator
+}
+
+// applyRequestTax taxes the items of a new order and adds the tax to its total.
+// TotalAmount of the request is taken as the value of the items after discount.
+func (oS *OrderService) applyRequestTax(ctx context.Context, orderRequest *models.CreateOrderRequest) error {
+	if oS.taxCalculator == nil || orderRequest.Country == "" {
+		return nil
+	}
+
+	lines := make([]taxableLine, 0, len(orderRequest.OrderItems))
+	for i := range orderRequest.OrderItems {
+		item := &orderRequest.OrderItems[i]
+		if item.TaxClass == "" {
+			item.TaxClass = tax.ClassStandard
+		}
+		lines = append(lines, taxableLine{
+			ref:      item.ProductID.String(),
+			taxClass: item.TaxClass,
+			value:    float64(item.Quantity) * item.UniquePrice,
+		})
+	}
+
+	result, err := oS.calculateTax(ctx, orderRequest.Country, orderRequest.Region, lines, orderRequest.TotalAmount)
+	if err != nil {
+		return err
+	}
+	for i := range orderRequest.OrderItems {
+		orderRequest.OrderItems[i].TaxRate = result.Lines[i].Rate
+		orderRequest.OrderItems[i].TaxAmount = result.Lines[i].Amount
+	}
+	orderRequest.TaxAmount = result.Total
+	orderRequest.TotalAmount = tax.Round(orderRequest.TotalAmount + result.Total)
+	return nil
+}
+
+// applyOrderTax recomputes the tax of the items of an existing order after they changed
+func (oS *OrderService) applyOrderTax(ctx context.Context, order *models.Order) error {
+	if oS.taxCalculator == nil || order.TaxCountry == "" {
+		return nil
+	}
+
+	lines := make([]taxableLine, 0, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		lines = append(lines, taxableLine{
+			ref:      item.ID.String(),
+			taxClass: item.TaxClass,
+			value:    float64(item.Quantity) * item.UnitPrice,
+		})
+	}
+
+	result, err := oS.calculateTax(ctx, order.TaxCountry, order.TaxRegion, lines, order.NetOfDiscount())
+	if err != nil {
+		return err
+	}
+	for i := range order.OrderItems {
+		order.OrderItems[i].TaxRate = result.Lines[i].Rate
+		order.OrderItems[i].TaxAmount = result.Lines[i].Amount
+	}
+	order.TaxAmount = result.Total
+	return nil
+}
+
+type taxableLine struct {
+	ref      string
+	taxClass string
+	value    float64
+}
+
+// calculateTax asks the calculator for the tax of lines. The discount is spread over the lines
+// in proportion to their value, so each line is taxed on what the customer actually pays for it.
+func (oS *OrderService) calculateTax(
+	ctx context.Context,
+	country, region string,
+	lines []taxableLine,
+	net float64,
+) (*tax.Result, error) {
+	var subtotal float64
+	for _, line := range lines {
+		subtotal += line.value
+	}
+
+	req := tax.Request{Country: country, Region: region, Lines: make([]tax.Line, 0, len(lines))}
+	for _, line := range lines {
+		amount := 0.0
+		if subtotal > 0 {
+			amount = line.value * net / subtotal
+		}
+		req.Lines = append(req.Lines, tax.Line{Ref: line.ref, TaxClass: line.taxClass, Amount: amount})
+	}
+
+	result, err := oS.taxCalculator.Calculate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("calculate tax: %w", err)
+	}
+	if len(result.Lines) != len(lines) {
+		return nil, fmt.Errorf("calculate tax: %d tax lines for %d order lines", len(result.Lines), len(lines))
+	}
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/internal/tax"
+)
+
+// flatTaxRates charges every class the same rate in every country
+type flatTaxRates struct {
+	repo.TaxRateRepoInterface
+	rate float64
+}
+
+func (f flatTaxRates) FindRate(ctx context.Context, country, region, taxClass string) (*models.TaxRate, error) {
+	if taxClass != tax.ClassStandard {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.TaxRate{Country: country, TaxClass: taxClass, Rate: f.rate}, nil
+}
+
+func TestApplyRequestTaxSpreadsTheDiscount(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     float64
+		items     []models.CreateOrderItemRequest
+		wantLines []float64
+		wantTax   float64
+		wantTotal float64
+	}{
+		{
+			name:  "without discount",
+			total: 30,
+			items: []models.CreateOrderItemRequest{
+				{ProductID: uuid.New(), Quantity: 2, UniquePrice: 10},
+				{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10},
+			},
+			wantLines: []float64{3.8, 1.9},
+			wantTax:   5.7,
+			wantTotal: 35.7,
+		},
+		{
+			name:  "discount in proportion to the line values",
+			total: 25,
+			items: []models.CreateOrderItemRequest{
+				{ProductID: uuid.New(), Quantity: 2, UniquePrice: 10},
+				{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10},
+			},
+			// 16.67 and 8.33 after the discount
+			wantLines: []float64{3.17, 1.58},
+			wantTax:   4.75,
+			wantTotal: 29.75,
+		},
+		{
+			name:  "untaxed class keeps its share of the discount",
+			total: 10,
+			items: []models.CreateOrderItemRequest{
+				{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10},
+				{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10, TaxClass: "exempt"},
+			},
+			wantLines: []float64{0.95, 0},
+			wantTax:   0.95,
+			wantTotal: 10.95,
+		},
+		{
+			name:  "fully discounted",
+			total: 0,
+			items: []models.CreateOrderItemRequest{
+				{ProductID: uuid.New(), Quantity: 3, UniquePrice: 7},
+			},
+			wantLines: []float64{0},
+			wantTax:   0,
+			wantTotal: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oS := &OrderService{}
+			oS.EnableTax(tax.NewTableCalculator(flatTaxRates{rate: 0.19}))
+			req := &models.CreateOrderRequest{Country: "DE", TotalAmount: tt.total, OrderItems: tt.items}
+
+			if err := oS.applyRequestTax(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			for i, item := range req.OrderItems {
+				if item.TaxAmount != tt.wantLines[i] {
+					t.Errorf("item %d tax = %v, want %v", i, item.TaxAmount, tt.wantLines[i])
+				}
+			}
+			if req.TaxAmount != tt.wantTax || req.TotalAmount != tt.wantTotal {
+				t.Errorf("tax %v, total %v, want %v, %v", req.TaxAmount, req.TotalAmount, tt.wantTax, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestApplyRequestTaxSkipsOrdersWithoutCountry(t *testing.T) {
+	oS := &OrderService{}
+	oS.EnableTax(tax.NewTableCalculator(flatTaxRates{rate: 0.19}))
+	req := &models.CreateOrderRequest{TotalAmount: 10, OrderItems: []models.CreateOrderItemRequest{{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}}}
+
+	if err := oS.applyRequestTax(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if req.TaxAmount != 0 || req.TotalAmount != 10 {
+		t.Errorf("order without country taxed: tax %v, total %v", req.TaxAmount, req.TotalAmount)
+	}
+}
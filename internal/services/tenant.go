@@ -0,0 +1,163 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+	"order/pkg/core/tenant"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownTenant  = errors.New("unknown tenant")
+	ErrInactiveTenant = errors.New("tenant is not active")
+)
+
+type TenantServiceInterface interface {
+	Authorize(ctx context.Context, id string) error
+	Settings(ctx context.Context) models.Tenant
+	ActiveTenants(ctx context.Context) ([]string, error)
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+	UpsertTenant(ctx context.Context, id string, req models.UpsertTenantRequest) (*models.Tenant, error)
+}
+
+type cachedTenant struct {
+	tenant  *models.Tenant
+	err     error
+	expires time.Time
+}
+
+// TenantService resolves tenants and their settings. Lookups are cached for ttl since every
+// request is authorized against its tenant.
+type TenantService struct {
+	tenantRepo repo.TenantRepoInterface
+	defaults   models.Tenant
+	ttl        time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+// NewTenantService builds the tenant service. defaults holds the service-wide settings; its ID is
+// the default tenant, which exists without a row in the tenants table.
+func NewTenantService(tenantRepo repo.TenantRepoInterface, defaults models.Tenant, ttl time.Duration) *TenantService {
+	return &TenantService{
+		tenantRepo: tenantRepo,
+		defaults:   defaults,
+		ttl:        ttl,
+		cache:      map[string]cachedTenant{},
+	}
+}
+
+// Authorize checks that id names an active tenant
+func (tS *TenantService) Authorize(ctx context.Context, id string) error {
+	t, err := tS.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !t.IsActive {
+		return ErrInactiveTenant
+	}
+	return nil
+}
+
+// Settings returns the settings of the tenant of ctx, completed with the service-wide ones
+func (tS *TenantService) Settings(ctx context.Context) models.Tenant {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return tS.defaults
+	}
+	t, err := tS.get(ctx, id)
+	if err != nil {
+		return tS.defaults
+	}
+	return *t
+}
+
+// ActiveTenants lists the IDs of all active tenants, including the default tenant
+func (tS *TenantService) ActiveTenants(ctx context.Context) ([]string, error) {
+	tenants, err := tS.tenantRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	hasDefault := false
+	for _, t := range tenants {
+		if t.ID == tS.defaults.ID {
+			hasDefault = true
+		}
+		if t.IsActive {
+			ids = append(ids, t.ID)
+		}
+	}
+	if !hasDefault && tS.defaults.ID != "" {
+		ids = append(ids, tS.defaults.ID)
+	}
+	return ids, nil
+}
+
+func (tS *TenantService) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	return tS.tenantRepo.List(ctx)
+}
+
+// UpsertTenant creates or updates a tenant. New tenants are active unless stated otherwise.
+func (tS *TenantService) UpsertTenant(ctx context.Context, id string, req models.UpsertTenantRequest) (*models.Tenant, error) {
+	t := &models.Tenant{
+		ID:                   id,
+		Name:                 req.Name,
+		IsActive:             req.IsActive == nil || *req.IsActive,
+		BaseCurrency:         req.BaseCurrency,
+		InvoiceSellerName:    req.InvoiceSellerName,
+		InvoiceSellerTaxID:   req.InvoiceSellerTaxID,
+		InvoiceSellerAddress: req.InvoiceSellerAddress,
+		InvoiceSellerCountry: req.InvoiceSellerCountry,
+	}
+	if err := tS.tenantRepo.Upsert(ctx, t); err != nil {
+		return nil, err
+	}
+
+	tS.mu.Lock()
+	delete(tS.cache, id)
+	tS.mu.Unlock()
+	return t, nil
+}
+
+// get returns the tenant with empty settings taken from the defaults
+func (tS *TenantService) get(ctx context.Context, id string) (*models.Tenant, error) {
+	now := time.Now()
+
+	tS.mu.Lock()
+	cached, ok := tS.cache[id]
+	tS.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.tenant, cached.err
+	}
+
+	t, err := tS.tenantRepo.GetByID(ctx, id)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) && id == tS.defaults.ID:
+		t, err = &models.Tenant{ID: id, Name: id, IsActive: true}, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = ErrUnknownTenant
+	case err != nil:
+		// lookup failures are not cached
+		return nil, err
+	}
+	if t != nil {
+		t.BaseCurrency = cmp.Or(t.BaseCurrency, tS.defaults.BaseCurrency)
+		t.InvoiceSellerName = cmp.Or(t.InvoiceSellerName, tS.defaults.InvoiceSellerName)
+		t.InvoiceSellerTaxID = cmp.Or(t.InvoiceSellerTaxID, tS.defaults.InvoiceSellerTaxID)
+		t.InvoiceSellerAddress = cmp.Or(t.InvoiceSellerAddress, tS.defaults.InvoiceSellerAddress)
+		t.InvoiceSellerCountry = cmp.Or(t.InvoiceSellerCountry, tS.defaults.InvoiceSellerCountry)
+	}
+
+	tS.mu.Lock()
+	tS.cache[id] = cachedTenant{tenant: t, err: err, expires: now.Add(tS.ttl)}
+	tS.mu.Unlock()
+	return t, err
+}
@@ -0,0 +1,78 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// ClassStandard is the tax class of products that do not name one
+const ClassStandard = "standard"
+
+var ErrUnknownProvider = errors.New("unknown tax provider")
+
+// Line is one taxable order line. Amount is the net line value after discounts.
+type Line struct {
+	Ref      string
+	TaxClass string
+	Amount   float64
+}
+
+// Request asks for the tax of the lines of an order shipped to Country / Region
+type Request struct {
+	Country string
+	Region  string
+	Lines   []Line
+}
+
+// LineTax is the tax of the request line with the same Ref
+type LineTax struct {
+	Ref    string
+	Rate   float64
+	Amount float64
+}
+
+type Result struct {
+	Lines []LineTax
+	Total float64
+}
+
+// TaxCalculator computes the tax lines of an order. Implementations return one LineTax per
+// request line, in request order.
+type TaxCalculator interface {
+	Calculate(ctx context.Context, req Request) (*Result, error)
+}
+
+// Factory builds the calculator of a provider
+type Factory func() (TaxCalculator, error)
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Factory{}
+)
+
+// Register makes a provider available under name. External providers register themselves
+// here and are selected with TAX_PROVIDER.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[name] = factory
+}
+
+// New builds the calculator registered under name
+func New(name string) (TaxCalculator, error) {
+	mu.RLock()
+	factory, ok := providers[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return factory()
+}
+
+// Round rounds an amount to cents
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
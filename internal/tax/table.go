@@ -0,0 +1,54 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	repo "order/internal/repositories"
+)
+
+// ProviderTable is the built-in provider backed by the tax_rates table
+const ProviderTable = "table"
+
+// TableCalculator looks rates up by country, region and tax class. A rate for the region wins
+// over the country-wide rate (empty region); lines without any matching rate are not taxed.
+type TableCalculator struct {
+	rateRepo repo.TaxRateRepoInterface
+}
+
+func NewTableCalculator(rateRepo repo.TaxRateRepoInterface) *TableCalculator {
+	return &TableCalculator{rateRepo: rateRepo}
+}
+
+func (c *TableCalculator) Calculate(ctx context.Context, req Request) (*Result, error) {
+	rates := map[string]float64{}
+	result := &Result{Lines: make([]LineTax, 0, len(req.Lines))}
+
+	for _, line := range req.Lines {
+		class := line.TaxClass
+		if class == "" {
+			class = ClassStandard
+		}
+
+		rate, ok := rates[class]
+		if !ok {
+			found, err := c.rateRepo.FindRate(ctx, req.Country, req.Region, class)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				found = nil
+			} else if err != nil {
+				return nil, err
+			}
+			if found != nil {
+				rate = found.Rate
+			}
+			rates[class] = rate
+		}
+
+		amount := Round(line.Amount * rate)
+		result.Lines = append(result.Lines, LineTax{Ref: line.Ref, Rate: rate, Amount: amount})
+		result.Total += amount
+	}
+
+	result.Total = Round(result.Total)
+	return result, nil
+}
@@ -0,0 +1,163 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"order/internal/models"
+	repo "order/internal/repositories"
+)
+
+// fakeRates serves rates the way the tax_rates lookup does: the rate of the region wins over
+// the country-wide rate
+type fakeRates struct {
+	repo.TaxRateRepoInterface
+	rates   []models.TaxRate
+	err     error
+	lookups int
+}
+
+func (f *fakeRates) FindRate(ctx context.Context, country, region, taxClass string) (*models.TaxRate, error) {
+	f.lookups++
+	if f.err != nil {
+		return nil, f.err
+	}
+	var found *models.TaxRate
+	for i, rate := range f.rates {
+		if rate.Country != country || rate.TaxClass != taxClass {
+			continue
+		}
+		switch rate.Region {
+		case region:
+			return &f.rates[i], nil
+		case "":
+			found = &f.rates[i]
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return found, nil
+}
+
+var testRates = []models.TaxRate{
+	{Country: "US", Region: "", TaxClass: ClassStandard, Rate: 0.05},
+	{Country: "US", Region: "CA", TaxClass: ClassStandard, Rate: 0.0725},
+	{Country: "DE", Region: "", TaxClass: ClassStandard, Rate: 0.19},
+	{Country: "DE", Region: "", TaxClass: "reduced", Rate: 0.07},
+}
+
+func TestTableCalculatorCalculate(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       Request
+		wantRates []float64
+		wantLines []float64
+		wantTotal float64
+	}{
+		{
+			name:      "rate of the region",
+			req:       Request{Country: "US", Region: "CA", Lines: []Line{{Ref: "a", TaxClass: ClassStandard, Amount: 100}}},
+			wantRates: []float64{0.0725},
+			wantLines: []float64{7.25},
+			wantTotal: 7.25,
+		},
+		{
+			name:      "region without rate falls back to the country",
+			req:       Request{Country: "US", Region: "NY", Lines: []Line{{Ref: "a", TaxClass: ClassStandard, Amount: 100}}},
+			wantRates: []float64{0.05},
+			wantLines: []float64{5},
+			wantTotal: 5,
+		},
+		{
+			name:      "line without class is standard",
+			req:       Request{Country: "DE", Lines: []Line{{Ref: "a", Amount: 10}}},
+			wantRates: []float64{0.19},
+			wantLines: []float64{1.9},
+			wantTotal: 1.9,
+		},
+		{
+			name: "unknown tax class is not taxed",
+			req: Request{Country: "DE", Lines: []Line{
+				{Ref: "a", TaxClass: "luxury", Amount: 50},
+				{Ref: "b", TaxClass: "reduced", Amount: 50},
+			}},
+			wantRates: []float64{0, 0.07},
+			wantLines: []float64{0, 3.5},
+			wantTotal: 3.5,
+		},
+		{
+			name:      "country without rates is not taxed",
+			req:       Request{Country: "FR", Lines: []Line{{Ref: "a", TaxClass: ClassStandard, Amount: 50}}},
+			wantRates: []float64{0},
+			wantLines: []float64{0},
+			wantTotal: 0,
+		},
+		{
+			name: "lines are rounded to cents before they are summed",
+			req: Request{Country: "DE", Lines: []Line{
+				{Ref: "a", TaxClass: "reduced", Amount: 0.35},
+				{Ref: "b", TaxClass: "reduced", Amount: 0.35},
+				{Ref: "c", TaxClass: "reduced", Amount: 0.35},
+			}},
+			wantRates: []float64{0.07, 0.07, 0.07},
+			wantLines: []float64{0.02, 0.02, 0.02},
+			wantTotal: 0.06,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTableCalculator(&fakeRates{rates: testRates}).Calculate(context.Background(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Lines) != len(tt.req.Lines) {
+				t.Fatalf("%d tax lines for %d request lines", len(result.Lines), len(tt.req.Lines))
+			}
+			for i, line := range result.Lines {
+				if line.Ref != tt.req.Lines[i].Ref {
+					t.Errorf("line %d ref = %q, want %q", i, line.Ref, tt.req.Lines[i].Ref)
+				}
+				if line.Rate != tt.wantRates[i] || line.Amount != tt.wantLines[i] {
+					t.Errorf("line %d = %v at %v, want %v at %v", i, line.Amount, line.Rate, tt.wantLines[i], tt.wantRates[i])
+				}
+			}
+			if result.Total != tt.wantTotal {
+				t.Errorf("total = %v, want %v", result.Total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestTableCalculatorLooksUpEachClassOnce(t *testing.T) {
+	rates := &fakeRates{rates: testRates}
+	_, err := NewTableCalculator(rates).Calculate(context.Background(), Request{Country: "DE", Lines: []Line{
+		{Ref: "a", Amount: 1}, {Ref: "b", TaxClass: ClassStandard, Amount: 2}, {Ref: "c", TaxClass: "reduced", Amount: 3}, {Ref: "d", TaxClass: "luxury", Amount: 4}, {Ref: "e", TaxClass: "luxury", Amount: 5},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates.lookups != 3 {
+		t.Errorf("%d rate lookups, want one per tax class", rates.lookups)
+	}
+}
+
+func TestTableCalculatorFailsOnLookupError(t *testing.T) {
+	broken := errors.New("connection reset")
+	_, err := NewTableCalculator(&fakeRates{err: broken}).Calculate(context.Background(), Request{Country: "DE", Lines: []Line{{Ref: "a", Amount: 1}}})
+	if !errors.Is(err, broken) {
+		t.Errorf("Calculate() error = %v, want %v", err, broken)
+	}
+}
+
+func TestNew(t *testing.T) {
+	Register("test", func() (TaxCalculator, error) { return NewTableCalculator(&fakeRates{}), nil })
+	if calculator, err := New("test"); err != nil || calculator == nil {
+		t.Errorf("New(test) = %v, %v", calculator, err)
+	}
+	if _, err := New("avalara"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("New(avalara) error = %v, want %v", err, ErrUnknownProvider)
+	}
+}
@@ -3,6 +3,8 @@ package workers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -12,27 +14,59 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"log"
+	"order/internal/events"
+	"order/internal/grpc/clients/inventory"
 	"order/internal/grpc/clients/payment"
 	model "order/internal/models"
 	repo "order/internal/repositories/pg-gorm"
+	"order/pkg/core/kafka"
+	"order/pkg/core/tenant"
+	pbInventory "order/pkg/proto/inventorypb"
 	pbPayment "order/pkg/proto/paymentpb"
 	"sync"
 	"time"
 )
 
+var (
+	errInvalidPayload = errors.New("invalid outbox payload")
+	errNoPublisher    = errors.New("no publisher configured for outbox event")
+)
+
 type OutBoxWorker struct {
-	pg       repo.PGInterface
-	payment  paymentclient.PaymentClient
-	interval time.Duration
-	limit    int
+	pg        repo.PGInterface
+	payment   paymentclient.PaymentClient
+	inventory inventoryclient.InventoryClient
+	publisher *kafka.Producer
+	routes    map[string]*kafka.Producer
+	interval  time.Duration
+	limit     int
 }
 
-func NewOutboxWorkerInit(pg repo.PGInterface, pay paymentclient.PaymentClient) *OutBoxWorker {
+// NewOutboxWorkerInit builds the outbox worker. payment_required rows are delivered to the
+// payment service and reservation and restock follow-ups to the inventory service; every other event is
+// published on publisher, which may be nil when no event topic is configured; such events are
+// marked done without being published.
+func NewOutboxWorkerInit(
+	pg repo.PGInterface,
+	pay paymentclient.PaymentClient,
+	inventory inventoryclient.InventoryClient,
+	publisher *kafka.Producer,
+) *OutBoxWorker {
 	return &OutBoxWorker{
-		pg:       pg,
-		payment:  pay,
-		interval: 5 * time.Second,
-		limit:    10,
+		pg:        pg,
+		payment:   pay,
+		inventory: inventory,
+		publisher: publisher,
+		routes:    map[string]*kafka.Producer{},
+		interval:  5 * time.Second,
+		limit:     10,
+	}
+}
+
+// Route publishes rows of eventType on producer instead of the default publisher
+func (w *OutBoxWorker) Route(eventType events.EventType, producer *kafka.Producer) {
+	if producer != nil {
+		w.routes[eventType.String()] = producer
 	}
 }
 
@@ -59,7 +93,10 @@ func (w *OutBoxWorker) processBatch(ctx context.Context) error {
 		trace.WithAttributes(attribute.Int("limit", w.limit)))
 	defer span.End()
 
-	tx, cancel := w.pg.DBWithTimeout(ctx)
+	// the relay picks up the rows of all tenants; each row is then delivered as its tenant
+	ctx = tenant.System(ctx)
+
+	tx, cancel := w.pg.DBWithTimeout(ctx, "OutBoxWorker.processBatch")
 	if cancel != nil {
 		defer cancel()
 	}
@@ -92,15 +129,19 @@ func (w *OutBoxWorker) processBatch(ctx context.Context) error {
 			defer func() { <-sem }()
 
 			// get a db connection for this goroutine
-			db, dbCancel := w.pg.DBWithTimeout(ctx)
+			db, dbCancel := w.pg.DBWithTimeout(ctx, "OutBoxWorker.processBatch")
 			if dbCancel != nil {
 				defer dbCancel()
 			}
 
 			// create span using the context (not the *gorm.DB)
 			tracer = otel.Tracer("order/outbox-worker")
-			msgCtx, msgSpan := tracer.Start(ctx, "OutBoxWorker.processMessage",
-				trace.WithAttributes(attribute.String("event_id", o.EventID.String()), attribute.String("event_type", o.EventType)))
+			msgCtx, msgSpan := tracer.Start(tenant.NewContext(ctx, o.TenantID), "OutBoxWorker.processMessage",
+				trace.WithAttributes(
+					attribute.String("event_id", o.EventID.String()),
+					attribute.String("event_type", o.EventType),
+					attribute.String("tenant_id", o.TenantID),
+				))
 			defer msgSpan.End()
 
 			// attempt delivery in transaction to handle concurrent workers safely
@@ -110,14 +151,13 @@ func (w *OutBoxWorker) processBatch(ctx context.Context) error {
 				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", o.ID).First(&row).Error; err != nil {
 					return err
 				}
-				// skip if already done by another worker
-				if row.Status == model.OutboxStatusDone {
+				// skip if already done by another worker or superseded meanwhile
+				if row.Status != model.OutboxStatusPending && row.Status != model.OutboxStatusRetry {
 					return nil
 				}
 
-				// unmarshal payload into PayRequest
-				var payReq pbPayment.PayRequest
-				if err := json.Unmarshal([]byte(row.Payload), &payReq); err != nil {
+				err := w.deliver(msgCtx, &row)
+				if errors.Is(err, errInvalidPayload) {
 					msgSpan.RecordError(err)
 					msgSpan.SetStatus(codes.Error, "invalid payload")
 
@@ -128,17 +168,11 @@ func (w *OutBoxWorker) processBatch(ctx context.Context) error {
 					return tx.Save(&row).Error
 				}
 
-				// set EventID in request for idempotency
-				payReq.EventId = row.EventID.String()
-
-				// inject trace headers into outgoing metadata and put into context
-				headers := map[string]string{}
-				otel.GetTextMapPropagator().Inject(msgCtx, propagation.MapCarrier(headers))
-				md := metadata.New(headers)
-				callCtx := metadata.NewOutgoingContext(msgCtx, md)
-
-				// call payment service with the context that carries tracing/metadata
-				_, err := w.payment.Pay(callCtx, &payReq)
+				// nobody subscribes to events without a topic, so there is nothing to retry
+				if errors.Is(err, errNoPublisher) {
+					msgSpan.AddEvent("no publisher, event dropped")
+					err = nil
+				}
 
 				// if successful, mark done
 				if err == nil {
@@ -164,3 +198,67 @@ func (w *OutBoxWorker) processBatch(ctx context.Context) error {
 	wg.Wait()
 	return nil
 }
+
+// deliver dispatches an outbox row by event type. ctx is scoped to the tenant of the row, which
+// gRPC calls send in their metadata and Kafka messages in their headers.
+func (w *OutBoxWorker) deliver(ctx context.Context, row *model.Outbox) error {
+	switch row.EventType {
+	case events.EventPaymentRequired.String():
+		return w.pay(ctx, row)
+	case events.EventInventoryConfirm.String():
+		var req pbInventory.ConfirmRequest
+		if err := json.Unmarshal([]byte(row.Payload), &req); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidPayload, err)
+		}
+		req.EventId = row.EventID.String()
+		_, err := w.inventory.Confirm(ctx, &req)
+		return err
+	case events.EventInventoryRelease.String():
+		var req pbInventory.ReleaseRequest
+		if err := json.Unmarshal([]byte(row.Payload), &req); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidPayload, err)
+		}
+		req.EventId = row.EventID.String()
+		_, err := w.inventory.Release(ctx, &req)
+		return err
+	case events.EventInventoryRestock.String():
+		var req pbInventory.RestockRequest
+		if err := json.Unmarshal([]byte(row.Payload), &req); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidPayload, err)
+		}
+		req.EventId = row.EventID.String()
+		_, err := w.inventory.Restock(ctx, &req)
+		return err
+	default:
+		if producer, ok := w.routes[row.EventType]; ok {
+			return producer.SendMessage(ctx, row.AggregateID.String(), row.Payload)
+		}
+		if w.publisher == nil {
+			return errNoPublisher
+		}
+		return w.publisher.SendMessage(ctx, row.AggregateID.String(), row.Payload)
+	}
+}
+
+// pay delivers a payment_required row to the payment service
+func (w *OutBoxWorker) pay(ctx context.Context, row *model.Outbox) error {
+
+	// unmarshal payload into PayRequest
+	var payReq pbPayment.PayRequest
+	if err := json.Unmarshal([]byte(row.Payload), &payReq); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPayload, err)
+	}
+
+	// set EventID in request for idempotency
+	payReq.EventId = row.EventID.String()
+
+	// inject trace headers into outgoing metadata and put into context
+	headers := map[string]string{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	md := metadata.New(headers)
+	callCtx := metadata.NewOutgoingContext(ctx, md)
+
+	// call payment service with the context that carries tracing/metadata
+	_, err := w.payment.Pay(callCtx, &payReq)
+	return err
+}
@@ -3,8 +3,11 @@ package workers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"order/internal/events"
+	"strings"
 
 	"github.com/google/uuid"
 	"order/internal/models"
@@ -14,16 +17,15 @@ import (
 
 type PaymentEventWorker struct {
 	orderService services.OrderServiceInterface
-	producer     *kafka.Producer
 }
 
-func NewPaymentEventWorker(orderService services.OrderServiceInterface, producer *kafka.Producer) *PaymentEventWorker {
-	return &PaymentEventWorker{orderService: orderService, producer: producer}
+func NewPaymentEventWorker(orderService services.OrderServiceInterface) *PaymentEventWorker {
+	return &PaymentEventWorker{orderService: orderService}
 }
 
-func (w *PaymentEventWorker) Handle(ctx context.Context, data []byte) {
+func (w *PaymentEventWorker) Handle(ctx context.Context, msg kafka.Message) {
 	var evt models.PaymentAuthorizedEvent
-	if err := json.Unmarshal(data, &evt); err != nil {
+	if err := json.Unmarshal(msg.Value, &evt); err != nil {
 		log.Printf("failed to unmarshal payment event: %v", err)
 		return
 	}
@@ -34,31 +36,35 @@ func (w *PaymentEventWorker) Handle(ctx context.Context, data []byte) {
 		return
 	}
 
-	if evt.Status != events.PaymentAuthorized {
-		log.Printf("ignore payment event with status: %s", events.PaymentAuthorized)
+	var status models.OrderStatus
+	switch evt.Status {
+	case events.PaymentAuthorized:
+		status = models.OrderStatusAuthorized
+	case events.PaymentDeclined:
+		status = models.OrderStatusDeclined
+	default:
+		log.Printf("ignore payment event with status: %s", evt.Status)
 		return
 	}
 
-	// gọi service để cập nhật trạng thái đơn hàng
-	if err = w.orderService.UpdateOrderStatus(ctx, orderID, events.PaymentAuthorized); err != nil {
-		log.Printf("failed to update order status: %v", err)
-		return
-	}
-
-	promotionEvent := models.PromotionRewardEvent{
-		OrderID: evt.OrderID,
+	change := models.StatusChange{
+		ActorType: models.ActorTypeWorker,
+		ActorID:   "payment_event_worker",
+		Source:    models.ChangeSourceKafka,
+		SourceRef: fmt.Sprintf("%s/%d@%d", msg.Topic, msg.Partition, msg.Offset),
+		Reason:    "payment " + evt.PaymentID + " " + strings.ToLower(string(evt.Status)),
 	}
 
-	eventData, err := json.Marshal(promotionEvent)
-	if err != nil {
-		log.Printf("failed to marshal promotion event: %v", err)
+	// the checkout saga confirms the stock and requests the promotion reward, or compensates
+	err = w.orderService.ApplyPaymentResult(ctx, orderID, evt, status, change)
+	if errors.Is(err, services.ErrStalePayment) {
+		log.Printf("ignore payment %s of order %s for a superseded payment request", evt.PaymentID, orderID.String())
 		return
 	}
-
-	if err = w.producer.SendMessage(ctx, evt.OrderID, string(eventData)); err != nil {
-		log.Printf("failed to send promotion event: %v", err)
+	if err != nil {
+		log.Printf("failed to update order status: %v", err)
 		return
 	}
 
-	log.Printf("order %s updated to Authorized by payment event", orderID.String())
+	log.Printf("order %s updated to %s by payment event", orderID.String(), status)
 }
@@ -0,0 +1,58 @@
+package workers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order/internal/events"
+	"order/internal/grpc/clients/inventory"
+	"order/internal/models"
+	"order/internal/pgtest"
+	repo "order/internal/repositories"
+	"order/internal/services"
+	"order/pkg/core/kafka"
+)
+
+func TestPaymentEventWorkerRecordsTheMessageInTheHistory(t *testing.T) {
+	pg := pgtest.Open(t)
+	ctx := pgtest.Context()
+	historyRepo := repo.NewOrderStatusHistoryRepository(pg)
+	oS := services.NewOrderService(repo.NewOrderRepository(pg), pg, nil, inventoryclient.NewFakeInventoryClient(nil),
+		repo.NewOutboxRepository(pg), historyRepo, repo.NewPromotionRepository(pg), time.Hour)
+
+	created, err := oS.CreateOrder(ctx, models.CreateOrderRequest{
+		CustomerID:  uuid.New(),
+		TotalAmount: 10,
+		Status:      "pending",
+		Currency:    "EUR",
+		OrderItems:  []models.CreateOrderItemRequest{{ProductID: uuid.New(), Quantity: 1, UniquePrice: 10}},
+		Audit:       models.StatusChange{ActorType: models.ActorTypeSystem, Source: models.ChangeSourceGRPC},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+
+	value, _ := json.Marshal(models.PaymentAuthorizedEvent{
+		PaymentID: "pay-1",
+		OrderID:   created.Data.OrderID.String(),
+		Amount:    created.Data.TotalAmount,
+		Status:    events.PaymentAuthorized,
+	})
+	NewPaymentEventWorker(oS).Handle(ctx, kafka.Message{Topic: "payments", Partition: 3, Offset: 42, Value: value})
+
+	history, err := historyRepo.ListByOrderID(ctx, created.Data.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history = %+v, want the creation and the authorization", history)
+	}
+	paid := history[1]
+	if paid.ToStatus != string(models.OrderStatusAuthorized) || paid.Source != models.ChangeSourceKafka ||
+		paid.SourceRef != "payments/3@42" || paid.ActorType != models.ActorTypeWorker || paid.ActorID != "payment_event_worker" {
+		t.Errorf("authorization = %+v, want it attributed to payments/3@42", paid)
+	}
+}
@@ -0,0 +1,39 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"order/internal/services"
+	"order/pkg/core/tenant"
+	"time"
+)
+
+// PromotionMetricsWorker periodically refreshes the promotion analytics gauges
+type PromotionMetricsWorker struct {
+	promotionService services.PromotionServiceInterface
+	interval         time.Duration
+}
+
+func NewPromotionMetricsWorker(promotionService services.PromotionServiceInterface) *PromotionMetricsWorker {
+	return &PromotionMetricsWorker{
+		promotionService: promotionService,
+		interval:         time.Minute,
+	}
+}
+
+// Run refreshes the gauges of the promotions of all tenants until ctx is done
+func (w *PromotionMetricsWorker) Run(ctx context.Context) {
+	ctx = tenant.System(ctx)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.promotionService.RefreshPromotionMetrics(ctx); err != nil {
+				log.Printf("promotion metrics worker error: %v", err)
+			}
+		}
+	}
+}
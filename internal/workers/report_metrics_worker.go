@@ -0,0 +1,39 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"order/internal/services"
+	"order/pkg/core/tenant"
+	"time"
+)
+
+// ReportMetricsWorker periodically sets the headline order gauges from the daily order stats
+type ReportMetricsWorker struct {
+	reportService services.ReportServiceInterface
+	interval      time.Duration
+}
+
+func NewReportMetricsWorker(reportService services.ReportServiceInterface) *ReportMetricsWorker {
+	return &ReportMetricsWorker{
+		reportService: reportService,
+		interval:      time.Minute,
+	}
+}
+
+// Run refreshes the gauges of all tenants until ctx is done
+func (w *ReportMetricsWorker) Run(ctx context.Context) {
+	ctx = tenant.System(ctx)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.reportService.RefreshReportMetrics(ctx); err != nil {
+				log.Printf("report metrics worker error: %v", err)
+			}
+		}
+	}
+}
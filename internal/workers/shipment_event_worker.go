@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"order/internal/models"
+	"order/internal/services"
+	"order/pkg/core/kafka"
+)
+
+// ShipmentEventWorker applies carrier tracking updates published on the shipment updates topic
+type ShipmentEventWorker struct {
+	shipmentService services.ShipmentServiceInterface
+}
+
+func NewShipmentEventWorker(shipmentService services.ShipmentServiceInterface) *ShipmentEventWorker {
+	return &ShipmentEventWorker{shipmentService: shipmentService}
+}
+
+func (w *ShipmentEventWorker) Handle(ctx context.Context, msg kafka.Message) {
+	var update models.ShipmentUpdate
+	if err := json.Unmarshal(msg.Value, &update); err != nil {
+		log.Printf("failed to unmarshal shipment event: %v", err)
+		return
+	}
+	update.Status = models.ShipmentStatus(strings.ToUpper(string(update.Status)))
+	update.Source = models.ChangeSourceKafka
+	update.SourceRef = fmt.Sprintf("%s/%d@%d", msg.Topic, msg.Partition, msg.Offset)
+
+	shipment, err := w.shipmentService.UpdateShipmentStatus(ctx, update)
+	if err != nil {
+		log.Printf("failed to update shipment %s/%s: %v", update.Carrier, update.TrackingNumber, err)
+		return
+	}
+
+	log.Printf("shipment %s is %s", shipment.ID.String(), shipment.Status)
+}
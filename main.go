@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	limit "github.com/aviddiviner/gin-limit"
 	"github.com/gin-gonic/gin"
 	"log"
@@ -21,6 +22,15 @@ func main() {
 	logger.Init(utils.APPNAME)
 	logger.SetupLogger()
 
+	// `order migrate ...` manages the schema instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := bootstrap.Migrate(context.Background(), os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize application
 	app, err := bootstrap.InitializeAppConfiguration()
 	if err != nil {
@@ -38,6 +48,13 @@ func main() {
 	// ensure tracer provider shutdown on process exit
 	defer func() { _ = cleanup(context.Background()) }()
 
+	// the order service is shared by the admin routes and the gRPC server
+	app.OrderCore, err = bootstrap.NewOrderCore(ctx, app)
+	if err != nil {
+		logger.LogError(logger.WithTag("Backend|Main"), err, "failed to initialize order service")
+		return
+	}
+
 	// Setup and start server
 	router := gin.Default()
 	router.Use(limit.MaxAllowed(200))
@@ -15,6 +15,32 @@ type Config struct {
 	PostgresPort     string `env:"POSTGRES_PORT" envDefault:"5432"`
 	PostgresDatabase string `env:"POSTGRES_DATABASE"`
 
+	// Connection configs; the SSL certs are paths, a zero statement timeout leaves statements unbounded
+	PostgresSSLMode            string `env:"POSTGRES_SSL_MODE" envDefault:"disable"`
+	PostgresSSLRootCert        string `env:"POSTGRES_SSL_ROOT_CERT"`
+	PostgresSSLCert            string `env:"POSTGRES_SSL_CERT"`
+	PostgresSSLKey             string `env:"POSTGRES_SSL_KEY"`
+	PostgresApplicationName    string `env:"POSTGRES_APPLICATION_NAME" envDefault:"order-service"`
+	PostgresConnectTimeoutSecs int    `env:"POSTGRES_CONNECT_TIMEOUT_SECONDS" envDefault:"10"`
+	PostgresStatementTimeoutMs int    `env:"POSTGRES_STATEMENT_TIMEOUT_MS" envDefault:"0"`
+
+	// Pool configs, applied to the primary and to every replica
+	PostgresMaxOpenConns           int `env:"POSTGRES_MAX_OPEN_CONNS" envDefault:"25"`
+	PostgresMaxIdleConns           int `env:"POSTGRES_MAX_IDLE_CONNS" envDefault:"10"`
+	PostgresConnMaxLifetimeMinutes int `env:"POSTGRES_CONN_MAX_LIFETIME_MINUTES" envDefault:"30"`
+	PostgresConnMaxIdleTimeMinutes int `env:"POSTGRES_CONN_MAX_IDLE_TIME_MINUTES" envDefault:"5"`
+
+	// Query timeouts of the repositories; POSTGRES_OPERATION_TIMEOUTS overrides the default per
+	// operation, eg. "OrderStatsRepository.Rebuild:5m,OrderRepository.StreamOrders:0s"
+	PostgresQueryTimeoutSeconds int               `env:"POSTGRES_QUERY_TIMEOUT_SECONDS" envDefault:"60"`
+	PostgresOperationTimeouts   map[string]string `env:"POSTGRES_OPERATION_TIMEOUTS"`
+
+	// Read replica configs; list and report reads go to the replicas that pass the health
+	// check and lag less than the max lag, to the primary otherwise
+	PostgresReplicaDSNs          []string `env:"POSTGRES_REPLICA_DSNS" envSeparator:";"`
+	PostgresReplicaCheckSeconds  int      `env:"POSTGRES_REPLICA_CHECK_SECONDS" envDefault:"5"`
+	PostgresReplicaMaxLagSeconds int      `env:"POSTGRES_REPLICA_MAX_LAG_SECONDS" envDefault:"10"`
+
 	// Server configs
 	ServerPort string `env:"SERVER_PORT" envDefault:"8080"`
 
@@ -34,11 +60,61 @@ type Config struct {
 	// Payment service address
 	PaymentServiceAddr string `env:"PAYMENT_SERVICE_ADDR" envDefault:"localhost:50052"`
 
+	// Inventory service address; INVENTORY_FAKE uses an in-memory inventory with unlimited stock
+	// instead, e.g. for local development
+	InventoryServiceAddr           string `env:"INVENTORY_SERVICE_ADDR"`
+	InventoryFake                  bool   `env:"INVENTORY_FAKE" envDefault:"false"`
+	InventoryReservationTTLMinutes int    `env:"INVENTORY_RESERVATION_TTL_MINUTES" envDefault:"30"`
+
 	// Metrics server port
 	MetricsAddress string `env:"METRICS_ADDR" envDefault:":9090"`
 
 	// Jeager tracing configs
 	JaegerEndpoint string `env:"JAEGER_ENDPOINT" envDefault:"http://localhost:14268/api/traces"`
+
+	// Scheduler configs
+	SchedulerEnabled           bool `env:"SCHEDULER_ENABLED" envDefault:"true"`
+	OrderPaymentTimeoutMinutes int  `env:"ORDER_PAYMENT_TIMEOUT_MINUTES" envDefault:"30"`
+	OrderReconcileAfterMinutes int  `env:"ORDER_RECONCILE_AFTER_MINUTES" envDefault:"10"`
+	OutboxRetentionHours       int  `env:"OUTBOX_RETENTION_HOURS" envDefault:"168"`
+
+	// Checkout saga configs; without the saga new orders reserve their stock and request their
+	// payment directly
+	CheckoutSagaEnabled bool `env:"CHECKOUT_SAGA_ENABLED" envDefault:"true"`
+
+	// Event sourcing configs
+	OrderEventSourcingEnabled bool `env:"ORDER_EVENT_SOURCING_ENABLED" envDefault:"false"`
+	OrderSnapshotEvery        int  `env:"ORDER_SNAPSHOT_EVERY" envDefault:"50"`
+
+	// Cart configs
+	CartTTLHours int `env:"CART_TTL_HOURS" envDefault:"72"`
+
+	// Shipment configs; webhooks are rejected while the token is empty
+	ShipmentWebhookToken string `env:"SHIPMENT_WEBHOOK_TOKEN"`
+
+	// Tax configs; orders are not taxed while the provider is empty
+	TaxProvider string `env:"TAX_PROVIDER" envDefault:"table"`
+
+	// Search configs; the provider answers the order search of support agents
+	SearchProvider string `env:"SEARCH_PROVIDER" envDefault:"postgres"`
+
+	// Tenant configs; requests and messages naming no tenant belong to TENANT_DEFAULT.
+	// An empty default makes the tenant mandatory.
+	TenantDefault         string `env:"TENANT_DEFAULT" envDefault:"default"`
+	TenantCacheTTLSeconds int    `env:"TENANT_CACHE_TTL_SECONDS" envDefault:"60"`
+
+	// Currency configs; promotions and analytics are in the base currency
+	BaseCurrency string `env:"BASE_CURRENCY" envDefault:"USD"`
+
+	// Invoice configs; the seller is printed on every invoice and credit note
+	InvoiceSellerName    string `env:"INVOICE_SELLER_NAME"`
+	InvoiceSellerTaxID   string `env:"INVOICE_SELLER_TAX_ID"`
+	InvoiceSellerAddress string `env:"INVOICE_SELLER_ADDRESS"`
+	InvoiceSellerCountry string `env:"INVOICE_SELLER_COUNTRY"`
+
+	// Export configs; async exports are written below EXPORT_DIR and kept for EXPORT_RETENTION_HOURS
+	ExportDir            string `env:"EXPORT_DIR" envDefault:"./exports"`
+	ExportRetentionHours int    `env:"EXPORT_RETENTION_HOURS" envDefault:"72"`
 }
 
 var (
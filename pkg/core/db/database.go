@@ -6,7 +6,10 @@ import (
 	"gorm.io/gorm"
 	"log"
 	"order/pkg/core/configloader"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 var (
@@ -24,13 +27,9 @@ func DatabaseInitialization(config *configloader.Config) (*gorm.DB, error) {
 
 // initializeDatabase creates and configures the database connection
 func initializeDatabase(config *configloader.Config) (*gorm.DB, error) {
-	// Create connection string using environment config
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		config.PostgresHost, config.PostgresPort, config.PostgresUser, config.PostgresPassword, config.PostgresDatabase)
-
 	// Open database connection
 	gormDB, err := gorm.Open(postgres.New(postgres.Config{
-		DSN:                  dsn,
+		DSN:                  DSN(config),
 		PreferSimpleProtocol: true, // disables implicit prepared statement usage
 	}), &gorm.Config{})
 
@@ -38,6 +37,10 @@ func initializeDatabase(config *configloader.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err = configurePool(gormDB, config); err != nil {
+		return nil, err
+	}
+
 	// Verify connection
 	var result int
 	if err = gormDB.Raw("SELECT 1").Scan(&result).Error; err != nil {
@@ -47,3 +50,77 @@ func initializeDatabase(config *configloader.Config) (*gorm.DB, error) {
 	log.Println("Database connection established")
 	return gormDB, nil
 }
+
+// ReplicaInitialization opens the read replicas of config. Replicas are not pinged here: one that is
+// down at startup only takes reads once its health check passes.
+func ReplicaInitialization(config *configloader.Config) ([]*gorm.DB, error) {
+	var replicas []*gorm.DB
+	for i, dsn := range config.PostgresReplicaDSNs {
+		if strings.TrimSpace(dsn) == "" {
+			continue
+		}
+		replica, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  dsn,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{DisableAutomaticPing: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		if err = configurePool(replica, config); err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
+// DSN is the connection string of the primary. application_name and statement_timeout are sent
+// as run-time parameters, so they hold for every connection of the pool.
+func DSN(config *configloader.Config) string {
+	params := [][2]string{
+		{"host", config.PostgresHost},
+		{"port", config.PostgresPort},
+		{"user", config.PostgresUser},
+		{"password", config.PostgresPassword},
+		{"dbname", config.PostgresDatabase},
+		{"sslmode", config.PostgresSSLMode},
+		{"sslrootcert", config.PostgresSSLRootCert},
+		{"sslcert", config.PostgresSSLCert},
+		{"sslkey", config.PostgresSSLKey},
+		{"application_name", config.PostgresApplicationName},
+	}
+	if config.PostgresConnectTimeoutSecs > 0 {
+		params = append(params, [2]string{"connect_timeout", strconv.Itoa(config.PostgresConnectTimeoutSecs)})
+	}
+	if config.PostgresStatementTimeoutMs > 0 {
+		params = append(params, [2]string{"statement_timeout", strconv.Itoa(config.PostgresStatementTimeoutMs)})
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, param := range params {
+		if param[1] == "" {
+			continue
+		}
+		parts = append(parts, param[0]+"="+quoteDSNValue(param[1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSNValue quotes a keyword/value connection string value, eg. a password with spaces
+func quoteDSNValue(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + value + "'"
+}
+
+// configurePool applies the pool limits of config to db
+func configurePool(db *gorm.DB, config *configloader.Config) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database pool: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(config.PostgresMaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.PostgresMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(config.PostgresConnMaxLifetimeMinutes) * time.Minute)
+	sqlDB.SetConnMaxIdleTime(time.Duration(config.PostgresConnMaxIdleTimeMinutes) * time.Minute)
+	return nil
+}
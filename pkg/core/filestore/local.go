@@ -0,0 +1,86 @@
+package filestore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidName = errors.New("invalid file name")
+
+// Local keeps files in a directory of the local disk. Instances serving downloads of each
+// other's files need the directory on a shared volume.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir}, nil
+}
+
+// File is a file being written; it only shows up under its name once it is committed
+type File struct {
+	*os.File
+	path string
+}
+
+// Create starts writing the file name
+func (l *Local) Create(name string) (*File, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(l.dir, ".part-*")
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: f, path: path}, nil
+}
+
+// Commit closes the file and moves it under its name
+func (f *File) Commit() error {
+	if err := f.File.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), f.path)
+}
+
+// Abort closes and drops the file
+func (f *File) Abort() {
+	_ = f.File.Close()
+	_ = os.Remove(f.Name())
+}
+
+// Open opens a committed file for reading
+func (l *Local) Open(name string) (*os.File, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Remove deletes a committed file; a missing file is not an error
+func (l *Local) Remove(name string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path resolves name inside the store directory; names cannot leave it
+func (l *Local) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(l.dir, name), nil
+}
@@ -2,9 +2,12 @@ package jwt
 
 import (
 	"context"
+	"fmt"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"order/pkg/core/configloader"
 	"order/pkg/core/logger"
+	"order/pkg/core/tenant"
 	"order/pkg/http/utils/errors"
 	"strconv"
 	"time"
@@ -99,3 +102,39 @@ func GenerateJWTTokenUser(context context.Context,
 //		},
 //	}
 //}
+
+// CallerFromToken verifies an access token and returns its tenant claim and whether its role
+// may act for any tenant
+func CallerFromToken(tokenString string) (tenant.Caller, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(configloader.GetConfig().JWTAccessSecure), nil
+	})
+	if err != nil {
+		return tenant.Caller{}, err
+	}
+	id, _ := claims[tenant.Claim].(string)
+	role, _ := claims["role"].(string)
+	return tenant.Caller{Tenant: id, Platform: role == tenant.PlatformRole}, nil
+}
+
+// CustomerFromToken verifies an access token and returns the customer ID held in its subject
+func CustomerFromToken(tokenString string) (uuid.UUID, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(configloader.GetConfig().JWTAccessSecure), nil
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if claims.TokenType != "" && claims.TokenType != UserAccess {
+		return uuid.Nil, fmt.Errorf("unexpected token type %q", claims.TokenType)
+	}
+	return uuid.Parse(claims.Subject)
+}
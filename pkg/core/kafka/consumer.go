@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/segmentio/kafka-go"
 	"log"
+	"order/pkg/core/tenant"
 	"strings"
 )
 
@@ -58,7 +59,29 @@ func NewReader(brokers, topic, groupID string) ReaderWrapper {
 	return &KafkaReader{reader: r}
 }
 
+// Message is the kafka message handed to ListenMessage handlers
+type Message = kafka.Message
+
+// TenantContext scopes ctx to the tenant named in the x-tenant-id header of msg, or to fallback
+// for messages without one
+func TenantContext(ctx context.Context, msg Message, fallback string) context.Context {
+	for _, header := range msg.Headers {
+		if header.Key == tenant.Header && len(header.Value) > 0 {
+			return tenant.NewContext(ctx, string(header.Value))
+		}
+	}
+	if fallback == "" {
+		return ctx
+	}
+	return tenant.NewContext(ctx, fallback)
+}
+
 func (c *Consumer) Listen(ctx context.Context, handler func([]byte)) {
+	c.ListenMessage(ctx, func(msg Message) { handler(msg.Value) })
+}
+
+// ListenMessage is like Listen but hands the whole message (topic, partition, offset, headers) to the handler
+func (c *Consumer) ListenMessage(ctx context.Context, handler func(Message)) {
 	for {
 		msg, err := c.Reader.FetchMessage(ctx)
 		if err != nil {
@@ -73,7 +96,7 @@ func (c *Consumer) Listen(ctx context.Context, handler func([]byte)) {
 			continue
 		}
 
-		handler(msg.Value)
+		handler(msg)
 
 		if err := c.Reader.CommitMessages(ctx, msg); err != nil {
 			log.Printf("Failed to commit message: %v", err)
@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"github.com/segmentio/kafka-go"
+	"order/pkg/core/tenant"
 )
 
 type Producer struct {
@@ -15,11 +16,15 @@ func NewProducer(brokers, topic string) *Producer {
 	}
 }
 
+// SendMessage publishes value under key. The tenant of ctx, if any, is sent in the x-tenant-id header.
 func (p *Producer) SendMessage(ctx context.Context, key, value string) error {
 	msg := kafka.Message{
 		Key:   []byte(key),
 		Value: []byte(value),
 	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: tenant.Header, Value: []byte(id)})
+	}
 	return p.Writer.WriteMessages(ctx, msg)
 }
 
@@ -0,0 +1,79 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// Header carries the tenant in gRPC metadata, HTTP requests and Kafka messages
+	Header = "x-tenant-id"
+	// Claim is the JWT claim naming the tenant of the caller
+	Claim = "tenant_id"
+	// Default is the tenant rows created before multi-tenancy belong to
+	Default = "default"
+	// PlatformRole is the token role of operators and services trusted to act for any tenant
+	PlatformRole = "platform_admin"
+)
+
+var (
+	ErrMissingTenant   = errors.New("tenant not resolved")
+	ErrTenantMismatch  = errors.New("tenant of token and header differ")
+	ErrUntrustedHeader = errors.New("tenant header needs a matching tenant claim or the platform role")
+)
+
+// Caller is what the verified token of a request says about its tenant. The zero Caller
+// stands for a request without token.
+type Caller struct {
+	// Tenant is the tenant_id claim, empty for tokens not bound to a tenant
+	Tenant string
+	// Platform is set for tokens with the PlatformRole
+	Platform bool
+}
+
+type tenantKey struct{}
+
+type systemKey struct{}
+
+// NewContext returns a copy of ctx scoped to tenant id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ctx is scoped to
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
+
+// System marks ctx for trusted background work that spans tenants, such as the outbox relay.
+// Queries in a system context are not scoped, and rows created in it must name their tenant.
+func System(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+// IsSystem reports whether ctx was marked with System
+func IsSystem(ctx context.Context) bool {
+	system, _ := ctx.Value(systemKey{}).(bool)
+	return system
+}
+
+// Resolve picks the tenant of a request from the token claim of caller and the header. The
+// header only selects a tenant when it agrees with the claim or the caller has the platform
+// role; without either the fallback is used, if any.
+func Resolve(caller Caller, header, fallback string) (string, error) {
+	switch {
+	case caller.Tenant != "" && header != "" && caller.Tenant != header:
+		return "", ErrTenantMismatch
+	case caller.Tenant != "":
+		return caller.Tenant, nil
+	case header != "" && !caller.Platform:
+		return "", ErrUntrustedHeader
+	case header != "":
+		return header, nil
+	case fallback != "":
+		return fallback, nil
+	default:
+		return "", ErrMissingTenant
+	}
+}
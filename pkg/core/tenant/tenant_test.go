@@ -0,0 +1,55 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		caller   Caller
+		header   string
+		fallback string
+		want     string
+		wantErr  error
+	}{
+		{name: "claim", caller: Caller{Tenant: "acme"}, fallback: Default, want: "acme"},
+		{name: "claim matching header", caller: Caller{Tenant: "acme"}, header: "acme", want: "acme"},
+		{name: "claim and other header", caller: Caller{Tenant: "acme"}, header: "globex", wantErr: ErrTenantMismatch},
+		{name: "platform claim and other header", caller: Caller{Tenant: "acme", Platform: true}, header: "globex", wantErr: ErrTenantMismatch},
+		{name: "platform header", caller: Caller{Platform: true}, header: "globex", fallback: Default, want: "globex"},
+		{name: "platform without header", caller: Caller{Platform: true}, fallback: Default, want: Default},
+		{name: "token without claim and header", caller: Caller{}, header: "globex", fallback: Default, wantErr: ErrUntrustedHeader},
+		{name: "no token and header", header: "globex", fallback: Default, wantErr: ErrUntrustedHeader},
+		{name: "no token", fallback: Default, want: Default},
+		{name: "nothing to go by", wantErr: ErrMissingTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.caller, tt.header, tt.fallback)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("FromContext() of a bare context resolved a tenant")
+	}
+	if _, ok := FromContext(NewContext(context.Background(), "")); ok {
+		t.Error("FromContext() resolved an empty tenant")
+	}
+	if id, ok := FromContext(NewContext(context.Background(), "acme")); !ok || id != "acme" {
+		t.Errorf("FromContext() = %q, %v, want acme, true", id, ok)
+	}
+	if IsSystem(context.Background()) || !IsSystem(System(context.Background())) {
+		t.Error("IsSystem() does not follow System()")
+	}
+}
@@ -5,11 +5,25 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"order/pkg/core/configloader"
+	"order/pkg/core/tenant"
 	"order/pkg/http/utils/errors"
 	"strings"
 )
 
+// RolePlatformAdmin is the role of operators who manage tenants; a tenant admin does not have it
+const RolePlatformAdmin = tenant.PlatformRole
+
 func AuthMiddleware() gin.HandlerFunc {
+	return roleMiddleware(authorize)
+}
+
+// PlatformAdminMiddleware admits only callers whose token carries the platform_admin role. It
+// guards the routes that act across tenants, which tenant admins must not reach.
+func PlatformAdminMiddleware() gin.HandlerFunc {
+	return roleMiddleware(func(role string) bool { return role == RolePlatformAdmin })
+}
+
+func roleMiddleware(authorize func(role string) bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		config := configloader.GetConfig()
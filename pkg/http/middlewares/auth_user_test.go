@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"order/pkg/core/configloader"
+)
+
+func TestPlatformAdminMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_ACCESS_SECURE", "test-secret")
+	secret := []byte(configloader.GetConfig().JWTAccessSecure)
+
+	token := func(role string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"role": role, "tenant_id": "acme"}).SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + signed
+	}
+
+	tests := []struct {
+		name      string
+		header    string
+		wantAdmit bool
+	}{
+		{name: "platform admin", header: token(RolePlatformAdmin), wantAdmit: true},
+		{name: "tenant admin", header: token("admin")},
+		{name: "customer", header: token("customer")},
+		{name: "no token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/v1/internal/tenants", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("Authorization", tt.header)
+			}
+
+			PlatformAdminMiddleware()(c)
+			if admitted := !c.IsAborted(); admitted != tt.wantAdmit {
+				t.Errorf("admitted = %v, want %v", admitted, tt.wantAdmit)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"order/pkg/core/jwt"
+	"order/pkg/http/utils/errors"
+	"strings"
+)
+
+// CustomerAuthMiddleware authenticates a customer by the bearer token, whose subject is the
+// customer ID, and sets customer_id for the handlers
+func CustomerAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			_ = c.Error(errors.Error("fail to authenticate", errors.StatusValidationError))
+			c.Abort()
+			return
+		}
+
+		customerID, err := jwt.CustomerFromToken(tokenString)
+		if err != nil {
+			_ = c.Error(errors.Error("fail to authenticate", errors.StatusValidationError))
+			c.Abort()
+			return
+		}
+
+		c.Set("customer_id", customerID)
+		c.Next()
+	}
+}
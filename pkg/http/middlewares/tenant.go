@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"order/pkg/core/jwt"
+	"order/pkg/core/tenant"
+	"order/pkg/http/utils/errors"
+	"strings"
+)
+
+// TenantMiddleware scopes the request to the tenant named by the tenant_id claim of the bearer
+// token or by the X-Tenant-ID header, falling back to fallback when neither is sent. The header
+// is only trusted from platform tokens or when it matches the claim. authorize rejects tenants
+// that are unknown or not active.
+func TenantMiddleware(fallback string, authorize func(ctx context.Context, id string) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var caller tenant.Caller
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+			if !ok {
+				_ = c.Error(errors.Error("fail to authenticate", errors.StatusValidationError))
+				c.Abort()
+				return
+			}
+			var err error
+			if caller, err = jwt.CallerFromToken(tokenString); err != nil {
+				_ = c.Error(errors.Error("fail to authenticate", errors.StatusValidationError))
+				c.Abort()
+				return
+			}
+		}
+
+		id, err := tenant.Resolve(caller, c.GetHeader(tenant.Header), fallback)
+		if err == nil {
+			err = authorize(c.Request.Context(), id)
+		}
+		if err != nil {
+			_ = c.Error(errors.Error("you are not authorized to access this tenant", errors.StatusForbidden))
+			c.Abort()
+			return
+		}
+
+		c.Set("tenant_id", id)
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"order/pkg/core/configloader"
+	"order/pkg/core/tenant"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_ACCESS_SECURE", "test-secret")
+	secret := []byte(configloader.GetConfig().JWTAccessSecure)
+
+	token := func(claims jwt.MapClaims) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + signed
+	}
+	active := func(_ context.Context, id string) error {
+		if id == "suspended" {
+			return errors.New("tenant suspended")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		auth       string
+		header     string
+		wantTenant string
+	}{
+		{name: "tenant claim", auth: token(jwt.MapClaims{"role": "admin", "tenant_id": "acme"}), wantTenant: "acme"},
+		{name: "tenant claim matching header", auth: token(jwt.MapClaims{"role": "admin", "tenant_id": "acme"}), header: "acme", wantTenant: "acme"},
+		{name: "tenant claim and other header", auth: token(jwt.MapClaims{"role": "admin", "tenant_id": "acme"}), header: "globex"},
+		{name: "admin without claim picks header", auth: token(jwt.MapClaims{"role": "admin"}), header: "globex"},
+		{name: "admin without claim", auth: token(jwt.MapClaims{"role": "admin"}), wantTenant: tenant.Default},
+		{name: "platform admin picks header", auth: token(jwt.MapClaims{"role": RolePlatformAdmin}), header: "globex", wantTenant: "globex"},
+		{name: "platform admin picks inactive tenant", auth: token(jwt.MapClaims{"role": RolePlatformAdmin}), header: "suspended"},
+		{name: "no token picks header", header: "globex"},
+		{name: "no token", wantTenant: tenant.Default},
+		{name: "not a bearer token", auth: "Basic abc"},
+		{name: "forged token", auth: "Bearer not-a-token", header: "globex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/v1/orders", nil)
+			if tt.auth != "" {
+				c.Request.Header.Set("Authorization", tt.auth)
+			}
+			if tt.header != "" {
+				c.Request.Header.Set(tenant.Header, tt.header)
+			}
+
+			TenantMiddleware(tenant.Default, active)(c)
+			if tt.wantTenant == "" {
+				if !c.IsAborted() {
+					t.Fatal("request admitted, want it rejected")
+				}
+				return
+			}
+			if c.IsAborted() {
+				t.Fatalf("request rejected: %v", c.Errors)
+			}
+			if got, _ := tenant.FromContext(c.Request.Context()); got != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
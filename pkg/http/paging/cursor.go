@@ -0,0 +1,146 @@
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a row in a listing ordered newest first by created_at, then id
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// CursorPager pages through a listing by keyset instead of offset, so no page needs a COUNT
+// and rows inserted meanwhile do not shift the pages. After continues below a cursor,
+// Before goes back above one; without either the listing starts with the newest row.
+type CursorPager struct {
+	After    string `json:"after" form:"after"`
+	Before   string `json:"before" form:"before"`
+	PageSize int    `json:"page_size" form:"page_size"`
+
+	// Filters are parsed from the filter[field][operator] parameters and checked against the
+	// GetFilterableFields of the model
+	Filters []Condition `json:"-" form:"-"`
+
+	// Next and Prev are set by CursorQuery and are empty when there is nothing more that way
+	Next string `json:"-" form:"-"`
+	Prev string `json:"-" form:"-"`
+}
+
+// NewCursorPagerWithGinCtx initializes a new CursorPager from the query of the request
+func NewCursorPagerWithGinCtx(c *gin.Context) (*CursorPager, error) {
+	pg := &CursorPager{}
+	if err := c.ShouldBindQuery(pg); err != nil {
+		return nil, err
+	}
+	if pg.After != "" && pg.Before != "" {
+		return nil, ErrInvalidCursor
+	}
+	filters, err := ParseFilters(c.Request.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	pg.Filters = filters
+	return pg, nil
+}
+
+func (p *CursorPager) GetPageSize() int {
+	if p.PageSize <= 0 {
+		return defaultPageSize
+	}
+	if p.PageSize > maxPageSize {
+		return maxPageSize
+	}
+	return p.PageSize
+}
+
+// CursorQuery loads one page of db ordered newest first by created_at and id, and sets the
+// Next and Prev cursors of p. key returns the cursor of a row. The table of db needs an index
+// ending in (created_at, id) for the keyset to be cheap.
+func CursorQuery[T any](p *CursorPager, db *gorm.DB, key func(T) Cursor) ([]T, error) {
+	size := p.GetPageSize()
+
+	var model T
+	tx, err := ApplyFilters(db, p.Filters, resolveFilterableFields(&model))
+	if err != nil {
+		return nil, err
+	}
+
+	backward := p.Before != ""
+	tx = tx.Limit(size + 1)
+	switch {
+	case p.After != "":
+		c, err := DecodeCursor(p.After)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("(created_at, id) < (?, ?)", c.CreatedAt, c.ID)
+	case backward:
+		c, err := DecodeCursor(p.Before)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("(created_at, id) > (?, ?)", c.CreatedAt, c.ID)
+	}
+	if backward {
+		tx = tx.Order("created_at ASC, id ASC")
+	} else {
+		tx = tx.Order("created_at DESC, id DESC")
+	}
+
+	var rows []T
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// the extra row only tells whether there is another page in the direction of travel
+	more := len(rows) > size
+	if more {
+		rows = rows[:size]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	// coming from a cursor there is always a page back the way we came
+	p.Next, p.Prev = "", ""
+	if len(rows) == 0 {
+		p.Next, p.Prev = p.Before, p.After
+		return rows, nil
+	}
+	if backward || more {
+		p.Next = key(rows[len(rows)-1]).Encode()
+	}
+	if p.After != "" || (backward && more) {
+		p.Prev = key(rows[0]).Encode()
+	}
+	return rows, nil
+}
@@ -0,0 +1,167 @@
+package paging
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB renders statements without a database to connect to
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost", PreferSimpleProtocol: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+type testRow struct{}
+
+func (testRow) GetFilterableFields() map[string]FieldType {
+	return map[string]FieldType{
+		"status":       FieldString,
+		"total_amount": FieldNumber,
+		"is_paid":      FieldBool,
+		"created_at":   FieldTime,
+		"customer_id":  FieldUUID,
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	valid := Cursor{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ID: uuid.New()}
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name    string
+		cursor  string
+		wantErr bool
+	}{
+		{name: "round trip", cursor: valid.Encode()},
+		{name: "empty", cursor: "", wantErr: true},
+		{name: "not base64", cursor: "not a cursor!", wantErr: true},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte(`{"t":"2024-05-01T12:00:00Z"}`)), wantErr: true},
+		{name: "not json", cursor: encode("created_at=2024"), wantErr: true},
+		{name: "missing id", cursor: encode(`{"t":"2024-05-01T12:00:00Z"}`), wantErr: true},
+		{name: "nil id", cursor: encode(`{"t":"2024-05-01T12:00:00Z","i":"` + uuid.Nil.String() + `"}`), wantErr: true},
+		{name: "malformed id", cursor: encode(`{"t":"2024-05-01T12:00:00Z","i":"42"}`), wantErr: true},
+		{name: "missing time", cursor: encode(`{"i":"` + valid.ID.String() + `"}`), wantErr: true},
+		{name: "malformed time", cursor: encode(`{"t":"yesterday","i":"` + valid.ID.String() + `"}`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("err = %v, want %v", err, ErrInvalidCursor)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.CreatedAt.Equal(valid.CreatedAt) || got.ID != valid.ID {
+				t.Errorf("cursor = %+v, want %+v", got, valid)
+			}
+		})
+	}
+}
+
+func TestNewCursorPagerWithGinCtx(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr error
+		// bindErr is a query the binding rejects before the cursors and filters are checked
+		bindErr bool
+	}{
+		{name: "first page", query: "page_size=20&filter[status]=PENDING"},
+		{name: "after and before", query: "after=a&before=b", wantErr: ErrInvalidCursor},
+		{name: "malformed filter", query: "filter[status][like]=x", wantErr: ErrInvalidFilter},
+		{name: "non numeric page size", query: "page_size=many", bindErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/orders?"+tt.query, nil)
+
+			pager, err := NewCursorPagerWithGinCtx(c)
+			switch {
+			case tt.bindErr:
+				if err == nil {
+					t.Error("want a binding error")
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case pager.GetPageSize() != 20 || len(pager.Filters) != 1:
+				t.Errorf("pager = %+v", pager)
+			}
+		})
+	}
+}
+
+func TestCursorPagerGetPageSize(t *testing.T) {
+	for _, tt := range []struct{ size, want int }{
+		{size: -1, want: defaultPageSize},
+		{size: 0, want: defaultPageSize},
+		{size: 5, want: 5},
+		{size: maxPageSize + 1, want: maxPageSize},
+	} {
+		if got := (&CursorPager{PageSize: tt.size}).GetPageSize(); got != tt.want {
+			t.Errorf("page size %d = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestCursorQueryRejectsMalformedInput(t *testing.T) {
+	key := func(r testRow) Cursor { return Cursor{} }
+
+	tests := []struct {
+		name    string
+		pager   CursorPager
+		wantErr error
+	}{
+		{name: "malformed after", pager: CursorPager{After: "nope"}, wantErr: ErrInvalidCursor},
+		{name: "malformed before", pager: CursorPager{Before: "nope"}, wantErr: ErrInvalidCursor},
+		{
+			name:    "unfilterable field",
+			pager:   CursorPager{Filters: []Condition{{Field: "password", Operator: "equals", Values: []string{"x"}}}},
+			wantErr: ErrInvalidFilter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pager := tt.pager
+			if _, err := CursorQuery(&pager, dryRunDB(t).Table("orders"), key); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCursorQueryEmptyPageKeepsTheWayBack(t *testing.T) {
+	after := Cursor{CreatedAt: time.Now(), ID: uuid.New()}.Encode()
+	pager := &CursorPager{After: after, Next: "stale", Prev: "stale"}
+
+	rows, err := CursorQuery(pager, dryRunDB(t).Table("orders"), func(r testRow) Cursor { return Cursor{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 || pager.Next != "" || pager.Prev != after {
+		t.Errorf("rows = %d, next = %q, prev = %q; want no rows, no next and prev %q", len(rows), pager.Next, pager.Prev, after)
+	}
+}
@@ -0,0 +1,225 @@
+package paging
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/url"
+	"order/pkg/http/utils"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FieldType is the type a filter value of a field is parsed as
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldNumber
+	FieldBool
+	FieldTime
+	FieldUUID
+)
+
+const maxFilterValues = 100
+
+// filterKey matches filter[field][operator], and filter[field] as a shorthand for equals
+var filterKey = regexp.MustCompile(`^filter\[([a-z0-9_]+)\](?:\[([a-z_]+)\])?$`)
+
+// Condition is one filter of a listing, eg. filter[status][is_any_of]=PENDING,AUTHORIZED
+type Condition struct {
+	Field    string   `json:"field"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+// FilterableFieldsGetter is implemented by models that can be filtered, keyed by column name
+type FilterableFieldsGetter interface {
+	GetFilterableFields() map[string]FieldType
+}
+
+// ParseFilters reads the filter[field][operator]=value parameters of a query. is_any_of takes
+// a comma separated list. Fields are only checked against the model by ApplyFilters.
+func ParseFilters(query url.Values) ([]Condition, error) {
+	validOperators := utils.ValidOperatorsMap()
+
+	// keys are taken in order so the same query always gives the same SQL
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var conditions []Condition
+	for _, key := range keys {
+		values := query[key]
+		match := filterKey.FindStringSubmatch(key)
+		if match == nil {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidFilter, key)
+		}
+		operator := match[2]
+		if operator == "" {
+			operator = "equals"
+		}
+		if !validOperators[operator] {
+			return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, operator)
+		}
+
+		for _, value := range values {
+			cond := Condition{Field: match[1], Operator: operator, Values: []string{value}}
+			if operator == "is_any_of" {
+				cond.Values = strings.Split(value, ",")
+				if len(cond.Values) > maxFilterValues {
+					return nil, fmt.Errorf("%w: too many values for %q", ErrInvalidFilter, key)
+				}
+			}
+			conditions = append(conditions, cond)
+		}
+	}
+	return conditions, nil
+}
+
+// ApplyFilters adds conditions to db after checking them against fields, the filterable columns
+// of the model and their types. Values are always bound as parameters.
+func ApplyFilters(db *gorm.DB, conditions []Condition, fields map[string]FieldType) (*gorm.DB, error) {
+	for _, cond := range conditions {
+		fieldType, ok := fields[cond.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q is not filterable", ErrInvalidFilter, cond.Field)
+		}
+		expr, err := filterExpression(cond, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(expr)
+	}
+	return db, nil
+}
+
+// ValidateFilters checks conditions against fields like ApplyFilters, for filters that are
+// stored to be applied later
+func ValidateFilters(conditions []Condition, fields map[string]FieldType) error {
+	for _, cond := range conditions {
+		fieldType, ok := fields[cond.Field]
+		if !ok {
+			return fmt.Errorf("%w: field %q is not filterable", ErrInvalidFilter, cond.Field)
+		}
+		if _, err := filterExpression(cond, fieldType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveFilterableFields returns the filterable fields of the model held by value
+func resolveFilterableFields(value interface{}) map[string]FieldType {
+	refType := reflect.TypeOf(value)
+	for refType.Kind() == reflect.Ptr || refType.Kind() == reflect.Slice {
+		refType = refType.Elem()
+	}
+	if getter, ok := reflect.New(refType).Interface().(FilterableFieldsGetter); ok {
+		return getter.GetFilterableFields()
+	}
+	return nil
+}
+
+func filterExpression(cond Condition, fieldType FieldType) (clause.Expression, error) {
+	column := clause.Column{Name: cond.Field}
+
+	switch cond.Operator {
+	case "is_empty", "is_not_empty":
+		expr := clause.Expression(clause.Eq{Column: column, Value: nil})
+		if fieldType == FieldString {
+			expr = clause.Or(expr, clause.Eq{Column: column, Value: ""})
+		}
+		if cond.Operator == "is_not_empty" {
+			return clause.Not(expr), nil
+		}
+		return expr, nil
+	case "contains", "not_contains", "starts_with", "ends_with":
+		if fieldType != FieldString {
+			return nil, fmt.Errorf("%w: %s needs a text field, %q is not", ErrInvalidFilter, cond.Operator, cond.Field)
+		}
+		pattern := escapeLike(cond.Values[0])
+		switch cond.Operator {
+		case "starts_with":
+			pattern += "%"
+		case "ends_with":
+			pattern = "%" + pattern
+		default:
+			pattern = "%" + pattern + "%"
+		}
+		expr := clause.Expr{SQL: "? ILIKE ?", Vars: []interface{}{column, pattern}}
+		if cond.Operator == "not_contains" {
+			return clause.Not(expr), nil
+		}
+		return expr, nil
+	}
+
+	values := make([]interface{}, 0, len(cond.Values))
+	for _, raw := range cond.Values {
+		value, err := parseFilterValue(raw, fieldType)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad value %q for %q", ErrInvalidFilter, raw, cond.Field)
+		}
+		values = append(values, value)
+	}
+
+	switch cond.Operator {
+	case "equals":
+		return clause.Eq{Column: column, Value: values[0]}, nil
+	case "not_equals":
+		return clause.Neq{Column: column, Value: values[0]}, nil
+	case "is_any_of":
+		return clause.IN{Column: column, Values: values}, nil
+	}
+
+	if fieldType != FieldNumber && fieldType != FieldTime {
+		return nil, fmt.Errorf("%w: %s needs a number or time field, %q is not", ErrInvalidFilter, cond.Operator, cond.Field)
+	}
+	switch cond.Operator {
+	case "greater_than":
+		return clause.Gt{Column: column, Value: values[0]}, nil
+	case "less_than":
+		return clause.Lt{Column: column, Value: values[0]}, nil
+	case "greater_than_or_equal":
+		return clause.Gte{Column: column, Value: values[0]}, nil
+	case "less_than_or_equal":
+		return clause.Lte{Column: column, Value: values[0]}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, cond.Operator)
+}
+
+func parseFilterValue(raw string, fieldType FieldType) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	switch fieldType {
+	case FieldNumber:
+		return strconv.ParseFloat(raw, 64)
+	case FieldBool:
+		return strconv.ParseBool(raw)
+	case FieldTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.DateOnly, raw)
+	case FieldUUID:
+		return uuid.Parse(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// escapeLike makes the wildcards of a user supplied value match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,140 @@
+package paging
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []Condition
+		wantErr bool
+	}{
+		{name: "no filters", query: "page_size=10"},
+		{
+			name:  "shorthand equals",
+			query: "filter[status]=PENDING",
+			want:  []Condition{{Field: "status", Operator: "equals", Values: []string{"PENDING"}}},
+		},
+		{
+			name:  "is_any_of splits on commas",
+			query: "filter[status][is_any_of]=PENDING,AUTHORIZED",
+			want:  []Condition{{Field: "status", Operator: "is_any_of", Values: []string{"PENDING", "AUTHORIZED"}}},
+		},
+		{
+			name:  "repeated parameter gives one condition each, in key order",
+			query: "filter[total_amount][less_than]=10&filter[status]=A&filter[status]=B",
+			want: []Condition{
+				{Field: "status", Operator: "equals", Values: []string{"A"}},
+				{Field: "status", Operator: "equals", Values: []string{"B"}},
+				{Field: "total_amount", Operator: "less_than", Values: []string{"10"}},
+			},
+		},
+		{name: "unknown operator", query: "filter[status][like]=x", wantErr: true},
+		{name: "unclosed bracket", query: "filter[status=x", wantErr: true},
+		{name: "upper case field", query: "filter[Status]=x", wantErr: true},
+		{name: "sql in the field", query: "filter[status%22)%20OR%201=1--]=x", wantErr: true},
+		{name: "third bracket", query: "filter[status][equals][x]=x", wantErr: true},
+		{name: "too many values", query: "filter[status][is_any_of]=" + strings.Repeat("a,", maxFilterValues) + "a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseFilters(query)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidFilter) {
+					t.Fatalf("err = %v, want %v", err, ErrInvalidFilter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conditions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	fields := testRow{}.GetFilterableFields()
+
+	tests := []struct {
+		name     string
+		cond     Condition
+		wantSQL  string
+		wantVars []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "equals",
+			cond:     Condition{Field: "status", Operator: "equals", Values: []string{"PENDING"}},
+			wantSQL:  `"status" = $1`,
+			wantVars: []interface{}{"PENDING"},
+		},
+		{
+			name:     "wildcards of contains match literally",
+			cond:     Condition{Field: "status", Operator: "contains", Values: []string{`50%_\`}},
+			wantSQL:  `"status" ILIKE $1`,
+			wantVars: []interface{}{`%50\%\_\\%`},
+		},
+		{
+			name:    "is_empty of a text field matches the empty string",
+			cond:    Condition{Field: "status", Operator: "is_empty", Values: []string{""}},
+			wantSQL: `("status" IS NULL OR "status" = $1)`,
+		},
+		{
+			name:     "number comparison",
+			cond:     Condition{Field: "total_amount", Operator: "greater_than_or_equal", Values: []string{" 12.5 "}},
+			wantSQL:  `"total_amount" >= $1`,
+			wantVars: []interface{}{12.5},
+		},
+		{name: "unknown field", cond: Condition{Field: "password", Operator: "equals", Values: []string{"x"}}, wantErr: true},
+		{name: "malformed number", cond: Condition{Field: "total_amount", Operator: "equals", Values: []string{"ten"}}, wantErr: true},
+		{name: "malformed bool", cond: Condition{Field: "is_paid", Operator: "equals", Values: []string{"yes please"}}, wantErr: true},
+		{name: "malformed time", cond: Condition{Field: "created_at", Operator: "less_than", Values: []string{"yesterday"}}, wantErr: true},
+		{name: "malformed uuid", cond: Condition{Field: "customer_id", Operator: "is_any_of", Values: []string{uuidString, "42"}}, wantErr: true},
+		{name: "contains on a number", cond: Condition{Field: "total_amount", Operator: "contains", Values: []string{"1"}}, wantErr: true},
+		{name: "comparison on text", cond: Condition{Field: "status", Operator: "greater_than", Values: []string{"A"}}, wantErr: true},
+		{name: "comparison on uuid", cond: Condition{Field: "customer_id", Operator: "less_than", Values: []string{uuidString}}, wantErr: true},
+		{name: "unknown operator", cond: Condition{Field: "total_amount", Operator: "between", Values: []string{"1"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := []Condition{tt.cond}
+			validateErr := ValidateFilters(conditions, fields)
+			tx, err := ApplyFilters(dryRunDB(t).Table("orders"), conditions, fields)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidFilter) {
+					t.Errorf("ApplyFilters err = %v, want %v", err, ErrInvalidFilter)
+				}
+				if !errors.Is(validateErr, ErrInvalidFilter) {
+					t.Errorf("ValidateFilters err = %v, want %v", validateErr, ErrInvalidFilter)
+				}
+				return
+			}
+			if err != nil || validateErr != nil {
+				t.Fatalf("ApplyFilters err = %v, ValidateFilters err = %v", err, validateErr)
+			}
+
+			stmt := tx.Find(&[]map[string]interface{}{}).Statement
+			if want := "WHERE " + tt.wantSQL; !strings.HasSuffix(stmt.SQL.String(), want) {
+				t.Errorf("sql = %s, want it to end in %s", stmt.SQL.String(), want)
+			}
+			if tt.wantVars != nil && !reflect.DeepEqual(stmt.Vars, tt.wantVars) {
+				t.Errorf("vars = %v, want %v", stmt.Vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+const uuidString = "0b6c3a8e-4d47-4f3a-9c36-8f3b7a1e2d10"
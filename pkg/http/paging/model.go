@@ -28,6 +28,10 @@ type BodyMeta struct {
 	PageCount int    `json:"pageCount"`
 	CanNext   bool   `json:"canNext"`
 	CanPre    bool   `json:"canPre"`
+
+	// NextCursor and PrevCursor are set instead of the page fields on cursor paginated listings
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
 }
 
 // GeneralBody defines a general response body
@@ -57,3 +61,21 @@ func NewBodyPaginated(ctx context.Context, data interface{}, pager *Pager) *Gene
 		},
 	}
 }
+
+// NewBodyCursor builds the body of a cursor paginated listing after CursorQuery has run
+func NewBodyCursor(ctx context.Context, data interface{}, pager *CursorPager) *GeneralBody {
+	requestID, _ := ctx.Value("x-request-id").(string)
+
+	return &GeneralBody{
+		Data: data,
+		Meta: BodyMeta{
+			TraceID:    requestID,
+			Success:    true,
+			PageSize:   pager.GetPageSize(),
+			CanNext:    pager.Next != "",
+			CanPre:     pager.Prev != "",
+			NextCursor: pager.Next,
+			PrevCursor: pager.Prev,
+		},
+	}
+}
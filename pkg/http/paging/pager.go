@@ -24,6 +24,11 @@ type Pager struct {
 	TotalRows      int64       `json:"total"`
 	SortableFields []string    `json:"sortable_fields"`
 	Metadata       interface{} `json:"metadata"`
+
+	// Filters are parsed from the filter[field][operator] parameters and checked against
+	// FilterableFields, or the GetFilterableFields of the model when that is empty
+	Filters          []Condition          `json:"-" form:"-"`
+	FilterableFields map[string]FieldType `json:"-" form:"-"`
 }
 
 type SortableFieldsGetter interface {
@@ -36,6 +41,11 @@ func NewPagerWithGinCtx(c *gin.Context) *Pager {
 	if err := c.ShouldBind(pg); err != nil {
 		return nil
 	}
+	filters, err := ParseFilters(c.Request.URL.Query())
+	if err != nil {
+		return nil
+	}
+	pg.Filters = filters
 	return pg
 }
 
@@ -120,12 +130,24 @@ func (p *Pager) TraceID() string {
 	return TradeId.String()
 }
 
-// DoQuery The execution will stop on count error then return that transaction
+// DoQuery The execution will stop on filter or count error then return that transaction
 func (p *Pager) DoQuery(value interface{}, db *gorm.DB) *gorm.DB {
 	var (
 		totalRows int64
 		tx        *gorm.DB
 	)
+
+	filterableFields := p.FilterableFields
+	if len(filterableFields) == 0 {
+		filterableFields = resolveFilterableFields(value)
+	}
+	filtered, err := ApplyFilters(db, p.Filters, filterableFields)
+	if err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	db = filtered
+
 	if tx = db.Count(&totalRows); tx.Error != nil {
 		return tx
 	}
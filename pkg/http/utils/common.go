@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/astaxie/beego/validation"
 	"github.com/ericlagergren/decimal"
@@ -37,7 +38,7 @@ func CheckRequireValid(ob interface{}) error {
 		for _, e := range validator.Errors {
 			err += fmt.Sprintf("[%s: %s] ", e.Field, e.Message)
 		}
-		return fmt.Errorf(err)
+		return errors.New(err)
 	}
 	return nil
 }
@@ -28,6 +28,7 @@ type PayRequest struct {
 	CustomerId    string                 `protobuf:"bytes,3,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
 	Amount        float64                `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
 	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Currency      string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"` // ISO 4217 code of amount
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -97,6 +98,13 @@ func (x *PayRequest) GetStatus() string {
 	return ""
 }
 
+func (x *PayRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
 type PayResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -161,7 +169,7 @@ var File_pkg_proto_payment_proto protoreflect.FileDescriptor
 
 const file_pkg_proto_payment_proto_rawDesc = "" +
 	"\n" +
-	"\x17pkg/proto/payment.proto\x12\tpaymentpb\"\x93\x01\n" +
+	"\x17pkg/proto/payment.proto\x12\tpaymentpb\"\xaf\x01\n" +
 	"\n" +
 	"PayRequest\x12\x19\n" +
 	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x19\n" +
@@ -169,7 +177,8 @@ const file_pkg_proto_payment_proto_rawDesc = "" +
 	"\vcustomer_id\x18\x03 \x01(\tR\n" +
 	"customerId\x12\x16\n" +
 	"\x06amount\x18\x04 \x01(\x01R\x06amount\x12\x16\n" +
-	"\x06status\x18\x05 \x01(\tR\x06status\"^\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\"^\n" +
 	"\vPayResponse\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1d\n" +
 	"\n" +